@@ -0,0 +1,19 @@
+package access
+
+import (
+	"github.com/envsecrets/envsecrets/internal/access"
+)
+
+type RequestOptions struct {
+	EnvID string `json:"env_id"`
+
+	//	For example, "30m" or "2h".
+	Duration string `json:"duration,omitempty"`
+	Reason   string `json:"reason"`
+}
+
+type ListOptions struct {
+	OrgID  string        `query:"org_id"`
+	EnvID  string        `query:"env_id"`
+	Status access.Status `query:"status"`
+}
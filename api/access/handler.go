@@ -0,0 +1,251 @@
+package access
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/envsecrets/envsecrets/cli/auth"
+	"github.com/envsecrets/envsecrets/internal/access"
+	"github.com/envsecrets/envsecrets/internal/audits"
+	"github.com/envsecrets/envsecrets/internal/clients"
+	"github.com/envsecrets/envsecrets/internal/context"
+	"github.com/envsecrets/envsecrets/internal/organisations"
+	"github.com/envsecrets/envsecrets/internal/permissions"
+	"github.com/envsecrets/envsecrets/internal/roles"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/labstack/echo/v4"
+)
+
+// --- Flow ---
+//
+//  1. Validate that the user is a member of the environment's organisation.
+//  2. Save the pending access request.
+//  3. Record it in the organisation's audit logs.
+func RequestHandler(c echo.Context) error {
+
+	//	Unmarshal the incoming payload
+	var payload RequestOptions
+	if err := c.Bind(&payload); err != nil {
+		return c.JSON(http.StatusBadRequest, &clients.APIResponse{
+			Message: "failed to parse the body",
+			Error:   err.Error(),
+		})
+	}
+
+	if payload.EnvID == "" || strings.TrimSpace(payload.Reason) == "" {
+		return c.JSON(http.StatusBadRequest, &clients.APIResponse{
+			Message: "invalid access request",
+			Error:   "both the environment and a reason are required",
+		})
+	}
+
+	var duration time.Duration
+	if payload.Duration != "" {
+		var err error
+		duration, err = time.ParseDuration(payload.Duration)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, &clients.APIResponse{
+				Message: "invalid duration",
+				Error:   err.Error(),
+			})
+		}
+	}
+
+	//	Initialize a new default context
+	ctx := context.NewContext(&context.Config{Type: context.APIContext, EchoContext: c})
+
+	token := c.Get("user").(*jwt.Token)
+	claims := token.Claims.(*auth.Claims)
+
+	//	Initialize Hasura client with admin privileges,
+	//	since access grants can only be inserted by the server.
+	client := clients.NewGQLClient(&clients.GQLConfig{
+		Type: clients.HasuraClientType,
+		Headers: []clients.Header{
+			clients.XHasuraAdminSecretHeader,
+		},
+	})
+
+	//	Only members of the organisation can request access.
+	if _, err := permissions.GetService().Get(ctx, client, &permissions.GetOptions{
+		Scope:  permissions.Scope{EnvID: payload.EnvID},
+		UserID: claims.Hasura.UserID,
+	}); err != nil {
+		return c.JSON(http.StatusForbidden, &clients.APIResponse{
+			Message: "You are not a member of this environment's organisation",
+			Error:   err.Error(),
+		})
+	}
+
+	organisation, err := organisations.GetService().GetByEnvironment(ctx, client, payload.EnvID)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &clients.APIResponse{
+			Message: "Failed to fetch the organisation this environment is associated with",
+			Error:   err.Error(),
+		})
+	}
+
+	grant, err := access.GetService().Create(ctx, client, &access.CreateOptions{
+		OrgID:    organisation.ID,
+		EnvID:    payload.EnvID,
+		UserID:   claims.Hasura.UserID,
+		Reason:   payload.Reason,
+		Duration: duration,
+	})
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &clients.APIResponse{
+			Message: "Failed to request access",
+			Error:   err.Error(),
+		})
+	}
+
+	audit(c, ctx, client, claims.Hasura.UserID, audits.AccessRequestedAction, grant)
+
+	return c.JSON(http.StatusCreated, &clients.APIResponse{
+		Message: "successfully requested access",
+		Data:    grant,
+	})
+}
+
+// Lists the access grants visible to the user:
+// their own, plus those of every member if they can read the organisation's permissions.
+func ListHandler(c echo.Context) error {
+
+	var payload ListOptions
+	if err := c.Bind(&payload); err != nil {
+		return c.JSON(http.StatusBadRequest, &clients.APIResponse{
+			Message: "failed to parse the query",
+			Error:   err.Error(),
+		})
+	}
+
+	//	Initialize a new default context
+	ctx := context.NewContext(&context.Config{Type: context.APIContext, EchoContext: c})
+
+	//	Initialize new Hasura client
+	client := clients.NewGQLClient(&clients.GQLConfig{
+		Type:          clients.HasuraClientType,
+		Authorization: c.Request().Header.Get(echo.HeaderAuthorization),
+	})
+
+	result, err := access.GetService().List(ctx, client, &access.ListOptions{
+		OrgID:  payload.OrgID,
+		EnvID:  payload.EnvID,
+		Status: payload.Status,
+	})
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &clients.APIResponse{
+			Message: "Failed to list the access grants",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, &clients.APIResponse{
+		Message: "successfully fetched the access grants",
+		Data:    result,
+	})
+}
+
+func ApproveHandler(c echo.Context) error {
+	return review(c, audits.AccessApprovedAction)
+}
+
+func RejectHandler(c echo.Context) error {
+	return review(c, audits.AccessRejectedAction)
+}
+
+func RevokeHandler(c echo.Context) error {
+	return review(c, audits.AccessRevokedAction)
+}
+
+// --- Flow ---
+//
+//  1. Validate that the user is allowed to manage the permissions of the grant's environment.
+//     Requesters can always revoke their own grants.
+//  2. Update the grant and record it in the organisation's audit logs.
+func review(c echo.Context, action audits.Action) error {
+
+	//	Initialize a new default context
+	ctx := context.NewContext(&context.Config{Type: context.APIContext, EchoContext: c})
+
+	token := c.Get("user").(*jwt.Token)
+	claims := token.Claims.(*auth.Claims)
+
+	//	Initialize Hasura client with admin privileges,
+	//	since access grants can only be updated by the server.
+	client := clients.NewGQLClient(&clients.GQLConfig{
+		Type: clients.HasuraClientType,
+		Headers: []clients.Header{
+			clients.XHasuraAdminSecretHeader,
+		},
+	})
+
+	service := access.GetService()
+
+	grant, err := service.Get(ctx, client, c.Param(GRANT_ID))
+	if err != nil {
+		return c.JSON(http.StatusNotFound, &clients.APIResponse{
+			Message: "Failed to fetch the access grant",
+			Error:   err.Error(),
+		})
+	}
+
+	ownRevocation := action == audits.AccessRevokedAction && grant.UserID == claims.Hasura.UserID
+	if !ownRevocation {
+		if err := permissions.AuthorizeRequest(c, permissions.Scope{EnvID: grant.EnvID}, roles.PermissionsResource, roles.UpdateAction); err != nil {
+			return c.JSON(http.StatusForbidden, &clients.APIResponse{
+				Message: "You are not allowed to manage the access to this environment",
+				Error:   err.Error(),
+			})
+		}
+	}
+
+	options := &access.ReviewOptions{
+		ID:         grant.ID,
+		ReviewerID: claims.Hasura.UserID,
+	}
+
+	switch action {
+	case audits.AccessApprovedAction:
+		grant, err = service.Approve(ctx, client, options)
+	case audits.AccessRejectedAction:
+		grant, err = service.Reject(ctx, client, options)
+	case audits.AccessRevokedAction:
+		grant, err = service.Revoke(ctx, client, options)
+	}
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, access.ErrNotPending) || errors.Is(err, access.ErrNotApproved) {
+			status = http.StatusConflict
+		} else if errors.Is(err, access.ErrSelfApproval) {
+			status = http.StatusForbidden
+		}
+		return c.JSON(status, &clients.APIResponse{
+			Message: "Failed to update the access grant",
+			Error:   err.Error(),
+		})
+	}
+
+	audit(c, ctx, client, claims.Hasura.UserID, action, grant)
+
+	return c.JSON(http.StatusOK, &clients.APIResponse{
+		Message: "successfully updated the access grant",
+		Data:    grant,
+	})
+}
+
+// Records the action on an access grant in the organisation's audit logs.
+func audit(c echo.Context, ctx context.ServiceContext, client *clients.GQLClient, userID string, action audits.Action, grant *access.Grant) {
+	if _, err := audits.GetService().Create(ctx, client, &audits.CreateOptions{
+		OrgID:      grant.OrgID,
+		UserID:     userID,
+		Action:     action,
+		EntityType: audits.AccessGrantEntity,
+		EntityID:   grant.ID,
+		Metadata:   grant.Metadata(),
+	}); err != nil {
+		c.Logger().Error(err)
+	}
+}
@@ -0,0 +1,22 @@
+package access
+
+import (
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	GRANT_ID = "grant_id"
+)
+
+func AddRoutes(sg *echo.Group) {
+
+	group := sg.Group("/access")
+	group.GET("", ListHandler)
+	group.POST("", RequestHandler)
+
+	//	Reviewers are authorized against the environment of the grant.
+	grant := group.Group("/:" + GRANT_ID)
+	grant.POST("/approve", ApproveHandler)
+	grant.POST("/reject", RejectHandler)
+	grant.POST("/revoke", RevokeHandler)
+}
@@ -1,15 +1,22 @@
 package api
 
 import (
+	"github.com/envsecrets/envsecrets/api/access"
 	"github.com/envsecrets/envsecrets/api/actions"
 	"github.com/envsecrets/envsecrets/api/auth"
+	"github.com/envsecrets/envsecrets/api/changes"
 	"github.com/envsecrets/envsecrets/api/environments"
 	"github.com/envsecrets/envsecrets/api/events"
+	"github.com/envsecrets/envsecrets/api/federation"
 	"github.com/envsecrets/envsecrets/api/integrations"
 	"github.com/envsecrets/envsecrets/api/invites"
 	"github.com/envsecrets/envsecrets/api/organisations"
 	"github.com/envsecrets/envsecrets/api/payments"
 	"github.com/envsecrets/envsecrets/api/projects"
+	"github.com/envsecrets/envsecrets/api/roles"
+	"github.com/envsecrets/envsecrets/api/scim"
+	"github.com/envsecrets/envsecrets/api/serviceaccounts"
+	"github.com/envsecrets/envsecrets/api/sso"
 	"github.com/envsecrets/envsecrets/api/tokens"
 	"github.com/envsecrets/envsecrets/api/triggers"
 	"github.com/envsecrets/envsecrets/internal/secrets"
@@ -28,11 +35,18 @@ func AddRoutes(e *echo.Echo) {
 	secrets.AddRoutes(v1Group)
 	integrations.AddRoutes(v1Group)
 	environments.AddRoutes(v1Group)
+	changes.AddRoutes(v1Group)
+	access.AddRoutes(v1Group)
 	invites.AddRoutes(v1Group)
 	payments.AddRoutes(v1Group)
 	tokens.AddRoutes(v1Group)
 	events.AddRoutes(v1Group)
 	projects.AddRoutes(v1Group)
 	organisations.AddRoutes(v1Group)
+	roles.AddRoutes(v1Group)
+	sso.AddRoutes(v1Group)
+	scim.AddRoutes(v1Group)
+	serviceaccounts.AddRoutes(v1Group)
+	federation.AddRoutes(v1Group)
 	//keys.AddRoutes(v1Group)
 }
@@ -21,3 +21,29 @@ type SignupOptions struct {
 	Password string `json:"password"`
 	Name     string `json:"name"`
 }
+
+type SSOSigninOptions struct {
+	OrgID        string `json:"org_id"`
+	Code         string `json:"code"`
+	CodeVerifier string `json:"code_verifier"`
+	RedirectURI  string `json:"redirect_uri"`
+	Nonce        string `json:"nonce"`
+
+	//	Password of the user's existing account, to link it to their identity on the first sign in.
+	Password string `json:"password,omitempty"`
+}
+
+type SSOConnectionOptions struct {
+	Email string `query:"email"`
+}
+
+type SetPassphraseOptions struct {
+	Passphrase string `json:"passphrase"`
+}
+
+type ServiceAccountSigninOptions struct {
+
+	//	Only the personal access token part of the credential.
+	//	The passphrase never leaves the client.
+	PAT string `json:"pat"`
+}
@@ -2,6 +2,8 @@ package auth
 
 import (
 	"encoding/base64"
+	"errors"
+	"fmt"
 	"net/http"
 	"time"
 
@@ -10,6 +12,10 @@ import (
 	"github.com/envsecrets/envsecrets/internal/context"
 	"github.com/envsecrets/envsecrets/internal/keys"
 	keyCommons "github.com/envsecrets/envsecrets/internal/keys/commons"
+	"github.com/envsecrets/envsecrets/internal/serviceaccounts"
+	"github.com/envsecrets/envsecrets/internal/sso"
+	"github.com/envsecrets/envsecrets/internal/users"
+	"github.com/envsecrets/envsecrets/utils"
 	"github.com/golang-jwt/jwt/v4"
 	"github.com/labstack/echo/v4"
 )
@@ -359,6 +365,266 @@ func GetSyncKeyHandler(c echo.Context) error {
 	})
 }
 
+// Finds the organisation whose identity provider the user should sign in with,
+// from the domain of their email.
+func SSOConnectionHandler(c echo.Context) error {
+
+	var payload SSOConnectionOptions
+	if err := c.Bind(&payload); err != nil {
+		return c.JSON(http.StatusBadRequest, &clients.APIResponse{
+			Message: "failed to parse the query",
+			Error:   err.Error(),
+		})
+	}
+
+	//	Initialize a new default context
+	ctx := context.NewContext(&context.Config{Type: context.APIContext, EchoContext: c})
+
+	//	Initialize Hasura client with admin privileges
+	client := clients.NewGQLClient(&clients.GQLConfig{
+		Type: clients.HasuraClientType,
+		Headers: []clients.Header{
+			clients.XHasuraAdminSecretHeader,
+		},
+	})
+
+	connection, err := sso.GetService().FindConnection(ctx, client, payload.Email)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, &clients.APIResponse{
+			Message: "No identity provider is configured for this email",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, &clients.APIResponse{
+		Message: "successfully fetched the identity provider",
+		Data: map[string]string{
+			"org_id":    connection.OrgID,
+			"issuer":    connection.Issuer,
+			"client_id": connection.ClientID,
+		},
+	})
+}
+
+// --- Flow ---
+//
+//  1. Validate the authorization code with the organisation's identity provider,
+//     and find or provision the user's identity, linking it to an existing account with its password.
+//  2. Issue a regular session for the user.
+//  3. Tell the client whether the user still has to set their unlock passphrase.
+//
+// Unlike password sign ins, the user's keys are never decrypted here,
+// since the unlock passphrase doesn't leave the client.
+func SSOSigninHandler(c echo.Context) error {
+
+	//	Unmarshal the incoming payload
+	var payload SSOSigninOptions
+	if err := c.Bind(&payload); err != nil {
+		return c.JSON(http.StatusBadRequest, &clients.APIResponse{
+			Message: "failed to parse the body",
+			Error:   err.Error(),
+		})
+	}
+
+	//	Initialize a new default context
+	ctx := context.NewContext(&context.Config{Type: context.APIContext, EchoContext: c})
+
+	//	Initialize Hasura client with admin privileges
+	client := clients.NewGQLClient(&clients.GQLConfig{
+		Type: clients.HasuraClientType,
+		Headers: []clients.Header{
+			clients.XHasuraAdminSecretHeader,
+		},
+	})
+
+	identity, err := sso.GetService().Signin(ctx, client, &sso.SigninOptions{
+		OrgID:        payload.OrgID,
+		Code:         payload.Code,
+		CodeVerifier: payload.CodeVerifier,
+		RedirectURI:  payload.RedirectURI,
+		Nonce:        payload.Nonce,
+		Password:     payload.Password,
+	})
+	if errors.Is(err, sso.ErrLinkRequired) {
+		return c.JSON(http.StatusForbidden, &clients.APIResponse{
+			Message: err.Error(),
+			Error:   string(clients.ErrorTypeAccountLinkRequired),
+		})
+	}
+	if err != nil {
+		status := http.StatusUnauthorized
+		if errors.Is(err, sso.ErrDeprovisioned) || errors.Is(err, sso.ErrDomainNotAllowed) {
+			status = http.StatusForbidden
+		}
+		return c.JSON(status, &clients.APIResponse{
+			Message: "Login failed. Your identity provider could not verify you.",
+			Error:   err.Error(),
+		})
+	}
+
+	response, err := auth.GetService().SigninWithUserID(ctx, clients.NewNhostClient(&clients.NhostConfig{}), client, identity.UserID)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &clients.APIResponse{
+			Message: "Login failed. Could not create your session.",
+			Error:   err.Error(),
+		})
+	}
+
+	_, err = keys.GetPublicKeyByUserID(ctx, client, identity.UserID)
+
+	return c.JSON(http.StatusOK, &clients.APIResponse{
+		Message: "successfully signed in",
+		Data: &auth.SigninWithSSOResponse{
+			Session: response.Session,
+			HasKeys: err == nil,
+		},
+	})
+}
+
+// Generates the key pair of a user who signs in through their identity provider,
+// sealed with the unlock passphrase they chose instead of an account password.
+func SetPassphraseHandler(c echo.Context) error {
+
+	//	Unmarshal the incoming payload
+	var payload SetPassphraseOptions
+	if err := c.Bind(&payload); err != nil {
+		return c.JSON(http.StatusBadRequest, &clients.APIResponse{
+			Message: "failed to parse the body",
+			Error:   err.Error(),
+		})
+	}
+
+	if len(payload.Passphrase) < auth.MIN_PASSPHRASE_LENGTH {
+		return c.JSON(http.StatusBadRequest, &clients.APIResponse{
+			Message: "Your unlock passphrase is too short",
+			Error:   fmt.Sprintf("the passphrase must be at least %d characters long", auth.MIN_PASSPHRASE_LENGTH),
+		})
+	}
+
+	//	Initialize a new default context
+	ctx := context.NewContext(&context.Config{Type: context.APIContext, EchoContext: c})
+
+	//	Initialize Hasura client
+	client := clients.NewGQLClient(&clients.GQLConfig{
+		Type:          clients.HasuraClientType,
+		Authorization: c.Request().Header.Get(echo.HeaderAuthorization),
+	})
+
+	//	The passphrase can only be set once.
+	//	Afterwards, it can't be changed without the private key it protects.
+	if _, err := keys.GetPublicKey(ctx, client); err == nil {
+		return c.JSON(http.StatusConflict, &clients.APIResponse{
+			Message: "You have already set your unlock passphrase",
+			Error:   "key pair already exists",
+		})
+	}
+
+	//	Generate Key pair
+	pair, err := keys.GenerateKeyPair(payload.Passphrase)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &clients.APIResponse{
+			Message: "Failed to issue a fresh key pair",
+			Error:   err.Error(),
+		})
+	}
+
+	//	Encrypt the sync key using server's symmetric key
+	syncKeyBytes, err := keys.SealSymmetricallyByServer(pair.SyncKey[:])
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &clients.APIResponse{
+			Message: "Failed to issue a fresh key pair",
+			Error:   err.Error(),
+		})
+	}
+
+	//	Upload the keys to their cloud account.
+	if err := keys.Create(ctx, client, &keyCommons.CreateOptions{
+		PublicKey:    base64.StdEncoding.EncodeToString(pair.PublicKey),
+		PrivateKey:   base64.StdEncoding.EncodeToString(pair.PrivateKey),
+		ProtectedKey: base64.StdEncoding.EncodeToString(pair.ProtectedKey),
+		Salt:         base64.StdEncoding.EncodeToString(pair.Salt),
+		SyncKey:      base64.StdEncoding.EncodeToString(syncKeyBytes),
+	}); err != nil {
+		return c.JSON(http.StatusBadRequest, &clients.APIResponse{
+			Message: "Failed to issue a fresh key pair",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusCreated, &clients.APIResponse{
+		Message: "successfully set your unlock passphrase",
+	})
+}
+
+// --- Flow ---
+//
+//  1. Exchange the personal access token of the service account for a session.
+//  2. Validate that the session belongs to a service account.
+//  3. Record when the service account was last used.
+//
+// Like SSO sign ins, the keys are decrypted by the client,
+// with the passphrase carried by the rest of the credential.
+func ServiceAccountSigninHandler(c echo.Context) error {
+
+	//	Unmarshal the incoming payload
+	var payload ServiceAccountSigninOptions
+	if err := c.Bind(&payload); err != nil {
+		return c.JSON(http.StatusBadRequest, &clients.APIResponse{
+			Message: "failed to parse the body",
+			Error:   err.Error(),
+		})
+	}
+
+	//	Initialize a new default context
+	ctx := context.NewContext(&context.Config{Type: context.APIContext, EchoContext: c})
+
+	response, err := auth.GetService().SigninWithPAT(ctx, clients.NewNhostClient(&clients.NhostConfig{}), &auth.SigninWithPATOptions{
+		PAT: payload.PAT,
+	})
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, &clients.APIResponse{
+			Message: "Login failed. The credential is invalid, expired or has been rotated.",
+			Error:   err.Error(),
+		})
+	}
+
+	var session struct {
+		User users.User `json:"user"`
+	}
+
+	if err := utils.MapToStruct(response.Session, &session); err != nil {
+		return c.JSON(http.StatusInternalServerError, &clients.APIResponse{
+			Message: "Failed to parse the session",
+			Error:   err.Error(),
+		})
+	}
+
+	//	Initialize Hasura client with admin privileges
+	client := clients.NewGQLClient(&clients.GQLConfig{
+		Type: clients.HasuraClientType,
+		Headers: []clients.Header{
+			clients.XHasuraAdminSecretHeader,
+		},
+	})
+
+	account, err := serviceaccounts.GetService().GetByUserID(ctx, client, session.User.ID)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, &clients.APIResponse{
+			Message: "Login failed. The credential doesn't belong to a service account.",
+			Error:   err.Error(),
+		})
+	}
+
+	if err := serviceaccounts.GetService().MarkUsed(ctx, client, account.ID); err != nil {
+		c.Logger().Error(err)
+	}
+
+	return c.JSON(http.StatusOK, &clients.APIResponse{
+		Message: "successfully signed in",
+		Data:    response,
+	})
+}
+
 //	---	Helpers ---
 
 func writeCookie(c echo.Context, value string) error {
@@ -16,6 +16,13 @@ func AddRoutes(sg *echo.Group) {
 	group.POST("/signup", SignupHandler)
 	group.POST("/update-password", UpdatePasswordHandler)
 
+	ssoGroup := group.Group("/sso")
+	ssoGroup.GET("/connection", SSOConnectionHandler)
+	ssoGroup.POST("/signin", SSOSigninHandler)
+	ssoGroup.POST("/passphrase", SetPassphraseHandler)
+
+	group.POST("/service-accounts/signin", ServiceAccountSigninHandler)
+
 	srpGroup := group.Group("/srp")
 	srpGroup.POST("/getB", nil)
 	srpGroup.POST("/getM2", nil)
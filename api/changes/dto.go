@@ -0,0 +1,29 @@
+package changes
+
+import (
+	"github.com/envsecrets/envsecrets/internal/changes"
+	"github.com/envsecrets/envsecrets/internal/secrets/pkg/keypayload"
+)
+
+type CreateOptions struct {
+
+	//	Encrypted key=value pairs to set.
+	Data    keypayload.KPMap `json:"data,omitempty"`
+	Removed []string         `json:"removed,omitempty"`
+	Message string           `json:"message,omitempty"`
+
+	//	Sync the secrets with the environment's integrations once approved.
+	Sync bool `json:"sync,omitempty"`
+}
+
+type ListOptions struct {
+	Status changes.Status `query:"status"`
+}
+
+type ReviewOptions struct {
+	Comment string `json:"comment,omitempty"`
+
+	//	Password of the approver, to decrypt the secrets for syncing them.
+	//	Only required if the change request asks for a sync.
+	Password string `json:"password,omitempty"`
+}
@@ -0,0 +1,329 @@
+package changes
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/envsecrets/envsecrets/cli/auth"
+	"github.com/envsecrets/envsecrets/internal/audits"
+	"github.com/envsecrets/envsecrets/internal/changes"
+	"github.com/envsecrets/envsecrets/internal/clients"
+	"github.com/envsecrets/envsecrets/internal/context"
+	"github.com/envsecrets/envsecrets/internal/environments"
+	"github.com/envsecrets/envsecrets/internal/keys"
+	keyCommons "github.com/envsecrets/envsecrets/internal/keys/commons"
+	"github.com/envsecrets/envsecrets/internal/organisations"
+	"github.com/envsecrets/envsecrets/internal/permissions"
+	"github.com/envsecrets/envsecrets/internal/roles"
+	"github.com/envsecrets/envsecrets/internal/secrets"
+	secretCommons "github.com/envsecrets/envsecrets/internal/secrets/commons"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/labstack/echo/v4"
+)
+
+func CreateHandler(c echo.Context) error {
+
+	//	Unmarshal the incoming payload
+	var payload CreateOptions
+	if err := c.Bind(&payload); err != nil {
+		return c.JSON(http.StatusBadRequest, &clients.APIResponse{
+			Message: "failed to parse the body",
+			Error:   err.Error(),
+		})
+	}
+
+	envID := c.Param(ENV_ID)
+
+	//	Initialize a new default context
+	ctx := context.NewContext(&context.Config{Type: context.APIContext, EchoContext: c})
+
+	token := c.Get("user").(*jwt.Token)
+	claims := token.Claims.(*auth.Claims)
+
+	//	Initialize Hasura client with admin privileges,
+	//	since the user's permissions have already been validated.
+	client := clients.NewGQLClient(&clients.GQLConfig{
+		Type: clients.HasuraClientType,
+		Headers: []clients.Header{
+			clients.XHasuraAdminSecretHeader,
+		},
+	})
+
+	change, err := changes.GetService().Create(ctx, client, &changes.CreateOptions{
+		EnvID:   envID,
+		UserID:  claims.Hasura.UserID,
+		Data:    payload.Data,
+		Removed: payload.Removed,
+		Message: payload.Message,
+		Sync:    payload.Sync,
+	})
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &clients.APIResponse{
+			Message: "Failed to create the change request",
+			Error:   err.Error(),
+		})
+	}
+
+	audit(c, ctx, client, claims.Hasura.UserID, audits.ChangeRequestedAction, change)
+
+	return c.JSON(http.StatusCreated, &clients.APIResponse{
+		Message: "successfully created the change request",
+		Data:    change,
+	})
+}
+
+func ListHandler(c echo.Context) error {
+
+	var payload ListOptions
+	if err := c.Bind(&payload); err != nil {
+		return c.JSON(http.StatusBadRequest, &clients.APIResponse{
+			Message: "failed to parse the query",
+			Error:   err.Error(),
+		})
+	}
+
+	//	Initialize a new default context
+	ctx := context.NewContext(&context.Config{Type: context.APIContext, EchoContext: c})
+
+	//	Initialize new Hasura client
+	client := clients.NewGQLClient(&clients.GQLConfig{
+		Type:          clients.HasuraClientType,
+		Authorization: c.Request().Header.Get(echo.HeaderAuthorization),
+	})
+
+	result, err := changes.GetService().List(ctx, client, &changes.ListOptions{
+		EnvID:  c.Param(ENV_ID),
+		Status: payload.Status,
+	})
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &clients.APIResponse{
+			Message: "Failed to list the change requests",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, &clients.APIResponse{
+		Message: "successfully fetched the change requests",
+		Data:    result,
+	})
+}
+
+func GetHandler(c echo.Context) error {
+
+	//	Initialize a new default context
+	ctx := context.NewContext(&context.Config{Type: context.APIContext, EchoContext: c})
+
+	//	Initialize new Hasura client.
+	//	Hasura only returns the change request if the user can read the environment.
+	client := clients.NewGQLClient(&clients.GQLConfig{
+		Type:          clients.HasuraClientType,
+		Authorization: c.Request().Header.Get(echo.HeaderAuthorization),
+	})
+
+	change, err := changes.GetService().Get(ctx, client, c.Param(CHANGE_ID))
+	if err != nil {
+		return c.JSON(http.StatusNotFound, &clients.APIResponse{
+			Message: "Failed to fetch the change request",
+			Error:   err.Error(),
+		})
+	}
+
+	if err := permissions.AuthorizeRequest(c, permissions.Scope{EnvID: change.EnvID}, roles.SecretsResource, roles.ReadAction); err != nil {
+		return c.JSON(http.StatusForbidden, &clients.APIResponse{
+			Message: "You are not allowed to read the secrets of this environment",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, &clients.APIResponse{
+		Message: "successfully fetched the change request",
+		Data:    change,
+	})
+}
+
+func ApproveHandler(c echo.Context) error {
+	return review(c, changes.ApproveDecision)
+}
+
+func RejectHandler(c echo.Context) error {
+	return review(c, changes.RejectDecision)
+}
+
+// --- Flow ---
+//
+//  1. Validate that the user is allowed to review changes of the environment.
+//     Requesters can always reject, i.e. withdraw, their own change requests.
+//  2. Record the review, which applies the change once it has enough approvals.
+//  3. If the applied change asks for a sync, decrypt the new version with
+//     the approver's copy of the organisation's key and sync it.
+func review(c echo.Context, decision changes.Decision) error {
+
+	//	Unmarshal the incoming payload
+	var payload ReviewOptions
+	if err := c.Bind(&payload); err != nil {
+		return c.JSON(http.StatusBadRequest, &clients.APIResponse{
+			Message: "failed to parse the body",
+			Error:   err.Error(),
+		})
+	}
+
+	//	Initialize a new default context
+	ctx := context.NewContext(&context.Config{Type: context.APIContext, EchoContext: c})
+
+	token := c.Get("user").(*jwt.Token)
+	claims := token.Claims.(*auth.Claims)
+
+	//	Initialize Hasura client with admin privileges,
+	//	since reviewers write the secrets on behalf of the requester.
+	client := clients.NewGQLClient(&clients.GQLConfig{
+		Type: clients.HasuraClientType,
+		Headers: []clients.Header{
+			clients.XHasuraAdminSecretHeader,
+		},
+	})
+
+	change, err := changes.GetService().Get(ctx, client, c.Param(CHANGE_ID))
+	if err != nil {
+		return c.JSON(http.StatusNotFound, &clients.APIResponse{
+			Message: "Failed to fetch the change request",
+			Error:   err.Error(),
+		})
+	}
+
+	withdrawal := decision == changes.RejectDecision && change.UserID == claims.Hasura.UserID
+	if !withdrawal {
+		if err := permissions.AuthorizeRequest(c, permissions.Scope{EnvID: change.EnvID}, roles.SecretsResource, roles.ApproveAction); err != nil {
+			return c.JSON(http.StatusForbidden, &clients.APIResponse{
+				Message: "You are not allowed to review the changes of this environment",
+				Error:   err.Error(),
+			})
+		}
+	}
+
+	change, err = changes.GetService().Review(ctx, client, &changes.ReviewOptions{
+		ID:       change.ID,
+		UserID:   claims.Hasura.UserID,
+		Decision: decision,
+		Comment:  payload.Comment,
+	})
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, changes.ErrNotPending) || errors.Is(err, changes.ErrAlreadyVoted) {
+			status = http.StatusConflict
+		} else if errors.Is(err, changes.ErrSelfApproval) {
+			status = http.StatusForbidden
+		}
+		return c.JSON(status, &clients.APIResponse{
+			Message: "Failed to review the change request",
+			Error:   err.Error(),
+		})
+	}
+
+	action := audits.ChangeRejectedAction
+	if decision == changes.ApproveDecision {
+		action = audits.ChangeApprovedAction
+	}
+	audit(c, ctx, client, claims.Hasura.UserID, action, change)
+
+	//	Never return the encrypted data back.
+	change.Data = nil
+
+	response := changes.ReviewResponse{
+		ChangeRequest: change,
+	}
+
+	if change.Status == changes.ApprovedStatus && change.Sync {
+		if err := sync(c, ctx, claims.Hasura.UserID, change, payload.Password); err != nil {
+			c.Logger().Error(err)
+			response.SyncError = err.Error()
+		} else {
+			response.Synced = true
+		}
+	}
+
+	return c.JSON(http.StatusOK, &clients.APIResponse{
+		Message: "successfully reviewed the change request",
+		Data:    &response,
+	})
+}
+
+// Decrypts the version created by an approved change request and syncs it
+// with the environment's integrations.
+func sync(c echo.Context, ctx context.ServiceContext, userID string, change *changes.ChangeRequest, password string) error {
+
+	if password == "" {
+		return errors.New("password is required to sync the approved secrets")
+	}
+
+	//	Initialize new Hasura client
+	client := clients.NewGQLClient(&clients.GQLConfig{
+		Type:          clients.HasuraClientType,
+		Authorization: c.Request().Header.Get(echo.HeaderAuthorization),
+	})
+
+	organisation, err := organisations.GetService().GetByEnvironment(ctx, client, change.EnvID)
+	if err != nil {
+		return err
+	}
+
+	//	Decrypt and get the bytes of user's own copy of organisation's encryption key.
+	key, err := keys.DecryptMemberKey(ctx, client, userID, &keyCommons.DecryptOptions{
+		OrgID:    organisation.ID,
+		Password: password,
+	})
+	if err != nil {
+		return err
+	}
+
+	secret, err := secrets.Get(ctx, client, &secretCommons.GetOptions{
+		EnvID:   change.EnvID,
+		Version: change.Version,
+	})
+	if err != nil {
+		return err
+	}
+
+	decrypted, err := secrets.Decrypt(ctx, client, &secretCommons.DecryptOptions{
+		Secret: secret,
+		Key:    key,
+	})
+	if err != nil {
+		return err
+	}
+
+	return environments.GetService().Sync(ctx, client, &environments.SyncOptions{
+		EnvID:   change.EnvID,
+		Pairs:   &decrypted.Data,
+		Version: change.Version,
+	})
+}
+
+// Records the action on a change request in the organisation's audit logs.
+func audit(c echo.Context, ctx context.ServiceContext, client *clients.GQLClient, userID string, action audits.Action, change *changes.ChangeRequest) {
+
+	organisation, err := organisations.GetService().GetByEnvironment(ctx, client, change.EnvID)
+	if err != nil {
+		c.Logger().Error(err)
+		return
+	}
+
+	metadata := map[string]interface{}{
+		"env_id": change.EnvID,
+		"diff":   change.Diff,
+		"status": change.Status,
+	}
+	if change.Version != nil {
+		metadata["version"] = *change.Version
+	}
+
+	if _, err := audits.GetService().Create(ctx, client, &audits.CreateOptions{
+		OrgID:      organisation.ID,
+		UserID:     userID,
+		Action:     action,
+		EntityType: audits.ChangeRequestEntity,
+		EntityID:   change.ID,
+		Metadata:   metadata,
+	}); err != nil {
+		c.Logger().Error(err)
+	}
+}
@@ -0,0 +1,26 @@
+package changes
+
+import (
+	"github.com/envsecrets/envsecrets/internal/middlewares"
+	"github.com/envsecrets/envsecrets/internal/roles"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	ENV_ID    = "env_id"
+	CHANGE_ID = "change_id"
+)
+
+func AddRoutes(sg *echo.Group) {
+
+	//	Change requests of protected environments.
+	environment := sg.Group("/environments/:" + ENV_ID + "/changes")
+	environment.GET("", ListHandler, middlewares.Authorize(roles.SecretsResource, roles.ReadAction))
+	environment.POST("", CreateHandler, middlewares.Authorize(roles.SecretsResource, roles.WriteAction))
+
+	//	Reviewers are authorized against the environment of the change request.
+	group := sg.Group("/changes/:" + CHANGE_ID)
+	group.GET("", GetHandler)
+	group.POST("/approve", ApproveHandler)
+	group.POST("/reject", RejectHandler)
+}
@@ -1,6 +1,10 @@
 package environments
 
-import "github.com/envsecrets/envsecrets/internal/secrets/pkg/keypayload"
+import (
+	"github.com/envsecrets/envsecrets/internal/integrations"
+	"github.com/envsecrets/envsecrets/internal/roles"
+	"github.com/envsecrets/envsecrets/internal/secrets/pkg/keypayload"
+)
 
 type SyncWithPasswordOptions struct {
 	EventIDs []string
@@ -14,4 +18,31 @@ type SyncWithPasswordOptions struct {
 type SyncOptions struct {
 	EventIDs []string          `json:"event_ids,omitempty"`
 	Pairs    *keypayload.KPMap `json:"pairs"`
+
+	//	Version of the secrets being synced, if known.
+	Version *int `json:"version,omitempty"`
+}
+
+type ImportOptions struct {
+	IntegrationID   string                 `json:"integration_id"`
+	IntegrationType integrations.Type      `json:"integration_type"`
+	EntityDetails   map[string]interface{} `json:"entity_details"`
+}
+
+type SetPermissionsOptions struct {
+	UserID      string          `json:"user_id"`
+	Permissions roles.Overrides `json:"permissions"`
+}
+
+type ProtectOptions struct {
+	Protected bool `json:"protected"`
+
+	//	Number of approvals a change request needs before it is applied.
+	RequiredApprovals int `json:"required_approvals,omitempty"`
+}
+
+type SetAllowedCIDRsOptions struct {
+
+	//	IP addresses or CIDR ranges. An empty list allows any network.
+	AllowedCIDRs []string `json:"allowed_cidrs"`
 }
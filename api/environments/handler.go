@@ -7,13 +7,17 @@ import (
 	"github.com/envsecrets/envsecrets/internal/clients"
 	"github.com/envsecrets/envsecrets/internal/context"
 	"github.com/envsecrets/envsecrets/internal/environments"
+	"github.com/envsecrets/envsecrets/internal/integrations"
 	"github.com/envsecrets/envsecrets/internal/keys"
 	keysCommons "github.com/envsecrets/envsecrets/internal/keys/commons"
 	"github.com/envsecrets/envsecrets/internal/organisations"
+	"github.com/envsecrets/envsecrets/internal/permissions"
 	"github.com/envsecrets/envsecrets/internal/projects"
+	"github.com/envsecrets/envsecrets/internal/roles"
 	"github.com/envsecrets/envsecrets/internal/secrets"
 	secretCommons "github.com/envsecrets/envsecrets/internal/secrets/commons"
 	"github.com/envsecrets/envsecrets/internal/subscriptions"
+	"github.com/envsecrets/envsecrets/internal/tokens"
 	"github.com/envsecrets/envsecrets/utils"
 	"github.com/golang-jwt/jwt/v4"
 	echo "github.com/labstack/echo/v4"
@@ -56,6 +60,17 @@ func ValidateInputHandler(c echo.Context) error {
 	//	If the number of environments is greater than the allowed limit, proceed to check whether the organisation has an active subscription.
 	//	Otherwise, approve the inputs and allow for creation of the project.
 	for _, row := range rows {
+
+		//	Validate the user's permission to create environments in this project.
+		if err := permissions.AuthorizeRequest(c, permissions.Scope{ProjectID: row.ProjectID}, roles.EnvironmentsResource, roles.CreateAction); err != nil {
+			return c.JSON(http.StatusBadRequest, &clients.HasuraActionResponse{
+				Message: "You are not allowed to create environments in this project",
+				Extensions: &clients.HasuraActionsResponseExtensions{
+					Error: err,
+				},
+			})
+		}
+
 		environments, err := environments.GetService().List(ctx, client, &environments.ListOptions{
 			ProjectID: row.ProjectID,
 		})
@@ -163,7 +178,7 @@ func SyncWithPasswordHandler(c echo.Context) error {
 
 	//	Extract the user's email from JWT
 	token := c.Get("user").(*jwt.Token)
-	claims := token.Claims.(*clients.Claims)
+	claims := token.Claims.(*auth.Claims)
 
 	//	Decrypt and get the bytes of user's own copy of organisation's encryption key.
 	key, err := keys.DecryptMemberKey(ctx, client, claims.Hasura.UserID, &keysCommons.DecryptOptions{
@@ -210,6 +225,7 @@ func SyncWithPasswordHandler(c echo.Context) error {
 		EnvID:    envID,
 		EventIDs: payload.EventIDs,
 		Pairs:    &decrypted.Data,
+		Version:  response.Version,
 	}); err != nil {
 		return c.JSON(http.StatusBadRequest, &clients.APIResponse{
 			Message: "Failed to sync the secrets",
@@ -295,6 +311,7 @@ func SyncHandler(c echo.Context) error {
 		EnvID:    envID,
 		Pairs:    payload.Pairs,
 		EventIDs: payload.EventIDs,
+		Version:  payload.Version,
 	}); err != nil {
 		return c.JSON(http.StatusBadRequest, &clients.APIResponse{
 			Message: "Failed to sync the secrets",
@@ -306,3 +323,302 @@ func SyncHandler(c echo.Context) error {
 		Message: "successfully synced secrets",
 	})
 }
+
+// --- Flow ---
+//
+//  1. Validate that the integration belongs to the organisation of this environment.
+//  2. Read the existing secrets from the integration's platform.
+//  3. Encrypt the values with the user's sync key before sending them back,
+//     so that the client can re-encrypt them with the organisation's key.
+func ImportHandler(c echo.Context) error {
+
+	//	Extract the entity type
+	envID := c.Param(ENV_ID)
+	if envID == "" {
+		return c.JSON(http.StatusBadRequest, &clients.APIResponse{
+			Message: "invalid environment ID",
+			Error:   "invalid environment ID",
+		})
+	}
+
+	//	Unmarshal the incoming payload
+	var payload ImportOptions
+	if err := c.Bind(&payload); err != nil {
+		return c.JSON(http.StatusBadRequest, &clients.APIResponse{
+			Message: "failed to parse the body",
+			Error:   err.Error(),
+		})
+	}
+
+	if !payload.IntegrationType.IsValid() {
+		return c.JSON(http.StatusBadRequest, &clients.APIResponse{
+			Message: "invalid integration type",
+			Error:   "invalid integration type",
+		})
+	}
+
+	//	Initialize a new default context
+	ctx := context.NewContext(&context.Config{Type: context.APIContext, EchoContext: c})
+
+	//	Initialize new Hasura client
+	client := clients.NewGQLClient(&clients.GQLConfig{
+		Type:          clients.HasuraClientType,
+		Authorization: c.Request().Header.Get(echo.HeaderAuthorization),
+	})
+
+	//	Fetch the organisation using environment ID.
+	organisation, err := organisations.GetService().GetByEnvironment(ctx, client, envID)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &clients.APIResponse{
+			Message: "Failed to fetch the organisation this environment is associated with",
+			Error:   err.Error(),
+		})
+	}
+
+	integration, err := integrations.GetService().Get(ctx, client, payload.IntegrationID)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &clients.APIResponse{
+			Message: "Failed to fetch the integration",
+			Error:   err.Error(),
+		})
+	}
+
+	if integration.OrgID != organisation.ID {
+		return c.JSON(http.StatusBadRequest, &clients.APIResponse{
+			Message: "integration does not belong to this environment's organisation",
+			Error:   "integration does not belong to this environment's organisation",
+		})
+	}
+
+	//	Read the secrets from the integration.
+	pairs, err := integrations.GetService().Import(ctx, client, payload.IntegrationType, &integrations.ImportOptions{
+		IntegrationID: payload.IntegrationID,
+		EntityDetails: payload.EntityDetails,
+	})
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &clients.APIResponse{
+			Message: "Failed to import the secrets",
+			Error:   err.Error(),
+		})
+	}
+
+	//	Extract the user's email from JWT
+	token := c.Get("user").(*jwt.Token)
+	claims := token.Claims.(*auth.Claims)
+
+	//	Get the user's sync key and decrypt it with server's own encryption key.
+	syncKey, err := keys.GetSyncKeyByUserID(ctx, client, claims.Hasura.UserID)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &clients.APIResponse{
+			Message: "failed to get the user's sync key",
+			Error:   err.Error(),
+		})
+	}
+
+	decryptedSyncKeyBytes, err := keys.OpenSymmetricallyByServer(syncKey)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &clients.APIResponse{
+			Message: "failed to decrypt the user's sync key",
+			Error:   err.Error(),
+		})
+	}
+
+	//	Encrypt the plaintext values using the decrypted sync key.
+	var decryptedSyncKey [32]byte
+	copy(decryptedSyncKey[:], decryptedSyncKeyBytes)
+	if err := pairs.Encrypt(decryptedSyncKey); err != nil {
+		return c.JSON(http.StatusBadRequest, &clients.APIResponse{
+			Message: "failed to encrypt the secrets",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, &clients.APIResponse{
+		Message: "successfully imported secrets",
+		Data:    pairs,
+	})
+}
+
+// Turns the protected mode of the environment on or off.
+func ProtectHandler(c echo.Context) error {
+
+	//	Unmarshal the incoming payload
+	var payload ProtectOptions
+	if err := c.Bind(&payload); err != nil {
+		return c.JSON(http.StatusBadRequest, &clients.APIResponse{
+			Message: "failed to parse the body",
+			Error:   err.Error(),
+		})
+	}
+
+	if payload.RequiredApprovals < 0 {
+		return c.JSON(http.StatusBadRequest, &clients.APIResponse{
+			Message: "invalid number of required approvals",
+			Error:   "invalid number of required approvals",
+		})
+	}
+
+	//	Initialize a new default context
+	ctx := context.NewContext(&context.Config{Type: context.APIContext, EchoContext: c})
+
+	//	Initialize Hasura client with admin privileges,
+	//	since the user's permissions have already been validated.
+	client := clients.NewGQLClient(&clients.GQLConfig{
+		Type: clients.HasuraClientType,
+		Headers: []clients.Header{
+			clients.XHasuraAdminSecretHeader,
+		},
+	})
+
+	environment, err := environments.GetService().Protect(ctx, client, c.Param(ENV_ID), &environments.ProtectOptions{
+		Protected:         payload.Protected,
+		RequiredApprovals: payload.RequiredApprovals,
+	})
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &clients.APIResponse{
+			Message: "Failed to update the environment's protection",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, &clients.APIResponse{
+		Message: "successfully updated the environment's protection",
+		Data:    environment,
+	})
+}
+
+// Replaces the networks the tokens of an environment can be used from.
+func SetAllowedCIDRsHandler(c echo.Context) error {
+
+	//	Unmarshal the incoming payload
+	var payload SetAllowedCIDRsOptions
+	if err := c.Bind(&payload); err != nil {
+		return c.JSON(http.StatusBadRequest, &clients.APIResponse{
+			Message: "failed to parse the body",
+			Error:   err.Error(),
+		})
+	}
+
+	cidrs := make([]string, 0, len(payload.AllowedCIDRs))
+	for _, item := range payload.AllowedCIDRs {
+		network, err := tokens.ParseCIDR(item)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, &clients.APIResponse{
+				Message: "invalid network: " + item,
+				Error:   err.Error(),
+			})
+		}
+		cidrs = append(cidrs, network.String())
+	}
+
+	//	Initialize a new default context
+	ctx := context.NewContext(&context.Config{Type: context.APIContext, EchoContext: c})
+
+	//	Initialize Hasura client with admin privileges,
+	//	since the user's permissions have already been validated.
+	client := clients.NewGQLClient(&clients.GQLConfig{
+		Type: clients.HasuraClientType,
+		Headers: []clients.Header{
+			clients.XHasuraAdminSecretHeader,
+		},
+	})
+
+	environment, err := environments.GetService().SetAllowedCIDRs(ctx, client, c.Param(ENV_ID), cidrs)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &clients.APIResponse{
+			Message: "Failed to update the environment's allowed networks",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, &clients.APIResponse{
+		Message: "successfully updated the environment's allowed networks",
+		Data:    environment,
+	})
+}
+
+// Overrides the role of a member for this environment.
+// For example, to allow a contractor to read the secrets of "dev" but not those of "prod".
+func SetPermissionsHandler(c echo.Context) error {
+
+	//	Unmarshal the incoming payload
+	var payload SetPermissionsOptions
+	if err := c.Bind(&payload); err != nil {
+		return c.JSON(http.StatusBadRequest, &clients.APIResponse{
+			Message: "failed to parse the body",
+			Error:   err.Error(),
+		})
+	}
+
+	//	Initialize a new default context
+	ctx := context.NewContext(&context.Config{Type: context.APIContext, EchoContext: c})
+
+	//	Initialize Hasura client with admin privileges,
+	//	since the user's permissions have already been validated.
+	client := clients.NewGQLClient(&clients.GQLConfig{
+		Type: clients.HasuraClientType,
+		Headers: []clients.Header{
+			clients.XHasuraAdminSecretHeader,
+		},
+	})
+
+	scope := permissions.Scope{
+		EnvID: c.Param(ENV_ID),
+	}
+
+	//	Overrides can only be set for members of the organisation.
+	if _, err := permissions.GetService().Get(ctx, client, &permissions.GetOptions{
+		Scope:  scope,
+		UserID: payload.UserID,
+	}); err != nil {
+		return c.JSON(http.StatusBadRequest, &clients.APIResponse{
+			Message: "the user is not a member of this environment's organisation",
+			Error:   err.Error(),
+		})
+	}
+
+	if err := permissions.GetService().SetOverrides(ctx, client, &permissions.SetOverridesOptions{
+		Scope:     scope,
+		UserID:    payload.UserID,
+		Overrides: payload.Permissions,
+	}); err != nil {
+		return c.JSON(http.StatusBadRequest, &clients.APIResponse{
+			Message: "Failed to set the permissions",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, &clients.APIResponse{
+		Message: "successfully set the permissions",
+	})
+}
+
+// Removes the overrides of a member's role for this environment.
+func DeletePermissionsHandler(c echo.Context) error {
+
+	//	Initialize a new default context
+	ctx := context.NewContext(&context.Config{Type: context.APIContext, EchoContext: c})
+
+	//	Initialize Hasura client with admin privileges,
+	//	since the user's permissions have already been validated.
+	client := clients.NewGQLClient(&clients.GQLConfig{
+		Type: clients.HasuraClientType,
+		Headers: []clients.Header{
+			clients.XHasuraAdminSecretHeader,
+		},
+	})
+
+	if err := permissions.GetService().DeleteOverrides(ctx, client, &permissions.DeleteOverridesOptions{
+		EnvID:  c.Param(ENV_ID),
+		UserID: c.Param(USER_ID),
+	}); err != nil {
+		return c.JSON(http.StatusBadRequest, &clients.APIResponse{
+			Message: "Failed to delete the permissions",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, &clients.APIResponse{
+		Message: "successfully deleted the permissions",
+	})
+}
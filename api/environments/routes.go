@@ -1,11 +1,14 @@
 package environments
 
 import (
+	"github.com/envsecrets/envsecrets/internal/middlewares"
+	"github.com/envsecrets/envsecrets/internal/roles"
 	"github.com/labstack/echo/v4"
 )
 
 const (
-	ENV_ID = "env_id"
+	ENV_ID  = "env_id"
+	USER_ID = "user_id"
 )
 
 func AddRoutes(sg *echo.Group) {
@@ -14,6 +17,17 @@ func AddRoutes(sg *echo.Group) {
 	group.POST("/validate-input", ValidateInputHandler)
 
 	environment := group.Group("/:" + ENV_ID)
-	environment.POST("/sync-password", SyncWithPasswordHandler)
-	environment.POST("/sync", SyncHandler)
+	environment.POST("/sync-password", SyncWithPasswordHandler, middlewares.Authorize(roles.SecretsResource, roles.SyncAction))
+	environment.POST("/sync", SyncHandler, middlewares.Authorize(roles.SecretsResource, roles.SyncAction))
+	environment.POST("/import", ImportHandler, middlewares.Authorize(roles.SecretsResource, roles.WriteAction))
+
+	//	Protected environments only accept secret changes through approved change requests.
+	environment.PUT("/protection", ProtectHandler, middlewares.Authorize(roles.PermissionsResource, roles.UpdateAction))
+
+	//	Networks the environment's tokens can be used from.
+	environment.PUT("/allowed-cidrs", SetAllowedCIDRsHandler, middlewares.Authorize(roles.PermissionsResource, roles.UpdateAction))
+
+	//	Per-environment overrides of members' roles.
+	environment.PUT("/permissions", SetPermissionsHandler, middlewares.Authorize(roles.PermissionsResource, roles.UpdateAction))
+	environment.DELETE("/permissions/:"+USER_ID, DeletePermissionsHandler, middlewares.Authorize(roles.PermissionsResource, roles.UpdateAction))
 }
@@ -6,6 +6,8 @@ import (
 	"github.com/envsecrets/envsecrets/internal/clients"
 	"github.com/envsecrets/envsecrets/internal/context"
 	"github.com/envsecrets/envsecrets/internal/events"
+	"github.com/envsecrets/envsecrets/internal/permissions"
+	"github.com/envsecrets/envsecrets/internal/roles"
 	"github.com/envsecrets/envsecrets/utils"
 	"github.com/labstack/echo/v4"
 )
@@ -28,6 +30,14 @@ func ActionsGetHandler(c echo.Context) error {
 		})
 	}
 
+	//	Validate the user's permission to read this environment.
+	if err := permissions.AuthorizeRequest(c, permissions.Scope{EnvID: inputs.EnvID}, roles.EnvironmentsResource, roles.ReadAction); err != nil {
+		return c.JSON(http.StatusForbidden, &clients.APIResponse{
+			Message: "You are not allowed to read this environment",
+			Error:   err.Error(),
+		})
+	}
+
 	//	Initialize a new default context
 	ctx := context.NewContext(&context.Config{Type: context.APIContext, EchoContext: c})
 
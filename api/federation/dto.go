@@ -0,0 +1,33 @@
+package federation
+
+import "github.com/envsecrets/envsecrets/internal/federation"
+
+type CreateOptions struct {
+	EnvID       string              `json:"env_id"`
+	Name        string              `json:"name"`
+	Provider    federation.Provider `json:"provider"`
+	Issuer      string              `json:"issuer,omitempty"`
+	Audience    string              `json:"audience,omitempty"`
+	Repository  string              `json:"repository,omitempty"`
+	Branch      string              `json:"branch,omitempty"`
+	Environment string              `json:"environment,omitempty"`
+	Claims      map[string]string   `json:"claims,omitempty"`
+
+	//	Exact IDs of the repository's owner and of the repository, on GitHub or GitLab.
+	RepositoryOwnerID string `json:"repository_owner_id,omitempty"`
+	RepositoryID      string `json:"repository_id,omitempty"`
+
+	//	Lifetime of the issued environment tokens, like "30m". Defaults to 15 minutes.
+	TokenExpiry string `json:"token_expiry,omitempty"`
+
+	//	Password of the admin, to decrypt their copy of the organisation's key.
+	Password string `json:"password"`
+
+	//	Consent to the server keeping a copy of the organisation's key,
+	//	with which it can decrypt every secret of the organisation on its own.
+	AllowServerDecryption bool `json:"allow_server_decryption"`
+}
+
+type DeleteResponse struct {
+	RevokedTokens int `json:"revoked_tokens"`
+}
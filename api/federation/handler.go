@@ -0,0 +1,320 @@
+package federation
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/envsecrets/envsecrets/cli/auth"
+	"github.com/envsecrets/envsecrets/internal/audits"
+	"github.com/envsecrets/envsecrets/internal/clients"
+	"github.com/envsecrets/envsecrets/internal/context"
+	"github.com/envsecrets/envsecrets/internal/federation"
+	"github.com/envsecrets/envsecrets/internal/keys"
+	keyCommons "github.com/envsecrets/envsecrets/internal/keys/commons"
+	"github.com/envsecrets/envsecrets/internal/organisations"
+	"github.com/envsecrets/envsecrets/internal/permissions"
+	"github.com/envsecrets/envsecrets/internal/roles"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/labstack/echo/v4"
+)
+
+func ListHandler(c echo.Context) error {
+
+	//	Initialize a new default context
+	ctx := context.NewContext(&context.Config{Type: context.APIContext, EchoContext: c})
+
+	result, err := federation.GetService().List(ctx, newAdminClient(), &federation.ListOptions{
+		OrgID: c.Param(ORG_ID),
+		EnvID: c.QueryParam("env_id"),
+	})
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &clients.APIResponse{
+			Message: "Failed to list the trust policies",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, &clients.APIResponse{
+		Message: "successfully listed the trust policies",
+		Data:    result,
+	})
+}
+
+// --- Flow ---
+//
+//  1. Validate that the environment belongs to the organisation, and that the admin can read its secrets.
+//  2. Decrypt the admin's copy of the organisation's key, for the server to seal its own copy.
+//  3. Create the policy, and record it in the organisation's audit logs.
+func CreateHandler(c echo.Context) error {
+
+	//	Unmarshal the incoming payload
+	var payload CreateOptions
+	if err := c.Bind(&payload); err != nil {
+		return c.JSON(http.StatusBadRequest, &clients.APIResponse{
+			Message: "failed to parse the body",
+			Error:   err.Error(),
+		})
+	}
+
+	if payload.EnvID == "" || payload.Name == "" || payload.Password == "" {
+		return c.JSON(http.StatusBadRequest, &clients.APIResponse{
+			Message: "invalid trust policy",
+			Error:   "environment, name and your password are required",
+		})
+	}
+
+	if !payload.AllowServerDecryption {
+		return c.JSON(http.StatusBadRequest, &clients.APIResponse{
+			Message: "invalid trust policy",
+			Error:   federation.ErrNoConsent.Error(),
+		})
+	}
+
+	var expiry time.Duration
+	if payload.TokenExpiry != "" {
+		var err error
+		expiry, err = time.ParseDuration(payload.TokenExpiry)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, &clients.APIResponse{
+				Message: "invalid token expiry",
+				Error:   "token expiry must be a duration, like 30m",
+			})
+		}
+	}
+
+	//	Pipelines trusted by the policy can read all the secrets of its environment.
+	if err := permissions.AuthorizeRequest(c, permissions.Scope{EnvID: payload.EnvID}, roles.SecretsResource, roles.ReadAction); err != nil {
+		return c.JSON(http.StatusForbidden, &clients.APIResponse{
+			Message: "You are not allowed to read the secrets of this environment",
+			Error:   err.Error(),
+		})
+	}
+
+	orgID := c.Param(ORG_ID)
+
+	//	Initialize a new default context
+	ctx := context.NewContext(&context.Config{Type: context.APIContext, EchoContext: c})
+
+	client := newAdminClient()
+
+	organisation, err := organisations.GetService().GetByEnvironment(ctx, client, payload.EnvID)
+	if err != nil || organisation.ID != orgID {
+		return c.JSON(http.StatusBadRequest, &clients.APIResponse{
+			Message: "invalid environment",
+			Error:   "the environment doesn't belong to this organisation",
+		})
+	}
+
+	token := c.Get("user").(*jwt.Token)
+	claims := token.Claims.(*auth.Claims)
+
+	orgKey, err := keys.DecryptMemberKey(ctx, clients.NewGQLClient(&clients.GQLConfig{
+		Type:          clients.HasuraClientType,
+		Authorization: c.Request().Header.Get(echo.HeaderAuthorization),
+	}), claims.Hasura.UserID, &keyCommons.DecryptOptions{
+		OrgID:    orgID,
+		Password: payload.Password,
+	})
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &clients.APIResponse{
+			Message: "Failed to decrypt the organisation's key",
+			Error:   err.Error(),
+		})
+	}
+
+	policy, err := federation.GetService().Create(ctx, client, &federation.CreateOptions{
+		OrgID:       orgID,
+		EnvID:       payload.EnvID,
+		Name:        payload.Name,
+		Provider:    payload.Provider,
+		Issuer:      payload.Issuer,
+		Audience:    payload.Audience,
+		Repository:  payload.Repository,
+		Branch:      payload.Branch,
+		Environment: payload.Environment,
+		Claims:      payload.Claims,
+		CreatedBy:   claims.Hasura.UserID,
+		TokenExpiry: expiry,
+		OrgKey:      orgKey,
+
+		AllowServerDecryption: payload.AllowServerDecryption,
+
+		RepositoryOwnerID: payload.RepositoryOwnerID,
+		RepositoryID:      payload.RepositoryID,
+	})
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &clients.APIResponse{
+			Message: "Failed to create the trust policy",
+			Error:   err.Error(),
+		})
+	}
+
+	audit(c, ctx, client, &audits.CreateOptions{
+		OrgID:      orgID,
+		UserID:     claims.Hasura.UserID,
+		Action:     audits.TrustPolicyCreatedAction,
+		EntityType: audits.TrustPolicyEntity,
+		EntityID:   policy.ID,
+		Metadata:   describe(policy),
+	})
+
+	return c.JSON(http.StatusCreated, &clients.APIResponse{
+		Message: "successfully created the trust policy",
+		Data:    policy,
+	})
+}
+
+// Deletes a trust policy, and revokes the environment tokens issued through it.
+func DeleteHandler(c echo.Context) error {
+
+	orgID := c.Param(ORG_ID)
+
+	//	Initialize a new default context
+	ctx := context.NewContext(&context.Config{Type: context.APIContext, EchoContext: c})
+
+	client := newAdminClient()
+
+	policy, err := federation.GetService().Get(ctx, client, c.Param(POLICY_ID))
+	if err == nil && policy.OrgID != orgID {
+		err = federation.ErrNotFound
+	}
+	if err != nil {
+		return c.JSON(http.StatusNotFound, &clients.APIResponse{
+			Message: "Failed to fetch the trust policy",
+			Error:   err.Error(),
+		})
+	}
+
+	revoked, err := federation.GetService().Delete(ctx, client, policy)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &clients.APIResponse{
+			Message: "Failed to delete the trust policy",
+			Error:   err.Error(),
+		})
+	}
+
+	token := c.Get("user").(*jwt.Token)
+	claims := token.Claims.(*auth.Claims)
+
+	metadata := describe(policy)
+	metadata["revoked_tokens"] = revoked
+
+	audit(c, ctx, client, &audits.CreateOptions{
+		OrgID:      orgID,
+		UserID:     claims.Hasura.UserID,
+		Action:     audits.TrustPolicyDeletedAction,
+		EntityType: audits.TrustPolicyEntity,
+		EntityID:   policy.ID,
+		Metadata:   metadata,
+	})
+
+	return c.JSON(http.StatusOK, &clients.APIResponse{
+		Message: "successfully deleted the trust policy",
+		Data: &DeleteResponse{
+			RevokedTokens: revoked,
+		},
+	})
+}
+
+// Exchanges a CI provider's ID token for a short-lived environment token.
+// The request isn't authenticated by a session; the ID token and the trust policies are the authentication.
+func ExchangeHandler(c echo.Context) error {
+
+	//	Unmarshal the incoming payload
+	var payload federation.ExchangeOptions
+	if err := c.Bind(&payload); err != nil {
+		return c.JSON(http.StatusBadRequest, &clients.APIResponse{
+			Message: "failed to parse the body",
+			Error:   err.Error(),
+		})
+	}
+
+	if payload.IDToken == "" {
+		return c.JSON(http.StatusBadRequest, &clients.APIResponse{
+			Message: "id_token is required",
+		})
+	}
+
+	if payload.EnvID == "" && payload.PolicyID == "" {
+		return c.JSON(http.StatusBadRequest, &clients.APIResponse{
+			Message: "either env_id or policy_id is required",
+		})
+	}
+
+	//	Initialize a new default context
+	ctx := context.NewContext(&context.Config{Type: context.APIContext, EchoContext: c})
+
+	client := newAdminClient()
+
+	result, err := federation.GetService().Exchange(ctx, client, &payload)
+	if err != nil {
+		status := http.StatusUnauthorized
+		if errors.Is(err, federation.ErrAmbiguousPolicies) {
+			status = http.StatusConflict
+		}
+		return c.JSON(status, &clients.APIResponse{
+			Message: "Failed to exchange the ID token",
+			Error:   err.Error(),
+		})
+	}
+
+	metadata := federation.Attributes(result.Policy.Provider, result.Claims)
+	audit(c, ctx, client, &audits.CreateOptions{
+		OrgID:      result.Policy.OrgID,
+		Action:     audits.TokenExchangedAction,
+		EntityType: audits.TrustPolicyEntity,
+		EntityID:   result.Policy.ID,
+		Metadata: map[string]interface{}{
+			"name":        result.Policy.Name,
+			"env_id":      result.EnvID,
+			"subject":     result.Claims.Subject(),
+			"repository":  metadata["repository"],
+			"branch":      metadata["branch"],
+			"environment": metadata["environment"],
+			"expires_at":  result.ExpiresAt,
+		},
+	})
+
+	return c.JSON(http.StatusOK, &clients.APIResponse{
+		Message: "successfully exchanged the ID token",
+		Data:    result,
+	})
+}
+
+//	---	Helpers ---
+
+// Initialize Hasura client with admin privileges,
+// since the user's permissions have already been validated.
+func newAdminClient() *clients.GQLClient {
+	return clients.NewGQLClient(&clients.GQLConfig{
+		Type: clients.HasuraClientType,
+		Headers: []clients.Header{
+			clients.XHasuraAdminSecretHeader,
+		},
+	})
+}
+
+func describe(policy *federation.TrustPolicy) map[string]interface{} {
+	return map[string]interface{}{
+		"name":         policy.Name,
+		"env_id":       policy.EnvID,
+		"provider":     policy.Provider,
+		"issuer":       policy.Issuer,
+		"audience":     policy.Audience,
+		"repository":   policy.Repository,
+		"branch":       policy.Branch,
+		"environment":  policy.Environment,
+		"claims":       policy.Claims,
+		"token_expiry": policy.TokenExpiry,
+
+		"repository_owner_id": policy.RepositoryOwnerID,
+		"repository_id":       policy.RepositoryID,
+	}
+}
+
+func audit(c echo.Context, ctx context.ServiceContext, client *clients.GQLClient, options *audits.CreateOptions) {
+	if _, err := audits.GetService().Create(ctx, client, options); err != nil {
+		c.Logger().Error(err)
+	}
+}
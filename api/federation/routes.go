@@ -0,0 +1,23 @@
+package federation
+
+import (
+	"github.com/envsecrets/envsecrets/internal/middlewares"
+	"github.com/envsecrets/envsecrets/internal/roles"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	ORG_ID    = "org_id"
+	POLICY_ID = "policy_id"
+)
+
+func AddRoutes(sg *echo.Group) {
+
+	group := sg.Group("/organisations/:" + ORG_ID + "/trust-policies")
+	group.GET("", ListHandler, middlewares.Authorize(roles.PermissionsResource, roles.ReadAction))
+	group.POST("", CreateHandler, middlewares.Authorize(roles.PermissionsResource, roles.CreateAction))
+	group.DELETE("/:"+POLICY_ID, DeleteHandler, middlewares.Authorize(roles.PermissionsResource, roles.DeleteAction))
+
+	//	Called by CI pipelines with their ID tokens, instead of a user's session.
+	sg.POST("/tokens/oidc", ExchangeHandler)
+}
@@ -10,6 +10,8 @@ import (
 	"github.com/envsecrets/envsecrets/internal/clients"
 	"github.com/envsecrets/envsecrets/internal/context"
 	"github.com/envsecrets/envsecrets/internal/integrations"
+	"github.com/envsecrets/envsecrets/internal/permissions"
+	"github.com/envsecrets/envsecrets/internal/roles"
 	"github.com/labstack/echo/v4"
 )
 
@@ -101,6 +103,14 @@ func SetupHandler(c echo.Context) error {
 		Authorization: c.Request().Header.Get(echo.HeaderAuthorization),
 	})
 
+	//	Validate the user's permission to add integrations to the organisation.
+	if err := permissions.AuthorizeRequest(c, permissions.Scope{OrgID: payload.OrgID}, roles.IntegrationsResource, roles.CreateAction); err != nil {
+		return c.JSON(http.StatusForbidden, &clients.APIResponse{
+			Message: "You are not allowed to add integrations to this organisation",
+			Error:   err.Error(),
+		})
+	}
+
 	//	Run the service handler.
 	_, err := service.Setup(ctx, client, serviceType, &payload)
 	if err != nil {
@@ -136,6 +146,22 @@ func ListEntitiesHandler(c echo.Context) error {
 		Authorization: c.Request().Header.Get(echo.HeaderAuthorization),
 	})
 
+	//	Validate the user's permission to read the integrations of the organisation.
+	integration, err := service.Get(ctx, client, c.Param(INTEGRATION_ID))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &clients.APIResponse{
+			Message: "Failed to fetch the integration",
+			Error:   err.Error(),
+		})
+	}
+
+	if err := permissions.AuthorizeRequest(c, permissions.Scope{OrgID: integration.OrgID}, roles.IntegrationsResource, roles.ReadAction); err != nil {
+		return c.JSON(http.StatusForbidden, &clients.APIResponse{
+			Message: "You are not allowed to read the integrations of this organisation",
+			Error:   err.Error(),
+		})
+	}
+
 	options := make(map[string]interface{})
 
 	for key, value := range c.QueryParams() {
@@ -178,6 +204,22 @@ func ListSubEntitiesHandler(c echo.Context) error {
 		Authorization: c.Request().Header.Get(echo.HeaderAuthorization),
 	})
 
+	//	Validate the user's permission to read the integrations of the organisation.
+	integration, err := service.Get(ctx, client, c.Param(INTEGRATION_ID))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &clients.APIResponse{
+			Message: "Failed to fetch the integration",
+			Error:   err.Error(),
+		})
+	}
+
+	if err := permissions.AuthorizeRequest(c, permissions.Scope{OrgID: integration.OrgID}, roles.IntegrationsResource, roles.ReadAction); err != nil {
+		return c.JSON(http.StatusForbidden, &clients.APIResponse{
+			Message: "You are not allowed to read the integrations of this organisation",
+			Error:   err.Error(),
+		})
+	}
+
 	//	Run the service handler.
 	entities, err := service.ListSubEntities(ctx, client, serviceType, c.Param(INTEGRATION_ID), c.QueryParams())
 	if err != nil {
@@ -10,10 +10,10 @@ func AddRoutes(sg *echo.Group) {
 	commonGroup := sg.Group("/integrations/:" + INTEGRATION_TYPE)
 
 	commonGroup.GET("/callback/setup", SetupCallbackHandler)
-	commonGroup.POST("/setup", SetupHandler)
+	commonGroup.POST("/setup", SetupHandler, middlewares.JWTAuth(nil))
 
 	integrationsGroup := commonGroup.Group("/:" + INTEGRATION_ID)
-	integrationsGroup.GET("/entities", ListEntitiesHandler)
-	integrationsGroup.GET("/sub-entities", ListSubEntitiesHandler)
+	integrationsGroup.GET("/entities", ListEntitiesHandler, middlewares.JWTAuth(nil))
+	integrationsGroup.GET("/sub-entities", ListSubEntitiesHandler, middlewares.JWTAuth(nil))
 	integrationsGroup.POST("/trigger", nil, middlewares.WebhookHeader())
 }
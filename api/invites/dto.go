@@ -10,3 +10,13 @@ type SendOptions struct {
 type AcceptOptions struct {
 	ID string `json:"id,omitempty"`
 }
+
+type GrantOptions struct {
+
+	//	Password of the admin, to decrypt their copy of the organisation's key on the server.
+	Password string `json:"password,omitempty"`
+
+	//	Base64 encoded copy of the organisation's key,
+	//	already sealed with the invitee's public key on the client.
+	Key string `json:"key,omitempty"`
+}
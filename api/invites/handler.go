@@ -1,6 +1,7 @@
 package invites
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/envsecrets/envsecrets/cli/auth"
@@ -9,6 +10,8 @@ import (
 	"github.com/envsecrets/envsecrets/internal/invites"
 	"github.com/envsecrets/envsecrets/internal/keys"
 	keyCommons "github.com/envsecrets/envsecrets/internal/keys/commons"
+	"github.com/envsecrets/envsecrets/internal/permissions"
+	"github.com/envsecrets/envsecrets/internal/roles"
 	"github.com/golang-jwt/jwt/v4"
 	"github.com/labstack/echo/v4"
 )
@@ -35,6 +38,15 @@ func AcceptHandler(c echo.Context) error {
 
 	//	Call the service function.
 	if err := invites.GetService().Accept(ctx, client, payload.ID); err != nil {
+
+		//	The invitee didn't have an account when the invite was sent.
+		//	Their membership will be completed once an admin grants them the organisation's key.
+		if errors.Is(err, invites.ErrKeyGrantPending) {
+			return c.JSON(http.StatusAccepted, &clients.APIResponse{
+				Message: err.Error(),
+			})
+		}
+
 		return c.JSON(http.StatusBadRequest, &clients.APIResponse{
 			Message: "Failed to accept the invite",
 			Error:   err.Error(),
@@ -63,6 +75,14 @@ func SendHandler(c echo.Context) error {
 		})
 	}
 
+	//	Validate the user's permission to add members to the organisation.
+	if err := permissions.AuthorizeRequest(c, permissions.Scope{OrgID: payload.OrgID}, roles.PermissionsResource, roles.CreateAction); err != nil {
+		return c.JSON(http.StatusForbidden, &clients.APIResponse{
+			Message: "You are not allowed to invite members to this organisation",
+			Error:   err.Error(),
+		})
+	}
+
 	//	Initialize a new default context
 	ctx := context.NewContext(&context.Config{Type: context.APIContext, EchoContext: c})
 
@@ -106,3 +126,132 @@ func SendHandler(c echo.Context) error {
 		Message: "successfully sent the invite",
 	})
 }
+
+// Lists the invites of an organisation whose invitees have signed up after being invited,
+// and are now waiting for an admin to grant them the organisation's key.
+func ListPendingGrantsHandler(c echo.Context) error {
+
+	orgID := c.QueryParam("org_id")
+	if orgID == "" {
+		return c.JSON(http.StatusBadRequest, &clients.APIResponse{
+			Message: "org_id is required",
+		})
+	}
+
+	//	Validate the user's permission to add members to the organisation.
+	if err := permissions.AuthorizeRequest(c, permissions.Scope{OrgID: orgID}, roles.PermissionsResource, roles.CreateAction); err != nil {
+		return c.JSON(http.StatusForbidden, &clients.APIResponse{
+			Message: "You are not allowed to grant access to this organisation",
+			Error:   err.Error(),
+		})
+	}
+
+	//	Initialize a new default context
+	ctx := context.NewContext(&context.Config{Type: context.APIContext, EchoContext: c})
+
+	//	Initialize new Hasura client
+	client := clients.NewGQLClient(&clients.GQLConfig{
+		Type:    clients.HasuraClientType,
+		Headers: []clients.Header{clients.XHasuraAdminSecretHeader},
+	})
+
+	grants, err := invites.GetService().ListPendingGrants(ctx, client, orgID)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &clients.APIResponse{
+			Message: "Failed to fetch the pending key grants",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, &clients.APIResponse{
+		Message: "successfully fetched the pending key grants",
+		Data:    grants,
+	})
+}
+
+// ---	Flow ---
+// 1. Fetch the invite using its ID.
+// 2. Validate the admin's permission to add members to the invite's organisation.
+// 3. Either use the key copy sealed by the admin's client,
+// or decrypt the admin's copy of the organisation's key using their password.
+// 4. Save the invitee's copy and complete their membership if they have already accepted the invite.
+func GrantHandler(c echo.Context) error {
+
+	//	Unmarshal the incoming payload
+	var payload GrantOptions
+	if err := c.Bind(&payload); err != nil {
+		return c.JSON(http.StatusBadRequest, &clients.APIResponse{
+			Message: "failed to parse the body",
+			Error:   err.Error(),
+		})
+	}
+
+	if payload.Password == "" && payload.Key == "" {
+		return c.JSON(http.StatusBadRequest, &clients.APIResponse{
+			Message: "either password or key is required",
+		})
+	}
+
+	//	Initialize a new default context
+	ctx := context.NewContext(&context.Config{Type: context.APIContext, EchoContext: c})
+
+	//	Initialize new Hasura client
+	client := clients.NewGQLClient(&clients.GQLConfig{
+		Type:    clients.HasuraClientType,
+		Headers: []clients.Header{clients.XHasuraAdminSecretHeader},
+	})
+
+	invite, err := invites.GetService().Get(ctx, client, c.Param(INVITE_ID))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &clients.APIResponse{
+			Message: "Failed to fetch the invite",
+			Error:   err.Error(),
+		})
+	}
+
+	//	Validate the user's permission to add members to the organisation.
+	if err := permissions.AuthorizeRequest(c, permissions.Scope{OrgID: invite.OrgID}, roles.PermissionsResource, roles.CreateAction); err != nil {
+		return c.JSON(http.StatusForbidden, &clients.APIResponse{
+			Message: "You are not allowed to grant access to this organisation",
+			Error:   err.Error(),
+		})
+	}
+
+	options := invites.GrantOptions{
+		SealedKey: payload.Key,
+	}
+
+	if options.SealedKey == "" {
+
+		token := c.Get("user").(*jwt.Token)
+		claims := token.Claims.(*auth.Claims)
+
+		//	Decrypt and get the bytes of user's own copy of organisation's encryption key.
+		key, err := keys.DecryptMemberKey(ctx, clients.NewGQLClient(&clients.GQLConfig{
+			Type:          clients.HasuraClientType,
+			Authorization: c.Request().Header.Get(echo.HeaderAuthorization),
+		}), claims.Hasura.UserID, &keyCommons.DecryptOptions{
+			OrgID:    invite.OrgID,
+			Password: payload.Password,
+		})
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, &clients.APIResponse{
+				Message: "Failed to decrypt the organisation's key",
+				Error:   err.Error(),
+			})
+		}
+
+		options.Key = key
+	}
+
+	if err := invites.GetService().Grant(ctx, client, invite.ID, &options); err != nil {
+		return c.JSON(http.StatusBadRequest, &clients.APIResponse{
+			Message: "Failed to grant the organisation's key",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, &clients.APIResponse{
+		Message: "successfully granted the organisation's key to " + invite.Email,
+	})
+}
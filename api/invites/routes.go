@@ -4,10 +4,16 @@ import (
 	"github.com/labstack/echo/v4"
 )
 
+const (
+	INVITE_ID = "invite_id"
+)
+
 func AddRoutes(sg *echo.Group) {
 
 	group := sg.Group("/invites")
 
 	group.POST("", SendHandler)
 	group.POST("/accept", AcceptHandler)
+	group.GET("/pending-grants", ListPendingGrantsHandler)
+	group.POST("/:"+INVITE_ID+"/grant", GrantHandler)
 }
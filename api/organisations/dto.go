@@ -1,5 +1,30 @@
 package organisations
 
+import (
+	"github.com/envsecrets/envsecrets/internal/organisations"
+)
+
 type CreateOptions struct {
 	Name string `json:"name,omitempty"`
 }
+
+type RemoveMemberOptions struct {
+	Email string `json:"email,omitempty"`
+
+	//	Rotate the organisation's key right after removing the member.
+	//	Rotation is always performed if the organisation's policy requires it.
+	RotateKey bool `json:"rotate_key,omitempty"`
+
+	//	Password of the admin, to decrypt their copy of the organisation's key.
+	//	Only required to rotate the key.
+	Password string `json:"password,omitempty"`
+}
+
+type RemoveMemberResponse struct {
+	RevokedTokens int                              `json:"revoked_tokens"`
+	Rotation      *organisations.RotateKeyResponse `json:"rotation,omitempty"`
+}
+
+type RotateKeyOptions struct {
+	Password string `json:"password,omitempty"`
+}
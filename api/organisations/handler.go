@@ -3,9 +3,16 @@ package organisations
 import (
 	"net/http"
 
+	"github.com/envsecrets/envsecrets/cli/auth"
+	"github.com/envsecrets/envsecrets/internal/audits"
 	"github.com/envsecrets/envsecrets/internal/clients"
 	"github.com/envsecrets/envsecrets/internal/context"
+	"github.com/envsecrets/envsecrets/internal/keys"
+	keyCommons "github.com/envsecrets/envsecrets/internal/keys/commons"
 	"github.com/envsecrets/envsecrets/internal/organisations"
+	"github.com/envsecrets/envsecrets/internal/serviceaccounts"
+	"github.com/envsecrets/envsecrets/internal/users"
+	"github.com/golang-jwt/jwt/v4"
 	"github.com/labstack/echo/v4"
 )
 
@@ -45,3 +52,233 @@ func CreateHandler(c echo.Context) error {
 		Data:    organisation,
 	})
 }
+
+// ---	Flow ---
+// 1. Validate whether the organisation's key has to be rotated, and decrypt the admin's copy of it if so.
+// 2. Delete the member's membership, their copy of the organisation's key and the tokens they created.
+// 3. Rotate the organisation's key, since the removed member could have cached the decrypted one.
+// 4. Record both the actions in the organisation's audit logs.
+func RemoveMemberHandler(c echo.Context) error {
+
+	//	Unmarshal the incoming payload
+	var payload RemoveMemberOptions
+	if err := c.Bind(&payload); err != nil {
+		return c.JSON(http.StatusBadRequest, &clients.APIResponse{
+			Message: "failed to parse the body",
+			Error:   err.Error(),
+		})
+	}
+
+	if payload.Email == "" {
+		return c.JSON(http.StatusBadRequest, &clients.APIResponse{
+			Message: "email is required",
+		})
+	}
+
+	orgID := c.Param(ORG_ID)
+
+	//	Initialize a new default context
+	ctx := context.NewContext(&context.Config{Type: context.APIContext, EchoContext: c})
+
+	//	Initialize Hasura client with admin privileges,
+	//	since the user's permissions have already been validated.
+	client := clients.NewGQLClient(&clients.GQLConfig{
+		Type: clients.HasuraClientType,
+		Headers: []clients.Header{
+			clients.XHasuraAdminSecretHeader,
+		},
+	})
+
+	organisation, err := organisations.GetService().Get(ctx, client, orgID)
+	if err != nil || organisation == nil {
+		return c.JSON(http.StatusNotFound, &clients.APIResponse{
+			Message: "Failed to fetch the organisation",
+		})
+	}
+
+	user, err := users.GetByEmail(ctx, client, payload.Email)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, &clients.APIResponse{
+			Message: "Failed to fetch the member",
+			Error:   err.Error(),
+		})
+	}
+
+	//	Service accounts are removed by deleting them, which also disables their credentials.
+	if _, err := serviceaccounts.GetService().GetByUserID(ctx, client, user.ID); err == nil {
+		return c.JSON(http.StatusBadRequest, &clients.APIResponse{
+			Message: "This member is a service account; delete the service account instead",
+		})
+	}
+
+	token := c.Get("user").(*jwt.Token)
+	claims := token.Claims.(*auth.Claims)
+
+	//	Decrypt the admin's copy of the organisation's key before removing the member,
+	//	so that a wrong password doesn't leave the member removed without the key rotated.
+	var orgKey []byte
+	rotate := payload.RotateKey || organisation.RotateKeyOnMemberRemoval
+	if rotate {
+
+		if payload.Password == "" {
+			return c.JSON(http.StatusBadRequest, &clients.APIResponse{
+				Message: "password is required to rotate the organisation's key",
+				Error:   string(clients.ErrorTypeKeyRotationRequired),
+			})
+		}
+
+		orgKey, err = keys.DecryptMemberKey(ctx, clients.NewGQLClient(&clients.GQLConfig{
+			Type:          clients.HasuraClientType,
+			Authorization: c.Request().Header.Get(echo.HeaderAuthorization),
+		}), claims.Hasura.UserID, &keyCommons.DecryptOptions{
+			OrgID:    orgID,
+			Password: payload.Password,
+		})
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, &clients.APIResponse{
+				Message: "Failed to decrypt the organisation's key",
+				Error:   err.Error(),
+			})
+		}
+	}
+
+	removal, err := organisations.GetService().RemoveMember(ctx, client, &organisations.RemoveMemberOptions{
+		ID:     orgID,
+		UserID: user.ID,
+	})
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &clients.APIResponse{
+			Message: "Failed to remove the member",
+			Error:   err.Error(),
+		})
+	}
+
+	if _, err := audits.GetService().Create(ctx, client, &audits.CreateOptions{
+		OrgID:      orgID,
+		UserID:     claims.Hasura.UserID,
+		Action:     audits.MemberRemovedAction,
+		EntityType: audits.UserEntity,
+		EntityID:   user.ID,
+		Metadata: map[string]interface{}{
+			"email":          user.Email,
+			"revoked_tokens": removal.RevokedTokens,
+			"key_rotated":    rotate,
+		},
+	}); err != nil {
+		c.Logger().Error(err)
+	}
+
+	response := RemoveMemberResponse{
+		RevokedTokens: removal.RevokedTokens,
+	}
+
+	if !rotate {
+		return c.JSON(http.StatusOK, &clients.APIResponse{
+			Message: "successfully removed the member; rotate the organisation's key if they could have cached it",
+			Data:    &response,
+		})
+	}
+
+	response.Rotation, err = rotateKey(c, ctx, client, orgID, claims.Hasura.UserID, orgKey)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, &clients.APIResponse{
+			Message: "Removed the member, but failed to rotate the organisation's key",
+			Error:   err.Error(),
+			Data:    &response,
+		})
+	}
+
+	return c.JSON(http.StatusOK, &clients.APIResponse{
+		Message: "successfully removed the member and rotated the organisation's key",
+		Data:    &response,
+	})
+}
+
+func RotateKeyHandler(c echo.Context) error {
+
+	//	Unmarshal the incoming payload
+	var payload RotateKeyOptions
+	if err := c.Bind(&payload); err != nil {
+		return c.JSON(http.StatusBadRequest, &clients.APIResponse{
+			Message: "failed to parse the body",
+			Error:   err.Error(),
+		})
+	}
+
+	orgID := c.Param(ORG_ID)
+
+	//	Initialize a new default context
+	ctx := context.NewContext(&context.Config{Type: context.APIContext, EchoContext: c})
+
+	token := c.Get("user").(*jwt.Token)
+	claims := token.Claims.(*auth.Claims)
+
+	//	Decrypt and get the bytes of user's own copy of organisation's encryption key.
+	orgKey, err := keys.DecryptMemberKey(ctx, clients.NewGQLClient(&clients.GQLConfig{
+		Type:          clients.HasuraClientType,
+		Authorization: c.Request().Header.Get(echo.HeaderAuthorization),
+	}), claims.Hasura.UserID, &keyCommons.DecryptOptions{
+		OrgID:    orgID,
+		Password: payload.Password,
+	})
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &clients.APIResponse{
+			Message: "Failed to decrypt the organisation's key",
+			Error:   err.Error(),
+		})
+	}
+
+	//	Initialize Hasura client with admin privileges,
+	//	since the user's permissions have already been validated.
+	client := clients.NewGQLClient(&clients.GQLConfig{
+		Type: clients.HasuraClientType,
+		Headers: []clients.Header{
+			clients.XHasuraAdminSecretHeader,
+		},
+	})
+
+	rotation, err := rotateKey(c, ctx, client, orgID, claims.Hasura.UserID, orgKey)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &clients.APIResponse{
+			Message: "Failed to rotate the organisation's key",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, &clients.APIResponse{
+		Message: "successfully rotated the organisation's key",
+		Data:    rotation,
+	})
+}
+
+// Rotates the organisation's key and records it in the organisation's audit logs.
+func rotateKey(c echo.Context, ctx context.ServiceContext, client *clients.GQLClient, orgID, userID string, orgKey []byte) (*organisations.RotateKeyResponse, error) {
+
+	rotation, err := organisations.GetService().RotateKey(ctx, client, &organisations.RotateKeyOptions{
+		ID:  orgID,
+		Key: orgKey,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := audits.GetService().Create(ctx, client, &audits.CreateOptions{
+		OrgID:      orgID,
+		UserID:     userID,
+		Action:     audits.OrgKeyRotatedAction,
+		EntityType: audits.OrganisationEntity,
+		EntityID:   orgID,
+		Metadata: map[string]interface{}{
+			"secrets":         rotation.Secrets,
+			"change_requests": rotation.ChangeRequests,
+			"members":         rotation.Members,
+			"invites":         rotation.Invites,
+			"trust_policies":  rotation.TrustPolicies,
+			"revoked_tokens":  rotation.RevokedTokens,
+		},
+	}); err != nil {
+		c.Logger().Error(err)
+	}
+
+	return rotation, nil
+}
@@ -1,11 +1,21 @@
 package organisations
 
 import (
+	"github.com/envsecrets/envsecrets/internal/middlewares"
+	"github.com/envsecrets/envsecrets/internal/roles"
 	"github.com/labstack/echo/v4"
 )
 
+const (
+	ORG_ID = "org_id"
+)
+
 func AddRoutes(sg *echo.Group) {
 
 	group := sg.Group("/organisations")
 	group.POST("", CreateHandler)
+
+	organisation := group.Group("/:" + ORG_ID)
+	organisation.DELETE("/members", RemoveMemberHandler, middlewares.Authorize(roles.PermissionsResource, roles.DeleteAction))
+	organisation.POST("/rotate-key", RotateKeyHandler, middlewares.Authorize(roles.PermissionsResource, roles.UpdateAction))
 }
@@ -11,6 +11,8 @@ import (
 	"github.com/envsecrets/envsecrets/internal/clients"
 	"github.com/envsecrets/envsecrets/internal/context"
 	"github.com/envsecrets/envsecrets/internal/organisations"
+	"github.com/envsecrets/envsecrets/internal/permissions"
+	"github.com/envsecrets/envsecrets/internal/roles"
 	"github.com/envsecrets/envsecrets/internal/subscriptions"
 	"github.com/envsecrets/envsecrets/internal/users"
 	"github.com/golang-jwt/jwt/v4"
@@ -31,6 +33,14 @@ func CreateCheckoutSessionHandler(c echo.Context) error {
 		})
 	}
 
+	//	Only members who can manage the organisation's members are allowed to manage its billing.
+	if err := permissions.AuthorizeRequest(c, permissions.Scope{OrgID: payload.OrgID}, roles.PermissionsResource, roles.UpdateAction); err != nil {
+		return c.JSON(http.StatusForbidden, &clients.APIResponse{
+			Message: "You are not allowed to manage the billing of this organisation",
+			Error:   err.Error(),
+		})
+	}
+
 	//	Initialize a new default context
 	ctx := context.NewContext(&context.Config{Type: context.APIContext, EchoContext: c})
 
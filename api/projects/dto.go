@@ -0,0 +1,8 @@
+package projects
+
+import "github.com/envsecrets/envsecrets/internal/roles"
+
+type SetPermissionsOptions struct {
+	UserID      string          `json:"user_id"`
+	Permissions roles.Overrides `json:"permissions"`
+}
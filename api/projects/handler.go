@@ -5,7 +5,9 @@ import (
 
 	"github.com/envsecrets/envsecrets/internal/clients"
 	"github.com/envsecrets/envsecrets/internal/context"
+	"github.com/envsecrets/envsecrets/internal/permissions"
 	"github.com/envsecrets/envsecrets/internal/projects"
+	"github.com/envsecrets/envsecrets/internal/roles"
 	"github.com/envsecrets/envsecrets/internal/subscriptions"
 	"github.com/envsecrets/envsecrets/utils"
 	"github.com/labstack/echo/v4"
@@ -48,6 +50,17 @@ func ValidateInputHandler(c echo.Context) error {
 	//	If the number of projects is greater than the allowed limit, proceed to check whether the organisation has an active subscription.
 	//	Otherwise, approve the inputs and allow for creation of the project.
 	for _, row := range rows {
+
+		//	Validate the user's permission to create projects in this organisation.
+		if err := permissions.AuthorizeRequest(c, permissions.Scope{OrgID: row.OrgID}, roles.ProjectsResource, roles.CreateAction); err != nil {
+			return c.JSON(http.StatusBadRequest, &clients.HasuraActionResponse{
+				Message: "You are not allowed to create projects in this organisation",
+				Extensions: &clients.HasuraActionsResponseExtensions{
+					Error: err,
+				},
+			})
+		}
+
 		projects, err := projects.GetService().List(ctx, client, &projects.ListOptions{
 			OrgID: row.OrgID,
 		})
@@ -98,3 +111,88 @@ func ValidateInputHandler(c echo.Context) error {
 		Message: "inputs validated and permitted",
 	})
 }
+
+// Overrides the role of a member for this project and its environments.
+func SetPermissionsHandler(c echo.Context) error {
+
+	//	Unmarshal the incoming payload
+	var payload SetPermissionsOptions
+	if err := c.Bind(&payload); err != nil {
+		return c.JSON(http.StatusBadRequest, &clients.APIResponse{
+			Message: "failed to parse the body",
+			Error:   err.Error(),
+		})
+	}
+
+	//	Initialize a new default context
+	ctx := context.NewContext(&context.Config{Type: context.APIContext, EchoContext: c})
+
+	//	Initialize Hasura client with admin privileges,
+	//	since the user's permissions have already been validated.
+	client := clients.NewGQLClient(&clients.GQLConfig{
+		Type: clients.HasuraClientType,
+		Headers: []clients.Header{
+			clients.XHasuraAdminSecretHeader,
+		},
+	})
+
+	scope := permissions.Scope{
+		ProjectID: c.Param(PROJECT_ID),
+	}
+
+	//	Overrides can only be set for members of the organisation.
+	if _, err := permissions.GetService().Get(ctx, client, &permissions.GetOptions{
+		Scope:  scope,
+		UserID: payload.UserID,
+	}); err != nil {
+		return c.JSON(http.StatusBadRequest, &clients.APIResponse{
+			Message: "the user is not a member of this project's organisation",
+			Error:   err.Error(),
+		})
+	}
+
+	if err := permissions.GetService().SetOverrides(ctx, client, &permissions.SetOverridesOptions{
+		Scope:     scope,
+		UserID:    payload.UserID,
+		Overrides: payload.Permissions,
+	}); err != nil {
+		return c.JSON(http.StatusBadRequest, &clients.APIResponse{
+			Message: "Failed to set the permissions",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, &clients.APIResponse{
+		Message: "successfully set the permissions",
+	})
+}
+
+// Removes the overrides of a member's role for this project.
+func DeletePermissionsHandler(c echo.Context) error {
+
+	//	Initialize a new default context
+	ctx := context.NewContext(&context.Config{Type: context.APIContext, EchoContext: c})
+
+	//	Initialize Hasura client with admin privileges,
+	//	since the user's permissions have already been validated.
+	client := clients.NewGQLClient(&clients.GQLConfig{
+		Type: clients.HasuraClientType,
+		Headers: []clients.Header{
+			clients.XHasuraAdminSecretHeader,
+		},
+	})
+
+	if err := permissions.GetService().DeleteOverrides(ctx, client, &permissions.DeleteOverridesOptions{
+		ProjectID: c.Param(PROJECT_ID),
+		UserID:    c.Param(USER_ID),
+	}); err != nil {
+		return c.JSON(http.StatusBadRequest, &clients.APIResponse{
+			Message: "Failed to delete the permissions",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, &clients.APIResponse{
+		Message: "successfully deleted the permissions",
+	})
+}
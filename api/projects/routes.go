@@ -1,10 +1,22 @@
 package projects
 
 import (
+	"github.com/envsecrets/envsecrets/internal/middlewares"
+	"github.com/envsecrets/envsecrets/internal/roles"
 	"github.com/labstack/echo/v4"
 )
 
+const (
+	PROJECT_ID = "project_id"
+	USER_ID    = "user_id"
+)
+
 func AddRoutes(sg *echo.Group) {
 	group := sg.Group("/projects")
 	group.POST("/validate-input", ValidateInputHandler)
+
+	//	Per-project overrides of members' roles.
+	project := group.Group("/:" + PROJECT_ID)
+	project.PUT("/permissions", SetPermissionsHandler, middlewares.Authorize(roles.PermissionsResource, roles.UpdateAction))
+	project.DELETE("/permissions/:"+USER_ID, DeletePermissionsHandler, middlewares.Authorize(roles.PermissionsResource, roles.UpdateAction))
 }
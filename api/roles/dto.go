@@ -0,0 +1,14 @@
+package roles
+
+import "github.com/envsecrets/envsecrets/internal/roles"
+
+type CreateOptions struct {
+	OrgID       string            `json:"org_id"`
+	Name        string            `json:"name"`
+	Permissions roles.Permissions `json:"permissions"`
+}
+
+type UpdateOptions struct {
+	Name        string            `json:"name,omitempty"`
+	Permissions roles.Permissions `json:"permissions"`
+}
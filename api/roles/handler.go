@@ -0,0 +1,187 @@
+package roles
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/envsecrets/envsecrets/internal/clients"
+	"github.com/envsecrets/envsecrets/internal/context"
+	"github.com/envsecrets/envsecrets/internal/permissions"
+	"github.com/envsecrets/envsecrets/internal/roles"
+	"github.com/labstack/echo/v4"
+)
+
+// Creates a custom role in the organisation.
+func CreateHandler(c echo.Context) error {
+
+	//	Unmarshal the incoming payload
+	var payload CreateOptions
+	if err := c.Bind(&payload); err != nil {
+		return c.JSON(http.StatusBadRequest, &clients.APIResponse{
+			Message: "failed to parse the body",
+			Error:   err.Error(),
+		})
+	}
+
+	if payload.Name == "" || roles.IsDefault(payload.Name) {
+		return c.JSON(http.StatusBadRequest, &clients.APIResponse{
+			Message: "invalid role name",
+			Error:   "invalid role name",
+		})
+	}
+
+	//	Validate the user's permission to manage the roles of the organisation.
+	if err := permissions.AuthorizeRequest(c, permissions.Scope{OrgID: payload.OrgID}, roles.PermissionsResource, roles.CreateAction); err != nil {
+		return c.JSON(http.StatusForbidden, &clients.APIResponse{
+			Message: "You are not allowed to create roles in this organisation",
+			Error:   err.Error(),
+		})
+	}
+
+	//	Initialize a new default context
+	ctx := context.NewContext(&context.Config{Type: context.APIContext, EchoContext: c})
+
+	//	Initialize Hasura client with admin privileges,
+	//	since the user's permissions have already been validated.
+	client := clients.NewGQLClient(&clients.GQLConfig{
+		Type: clients.HasuraClientType,
+		Headers: []clients.Header{
+			clients.XHasuraAdminSecretHeader,
+		},
+	})
+
+	role, err := roles.Insert(ctx, client, &roles.RoleInsertOptions{
+		OrgID:       payload.OrgID,
+		Name:        payload.Name,
+		Permissions: payload.Permissions,
+	})
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &clients.APIResponse{
+			Message: "Failed to create the role",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, &clients.APIResponse{
+		Message: "successfully created the role",
+		Data:    role,
+	})
+}
+
+func UpdateHandler(c echo.Context) error {
+
+	//	Unmarshal the incoming payload
+	var payload UpdateOptions
+	if err := c.Bind(&payload); err != nil {
+		return c.JSON(http.StatusBadRequest, &clients.APIResponse{
+			Message: "failed to parse the body",
+			Error:   err.Error(),
+		})
+	}
+
+	if roles.IsDefault(payload.Name) {
+		return c.JSON(http.StatusBadRequest, &clients.APIResponse{
+			Message: "invalid role name",
+			Error:   "invalid role name",
+		})
+	}
+
+	//	Initialize a new default context
+	ctx := context.NewContext(&context.Config{Type: context.APIContext, EchoContext: c})
+
+	//	Initialize Hasura client with admin privileges
+	client := clients.NewGQLClient(&clients.GQLConfig{
+		Type: clients.HasuraClientType,
+		Headers: []clients.Header{
+			clients.XHasuraAdminSecretHeader,
+		},
+	})
+
+	role, err := getCustomRole(ctx, client, c.Param(ROLE_ID))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &clients.APIResponse{
+			Message: "Failed to fetch the role",
+			Error:   err.Error(),
+		})
+	}
+
+	//	Validate the user's permission to manage the roles of the organisation.
+	if err := permissions.AuthorizeRequest(c, permissions.Scope{OrgID: role.OrgID}, roles.PermissionsResource, roles.UpdateAction); err != nil {
+		return c.JSON(http.StatusForbidden, &clients.APIResponse{
+			Message: "You are not allowed to update the roles of this organisation",
+			Error:   err.Error(),
+		})
+	}
+
+	if err := roles.Update(ctx, client, &roles.RoleUpdateOptions{
+		ID:          role.ID,
+		Name:        payload.Name,
+		Permissions: payload.Permissions,
+	}); err != nil {
+		return c.JSON(http.StatusBadRequest, &clients.APIResponse{
+			Message: "Failed to update the role",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, &clients.APIResponse{
+		Message: "successfully updated the role",
+	})
+}
+
+func DeleteHandler(c echo.Context) error {
+
+	//	Initialize a new default context
+	ctx := context.NewContext(&context.Config{Type: context.APIContext, EchoContext: c})
+
+	//	Initialize Hasura client with admin privileges
+	client := clients.NewGQLClient(&clients.GQLConfig{
+		Type: clients.HasuraClientType,
+		Headers: []clients.Header{
+			clients.XHasuraAdminSecretHeader,
+		},
+	})
+
+	role, err := getCustomRole(ctx, client, c.Param(ROLE_ID))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &clients.APIResponse{
+			Message: "Failed to fetch the role",
+			Error:   err.Error(),
+		})
+	}
+
+	//	Validate the user's permission to manage the roles of the organisation.
+	if err := permissions.AuthorizeRequest(c, permissions.Scope{OrgID: role.OrgID}, roles.PermissionsResource, roles.DeleteAction); err != nil {
+		return c.JSON(http.StatusForbidden, &clients.APIResponse{
+			Message: "You are not allowed to delete the roles of this organisation",
+			Error:   err.Error(),
+		})
+	}
+
+	if err := roles.Delete(ctx, client, role.ID); err != nil {
+		return c.JSON(http.StatusBadRequest, &clients.APIResponse{
+			Message: "Failed to delete the role. Make sure it is not assigned to any member.",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, &clients.APIResponse{
+		Message: "successfully deleted the role",
+	})
+}
+
+// Fetches the role, making sure it is not one of the default roles,
+// which every organisation relies on.
+func getCustomRole(ctx context.ServiceContext, client *clients.GQLClient, id string) (*roles.Role, error) {
+
+	role, err := roles.Get(ctx, client, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if roles.IsDefault(role.Name) {
+		return nil, errors.New("default roles can not be modified")
+	}
+
+	return role, nil
+}
@@ -0,0 +1,19 @@
+package roles
+
+import (
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	ROLE_ID = "role_id"
+)
+
+func AddRoutes(sg *echo.Group) {
+
+	group := sg.Group("/roles")
+	group.POST("", CreateHandler)
+
+	role := group.Group("/:" + ROLE_ID)
+	role.PATCH("", UpdateHandler)
+	role.DELETE("", DeleteHandler)
+}
@@ -0,0 +1,140 @@
+package scim
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+const (
+	UserSchema                  = "urn:ietf:params:scim:schemas:core:2.0:User"
+	GroupSchema                 = "urn:ietf:params:scim:schemas:core:2.0:Group"
+	ListResponseSchema          = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	PatchOpSchema               = "urn:ietf:params:scim:api:messages:2.0:PatchOp"
+	ErrorSchema                 = "urn:ietf:params:scim:api:messages:2.0:Error"
+	ServiceProviderConfigSchema = "urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"
+)
+
+type User struct {
+	Schemas     []string    `json:"schemas"`
+	ID          string      `json:"id,omitempty"`
+	ExternalID  string      `json:"externalId,omitempty"`
+	UserName    string      `json:"userName"`
+	Name        *Name       `json:"name,omitempty"`
+	DisplayName string      `json:"displayName,omitempty"`
+	Emails      []Email     `json:"emails,omitempty"`
+	Active      *bool       `json:"active,omitempty"`
+	Groups      []Reference `json:"groups,omitempty"`
+	Meta        *Meta       `json:"meta,omitempty"`
+}
+
+// Returns the email of the user, preferring the primary one over the user name.
+func (u *User) Email() string {
+	for _, item := range u.Emails {
+		if item.Primary && item.Value != "" {
+			return strings.ToLower(item.Value)
+		}
+	}
+
+	if strings.Contains(u.UserName, "@") || len(u.Emails) == 0 {
+		return strings.ToLower(u.UserName)
+	}
+
+	return strings.ToLower(u.Emails[0].Value)
+}
+
+// Returns the full name of the user, as best as it can be put together.
+func (u *User) FullName() string {
+	if u.DisplayName != "" {
+		return u.DisplayName
+	}
+
+	if u.Name != nil {
+		if u.Name.Formatted != "" {
+			return u.Name.Formatted
+		}
+		return strings.TrimSpace(u.Name.GivenName + " " + u.Name.FamilyName)
+	}
+
+	return ""
+}
+
+type Name struct {
+	Formatted  string `json:"formatted,omitempty"`
+	GivenName  string `json:"givenName,omitempty"`
+	FamilyName string `json:"familyName,omitempty"`
+}
+
+type Email struct {
+	Value   string `json:"value"`
+	Type    string `json:"type,omitempty"`
+	Primary bool   `json:"primary,omitempty"`
+}
+
+type Group struct {
+	Schemas     []string    `json:"schemas"`
+	ID          string      `json:"id,omitempty"`
+	ExternalID  string      `json:"externalId,omitempty"`
+	DisplayName string      `json:"displayName"`
+	Members     []Reference `json:"members,omitempty"`
+	Meta        *Meta       `json:"meta,omitempty"`
+}
+
+// Returns the IDs of the group's members.
+func (g *Group) MemberIDs() []string {
+	return values(g.Members)
+}
+
+// Reference to a user from a group, or to a group from a user.
+type Reference struct {
+	Value   string `json:"value"`
+	Display string `json:"display,omitempty"`
+	Ref     string `json:"$ref,omitempty"`
+}
+
+type Meta struct {
+	ResourceType string    `json:"resourceType"`
+	Created      time.Time `json:"created"`
+	LastModified time.Time `json:"lastModified"`
+	Location     string    `json:"location,omitempty"`
+}
+
+type ListResponse struct {
+	Schemas      []string      `json:"schemas"`
+	TotalResults int           `json:"totalResults"`
+	StartIndex   int           `json:"startIndex"`
+	ItemsPerPage int           `json:"itemsPerPage"`
+	Resources    []interface{} `json:"Resources"`
+}
+
+type ListOptions struct {
+	Filter     string `query:"filter"`
+	StartIndex int    `query:"startIndex"`
+	Count      *int   `query:"count"`
+}
+
+type PatchRequest struct {
+	Schemas    []string    `json:"schemas"`
+	Operations []Operation `json:"Operations"`
+}
+
+type Operation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+type Error struct {
+	Schemas  []string `json:"schemas"`
+	Status   string   `json:"status"`
+	Detail   string   `json:"detail,omitempty"`
+	SCIMType string   `json:"scimType,omitempty"`
+}
+
+func values(references []Reference) []string {
+	result := []string{}
+	for _, item := range references {
+		result = append(result, item.Value)
+	}
+	return result
+}
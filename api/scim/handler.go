@@ -0,0 +1,733 @@
+package scim
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/envsecrets/envsecrets/internal/audits"
+	"github.com/envsecrets/envsecrets/internal/clients"
+	"github.com/envsecrets/envsecrets/internal/context"
+	"github.com/envsecrets/envsecrets/internal/sso"
+	"github.com/labstack/echo/v4"
+)
+
+var (
+	filterPattern        = regexp.MustCompile(`(?i)^\s*(\w+)\s+eq\s+"([^"]*)"\s*$`)
+	memberFilterPattern  = regexp.MustCompile(`(?i)^members\[\s*value\s+eq\s+"([^"]*)"\s*\]$`)
+	errUnsupportedFilter = errors.New("only filters of the form `attribute eq \"value\"` are supported")
+)
+
+func ServiceProviderConfigHandler(c echo.Context) error {
+	return respond(c, http.StatusOK, map[string]interface{}{
+		"schemas": []string{ServiceProviderConfigSchema},
+		"patch": map[string]bool{
+			"supported": true,
+		},
+		"bulk": map[string]interface{}{
+			"supported":      false,
+			"maxOperations":  0,
+			"maxPayloadSize": 0,
+		},
+		"filter": map[string]interface{}{
+			"supported":  true,
+			"maxResults": MAX_RESULTS,
+		},
+		"changePassword": map[string]bool{
+			"supported": false,
+		},
+		"sort": map[string]bool{
+			"supported": false,
+		},
+		"etag": map[string]bool{
+			"supported": false,
+		},
+		"authenticationSchemes": []map[string]interface{}{
+			{
+				"type":        "oauthbearertoken",
+				"name":        "OAuth Bearer Token",
+				"description": "Authentication with the organisation's SCIM token",
+				"primary":     true,
+			},
+		},
+	})
+}
+
+func ListUsersHandler(c echo.Context) error {
+
+	var payload ListOptions
+	if err := c.Bind(&payload); err != nil {
+		return fail(c, http.StatusBadRequest, err.Error(), "invalidValue")
+	}
+
+	options := sso.ListIdentitiesOptions{
+		OrgID: connection(c).OrgID,
+	}
+
+	if payload.Filter != "" {
+		attribute, value, err := parseFilter(payload.Filter)
+		if err != nil {
+			return fail(c, http.StatusBadRequest, err.Error(), "invalidFilter")
+		}
+
+		switch strings.ToLower(attribute) {
+		case "username", "emails":
+			options.Email = value
+		case "externalid":
+			options.ExternalID = value
+		default:
+			return fail(c, http.StatusBadRequest, errUnsupportedFilter.Error(), "invalidFilter")
+		}
+	}
+
+	ctx, client := newClient(c)
+
+	identities, err := sso.GetService().ListIdentities(ctx, client, &options)
+	if err != nil {
+		return fail(c, http.StatusInternalServerError, err.Error(), "")
+	}
+
+	var resources []interface{}
+	for i := range identities {
+		resources = append(resources, toUser(c, &identities[i]))
+	}
+
+	return respond(c, http.StatusOK, paginate(resources, &payload))
+}
+
+func GetUserHandler(c echo.Context) error {
+
+	ctx, client := newClient(c)
+
+	identity, err := sso.GetService().GetIdentity(ctx, client, connection(c).OrgID, c.Param(ID))
+	if err != nil {
+		return failWith(c, err)
+	}
+
+	return respond(c, http.StatusOK, toUser(c, identity))
+}
+
+// Provisions the user in the organisation.
+// Active users are queued for membership until an admin grants them the organisation's key.
+func CreateUserHandler(c echo.Context) error {
+
+	var payload User
+	if err := bind(c, &payload); err != nil {
+		return fail(c, http.StatusBadRequest, err.Error(), "invalidSyntax")
+	}
+
+	email := payload.Email()
+	if !strings.Contains(email, "@") {
+		return fail(c, http.StatusBadRequest, "the user name or a primary email must be an email address", "invalidValue")
+	}
+
+	active := true
+	if payload.Active != nil {
+		active = *payload.Active
+	}
+
+	ctx, client := newClient(c)
+	orgID := connection(c).OrgID
+
+	identity, err := sso.GetService().Provision(ctx, client, &sso.ProvisionOptions{
+		OrgID:      orgID,
+		Email:      email,
+		Name:       payload.FullName(),
+		ExternalID: payload.ExternalID,
+		Active:     active,
+	})
+	if err != nil {
+		return failWith(c, err)
+	}
+
+	audit(c, ctx, client, orgID, audits.MemberProvisionedAction, identity, nil)
+
+	return respond(c, http.StatusCreated, toUser(c, identity))
+}
+
+func ReplaceUserHandler(c echo.Context) error {
+
+	var payload User
+	if err := bind(c, &payload); err != nil {
+		return fail(c, http.StatusBadRequest, err.Error(), "invalidSyntax")
+	}
+
+	//	A replaced user without the attribute is active.
+	active := true
+	if payload.Active != nil {
+		active = *payload.Active
+	}
+
+	return updateUser(c, &sso.UpdateIdentityOptions{
+		ExternalID: &payload.ExternalID,
+		Active:     &active,
+	})
+}
+
+func PatchUserHandler(c echo.Context) error {
+
+	var payload PatchRequest
+	if err := bind(c, &payload); err != nil {
+		return fail(c, http.StatusBadRequest, err.Error(), "invalidSyntax")
+	}
+
+	options, err := parseUserPatch(payload.Operations)
+	if err != nil {
+		return fail(c, http.StatusBadRequest, err.Error(), "invalidValue")
+	}
+
+	return updateUser(c, options)
+}
+
+// Deprovisions the user and deletes their identity.
+// Their account is kept, since it may belong to other organisations.
+func DeleteUserHandler(c echo.Context) error {
+
+	ctx, client := newClient(c)
+	orgID := connection(c).OrgID
+
+	identity, err := sso.GetService().GetIdentity(ctx, client, orgID, c.Param(ID))
+	if err != nil {
+		return failWith(c, err)
+	}
+
+	result, err := sso.GetService().DeleteIdentity(ctx, client, orgID, identity.ID)
+	if err != nil {
+		return failWith(c, err)
+	}
+
+	audit(c, ctx, client, orgID, audits.MemberDeprovisionedAction, identity, map[string]interface{}{
+		"revoked_tokens": result.RevokedTokens,
+		"deleted":        true,
+	})
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func ListGroupsHandler(c echo.Context) error {
+
+	var payload ListOptions
+	if err := c.Bind(&payload); err != nil {
+		return fail(c, http.StatusBadRequest, err.Error(), "invalidValue")
+	}
+
+	options := sso.ListGroupsOptions{
+		OrgID: connection(c).OrgID,
+	}
+
+	if payload.Filter != "" {
+		attribute, value, err := parseFilter(payload.Filter)
+		if err != nil {
+			return fail(c, http.StatusBadRequest, err.Error(), "invalidFilter")
+		}
+
+		switch strings.ToLower(attribute) {
+		case "displayname":
+			options.DisplayName = value
+		case "externalid":
+			options.ExternalID = value
+		default:
+			return fail(c, http.StatusBadRequest, errUnsupportedFilter.Error(), "invalidFilter")
+		}
+	}
+
+	ctx, client := newClient(c)
+
+	groups, err := sso.GetService().ListGroups(ctx, client, &options)
+	if err != nil {
+		return fail(c, http.StatusInternalServerError, err.Error(), "")
+	}
+
+	var resources []interface{}
+	for i := range groups {
+		resources = append(resources, toGroup(c, &groups[i]))
+	}
+
+	return respond(c, http.StatusOK, paginate(resources, &payload))
+}
+
+func GetGroupHandler(c echo.Context) error {
+
+	ctx, client := newClient(c)
+
+	group, err := sso.GetService().GetGroup(ctx, client, connection(c).OrgID, c.Param(ID))
+	if err != nil {
+		return failWith(c, err)
+	}
+
+	return respond(c, http.StatusOK, toGroup(c, group))
+}
+
+func CreateGroupHandler(c echo.Context) error {
+
+	var payload Group
+	if err := bind(c, &payload); err != nil {
+		return fail(c, http.StatusBadRequest, err.Error(), "invalidSyntax")
+	}
+
+	if payload.DisplayName == "" {
+		return fail(c, http.StatusBadRequest, "displayName is required", "invalidValue")
+	}
+
+	ctx, client := newClient(c)
+
+	group, err := sso.GetService().CreateGroup(ctx, client, &sso.CreateGroupOptions{
+		OrgID:       connection(c).OrgID,
+		DisplayName: payload.DisplayName,
+		ExternalID:  payload.ExternalID,
+		Members:     payload.MemberIDs(),
+	})
+	if err != nil {
+		return failWith(c, err)
+	}
+
+	return respond(c, http.StatusCreated, toGroup(c, group))
+}
+
+func ReplaceGroupHandler(c echo.Context) error {
+
+	var payload Group
+	if err := bind(c, &payload); err != nil {
+		return fail(c, http.StatusBadRequest, err.Error(), "invalidSyntax")
+	}
+
+	members := payload.MemberIDs()
+	options := sso.UpdateGroupOptions{
+		ExternalID: &payload.ExternalID,
+		Members:    &members,
+	}
+
+	if payload.DisplayName != "" {
+		options.DisplayName = &payload.DisplayName
+	}
+
+	return updateGroup(c, &options)
+}
+
+func PatchGroupHandler(c echo.Context) error {
+
+	var payload PatchRequest
+	if err := bind(c, &payload); err != nil {
+		return fail(c, http.StatusBadRequest, err.Error(), "invalidSyntax")
+	}
+
+	options, err := parseGroupPatch(payload.Operations)
+	if err != nil {
+		return fail(c, http.StatusBadRequest, err.Error(), "invalidPath")
+	}
+
+	return updateGroup(c, options)
+}
+
+// Deletes the group. Members of a group mapped to a role fall back to their next role.
+func DeleteGroupHandler(c echo.Context) error {
+
+	ctx, client := newClient(c)
+
+	if err := sso.GetService().DeleteGroup(ctx, client, connection(c).OrgID, c.Param(ID)); err != nil {
+		return failWith(c, err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+//	---	Helpers ---
+
+// Updates the user, and records it in the audit logs if they were deprovisioned or reactivated.
+func updateUser(c echo.Context, options *sso.UpdateIdentityOptions) error {
+
+	ctx, client := newClient(c)
+	orgID := connection(c).OrgID
+
+	before, err := sso.GetService().GetIdentity(ctx, client, orgID, c.Param(ID))
+	if err != nil {
+		return failWith(c, err)
+	}
+
+	identity, err := sso.GetService().UpdateIdentity(ctx, client, orgID, before.ID, options)
+	if err != nil {
+		return failWith(c, err)
+	}
+
+	if before.Active && !identity.Active {
+		audit(c, ctx, client, orgID, audits.MemberDeprovisionedAction, identity, nil)
+	} else if !before.Active && identity.Active {
+		audit(c, ctx, client, orgID, audits.MemberProvisionedAction, identity, nil)
+	}
+
+	return respond(c, http.StatusOK, toUser(c, identity))
+}
+
+func updateGroup(c echo.Context, options *sso.UpdateGroupOptions) error {
+
+	ctx, client := newClient(c)
+
+	group, err := sso.GetService().UpdateGroup(ctx, client, connection(c).OrgID, c.Param(ID), options)
+	if err != nil {
+		return failWith(c, err)
+	}
+
+	return respond(c, http.StatusOK, toGroup(c, group))
+}
+
+// Supports the "add" and "replace" operations on the "active" and "externalId" attributes.
+// Other attributes, like the user's name, are managed in their account.
+func parseUserPatch(operations []Operation) (*sso.UpdateIdentityOptions, error) {
+
+	var options sso.UpdateIdentityOptions
+
+	apply := func(attribute string, value json.RawMessage) error {
+		switch strings.ToLower(attribute) {
+		case "active":
+			active, err := parseBool(value)
+			if err != nil {
+				return err
+			}
+			options.Active = &active
+
+		case "externalid":
+			var externalID string
+			if err := json.Unmarshal(value, &externalID); err != nil {
+				return err
+			}
+			options.ExternalID = &externalID
+		}
+		return nil
+	}
+
+	for _, operation := range operations {
+
+		op := strings.ToLower(operation.Op)
+		if op != "add" && op != "replace" {
+			continue
+		}
+
+		if operation.Path != "" {
+			if err := apply(operation.Path, operation.Value); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		var attributes map[string]json.RawMessage
+		if err := json.Unmarshal(operation.Value, &attributes); err != nil {
+			return nil, err
+		}
+
+		for attribute, value := range attributes {
+			if err := apply(attribute, value); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return &options, nil
+}
+
+// Supports adding, removing and replacing members,
+// and replacing the "displayName" and "externalId" attributes.
+func parseGroupPatch(operations []Operation) (*sso.UpdateGroupOptions, error) {
+
+	var options sso.UpdateGroupOptions
+
+	apply := func(op, attribute string, value json.RawMessage) error {
+		switch strings.ToLower(attribute) {
+		case "members":
+			var members []Reference
+			if len(value) > 0 {
+				if err := json.Unmarshal(value, &members); err != nil {
+					return err
+				}
+			}
+
+			switch op {
+			case "add":
+				options.Add = append(options.Add, values(members)...)
+			case "remove":
+				if len(members) == 0 {
+					empty := []string{}
+					options.Members = &empty
+				} else {
+					options.Remove = append(options.Remove, values(members)...)
+				}
+			case "replace":
+				ids := values(members)
+				options.Members = &ids
+			}
+
+		case "displayname":
+			var name string
+			if err := json.Unmarshal(value, &name); err != nil {
+				return err
+			}
+			options.DisplayName = &name
+
+		case "externalid":
+			var externalID string
+			if err := json.Unmarshal(value, &externalID); err != nil {
+				return err
+			}
+			options.ExternalID = &externalID
+
+		default:
+			if match := memberFilterPattern.FindStringSubmatch(attribute); op == "remove" && match != nil {
+				options.Remove = append(options.Remove, match[1])
+				return nil
+			}
+			return errors.New("unsupported path: " + attribute)
+		}
+		return nil
+	}
+
+	for _, operation := range operations {
+
+		op := strings.ToLower(operation.Op)
+
+		if operation.Path != "" {
+			if err := apply(op, strings.TrimSpace(operation.Path), operation.Value); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		var attributes map[string]json.RawMessage
+		if err := json.Unmarshal(operation.Value, &attributes); err != nil {
+			return nil, err
+		}
+
+		for attribute, value := range attributes {
+			if err := apply(op, attribute, value); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return &options, nil
+}
+
+// Some identity providers send booleans as strings, i.e. "False".
+func parseBool(value json.RawMessage) (bool, error) {
+
+	var result bool
+	if err := json.Unmarshal(value, &result); err == nil {
+		return result, nil
+	}
+
+	var text string
+	if err := json.Unmarshal(value, &text); err != nil {
+		return false, err
+	}
+
+	return strconv.ParseBool(strings.ToLower(text))
+}
+
+func parseFilter(filter string) (string, string, error) {
+
+	match := filterPattern.FindStringSubmatch(filter)
+	if match == nil {
+		return "", "", errUnsupportedFilter
+	}
+
+	//	Emails are filtered by their value.
+	attribute := strings.TrimSuffix(match[1], ".value")
+	return attribute, match[2], nil
+}
+
+func paginate(resources []interface{}, options *ListOptions) *ListResponse {
+
+	if resources == nil {
+		resources = []interface{}{}
+	}
+
+	total := len(resources)
+
+	start := options.StartIndex
+	if start < 1 {
+		start = 1
+	}
+
+	count := MAX_RESULTS
+	if options.Count != nil && *options.Count >= 0 && *options.Count < count {
+		count = *options.Count
+	}
+
+	page := []interface{}{}
+	if start <= total {
+		end := start - 1 + count
+		if end > total {
+			end = total
+		}
+		page = resources[start-1 : end]
+	}
+
+	return &ListResponse{
+		Schemas:      []string{ListResponseSchema},
+		TotalResults: total,
+		StartIndex:   start,
+		ItemsPerPage: len(page),
+		Resources:    page,
+	}
+}
+
+func toUser(c echo.Context, identity *sso.Identity) *User {
+
+	active := identity.Active
+	result := User{
+		Schemas:    []string{UserSchema},
+		ID:         identity.ID,
+		ExternalID: identity.ExternalID,
+		UserName:   identity.Email,
+		Emails: []Email{
+			{
+				Value:   identity.Email,
+				Type:    "work",
+				Primary: true,
+			},
+		},
+		Active: &active,
+		Meta: &Meta{
+			ResourceType: "User",
+			Created:      identity.CreatedAt,
+			LastModified: identity.UpdatedAt,
+			Location:     location(c, "Users", identity.ID),
+		},
+	}
+
+	if identity.User != nil {
+		result.DisplayName = identity.User.DisplayName
+	}
+
+	for _, item := range identity.Groups {
+		reference := Reference{
+			Value: item.GroupID,
+			Ref:   location(c, "Groups", item.GroupID),
+		}
+		if item.Group != nil {
+			reference.Display = item.Group.DisplayName
+		}
+		result.Groups = append(result.Groups, reference)
+	}
+
+	return &result
+}
+
+func toGroup(c echo.Context, group *sso.Group) *Group {
+
+	result := Group{
+		Schemas:     []string{GroupSchema},
+		ID:          group.ID,
+		ExternalID:  group.ExternalID,
+		DisplayName: group.DisplayName,
+		Members:     []Reference{},
+		Meta: &Meta{
+			ResourceType: "Group",
+			Created:      group.CreatedAt,
+			LastModified: group.UpdatedAt,
+			Location:     location(c, "Groups", group.ID),
+		},
+	}
+
+	for _, item := range group.Members {
+		reference := Reference{
+			Value: item.IdentityID,
+			Ref:   location(c, "Users", item.IdentityID),
+		}
+		if item.Identity != nil {
+			reference.Display = item.Identity.Email
+		}
+		result.Members = append(result.Members, reference)
+	}
+
+	return &result
+}
+
+func location(c echo.Context, resource, id string) string {
+	return c.Scheme() + "://" + c.Request().Host + "/v1/scim/v2/" + resource + "/" + id
+}
+
+// Identity providers send "application/scim+json" bodies, which echo doesn't bind.
+func bind(c echo.Context, payload interface{}) error {
+	return json.NewDecoder(c.Request().Body).Decode(payload)
+}
+
+func respond(c echo.Context, status int, payload interface{}) error {
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	return c.Blob(status, CONTENT_TYPE, body)
+}
+
+func fail(c echo.Context, status int, detail, scimType string) error {
+	return respond(c, status, &Error{
+		Schemas:  []string{ErrorSchema},
+		Status:   strconv.Itoa(status),
+		Detail:   detail,
+		SCIMType: scimType,
+	})
+}
+
+func failWith(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, sso.ErrNotFound):
+		return fail(c, http.StatusNotFound, err.Error(), "")
+	case errors.Is(err, sso.ErrConflict):
+		return fail(c, http.StatusConflict, err.Error(), "uniqueness")
+	case errors.Is(err, sso.ErrDomainNotAllowed):
+		return fail(c, http.StatusBadRequest, err.Error(), "invalidValue")
+	default:
+		c.Logger().Error(err)
+		return fail(c, http.StatusBadRequest, err.Error(), "")
+	}
+}
+
+func connection(c echo.Context) *sso.Connection {
+	return c.Get("sso_connection").(*sso.Connection)
+}
+
+func newClient(c echo.Context) (context.ServiceContext, *clients.GQLClient) {
+
+	//	Initialize a new default context
+	ctx := context.NewContext(&context.Config{Type: context.APIContext, EchoContext: c})
+
+	//	Initialize Hasura client with admin privileges,
+	//	since identity providers act on behalf of the whole organisation.
+	client := clients.NewGQLClient(&clients.GQLConfig{
+		Type: clients.HasuraClientType,
+		Headers: []clients.Header{
+			clients.XHasuraAdminSecretHeader,
+		},
+	})
+
+	return ctx, client
+}
+
+// Records the provisioning of a user in the organisation's audit logs.
+// The action is performed by the identity provider, not by a user.
+func audit(c echo.Context, ctx context.ServiceContext, client *clients.GQLClient, orgID string, action audits.Action, identity *sso.Identity, metadata map[string]interface{}) {
+
+	if metadata == nil {
+		metadata = make(map[string]interface{})
+	}
+
+	metadata["email"] = identity.Email
+	metadata["identity_id"] = identity.ID
+	if identity.ExternalID != "" {
+		metadata["external_id"] = identity.ExternalID
+	}
+
+	if _, err := audits.GetService().Create(ctx, client, &audits.CreateOptions{
+		OrgID:      orgID,
+		Action:     action,
+		EntityType: audits.UserEntity,
+		EntityID:   identity.UserID,
+		Metadata:   metadata,
+	}); err != nil {
+		c.Logger().Error(err)
+	}
+}
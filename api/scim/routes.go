@@ -0,0 +1,39 @@
+package scim
+
+import (
+	"github.com/envsecrets/envsecrets/internal/middlewares"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	ID = "id"
+
+	CONTENT_TYPE = "application/scim+json"
+
+	//	Maximum number of resources returned in a single list response.
+	MAX_RESULTS = 200
+)
+
+// Routes of the SCIM 2.0 service provider,
+// authenticated with the organisation's SCIM token instead of a user session.
+func AddRoutes(sg *echo.Group) {
+
+	group := sg.Group("/scim/v2", middlewares.SCIMToken())
+	group.GET("/ServiceProviderConfig", ServiceProviderConfigHandler)
+
+	users := group.Group("/Users")
+	users.GET("", ListUsersHandler)
+	users.POST("", CreateUserHandler)
+	users.GET("/:"+ID, GetUserHandler)
+	users.PUT("/:"+ID, ReplaceUserHandler)
+	users.PATCH("/:"+ID, PatchUserHandler)
+	users.DELETE("/:"+ID, DeleteUserHandler)
+
+	groups := group.Group("/Groups")
+	groups.GET("", ListGroupsHandler)
+	groups.POST("", CreateGroupHandler)
+	groups.GET("/:"+ID, GetGroupHandler)
+	groups.PUT("/:"+ID, ReplaceGroupHandler)
+	groups.PATCH("/:"+ID, PatchGroupHandler)
+	groups.DELETE("/:"+ID, DeleteGroupHandler)
+}
@@ -0,0 +1,18 @@
+package serviceaccounts
+
+type CreateOptions struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	RoleID      string `json:"role_id"`
+
+	//	Lifetime of the credential, like "720h". Defaults to 90 days.
+	Expiry string `json:"expiry,omitempty"`
+
+	//	Password of the admin, to decrypt their copy of the organisation's key.
+	Password string `json:"password"`
+}
+
+type RotateOptions struct {
+	Expiry   string `json:"expiry,omitempty"`
+	Password string `json:"password"`
+}
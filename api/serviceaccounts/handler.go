@@ -0,0 +1,308 @@
+package serviceaccounts
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/envsecrets/envsecrets/cli/auth"
+	"github.com/envsecrets/envsecrets/internal/audits"
+	"github.com/envsecrets/envsecrets/internal/clients"
+	"github.com/envsecrets/envsecrets/internal/context"
+	"github.com/envsecrets/envsecrets/internal/keys"
+	keyCommons "github.com/envsecrets/envsecrets/internal/keys/commons"
+	"github.com/envsecrets/envsecrets/internal/roles"
+	"github.com/envsecrets/envsecrets/internal/serviceaccounts"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/labstack/echo/v4"
+)
+
+func ListHandler(c echo.Context) error {
+
+	//	Initialize a new default context
+	ctx := context.NewContext(&context.Config{Type: context.APIContext, EchoContext: c})
+
+	result, err := serviceaccounts.GetService().List(ctx, newAdminClient(), c.Param(ORG_ID))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &clients.APIResponse{
+			Message: "Failed to list the service accounts",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, &clients.APIResponse{
+		Message: "successfully listed the service accounts",
+		Data:    result,
+	})
+}
+
+// --- Flow ---
+//
+//  1. Validate that the role belongs to the organisation.
+//  2. Decrypt the admin's copy of the organisation's key, to seal a copy for the service account.
+//  3. Create the service account, and record it in the organisation's audit logs.
+//  4. Return its credential, which is never shown again.
+func CreateHandler(c echo.Context) error {
+
+	//	Unmarshal the incoming payload
+	var payload CreateOptions
+	if err := c.Bind(&payload); err != nil {
+		return c.JSON(http.StatusBadRequest, &clients.APIResponse{
+			Message: "failed to parse the body",
+			Error:   err.Error(),
+		})
+	}
+
+	if payload.Name == "" || payload.RoleID == "" || payload.Password == "" {
+		return c.JSON(http.StatusBadRequest, &clients.APIResponse{
+			Message: "invalid service account",
+			Error:   "name, role and your password are required",
+		})
+	}
+
+	expiry, err := parseExpiry(payload.Expiry)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &clients.APIResponse{
+			Message: "invalid expiry",
+			Error:   err.Error(),
+		})
+	}
+
+	orgID := c.Param(ORG_ID)
+
+	//	Initialize a new default context
+	ctx := context.NewContext(&context.Config{Type: context.APIContext, EchoContext: c})
+
+	client := newAdminClient()
+
+	role, err := roles.Get(ctx, client, payload.RoleID)
+	if err != nil || role.OrgID != orgID {
+		return c.JSON(http.StatusBadRequest, &clients.APIResponse{
+			Message: "invalid role",
+			Error:   "the role doesn't belong to this organisation",
+		})
+	}
+
+	token := c.Get("user").(*jwt.Token)
+	claims := token.Claims.(*auth.Claims)
+
+	orgKey, err := decryptOrgKey(c, ctx, orgID, claims.Hasura.UserID, payload.Password)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &clients.APIResponse{
+			Message: "Failed to decrypt the organisation's key",
+			Error:   err.Error(),
+		})
+	}
+
+	result, err := serviceaccounts.GetService().Create(ctx, client, &serviceaccounts.CreateOptions{
+		OrgID:       orgID,
+		Name:        payload.Name,
+		Description: payload.Description,
+		RoleID:      role.ID,
+		CreatedBy:   claims.Hasura.UserID,
+		OrgKey:      orgKey,
+		Expiry:      expiry,
+	})
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &clients.APIResponse{
+			Message: "Failed to create the service account",
+			Error:   err.Error(),
+		})
+	}
+
+	audit(c, ctx, client, claims.Hasura.UserID, audits.ServiceAccountCreatedAction, result.ServiceAccount, map[string]interface{}{
+		"role":   role.Name,
+		"expiry": expiry.String(),
+	})
+
+	return c.JSON(http.StatusCreated, &clients.APIResponse{
+		Message: "successfully created the service account; save its credential, since it won't be shown again",
+		Data:    result,
+	})
+}
+
+// Rotates the credential and the key pair of a service account.
+// Its previous credential and sessions stop working immediately.
+func RotateHandler(c echo.Context) error {
+
+	//	Unmarshal the incoming payload
+	var payload RotateOptions
+	if err := c.Bind(&payload); err != nil {
+		return c.JSON(http.StatusBadRequest, &clients.APIResponse{
+			Message: "failed to parse the body",
+			Error:   err.Error(),
+		})
+	}
+
+	if payload.Password == "" {
+		return c.JSON(http.StatusBadRequest, &clients.APIResponse{
+			Message: "password is required to re-seal the organisation's key for the service account",
+		})
+	}
+
+	expiry, err := parseExpiry(payload.Expiry)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &clients.APIResponse{
+			Message: "invalid expiry",
+			Error:   err.Error(),
+		})
+	}
+
+	orgID := c.Param(ORG_ID)
+
+	//	Initialize a new default context
+	ctx := context.NewContext(&context.Config{Type: context.APIContext, EchoContext: c})
+
+	client := newAdminClient()
+
+	account, err := get(ctx, client, orgID, c.Param(SERVICE_ACCOUNT_ID))
+	if err != nil {
+		return c.JSON(http.StatusNotFound, &clients.APIResponse{
+			Message: "Failed to fetch the service account",
+			Error:   err.Error(),
+		})
+	}
+
+	token := c.Get("user").(*jwt.Token)
+	claims := token.Claims.(*auth.Claims)
+
+	orgKey, err := decryptOrgKey(c, ctx, orgID, claims.Hasura.UserID, payload.Password)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &clients.APIResponse{
+			Message: "Failed to decrypt the organisation's key",
+			Error:   err.Error(),
+		})
+	}
+
+	result, err := serviceaccounts.GetService().Rotate(ctx, client, &serviceaccounts.RotateOptions{
+		ID:     account.ID,
+		OrgKey: orgKey,
+		Expiry: expiry,
+	})
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &clients.APIResponse{
+			Message: "Failed to rotate the credential of the service account",
+			Error:   err.Error(),
+		})
+	}
+
+	audit(c, ctx, client, claims.Hasura.UserID, audits.ServiceAccountRotatedAction, account, map[string]interface{}{
+		"revoked_sessions": result.RevokedSessions,
+		"expiry":           expiry.String(),
+	})
+
+	return c.JSON(http.StatusOK, &clients.APIResponse{
+		Message: "successfully rotated the credential of the service account",
+		Data:    result,
+	})
+}
+
+// Deletes a service account, and revokes the environment tokens it created.
+// Its user account is disabled instead of deleted, so that audit logs still refer to it.
+func DeleteHandler(c echo.Context) error {
+
+	orgID := c.Param(ORG_ID)
+
+	//	Initialize a new default context
+	ctx := context.NewContext(&context.Config{Type: context.APIContext, EchoContext: c})
+
+	client := newAdminClient()
+
+	account, err := get(ctx, client, orgID, c.Param(SERVICE_ACCOUNT_ID))
+	if err != nil {
+		return c.JSON(http.StatusNotFound, &clients.APIResponse{
+			Message: "Failed to fetch the service account",
+			Error:   err.Error(),
+		})
+	}
+
+	result, err := serviceaccounts.GetService().Delete(ctx, client, account.ID)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &clients.APIResponse{
+			Message: "Failed to delete the service account",
+			Error:   err.Error(),
+		})
+	}
+
+	token := c.Get("user").(*jwt.Token)
+	claims := token.Claims.(*auth.Claims)
+
+	audit(c, ctx, client, claims.Hasura.UserID, audits.ServiceAccountDeletedAction, account, map[string]interface{}{
+		"revoked_tokens": result.RevokedTokens,
+	})
+
+	return c.JSON(http.StatusOK, &clients.APIResponse{
+		Message: "successfully deleted the service account; rotate the organisation's key if its credential could have leaked",
+		Data:    result,
+	})
+}
+
+//	---	Helpers ---
+
+// Initialize Hasura client with admin privileges,
+// since the user's permissions have already been validated.
+func newAdminClient() *clients.GQLClient {
+	return clients.NewGQLClient(&clients.GQLConfig{
+		Type: clients.HasuraClientType,
+		Headers: []clients.Header{
+			clients.XHasuraAdminSecretHeader,
+		},
+	})
+}
+
+// Fetches a service account, validating that it belongs to the organisation.
+func get(ctx context.ServiceContext, client *clients.GQLClient, orgID, id string) (*serviceaccounts.ServiceAccount, error) {
+
+	account, err := serviceaccounts.GetService().Get(ctx, client, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if account.OrgID != orgID {
+		return nil, serviceaccounts.ErrNotFound
+	}
+
+	return account, nil
+}
+
+func parseExpiry(value string) (time.Duration, error) {
+
+	if value == "" {
+		return 0, nil
+	}
+
+	expiry, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, errors.New("expiry must be a duration, like 720h")
+	}
+
+	return expiry, nil
+}
+
+// Decrypts the admin's copy of the organisation's key with their password.
+func decryptOrgKey(c echo.Context, ctx context.ServiceContext, orgID, userID, password string) ([]byte, error) {
+	return keys.DecryptMemberKey(ctx, clients.NewGQLClient(&clients.GQLConfig{
+		Type:          clients.HasuraClientType,
+		Authorization: c.Request().Header.Get(echo.HeaderAuthorization),
+	}), userID, &keyCommons.DecryptOptions{
+		OrgID:    orgID,
+		Password: password,
+	})
+}
+
+func audit(c echo.Context, ctx context.ServiceContext, client *clients.GQLClient, userID string, action audits.Action, account *serviceaccounts.ServiceAccount, metadata map[string]interface{}) {
+
+	metadata["name"] = account.Name
+	metadata["user_id"] = account.UserID
+
+	if _, err := audits.GetService().Create(ctx, client, &audits.CreateOptions{
+		OrgID:      account.OrgID,
+		UserID:     userID,
+		Action:     action,
+		EntityType: audits.ServiceAccountEntity,
+		EntityID:   account.ID,
+		Metadata:   metadata,
+	}); err != nil {
+		c.Logger().Error(err)
+	}
+}
@@ -0,0 +1,21 @@
+package serviceaccounts
+
+import (
+	"github.com/envsecrets/envsecrets/internal/middlewares"
+	"github.com/envsecrets/envsecrets/internal/roles"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	ORG_ID             = "org_id"
+	SERVICE_ACCOUNT_ID = "service_account_id"
+)
+
+func AddRoutes(sg *echo.Group) {
+
+	group := sg.Group("/organisations/:" + ORG_ID + "/service-accounts")
+	group.GET("", ListHandler, middlewares.Authorize(roles.PermissionsResource, roles.ReadAction))
+	group.POST("", CreateHandler, middlewares.Authorize(roles.PermissionsResource, roles.CreateAction))
+	group.POST("/:"+SERVICE_ACCOUNT_ID+"/rotate", RotateHandler, middlewares.Authorize(roles.PermissionsResource, roles.UpdateAction))
+	group.DELETE("/:"+SERVICE_ACCOUNT_ID, DeleteHandler, middlewares.Authorize(roles.PermissionsResource, roles.DeleteAction))
+}
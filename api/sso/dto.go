@@ -0,0 +1,7 @@
+package sso
+
+type RotateSCIMTokenResponse struct {
+
+	//	Only ever returned once. Rotate it again if it's lost.
+	Token string `json:"token"`
+}
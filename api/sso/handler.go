@@ -0,0 +1,317 @@
+package sso
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/envsecrets/envsecrets/cli/auth"
+	"github.com/envsecrets/envsecrets/internal/audits"
+	"github.com/envsecrets/envsecrets/internal/clients"
+	"github.com/envsecrets/envsecrets/internal/context"
+	"github.com/envsecrets/envsecrets/internal/roles"
+	"github.com/envsecrets/envsecrets/internal/sso"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/labstack/echo/v4"
+)
+
+func GetHandler(c echo.Context) error {
+
+	//	Initialize a new default context
+	ctx := context.NewContext(&context.Config{Type: context.APIContext, EchoContext: c})
+
+	connection, err := sso.GetService().GetConnection(ctx, newAdminClient(), c.Param(ORG_ID))
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, sso.ErrNotConfigured) {
+			status = http.StatusNotFound
+		}
+		return c.JSON(status, &clients.APIResponse{
+			Message: "Failed to fetch the single sign-on connection",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, &clients.APIResponse{
+		Message: "successfully fetched the single sign-on connection",
+		Data:    connection,
+	})
+}
+
+// --- Flow ---
+//
+//  1. Validate that the default role belongs to the organisation.
+//  2. Discover the issuer and save the connection, sealing the client secret.
+//  3. Record it in the organisation's audit logs.
+func SaveHandler(c echo.Context) error {
+
+	//	Unmarshal the incoming payload
+	var payload sso.SaveConnectionOptions
+	if err := c.Bind(&payload); err != nil {
+		return c.JSON(http.StatusBadRequest, &clients.APIResponse{
+			Message: "failed to parse the body",
+			Error:   err.Error(),
+		})
+	}
+
+	payload.OrgID = c.Param(ORG_ID)
+
+	//	Initialize a new default context
+	ctx := context.NewContext(&context.Config{Type: context.APIContext, EchoContext: c})
+
+	client := newAdminClient()
+
+	if payload.DefaultRoleID != "" {
+		if err := validateRole(ctx, client, payload.OrgID, payload.DefaultRoleID); err != nil {
+			return c.JSON(http.StatusBadRequest, &clients.APIResponse{
+				Message: "invalid default role",
+				Error:   err.Error(),
+			})
+		}
+	}
+
+	connection, err := sso.GetService().SaveConnection(ctx, client, &payload)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &clients.APIResponse{
+			Message: "Failed to save the single sign-on connection",
+			Error:   err.Error(),
+		})
+	}
+
+	audit(c, ctx, client, &audits.CreateOptions{
+		OrgID:      payload.OrgID,
+		Action:     audits.SSOConfiguredAction,
+		EntityType: audits.SSOConnectionEntity,
+		EntityID:   connection.ID,
+		Metadata: map[string]interface{}{
+			"issuer":         connection.Issuer,
+			"client_id":      connection.ClientID,
+			"domains":        connection.Domains,
+			"secret_changed": payload.ClientSecret != "",
+			"enabled":        connection.Enabled,
+		},
+	})
+
+	return c.JSON(http.StatusOK, &clients.APIResponse{
+		Message: "successfully saved the single sign-on connection",
+		Data:    connection,
+	})
+}
+
+// Verifies the organisation's ownership of one of the connection's domains,
+// from the TXT record holding the connection's verification token.
+// Only the members of verified domains can sign in, or be provisioned.
+func VerifyDomainHandler(c echo.Context) error {
+
+	orgID := c.Param(ORG_ID)
+
+	//	Initialize a new default context
+	ctx := context.NewContext(&context.Config{Type: context.APIContext, EchoContext: c})
+
+	client := newAdminClient()
+
+	connection, err := sso.GetService().VerifyDomain(ctx, client, orgID, c.Param(DOMAIN))
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, sso.ErrDomainClaimed) {
+			status = http.StatusConflict
+		}
+		return c.JSON(status, &clients.APIResponse{
+			Message: "Failed to verify the domain",
+			Error:   err.Error(),
+		})
+	}
+
+	audit(c, ctx, client, &audits.CreateOptions{
+		OrgID:      orgID,
+		Action:     audits.SSODomainVerifiedAction,
+		EntityType: audits.SSOConnectionEntity,
+		EntityID:   connection.ID,
+		Metadata: map[string]interface{}{
+			"domain": c.Param(DOMAIN),
+		},
+	})
+
+	return c.JSON(http.StatusOK, &clients.APIResponse{
+		Message: "successfully verified the domain",
+		Data:    connection,
+	})
+}
+
+// Rotates the token the identity provider authenticates its SCIM requests with.
+// The previous token stops working immediately.
+func RotateSCIMTokenHandler(c echo.Context) error {
+
+	orgID := c.Param(ORG_ID)
+
+	//	Initialize a new default context
+	ctx := context.NewContext(&context.Config{Type: context.APIContext, EchoContext: c})
+
+	client := newAdminClient()
+
+	connection, err := sso.GetService().GetConnection(ctx, client, orgID)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &clients.APIResponse{
+			Message: "Failed to fetch the single sign-on connection",
+			Error:   err.Error(),
+		})
+	}
+
+	token, err := sso.GetService().RotateSCIMToken(ctx, client, orgID)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &clients.APIResponse{
+			Message: "Failed to rotate the SCIM token",
+			Error:   err.Error(),
+		})
+	}
+
+	audit(c, ctx, client, &audits.CreateOptions{
+		OrgID:      orgID,
+		Action:     audits.SCIMTokenRotatedAction,
+		EntityType: audits.SSOConnectionEntity,
+		EntityID:   connection.ID,
+	})
+
+	return c.JSON(http.StatusOK, &clients.APIResponse{
+		Message: "successfully rotated the SCIM token",
+		Data: &RotateSCIMTokenResponse{
+			Token: token,
+		},
+	})
+}
+
+func ListIdentitiesHandler(c echo.Context) error {
+
+	//	Initialize a new default context
+	ctx := context.NewContext(&context.Config{Type: context.APIContext, EchoContext: c})
+
+	identities, err := sso.GetService().ListIdentities(ctx, newAdminClient(), &sso.ListIdentitiesOptions{
+		OrgID: c.Param(ORG_ID),
+	})
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &clients.APIResponse{
+			Message: "Failed to list the provisioned users",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, &clients.APIResponse{
+		Message: "successfully listed the provisioned users",
+		Data:    identities,
+	})
+}
+
+func ListGroupsHandler(c echo.Context) error {
+
+	//	Initialize a new default context
+	ctx := context.NewContext(&context.Config{Type: context.APIContext, EchoContext: c})
+
+	groups, err := sso.GetService().ListGroups(ctx, newAdminClient(), &sso.ListGroupsOptions{
+		OrgID: c.Param(ORG_ID),
+	})
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &clients.APIResponse{
+			Message: "Failed to list the provisioned groups",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, &clients.APIResponse{
+		Message: "successfully listed the provisioned groups",
+		Data:    groups,
+	})
+}
+
+// Maps a provisioned group to a role of the organisation,
+// and re-assigns the roles of the group's members.
+func MapGroupHandler(c echo.Context) error {
+
+	//	Unmarshal the incoming payload
+	var payload sso.MapGroupOptions
+	if err := c.Bind(&payload); err != nil {
+		return c.JSON(http.StatusBadRequest, &clients.APIResponse{
+			Message: "failed to parse the body",
+			Error:   err.Error(),
+		})
+	}
+
+	orgID := c.Param(ORG_ID)
+
+	//	Initialize a new default context
+	ctx := context.NewContext(&context.Config{Type: context.APIContext, EchoContext: c})
+
+	client := newAdminClient()
+
+	if payload.RoleID != "" {
+		if err := validateRole(ctx, client, orgID, payload.RoleID); err != nil {
+			return c.JSON(http.StatusBadRequest, &clients.APIResponse{
+				Message: "invalid role",
+				Error:   err.Error(),
+			})
+		}
+	}
+
+	group, err := sso.GetService().MapGroup(ctx, client, orgID, c.Param(GROUP_ID), &payload)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, sso.ErrNotFound) {
+			status = http.StatusNotFound
+		}
+		return c.JSON(status, &clients.APIResponse{
+			Message: "Failed to map the group",
+			Error:   err.Error(),
+		})
+	}
+
+	audit(c, ctx, client, &audits.CreateOptions{
+		OrgID:      orgID,
+		Action:     audits.SSOGroupMappedAction,
+		EntityType: audits.SSOGroupEntity,
+		EntityID:   group.ID,
+		Metadata: map[string]interface{}{
+			"display_name": group.DisplayName,
+			"role_id":      payload.RoleID,
+			"priority":     payload.Priority,
+		},
+	})
+
+	return c.JSON(http.StatusOK, &clients.APIResponse{
+		Message: "successfully mapped the group",
+		Data:    group,
+	})
+}
+
+// Initialize Hasura client with admin privileges,
+// since the user's permissions have already been validated.
+func newAdminClient() *clients.GQLClient {
+	return clients.NewGQLClient(&clients.GQLConfig{
+		Type: clients.HasuraClientType,
+		Headers: []clients.Header{
+			clients.XHasuraAdminSecretHeader,
+		},
+	})
+}
+
+func validateRole(ctx context.ServiceContext, client *clients.GQLClient, orgID, roleID string) error {
+
+	role, err := roles.Get(ctx, client, roleID)
+	if err != nil {
+		return err
+	}
+
+	if role.OrgID != orgID {
+		return errors.New("the role doesn't belong to this organisation")
+	}
+
+	return nil
+}
+
+func audit(c echo.Context, ctx context.ServiceContext, client *clients.GQLClient, options *audits.CreateOptions) {
+
+	token := c.Get("user").(*jwt.Token)
+	claims := token.Claims.(*auth.Claims)
+	options.UserID = claims.Hasura.UserID
+
+	if _, err := audits.GetService().Create(ctx, client, options); err != nil {
+		c.Logger().Error(err)
+	}
+}
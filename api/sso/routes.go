@@ -0,0 +1,26 @@
+package sso
+
+import (
+	"github.com/envsecrets/envsecrets/internal/middlewares"
+	"github.com/envsecrets/envsecrets/internal/roles"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	ORG_ID   = "org_id"
+	GROUP_ID = "group_id"
+	DOMAIN   = "domain"
+)
+
+// Routes for organisation admins to manage single sign-on and SCIM provisioning.
+func AddRoutes(sg *echo.Group) {
+
+	group := sg.Group("/organisations/:" + ORG_ID + "/sso")
+	group.GET("", GetHandler, middlewares.Authorize(roles.PermissionsResource, roles.ReadAction))
+	group.PUT("", SaveHandler, middlewares.Authorize(roles.PermissionsResource, roles.UpdateAction))
+	group.POST("/domains/:"+DOMAIN+"/verify", VerifyDomainHandler, middlewares.Authorize(roles.PermissionsResource, roles.UpdateAction))
+	group.POST("/scim-token", RotateSCIMTokenHandler, middlewares.Authorize(roles.PermissionsResource, roles.UpdateAction))
+	group.GET("/identities", ListIdentitiesHandler, middlewares.Authorize(roles.PermissionsResource, roles.ReadAction))
+	group.GET("/groups", ListGroupsHandler, middlewares.Authorize(roles.PermissionsResource, roles.ReadAction))
+	group.PUT("/groups/:"+GROUP_ID+"/role", MapGroupHandler, middlewares.Authorize(roles.PermissionsResource, roles.UpdateAction))
+}
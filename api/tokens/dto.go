@@ -1,8 +1,13 @@
 package tokens
 
+import "github.com/envsecrets/envsecrets/internal/tokens"
+
 type CreateOptions struct {
 	Password string `json:"password"`
 	EnvID    string `json:"env_id"`
 	Expiry   string `json:"expiry"`
 	Name     string `json:"name,omitempty"`
+
+	//	Optional allow-list, user agent or CI identity binding, and usage limit of the token.
+	Restrictions *tokens.Restrictions `json:"restrictions,omitempty"`
 }
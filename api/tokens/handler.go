@@ -11,6 +11,8 @@ import (
 	"github.com/envsecrets/envsecrets/internal/keys"
 	keysCommons "github.com/envsecrets/envsecrets/internal/keys/commons"
 	"github.com/envsecrets/envsecrets/internal/organisations"
+	"github.com/envsecrets/envsecrets/internal/permissions"
+	"github.com/envsecrets/envsecrets/internal/roles"
 	"github.com/envsecrets/envsecrets/internal/tokens"
 	"github.com/golang-jwt/jwt/v4"
 	"github.com/labstack/echo/v4"
@@ -26,6 +28,14 @@ func CreateHandler(c echo.Context) error {
 		})
 	}
 
+	//	Environment tokens can read all the secrets of their environment.
+	if err := permissions.AuthorizeRequest(c, permissions.Scope{EnvID: payload.EnvID}, roles.SecretsResource, roles.ReadAction); err != nil {
+		return c.JSON(http.StatusForbidden, &clients.APIResponse{
+			Message: "You are not allowed to read the secrets of this environment",
+			Error:   err.Error(),
+		})
+	}
+
 	//	Initialize a new default context
 	ctx := context.NewContext(&context.Config{Type: context.APIContext, EchoContext: c})
 
@@ -81,10 +91,11 @@ func CreateHandler(c echo.Context) error {
 	}
 
 	token, err := service.Create(ctx, client, &tokens.CreateOptions{
-		OrgKey: orgKey,
-		EnvID:  payload.EnvID,
-		Expiry: expiry,
-		Name:   payload.Name,
+		OrgKey:       orgKey,
+		EnvID:        payload.EnvID,
+		Expiry:       expiry,
+		Name:         payload.Name,
+		Restrictions: payload.Restrictions,
 	})
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, &clients.APIResponse{
@@ -3,6 +3,8 @@ package triggers
 import (
 	"net/http"
 
+	"github.com/envsecrets/envsecrets/internal/access"
+	"github.com/envsecrets/envsecrets/internal/audits"
 	"github.com/envsecrets/envsecrets/internal/clients"
 	"github.com/envsecrets/envsecrets/internal/context"
 	"github.com/envsecrets/envsecrets/internal/environments"
@@ -235,3 +237,43 @@ func InviteInserted(c echo.Context) error {
 		Message: "successfully sent invitation email to " + row.Email,
 	})
 }
+
+// Called by the cron trigger to record the access grants which have expired.
+func AccessGrantsExpired(c echo.Context) error {
+
+	//	Initialize a new default context
+	ctx := context.NewContext(&context.Config{Type: context.APIContext, EchoContext: c})
+
+	//	Initialize Hasura client with admin privileges
+	client := clients.NewGQLClient(&clients.GQLConfig{
+		Type: clients.HasuraClientType,
+		Headers: []clients.Header{
+			clients.XHasuraAdminSecretHeader,
+		},
+	})
+
+	expired, err := access.GetService().Expire(ctx, client)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, &clients.APIResponse{
+			Message: "Failed to expire the access grants",
+			Error:   err.Error(),
+		})
+	}
+
+	for _, grant := range expired {
+		if _, err := audits.GetService().Create(ctx, client, &audits.CreateOptions{
+			OrgID:      grant.OrgID,
+			Action:     audits.AccessExpiredAction,
+			EntityType: audits.AccessGrantEntity,
+			EntityID:   grant.ID,
+			Metadata:   grant.Metadata(),
+		}); err != nil {
+			c.Logger().Error(err)
+		}
+	}
+
+	return c.JSON(http.StatusOK, &clients.APIResponse{
+		Message: "successfully expired the access grants",
+		Data:    len(expired),
+	})
+}
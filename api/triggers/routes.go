@@ -35,6 +35,10 @@ func AddRoutes(sg *echo.Group) {
 	//organisations := triggers.Group("/organisations")
 	//organisations.POST("/new", OrganisationCreated)
 
+	//	access grants group
+	grants := triggers.Group("/access-grants")
+	grants.POST("/expire", AccessGrantsExpired)
+
 	//	projects group
 	projects := triggers.Group("/projects")
 	projects.POST("/new", ProjectInserted)
@@ -0,0 +1,205 @@
+/*
+Copyright © 2023 Mrinal Wahal <mrinalwahal@gmail.com>
+All rights reserved.
+
+Redistribution and use in source and binary forms, with or without
+modification, are permitted provided that the following conditions are met:
+
+ 1. Redistributions of source code must retain the above copyright notice,
+    this list of conditions and the following disclaimer.
+
+ 2. Redistributions in binary form must reproduce the above copyright notice,
+    this list of conditions and the following disclaimer in the documentation
+    and/or other materials provided with the distribution.
+
+ 3. Neither the name of the copyright holder nor the names of its contributors
+    may be used to endorse or promote products derived from this software
+    without specific prior written permission.
+
+THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+POSSIBILITY OF SUCH DAMAGE.
+*/
+package cmd
+
+import (
+	"time"
+
+	"github.com/envsecrets/envsecrets/cli/commons"
+	"github.com/envsecrets/envsecrets/cli/internal"
+	"github.com/envsecrets/envsecrets/internal/access"
+	"github.com/spf13/cobra"
+)
+
+var accessDuration, accessReason, accessStatus string
+
+// accessCmd represents the access command
+var accessCmd = &cobra.Command{
+	Use:   "access",
+	Short: "Request and review time-boxed access to environments",
+	Long: `Request and review time-boxed access to read the secrets of environments.
+
+Approved access is revoked automatically once it expires.`,
+	PersistentPreRunE: authenticate,
+}
+
+// accessRequestCmd represents the access request command
+var accessRequestCmd = &cobra.Command{
+	Use:   "request",
+	Short: "Request access to read the secrets of an environment",
+	Long: `Request access to read the secrets of an environment.
+
+Example: envs access request --env prod --duration 2h --reason "INC-123"`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		if accessReason == "" {
+			commons.Log.Fatal("Pass the reason for your request with --reason")
+		}
+
+		environment := getEnvironment(requireEnvironmentName())
+
+		grant, err := internal.RequestAccess(commons.DefaultContext, commons.HTTPClient, environment.ID, accessDuration, accessReason)
+		if err != nil {
+			commons.Log.Debug(err)
+			commons.Log.Fatal("Failed to request access: ", err)
+		}
+
+		if outputFormat == "json" {
+			printOutput(grant, nil, nil)
+			return
+		}
+
+		commons.Log.Info("Requested access with ID ", grant.ID)
+		commons.Log.Info("It can be approved with: envs access approve ", grant.ID)
+	},
+}
+
+// accessListCmd represents the access list command
+var accessListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List the access requests and grants of your organisation",
+	Long: `List the access requests and grants of your organisation.
+
+Members who can't read the organisation's permissions only see their own.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		var status access.Status
+		if accessStatus != "all" {
+			status = access.Status(accessStatus)
+		}
+
+		list, err := internal.ListAccessGrants(commons.DefaultContext, commons.HTTPClient, getOrganisationID(), status)
+		if err != nil {
+			commons.Log.Debug(err)
+			commons.Log.Fatal("Failed to fetch the access grants")
+		}
+
+		var rows [][]string
+		for _, item := range list {
+
+			var environment, email, expiry string
+			if item.Environment != nil {
+				environment = item.Environment.Name
+			}
+			if item.User != nil {
+				email = item.User.Email
+			}
+			if item.ExpiresAt != nil {
+				expiry = item.ExpiresAt.Local().Format("2006-01-02 15:04")
+			}
+
+			rows = append(rows, []string{
+				item.ID,
+				email,
+				environment,
+				string(item.Status),
+				(time.Duration(item.Duration) * time.Second).String(),
+				expiry,
+				item.Reason,
+			})
+		}
+
+		printOutput(list, []string{"ID", "USER", "ENVIRONMENT", "STATUS", "DURATION", "EXPIRES", "REASON"}, rows)
+	},
+}
+
+// accessApproveCmd represents the access approve command
+var accessApproveCmd = &cobra.Command{
+	Use:   "approve [id]",
+	Short: "Approve an access request",
+	Long: `Approve an access request.
+
+The access starts right away and expires after the requested duration.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		reviewAccess(args[0], "approve")
+	},
+}
+
+// accessRejectCmd represents the access reject command
+var accessRejectCmd = &cobra.Command{
+	Use:   "reject [id]",
+	Short: "Reject an access request",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		reviewAccess(args[0], "reject")
+	},
+}
+
+// accessRevokeCmd represents the access revoke command
+var accessRevokeCmd = &cobra.Command{
+	Use:   "revoke [id]",
+	Short: "Revoke an access grant before it expires",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		reviewAccess(args[0], "revoke")
+	},
+}
+
+func reviewAccess(id, action string) {
+
+	grant, err := internal.ReviewAccessGrant(commons.DefaultContext, commons.HTTPClient, id, action)
+	if err != nil {
+		commons.Log.Debug(err)
+		commons.Log.Fatal("Failed to ", action, " the access: ", err)
+	}
+
+	if outputFormat == "json" {
+		printOutput(grant, nil, nil)
+		return
+	}
+
+	switch grant.Status {
+	case access.ApprovedStatus:
+		commons.Log.Info("Access approved until ", grant.ExpiresAt.Local().Format(time.RFC1123))
+	case access.RejectedStatus:
+		commons.Log.Info("Access rejected")
+	case access.RevokedStatus:
+		commons.Log.Info("Access revoked")
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(accessCmd)
+	accessCmd.AddCommand(accessRequestCmd, accessListCmd, accessApproveCmd, accessRejectCmd, accessRevokeCmd)
+
+	accessCmd.PersistentFlags().StringVarP(&organisationID, "organisation", "w", "", "Your envsecrets organisation; defaults to the one of your current project")
+	accessCmd.PersistentFlags().StringVarP(&projectID, "project", "p", "", "Name or ID of your envsecrets project; defaults to your current project")
+	addOutputFlag(accessCmd)
+
+	accessRequestCmd.Flags().StringVarP(&environmentName, "env", "e", "", "Remote environment to request access to")
+	accessRequestCmd.Flags().StringVarP(&accessDuration, "duration", "d", "1h", "Duration of the access, up to 24h")
+	accessRequestCmd.Flags().StringVarP(&accessReason, "reason", "r", "", "Reason for the access, like an incident ID")
+	accessListCmd.Flags().StringVar(&accessStatus, "status", "all", "Status of the grants to list; pending, approved, rejected, revoked, expired or all")
+}
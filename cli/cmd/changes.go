@@ -0,0 +1,324 @@
+/*
+Copyright © 2023 Mrinal Wahal <mrinalwahal@gmail.com>
+All rights reserved.
+
+Redistribution and use in source and binary forms, with or without
+modification, are permitted provided that the following conditions are met:
+
+ 1. Redistributions of source code must retain the above copyright notice,
+    this list of conditions and the following disclaimer.
+
+ 2. Redistributions in binary form must reproduce the above copyright notice,
+    this list of conditions and the following disclaimer in the documentation
+    and/or other materials provided with the distribution.
+
+ 3. Neither the name of the copyright holder nor the names of its contributors
+    may be used to endorse or promote products derived from this software
+    without specific prior written permission.
+
+THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+POSSIBILITY OF SUCH DAMAGE.
+*/
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/envsecrets/envsecrets/cli/commons"
+	"github.com/envsecrets/envsecrets/cli/internal"
+	"github.com/envsecrets/envsecrets/internal/changes"
+	"github.com/envsecrets/envsecrets/internal/environments"
+	"github.com/envsecrets/envsecrets/internal/keys"
+	"github.com/envsecrets/envsecrets/internal/memberships"
+	"github.com/envsecrets/envsecrets/internal/secrets/pkg/keypayload"
+	"github.com/envsecrets/envsecrets/internal/secrets/pkg/payload"
+	"github.com/spf13/cobra"
+)
+
+var changeStatus, changeMessage, changeComment string
+var syncOnApproval, showValues, changeRequested bool
+
+// changesCmd represents the changes command
+var changesCmd = &cobra.Command{
+	Use:   "changes",
+	Short: "Review the change requests of your protected environments",
+	Long: `Secrets of protected environments can only be changed through change requests.
+
+Running "envs set" or "envs delete" on a protected environment creates a change request,
+which is applied as a new version of the secrets once it has been approved.`,
+	PersistentPreRunE: authenticate,
+}
+
+// changesListCmd represents the changes list command
+var changesListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List the change requests of an environment",
+	Args:    cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		environment := getEnvironment(requireEnvironmentName())
+
+		var status changes.Status
+		if changeStatus != "all" {
+			status = changes.Status(changeStatus)
+		}
+
+		list, err := internal.ListChangeRequests(commons.DefaultContext, commons.HTTPClient, environment.ID, status)
+		if err != nil {
+			commons.Log.Debug(err)
+			commons.Log.Fatal("Failed to fetch the change requests")
+		}
+
+		var rows [][]string
+		for _, item := range list {
+			rows = append(rows, []string{
+				item.ID,
+				string(item.Status),
+				fmt.Sprint(item.Approvals()),
+				strings.Join(diffSummary(item.Diff), " "),
+				item.CreatedAt.Format("2006-01-02 15:04"),
+				item.Message,
+			})
+		}
+
+		printOutput(list, []string{"ID", "STATUS", "APPROVALS", "CHANGES", "CREATED", "MESSAGE"}, rows)
+	},
+}
+
+// changesShowCmd represents the changes show command
+var changesShowCmd = &cobra.Command{
+	Use:   "show [id]",
+	Short: "Show the key level changes of a change request",
+	Long: `Show the key level changes of a change request.
+
+Use --values to also decrypt and print the proposed values.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+
+		change, err := internal.GetChangeRequest(commons.DefaultContext, commons.HTTPClient, args[0])
+		if err != nil {
+			commons.Log.Debug(err)
+			commons.Log.Fatal("Failed to fetch the change request")
+		}
+
+		if showValues {
+			decryptChange(change)
+		} else {
+			change.Data.DeleteValues()
+		}
+
+		var rows [][]string
+		for _, key := range sortedKeys(change.Diff) {
+			var value string
+			if item := change.Data.Get(key); item != nil {
+				value = item.Value
+			}
+			rows = append(rows, []string{key, string(change.Diff[key]), value})
+		}
+
+		printOutput(change, []string{"KEY", "CHANGE", "VALUE"}, rows)
+	},
+}
+
+// changesApproveCmd represents the changes approve command
+var changesApproveCmd = &cobra.Command{
+	Use:   "approve [id]",
+	Short: "Approve a change request",
+	Long: `Approve a change request.
+
+The change is applied as a new version of the secrets once it has received
+the number of approvals required by its environment.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		reviewChange(args[0], changes.ApproveDecision)
+	},
+}
+
+// changesRejectCmd represents the changes reject command
+var changesRejectCmd = &cobra.Command{
+	Use:   "reject [id]",
+	Short: "Reject a change request",
+	Long: `Reject a change request.
+
+Requesters can also reject their own change requests to withdraw them.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		reviewChange(args[0], changes.RejectDecision)
+	},
+}
+
+func reviewChange(id string, decision changes.Decision) {
+
+	change, err := internal.GetChangeRequest(commons.DefaultContext, commons.HTTPClient, id)
+	if err != nil {
+		commons.Log.Debug(err)
+		commons.Log.Fatal("Failed to fetch the change request")
+	}
+
+	//	The approver's password is needed to decrypt the secrets for syncing them.
+	var password string
+	if decision == changes.ApproveDecision && change.Sync {
+		commons.Log.Info("This change will be synced with the environment's integrations once applied")
+		password = getPassword()
+	}
+
+	response, err := internal.ReviewChangeRequest(commons.DefaultContext, commons.HTTPClient, id, decision, changeComment, password)
+	if err != nil {
+		commons.Log.Debug(err)
+		commons.Log.Fatal("Failed to review the change request: ", err)
+	}
+
+	if outputFormat == "json" {
+		printOutput(response, nil, nil)
+		return
+	}
+
+	result := response.ChangeRequest
+	switch result.Status {
+	case changes.ApprovedStatus:
+		if result.Version != nil {
+			commons.Log.Infof("Change approved! Latest version is now %d", *result.Version)
+		}
+		if response.Synced {
+			commons.Log.Info("Secrets synced with the environment's integrations")
+		} else if response.SyncError != "" {
+			commons.Log.Warn("Failed to sync the secrets: ", response.SyncError)
+		}
+	case changes.RejectedStatus:
+		commons.Log.Info("Change rejected")
+	default:
+		commons.Log.Infof("Approval recorded; the change has %d approval(s) so far", result.Approvals())
+	}
+}
+
+// Creates a change request for a protected environment instead of writing its secrets.
+// The data must already be encrypted with the organisation's key.
+func requestChange(envID string, data keypayload.KPMap, removed []string) {
+
+	change, err := internal.CreateChangeRequest(commons.DefaultContext, commons.HTTPClient, envID, &changes.CreateOptions{
+		Data:    data,
+		Removed: removed,
+		Message: changeMessage,
+		Sync:    syncOnApproval,
+	})
+	if err != nil {
+		commons.Log.Debug(err)
+		commons.Log.Fatal("Failed to create the change request: ", err)
+	}
+
+	changeRequested = true
+	commons.Log.Infof("Environment `%s` is protected; created change request %s", environmentName, change.ID)
+	commons.Log.Info("It will be applied once approved with: envs changes approve ", change.ID)
+}
+
+// Returns whether the remote environment only accepts changes through change requests.
+func isProtected(envID string) bool {
+
+	environment, err := environments.GetService().Get(commons.DefaultContext, commons.GQLClient.GQLClient, envID)
+	if err != nil {
+		commons.Log.Debug(err)
+		commons.Log.Fatal("Failed to fetch the environment")
+	}
+
+	return environment.Protected
+}
+
+// Converts the encrypted pairs of the common secret to the payloads sent to the server.
+func encryptedPayloads() keypayload.KPMap {
+	data := keypayload.KPMap{}
+	for key, value := range commons.Secret.Data.ToKVMap().GetMapping() {
+		data[key] = &payload.Payload{
+			Value: value,
+		}
+	}
+	return data
+}
+
+// Decrypts the proposed values of a change request with the organisation's key.
+func decryptChange(change *changes.ChangeRequest) {
+
+	if commons.KeysConfig == nil || commons.AccountConfig == nil {
+		commons.Log.Fatal("Your keys were not found; run `envs login` first")
+	}
+
+	key, err := memberships.GetKey(commons.DefaultContext, commons.GQLClient.GQLClient, &memberships.GetKeyOptions{
+		OrgID:  getOrganisationID(),
+		UserID: commons.AccountConfig.User.ID,
+	})
+	if err != nil {
+		commons.Log.Debug(err)
+		commons.Log.Fatal("Failed to fetch your copy of the organisation's key")
+	}
+
+	var orgKey [32]byte
+	decryptedOrgKey, err := keys.DecryptAsymmetricallyAnonymous(commons.KeysConfig.Public, commons.KeysConfig.Private, key)
+	if err != nil {
+		commons.Log.Debug(err)
+		commons.Log.Fatal("Failed to decrypt the organisation's encryption key")
+	}
+	copy(orgKey[:], decryptedOrgKey)
+
+	if err := change.Data.Decrypt(orgKey); err != nil {
+		commons.Log.Debug(err)
+		commons.Log.Fatal("Failed to decrypt the proposed values")
+	}
+}
+
+func requireEnvironmentName() string {
+	if environmentName == "" {
+		commons.Log.Fatal("Pass the environment with --env")
+	}
+	return environmentName
+}
+
+// Returns the changed keys prefixed with +, ~ or - for additions, modifications and removals.
+func diffSummary(diff map[string]changes.Change) []string {
+
+	prefixes := map[changes.Change]string{
+		changes.AddedChange:    "+",
+		changes.ModifiedChange: "~",
+		changes.RemovedChange:  "-",
+	}
+
+	var result []string
+	for _, key := range sortedKeys(diff) {
+		result = append(result, prefixes[diff[key]]+key)
+	}
+	return result
+}
+
+func sortedKeys(diff map[string]changes.Change) []string {
+	var result []string
+	for key := range diff {
+		result = append(result, key)
+	}
+	sort.Strings(result)
+	return result
+}
+
+func init() {
+	rootCmd.AddCommand(changesCmd)
+	changesCmd.AddCommand(changesListCmd, changesShowCmd, changesApproveCmd, changesRejectCmd)
+
+	changesCmd.PersistentFlags().StringVarP(&organisationID, "organisation", "w", "", "Your envsecrets organisation; defaults to the one of your current project")
+	changesCmd.PersistentFlags().StringVarP(&projectID, "project", "p", "", "Name or ID of your envsecrets project; defaults to your current project")
+	addOutputFlag(changesCmd)
+
+	changesListCmd.Flags().StringVarP(&environmentName, "env", "e", "", "Remote environment to list the change requests of")
+	changesListCmd.Flags().StringVar(&changeStatus, "status", string(changes.PendingStatus), "Status of the change requests to list; pending, approved, rejected or all")
+	changesShowCmd.Flags().BoolVar(&showValues, "values", false, "Decrypt and print the proposed values")
+	changesApproveCmd.Flags().StringVarP(&changeComment, "comment", "c", "", "Comment for the requester")
+	changesRejectCmd.Flags().StringVarP(&changeComment, "comment", "c", "", "Comment for the requester")
+}
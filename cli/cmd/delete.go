@@ -55,6 +55,12 @@ var deleteCmd = &cobra.Command{
 		//	Auto-capitalize the key
 		key = strings.ToUpper(key)
 
+		//	Protected environments only accept changes through change requests.
+		if commons.Secret.EnvID != "" && isProtected(commons.Secret.EnvID) {
+			requestChange(commons.Secret.EnvID, nil, []string{key})
+			return
+		}
+
 		options := &secrets.DeleteOptions{
 			Key:   key,
 			EnvID: commons.Secret.EnvID,
@@ -75,6 +81,9 @@ var deleteCmd = &cobra.Command{
 		}
 	},
 	PostRun: func(cmd *cobra.Command, args []string) {
+		if changeRequested {
+			return
+		}
 		commons.Log.Infof("Key %s deleted", args[0])
 	},
 }
@@ -92,4 +101,6 @@ func init() {
 	// is called directly, e.g.:
 	// deleteCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
 	deleteCmd.Flags().StringVarP(&environmentName, "env", "e", "", "Remote environment to delete the secret key from. Defaults to the local environment.")
+	deleteCmd.Flags().StringVarP(&changeMessage, "message", "m", "", "Describe the change request, if the remote environment is protected")
+	deleteCmd.Flags().BoolVar(&syncOnApproval, "sync", false, "Sync the secrets with the environment's integrations once the change request is approved")
 }
@@ -0,0 +1,248 @@
+/*
+Copyright © 2023 Mrinal Wahal <mrinalwahal@gmail.com>
+All rights reserved.
+
+Redistribution and use in source and binary forms, with or without
+modification, are permitted provided that the following conditions are met:
+
+ 1. Redistributions of source code must retain the above copyright notice,
+    this list of conditions and the following disclaimer.
+
+ 2. Redistributions in binary form must reproduce the above copyright notice,
+    this list of conditions and the following disclaimer in the documentation
+    and/or other materials provided with the distribution.
+
+ 3. Neither the name of the copyright holder nor the names of its contributors
+    may be used to endorse or promote products derived from this software
+    without specific prior written permission.
+
+THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+POSSIBILITY OF SUCH DAMAGE.
+*/
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/envsecrets/envsecrets/cli/commons"
+	"github.com/envsecrets/envsecrets/cli/internal"
+	"github.com/envsecrets/envsecrets/internal/environments"
+	"github.com/spf13/cobra"
+)
+
+var requiredApprovals int
+
+// environmentsCmd represents the envs command
+var environmentsCmd = &cobra.Command{
+	Use:               "envs",
+	Aliases:           []string{"environments"},
+	Short:             "Manage the environments of your project",
+	PersistentPreRunE: authenticate,
+}
+
+// environmentsListCmd represents the envs list command
+var environmentsListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List the environments of your project",
+	Args:    cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		list, err := environments.GetService().List(commons.DefaultContext, commons.GQLClient.GQLClient, &environments.ListOptions{
+			ProjectID: getProjectID(),
+		})
+		if err != nil {
+			commons.Log.Debug(err)
+			commons.Log.Fatal("Failed to fetch the environments")
+		}
+
+		type item struct {
+			ID        string `json:"id"`
+			Name      string `json:"name"`
+			Protected bool   `json:"protected"`
+		}
+
+		var result []item
+		var rows [][]string
+		for _, environment := range list {
+			result = append(result, item{ID: environment.ID, Name: environment.Name, Protected: environment.Protected})
+			rows = append(rows, []string{environment.Name, environment.ID, fmt.Sprint(environment.Protected)})
+		}
+
+		printOutput(result, []string{"NAME", "ID", "PROTECTED"}, rows)
+	},
+}
+
+// environmentsCreateCmd represents the envs create command
+var environmentsCreateCmd = &cobra.Command{
+	Use:   "create [name]",
+	Short: "Create a new environment in your project",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+
+		if err := validateSlug(args[0]); err != nil {
+			commons.Log.Fatal(err)
+		}
+
+		environment, err := environments.GetService().Create(commons.DefaultContext, commons.GQLClient.GQLClient, &environments.CreateOptions{
+			ProjectID: getProjectID(),
+			Name:      args[0],
+		})
+		if err != nil {
+			commons.Log.Debug(err)
+			commons.Log.Fatal("Failed to create the environment")
+		}
+
+		if outputFormat == "json" {
+			printOutput(map[string]string{
+				"id":   environment.ID,
+				"name": environment.Name,
+			}, nil, nil)
+			return
+		}
+
+		commons.Log.Info("Created the environment ", environment.Name)
+	},
+}
+
+// environmentsRenameCmd represents the envs rename command
+var environmentsRenameCmd = &cobra.Command{
+	Use:   "rename [name] [new-name]",
+	Short: "Rename an environment of your project",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+
+		if err := validateSlug(args[1]); err != nil {
+			commons.Log.Fatal(err)
+		}
+
+		existing := getEnvironment(args[0])
+
+		environment, err := environments.GetService().Update(commons.DefaultContext, commons.GQLClient.GQLClient, existing.ID, &environments.UpdateOptions{
+			Name: args[1],
+		})
+		if err != nil {
+			commons.Log.Debug(err)
+			commons.Log.Fatal("Failed to rename the environment")
+		}
+
+		if outputFormat == "json" {
+			printOutput(map[string]string{
+				"id":   environment.ID,
+				"name": environment.Name,
+			}, nil, nil)
+			return
+		}
+
+		commons.Log.Info("Renamed the environment to ", environment.Name)
+	},
+}
+
+// environmentsDeleteCmd represents the envs delete command
+var environmentsDeleteCmd = &cobra.Command{
+	Use:   "delete [name]",
+	Short: "Delete an environment of your project",
+	Long:  `This command deletes the environment along with all of its secrets.`,
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+
+		environment := getEnvironment(args[0])
+
+		confirm(fmt.Sprintf("Delete the environment %s and all of its secrets", args[0]))
+
+		if err := environments.GetService().Delete(commons.DefaultContext, commons.GQLClient.GQLClient, environment.ID); err != nil {
+			commons.Log.Debug(err)
+			commons.Log.Fatal("Failed to delete the environment")
+		}
+
+		if outputFormat == "json" {
+			printOutput(map[string]string{
+				"id": environment.ID,
+			}, nil, nil)
+			return
+		}
+
+		commons.Log.Info("Deleted the environment ", args[0])
+	},
+}
+
+// environmentsProtectCmd represents the envs protect command
+var environmentsProtectCmd = &cobra.Command{
+	Use:   "protect [name]",
+	Short: "Require approvals for the secret changes of an environment",
+	Long: `Require approvals for the secret changes of an environment.
+
+Once protected, "envs set" and "envs delete" create change requests,
+which are only applied after being approved: envs changes approve [id]`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		protectEnvironment(args[0], true)
+	},
+}
+
+// environmentsUnprotectCmd represents the envs unprotect command
+var environmentsUnprotectCmd = &cobra.Command{
+	Use:   "unprotect [name]",
+	Short: "Allow the secrets of an environment to be changed without approvals",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		protectEnvironment(args[0], false)
+	},
+}
+
+func protectEnvironment(name string, protected bool) {
+
+	environment := getEnvironment(name)
+
+	if err := internal.ProtectEnvironment(commons.DefaultContext, commons.HTTPClient, environment.ID, protected, requiredApprovals); err != nil {
+		commons.Log.Debug(err)
+		commons.Log.Fatal("Failed to update the environment's protection: ", err)
+	}
+
+	if outputFormat == "json" {
+		printOutput(map[string]interface{}{
+			"id":        environment.ID,
+			"protected": protected,
+		}, nil, nil)
+		return
+	}
+
+	if protected {
+		commons.Log.Info("Protected the environment ", name)
+	} else {
+		commons.Log.Info("Removed the protection of the environment ", name)
+	}
+}
+
+// Fetches an environment of the project by its name.
+func getEnvironment(name string) *environments.Environment {
+
+	environment, err := environments.GetService().GetByNameAndProjectID(commons.DefaultContext, commons.GQLClient.GQLClient, name, getProjectID())
+	if err != nil {
+		commons.Log.Debug(err)
+		commons.Log.Fatal("Environment not found: ", name)
+	}
+
+	return environment
+}
+
+func init() {
+	rootCmd.AddCommand(environmentsCmd)
+	environmentsCmd.AddCommand(environmentsListCmd, environmentsCreateCmd, environmentsRenameCmd, environmentsDeleteCmd, environmentsProtectCmd, environmentsUnprotectCmd)
+
+	environmentsCmd.PersistentFlags().StringVarP(&organisationID, "organisation", "w", "", "Your envsecrets organisation; defaults to the one of your current project")
+	environmentsCmd.PersistentFlags().StringVarP(&projectID, "project", "p", "", "Name or ID of your envsecrets project; defaults to your current project")
+	addOutputFlag(environmentsCmd)
+
+	environmentsDeleteCmd.Flags().BoolVarP(&yes, "yes", "y", false, "Skip the confirmation")
+	environmentsProtectCmd.Flags().IntVar(&requiredApprovals, "approvals", 1, "Number of approvals a change request needs before it is applied")
+}
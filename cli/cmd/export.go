@@ -60,6 +60,9 @@ var exportCmd = &cobra.Command{
 	Short: "Prints decrypted list of your environment's (key=value) secret pairs",
 	PreRun: func(cmd *cobra.Command, args []string) {
 
+		//	Inside a trusted CI job, use the job's identity instead of a token.
+		useCIIdentity()
+
 		//	If the user has passed a token,
 		//	avoid using email+password to authenticate them against the API.
 		if XTokenHeader != "" {
@@ -73,70 +76,7 @@ var exportCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 
 		if XTokenHeader != "" {
-
-			options := &internal.GetValuesOptions{
-				Token: XTokenHeader,
-			}
-
-			if version > -1 {
-				options.Version = &version
-			}
-
-			result, err := internal.GetSecret(commons.DefaultContext, commons.HTTPClient, options)
-			if err != nil {
-				commons.Log.Debug(err)
-				if strings.Compare(err.Error(), string(clients.ErrorTypeRecordNotFound)) == 0 {
-					commons.Log.Error("You haven't set any secrets in this environment")
-					commons.Log.Info("Use `envs set --help` for more information")
-					os.Exit(1)
-				} else {
-					commons.Log.Fatal("Failed to fetch the secrets")
-				}
-			}
-
-			//	Mark all the secrets encoded by default.
-			result.Secret.MarkEncoded()
-
-			//	Decode the key.
-			keyBytes, err := base64.StdEncoding.DecodeString(result.Token.Key)
-			if err != nil {
-				commons.Log.Debug(err)
-				commons.Log.Fatal("Failed to decode the key")
-			}
-
-			//	Decode the token.
-			token, err := hex.DecodeString(XTokenHeader)
-			if err != nil {
-				commons.Log.Debug(err)
-				commons.Log.Fatal("Failed to decode the token")
-			}
-
-			//	Decrypt the token.
-			orgKeyBytes, err := tokens.GetService().Decrypt(commons.DefaultContext, commons.GQLClient.GQLClient, token, keyBytes)
-			if err != nil {
-				commons.Log.Debug(err)
-				commons.Log.Fatal("Failed to decrypt the token")
-			}
-
-			//	Convert the key to [32]byte.
-			var orgKey [32]byte
-			copy(orgKey[:], orgKeyBytes)
-
-			//	Decrypt the secrets.
-			if err := result.Secret.Decrypt(orgKey); err != nil {
-				commons.Log.Debug(err)
-				commons.Log.Fatal("Failed to decrypt the secret")
-			}
-
-			//	Temporary copy-over.
-			for k, v := range result.Secret.Data {
-				commons.Secret.Set(k, &dto.Payload{
-					Value: v.Value,
-				})
-			}
-
-			commons.Secret.Decode()
-
+			fetchWithToken()
 		} else {
 
 			//	Fetch only the required values.
@@ -241,6 +181,80 @@ var exportCmd = &cobra.Command{
 	},
 }
 
+// Fetches and decrypts the secrets of the environment the token passed with --token belongs to,
+// into the common secret.
+func fetchWithToken() {
+
+	commons.Secret = &dto.Secret{}
+
+	options := &internal.GetValuesOptions{
+		Token:   XTokenHeader,
+		IDToken: ciIDToken,
+	}
+
+	if version > -1 {
+		options.Version = &version
+	}
+
+	result, err := internal.GetSecret(commons.DefaultContext, commons.HTTPClient, options)
+	if err != nil {
+		commons.Log.Debug(err)
+		if strings.Compare(err.Error(), string(clients.ErrorTypeRecordNotFound)) == 0 {
+			commons.Log.Error("You haven't set any secrets in this environment")
+			commons.Log.Info("Use `envs set --help` for more information")
+			os.Exit(1)
+		} else if reason, restricted := strings.CutPrefix(err.Error(), string(clients.ErrorTypeTokenRestricted)+": "); restricted {
+			commons.Log.Error("Your token can't be used from here: ", reason)
+			commons.Log.Fatal("Ask an admin of your organisation to check the restrictions of the token and its environment")
+		} else {
+			commons.Log.Fatal("Failed to fetch the secrets")
+		}
+	}
+
+	//	Mark all the secrets encoded by default.
+	result.Secret.MarkEncoded()
+
+	//	Decode the key.
+	keyBytes, err := base64.StdEncoding.DecodeString(result.Token.Key)
+	if err != nil {
+		commons.Log.Debug(err)
+		commons.Log.Fatal("Failed to decode the key")
+	}
+
+	//	Decode the token.
+	token, err := hex.DecodeString(XTokenHeader)
+	if err != nil {
+		commons.Log.Debug(err)
+		commons.Log.Fatal("Failed to decode the token")
+	}
+
+	//	Decrypt the token.
+	orgKeyBytes, err := tokens.GetService().Decrypt(commons.DefaultContext, commons.GQLClient.GQLClient, token, keyBytes)
+	if err != nil {
+		commons.Log.Debug(err)
+		commons.Log.Fatal("Failed to decrypt the token")
+	}
+
+	//	Convert the key to [32]byte.
+	var orgKey [32]byte
+	copy(orgKey[:], orgKeyBytes)
+
+	//	Decrypt the secrets.
+	if err := result.Secret.Decrypt(orgKey); err != nil {
+		commons.Log.Debug(err)
+		commons.Log.Fatal("Failed to decrypt the secret")
+	}
+
+	//	Temporary copy-over.
+	for k, v := range result.Secret.Data {
+		commons.Secret.Set(k, &dto.Payload{
+			Value: v.Value,
+		})
+	}
+
+	commons.Secret.Decode()
+}
+
 func init() {
 	rootCmd.AddCommand(exportCmd)
 
@@ -0,0 +1,311 @@
+/*
+Copyright © 2023 Mrinal Wahal <mrinalwahal@gmail.com>
+All rights reserved.
+
+Redistribution and use in source and binary forms, with or without
+modification, are permitted provided that the following conditions are met:
+
+ 1. Redistributions of source code must retain the above copyright notice,
+    this list of conditions and the following disclaimer.
+
+ 2. Redistributions in binary form must reproduce the above copyright notice,
+    this list of conditions and the following disclaimer in the documentation
+    and/or other materials provided with the distribution.
+
+ 3. Neither the name of the copyright holder nor the names of its contributors
+    may be used to endorse or promote products derived from this software
+    without specific prior written permission.
+
+THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+POSSIBILITY OF SUCH DAMAGE.
+*/
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/envsecrets/envsecrets/cli/clients"
+	"github.com/envsecrets/envsecrets/cli/commons"
+	"github.com/envsecrets/envsecrets/cli/internal/secrets"
+	"github.com/envsecrets/envsecrets/dto"
+	internalClients "github.com/envsecrets/envsecrets/internal/clients"
+	"github.com/envsecrets/envsecrets/internal/integrations"
+	"github.com/envsecrets/envsecrets/internal/secrets/pkg/keypayload"
+	"github.com/envsecrets/envsecrets/utils"
+	"github.com/manifoldco/promptui"
+	"github.com/spf13/cobra"
+)
+
+var importFrom string
+var importIntegrationID string
+var importEntity string
+var importConfirmed bool
+
+// importCmd represents the import command
+var importCmd = &cobra.Command{
+	Use:   "import --from [platform] --integration [integration-id] --entity [entity] --env [your-remote-environment-name]",
+	Short: "Pull existing secrets from a connected platform into your environment",
+	Long: `This command reads the secrets already stored on a connected third-party platform,
+shows you how they differ from the latest version of your environment,
+and saves them as a new version, encrypted on client side.
+Imports into protected environments are proposed as change requests instead.
+
+Supported platforms: heroku, asm, gsm, netlify, railway, gitlab, github.
+
+The entity identifies where to read from:
+
+	heroku	=> app name or ID
+	asm	=> secret name or ARN
+	gsm	=> secret name
+	netlify	=> site ID
+	railway	=> project-id/environment-id[/service-id]
+	gitlab	=> project ID or path; prefix with "group:" for groups
+	github	=> owner/repository
+
+Github never exposes the values of its action secrets,
+so only their key names are listed and you will have to set their values yourself.`,
+	PreRun: func(cmd *cobra.Command, args []string) {
+
+		//	Initialize the common secret.
+		InitializeSecret(commons.Log)
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+
+		integrationType := integrations.Type(importFrom)
+		if !integrationType.IsValid() {
+			commons.Log.Fatal("Invalid platform: ", importFrom)
+		}
+
+		entityDetails, err := importEntityDetails(integrationType, importEntity)
+		if err != nil {
+			commons.Log.Fatal(err)
+		}
+
+		envID := commons.Secret.EnvID
+
+		body, err := json.Marshal(map[string]interface{}{
+			"integration_id":   importIntegrationID,
+			"integration_type": integrationType,
+			"entity_details":   entityDetails,
+		})
+		if err != nil {
+			commons.Log.Debug(err)
+			commons.Log.Fatal("failed to marshal your HTTP request body")
+		}
+
+		req, err := http.NewRequestWithContext(commons.DefaultContext, http.MethodPost, clients.API+"/v1/environments/"+envID+"/import", bytes.NewBuffer(body))
+		if err != nil {
+			commons.Log.Debug(err)
+			commons.Log.Fatal("failed to create your HTTP request")
+		}
+
+		var response clients.APIResponse
+		if err := commons.HTTPClient.Run(commons.DefaultContext, req, &response); err != nil {
+			commons.Log.Fatal(err)
+		}
+
+		if response.Error != "" {
+			commons.Log.Fatal(response.Error)
+		}
+
+		//	The server encrypts the imported values with our sync key.
+		imported := keypayload.KPMap{}
+		if err := utils.MapToStruct(response.Data, &imported); err != nil {
+			commons.Log.Debug(err)
+			commons.Log.Fatal("Failed to parse the imported secrets")
+		}
+		imported.MarkAllEncoded()
+
+		var syncKey [32]byte
+		copy(syncKey[:], commons.KeysConfig.Sync)
+		if err := imported.Decrypt(syncKey); err != nil {
+			commons.Log.Debug(err)
+			commons.Log.Fatal("Failed to decrypt the imported secrets")
+		}
+
+		if err := imported.Decode(); err != nil {
+			commons.Log.Debug(err)
+			commons.Log.Fatal("Failed to decode the imported secrets")
+		}
+
+		//	Fetch the latest version of the environment to compare against.
+		existing := map[string]*dto.Payload{}
+		result, err := secrets.GetService().Get(commons.DefaultContext, commons.GQLClient.GQLClient, &secrets.GetOptions{
+			EnvID: envID,
+		})
+		if err != nil {
+			if strings.Compare(err.Error(), string(internalClients.ErrorTypeRecordNotFound)) != 0 {
+				commons.Log.Debug(err)
+				commons.Log.Fatal("Failed to fetch the secrets")
+			}
+		} else {
+			commons.Secret = result
+
+			//	Decrypt and decode the common secret.
+			DecryptAndDecode()
+			existing = commons.Secret.Data.GetMapping()
+		}
+
+		//	Prepare the diff.
+		var names []string
+		for name := range imported {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		var added, changed, unchanged, unreadable []string
+		changes := dto.KPMap{}
+		for _, name := range names {
+			value := imported.GetValue(name)
+
+			if value == "" {
+				unreadable = append(unreadable, name)
+				continue
+			}
+
+			current, ok := existing[name]
+			switch {
+			case !ok:
+				added = append(added, name)
+			case current.GetValue() != value:
+				changed = append(changed, name)
+			default:
+				unchanged = append(unchanged, name)
+				continue
+			}
+
+			changes.Set(name, &dto.Payload{
+				Value: value,
+			})
+		}
+
+		for _, name := range added {
+			fmt.Println("+ " + name)
+		}
+
+		for _, name := range changed {
+			fmt.Println("~ " + name)
+		}
+
+		commons.Log.Infof("%d new, %d changed, %d unchanged", len(added), len(changed), len(unchanged))
+
+		if len(unreadable) > 0 {
+			commons.Log.Warnf("Values of these keys are not readable from %s, set them yourself: %s", integrationType, strings.Join(unreadable, ", "))
+		}
+
+		if changes.IsEmpty() {
+			commons.Log.Info("Nothing to import")
+			return
+		}
+
+		if !importConfirmed {
+			prompt := promptui.Prompt{
+				Label:     fmt.Sprintf("Save these secrets as a new version of `%s`", environmentName),
+				IsConfirm: true,
+			}
+
+			if _, err := prompt.Run(); err != nil {
+				os.Exit(1)
+			}
+		}
+
+		commons.Secret = &dto.Secret{
+			EnvID: envID,
+			Data:  &changes,
+		}
+
+		//	Encrypt the values.
+		Encrypt()
+
+		//	Protected environments only accept changes through change requests.
+		if isProtected(envID) {
+			requestChange(envID, encryptedPayloads(), nil)
+			return
+		}
+
+		if err := secrets.GetService().Set(commons.DefaultContext, commons.GQLClient.GQLClient, commons.Secret); err != nil {
+			commons.Log.Debug(err)
+			commons.Log.Fatal("Failed to set the secrets")
+		}
+
+		if commons.Secret.Version != nil {
+			commons.Log.Infof("Secrets imported! Latest version in remote `%s` is now %d ", environmentName, *commons.Secret.Version)
+		}
+	},
+}
+
+// Translates the entity passed on the command line to the entity details the integration expects.
+func importEntityDetails(integrationType integrations.Type, entity string) (map[string]interface{}, error) {
+
+	switch integrationType {
+	case integrations.Github:
+		return map[string]interface{}{
+			"full_name": entity,
+		}, nil
+	case integrations.Gitlab:
+		if strings.HasPrefix(entity, "group:") {
+			return map[string]interface{}{
+				"id":   strings.TrimPrefix(entity, "group:"),
+				"type": "group",
+			}, nil
+		}
+		return map[string]interface{}{
+			"id":   entity,
+			"type": "project",
+		}, nil
+	case integrations.Heroku, integrations.Netlify:
+		return map[string]interface{}{
+			"id": entity,
+		}, nil
+	case integrations.ASM, integrations.GSM:
+		return map[string]interface{}{
+			"name": entity,
+		}, nil
+	case integrations.Railway:
+		parts := strings.Split(entity, "/")
+		if len(parts) < 2 || len(parts) > 3 {
+			return nil, fmt.Errorf("railway entity must be in the format: project-id/environment-id[/service-id]")
+		}
+		details := map[string]interface{}{
+			"project":     map[string]interface{}{"id": parts[0]},
+			"environment": map[string]interface{}{"id": parts[1]},
+		}
+		if len(parts) == 3 {
+			details["service"] = map[string]interface{}{"id": parts[2]}
+		}
+		return details, nil
+	default:
+		return nil, fmt.Errorf("importing from %s is not supported", integrationType)
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(importCmd)
+
+	importCmd.Flags().StringVar(&importFrom, "from", "", "Platform to import the secrets from")
+	importCmd.Flags().StringVarP(&importIntegrationID, "integration", "i", "", "ID of the connected integration")
+	importCmd.Flags().StringVar(&importEntity, "entity", "", "App, project or secret on the platform to read from")
+	importCmd.Flags().BoolVarP(&importConfirmed, "yes", "y", false, "Skip the confirmation prompt")
+	importCmd.Flags().StringVarP(&environmentName, "env", "e", "", "Remote environment to import the secrets into.")
+	importCmd.Flags().StringVarP(&changeMessage, "message", "m", "", "Describe the change request, if the remote environment is protected")
+	importCmd.Flags().BoolVar(&syncOnApproval, "sync", false, "Sync the secrets with the environment's integrations once the change request is approved")
+	importCmd.MarkFlagRequired("from")
+	importCmd.MarkFlagRequired("integration")
+	importCmd.MarkFlagRequired("entity")
+	importCmd.MarkFlagRequired("env")
+}
@@ -31,10 +31,8 @@ POSSIBILITY OF SUCH DAMAGE.
 package cmd
 
 import (
-	"errors"
 	"fmt"
 	"os"
-	"regexp"
 	"time"
 
 	"github.com/envsecrets/envsecrets/cli/auth"
@@ -87,16 +85,6 @@ var initCmd = &cobra.Command{
 		var organisation organisations.Organisation
 		var project projects.Project
 
-		//	All names entered by the user must be slugs.
-		var re = regexp.MustCompile(`^[a-z0-9]+(?:-[a-z0-9]+)*$`)
-		validate := func(input string) error {
-			if len(re.FindAllString(input, -1)) == 0 {
-				return errors.New("should be a slug; example: my-new-idea")
-			}
-
-			return nil
-		}
-
 		//	Setup organisation first
 		if len(organisationID) == 0 {
 
@@ -150,7 +138,7 @@ var initCmd = &cobra.Command{
 				Label:    "Choose Your Project",
 				Items:    projectsStringList,
 				AddLabel: "Create New Project",
-				Validate: validate,
+				Validate: validateSlug,
 			}
 
 			index, result, err := selection.Run()
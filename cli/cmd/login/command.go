@@ -53,6 +53,7 @@ const (
 )
 
 var email, password string
+var useSSO, useServiceAccount bool
 
 // Cmd represents the login command
 var Cmd = &cobra.Command{
@@ -61,6 +62,11 @@ var Cmd = &cobra.Command{
 	Args:  cobra.NoArgs,
 	Run: func(cmd *cobra.Command, args []string) {
 
+		if useServiceAccount {
+			signinAsServiceAccount()
+			return
+		}
+
 		if len(email) == 0 {
 			i := getEmailInput()
 			m := model{input: &i, heading: "Your envsecrets account email"}
@@ -71,6 +77,11 @@ var Cmd = &cobra.Command{
 			email = i.Value()
 		}
 
+		if useSSO {
+			signinWithSSO()
+			return
+		}
+
 		if len(password) == 0 {
 			i := getPasswordInput()
 			m := model{input: &i, heading: "Your envsecrets account password"}
@@ -118,100 +129,112 @@ var Cmd = &cobra.Command{
 			}
 		}
 
-		var session struct {
-			AccessToken          string     `json:"accessToken"`
-			AccessTokenExpiresIn int        `json:"accessTokenExpiresIn"`
-			RefreshToken         string     `json:"refreshToken"`
-			User                 users.User `json:"user"`
-		}
-
-		if err := utils.MapToStruct(response.Session, &session); err != nil {
-			cobra.CheckErr(err)
-		}
-
-		//	Save the account config
-		if err := config.GetService().Save(configCommons.Account{
-			AccessToken:  session.AccessToken,
-			RefreshToken: session.RefreshToken,
-			User:         session.User,
-		}, configCommons.AccountConfig); err != nil {
-			commons.Log.Debug(err)
-			commons.Log.Fatal("Failed to save account configuration locally")
-		}
-
-		//	Reload the clients.
-		commons.Initialize(commons.Log)
-
-		//	Initialize a new GQL client with the user's access token.
-		gqlClient := clients.NewGQLClient(&clients.GQLConfig{
-			BaseURL:       clients.NHOST_GRAPHQL_URL,
-			Authorization: fmt.Sprintf("Bearer %s", session.AccessToken),
-			Logger:        commons.Log,
-		})
-
-		//	Extract and decrypt keys from user's session.
-		pair, err := auth.GetService().DecryptKeysFromSession(commons.DefaultContext, gqlClient.GQLClient, &auth.DecryptKeysFromSessionOptions{
-			Session:  response.Session,
-			Password: password,
-		})
-		if err != nil {
-			commons.Log.Debug(err)
-			commons.Log.Fatal("Failed to decrypt your keys")
-		}
-
-		//	We have to exclusively fetch the sync key.
-		req, err := http.NewRequestWithContext(commons.DefaultContext, http.MethodGet, clients.API+"/v1/auth/sync-key", nil)
-		if err != nil {
-			commons.Log.Debug(err)
-			commons.Log.Fatal("failed to create your HTTP request")
-		}
-
-		var keyResponse clients.APIResponse
-		err = commons.HTTPClient.Run(commons.DefaultContext, req, &keyResponse)
-		if err != nil {
-			commons.Log.Fatal(err)
-		}
-
-		if keyResponse.Error != "" {
-			commons.Log.Fatal(keyResponse.Error)
-		}
-
-		//	Unmarshal the response.
-		var keyPayload keyCommons.Key
-		if err := utils.MapToStruct(keyResponse.Data, &keyPayload); err != nil {
-			commons.Log.Fatal(err)
-		}
-
-		var publicKey, privateKey [32]byte
-		copy(publicKey[:], pair.PublicKey)
-		copy(privateKey[:], pair.PrivateKey)
-
-		//	Decrypt the sync key using user's private key.
-		decodedSyncKey, err := base64.StdEncoding.DecodeString(keyPayload.SyncKey)
-		if err != nil {
-			commons.Log.Fatal(err)
-		}
-
-		syncKey, err := keys.OpenAsymmetricallyAnonymous(decodedSyncKey, publicKey, privateKey)
-		if err != nil {
-			commons.Log.Fatal(err)
-		}
-
-		//	Save the public-private keys locally.
-		if err := config.GetService().Save(configCommons.Keys{
-			Public:  pair.PublicKey,
-			Private: pair.PrivateKey,
-			Sync:    syncKey,
-		}, configCommons.KeysConfig); err != nil {
-			commons.Log.Debug(err)
-			commons.Log.Fatal("Failed to save key configuration locally")
-		}
+		saveSession(response.Session)
+		unlockKeys(response.Session, password)
 	},
 	PostRun: func(cmd *cobra.Command, args []string) {
 		commons.Log.Info("You are logged in!")
 	},
 }
 
+// Saves the user's session as their account config, and reloads the clients with it.
+func saveSession(response map[string]interface{}) {
+
+	var session struct {
+		AccessToken          string     `json:"accessToken"`
+		AccessTokenExpiresIn int        `json:"accessTokenExpiresIn"`
+		RefreshToken         string     `json:"refreshToken"`
+		User                 users.User `json:"user"`
+	}
+
+	if err := utils.MapToStruct(response, &session); err != nil {
+		cobra.CheckErr(err)
+	}
+
+	//	Save the account config
+	if err := config.GetService().Save(configCommons.Account{
+		AccessToken:  session.AccessToken,
+		RefreshToken: session.RefreshToken,
+		User:         session.User,
+	}, configCommons.AccountConfig); err != nil {
+		commons.Log.Debug(err)
+		commons.Log.Fatal("Failed to save account configuration locally")
+	}
+
+	//	Reload the clients.
+	commons.Initialize(commons.Log)
+}
+
+// Decrypts the user's keys with the secret protecting them,
+// i.e. their password or unlock passphrase, and saves them locally along with the sync key.
+func unlockKeys(session map[string]interface{}, secret string) {
+
+	//	Initialize a new GQL client with the user's access token.
+	gqlClient := clients.NewGQLClient(&clients.GQLConfig{
+		BaseURL:       clients.NHOST_GRAPHQL_URL,
+		Authorization: fmt.Sprintf("Bearer %s", commons.AccountConfig.AccessToken),
+		Logger:        commons.Log,
+	})
+
+	//	Extract and decrypt keys from user's session.
+	pair, err := auth.GetService().DecryptKeysFromSession(commons.DefaultContext, gqlClient.GQLClient, &auth.DecryptKeysFromSessionOptions{
+		Session:  session,
+		Password: secret,
+	})
+	if err != nil {
+		commons.Log.Debug(err)
+		commons.Log.Fatal("Failed to decrypt your keys")
+	}
+
+	//	We have to exclusively fetch the sync key.
+	req, err := http.NewRequestWithContext(commons.DefaultContext, http.MethodGet, clients.API+"/v1/auth/sync-key", nil)
+	if err != nil {
+		commons.Log.Debug(err)
+		commons.Log.Fatal("failed to create your HTTP request")
+	}
+
+	var keyResponse clients.APIResponse
+	err = commons.HTTPClient.Run(commons.DefaultContext, req, &keyResponse)
+	if err != nil {
+		commons.Log.Fatal(err)
+	}
+
+	if keyResponse.Error != "" {
+		commons.Log.Fatal(keyResponse.Error)
+	}
+
+	//	Unmarshal the response.
+	var keyPayload keyCommons.Key
+	if err := utils.MapToStruct(keyResponse.Data, &keyPayload); err != nil {
+		commons.Log.Fatal(err)
+	}
+
+	var publicKey, privateKey [32]byte
+	copy(publicKey[:], pair.PublicKey)
+	copy(privateKey[:], pair.PrivateKey)
+
+	//	Decrypt the sync key using user's private key.
+	decodedSyncKey, err := base64.StdEncoding.DecodeString(keyPayload.SyncKey)
+	if err != nil {
+		commons.Log.Fatal(err)
+	}
+
+	syncKey, err := keys.OpenAsymmetricallyAnonymous(decodedSyncKey, publicKey, privateKey)
+	if err != nil {
+		commons.Log.Fatal(err)
+	}
+
+	//	Save the public-private keys locally.
+	if err := config.GetService().Save(configCommons.Keys{
+		Public:  pair.PublicKey,
+		Private: pair.PrivateKey,
+		Sync:    syncKey,
+	}, configCommons.KeysConfig); err != nil {
+		commons.Log.Debug(err)
+		commons.Log.Fatal("Failed to save key configuration locally")
+	}
+}
+
 func init() {
 	// Here you will define your flags and configuration settings.
 
@@ -223,4 +246,6 @@ func init() {
 	// is called directly, e.g.:
 	Cmd.Flags().StringVarP(&email, "email", "e", "", "Your envsecrets account email")
 	Cmd.Flags().StringVarP(&password, "password", "p", "", "Your envsecrets account password")
+	Cmd.Flags().BoolVar(&useSSO, "sso", false, "Login through your organisation's identity provider")
+	Cmd.Flags().BoolVar(&useServiceAccount, "service-account", false, "Login as a service account, with the credential from $"+SERVICE_ACCOUNT_ENV)
 }
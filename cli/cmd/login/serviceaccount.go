@@ -0,0 +1,86 @@
+/*
+Copyright © 2023 Mrinal Wahal <mrinalwahal@gmail.com>
+All rights reserved.
+
+Redistribution and use in source and binary forms, with or without
+modification, are permitted provided that the following conditions are met:
+
+ 1. Redistributions of source code must retain the above copyright notice,
+    this list of conditions and the following disclaimer.
+
+ 2. Redistributions in binary form must reproduce the above copyright notice,
+    this list of conditions and the following disclaimer in the documentation
+    and/or other materials provided with the distribution.
+
+ 3. Neither the name of the copyright holder nor the names of its contributors
+    may be used to endorse or promote products derived from this software
+    without specific prior written permission.
+
+THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+POSSIBILITY OF SUCH DAMAGE.
+*/
+package login
+
+import (
+	"os"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/envsecrets/envsecrets/cli/clients"
+	"github.com/envsecrets/envsecrets/cli/commons"
+	"github.com/envsecrets/envsecrets/cli/internal"
+	"github.com/envsecrets/envsecrets/internal/serviceaccounts"
+	"github.com/spf13/cobra"
+)
+
+const (
+
+	//	Environment variable to read the credential of a service account from,
+	//	so that it doesn't end up in the shell history of CI runners.
+	SERVICE_ACCOUNT_ENV = "ENVS_SERVICE_ACCOUNT"
+)
+
+// Signs in as a service account, and unlocks its keys with the passphrase carried by its credential.
+func signinAsServiceAccount() {
+
+	value := os.Getenv(SERVICE_ACCOUNT_ENV)
+	if value == "" {
+		i := getPasswordInput()
+		i.Placeholder = "Credential"
+		i.CharLimit = 256
+		m := model{input: &i, heading: "Your service account credential"}
+		if _, err := tea.NewProgram(m).Run(); err != nil {
+			cobra.CheckErr(err)
+		}
+
+		value = i.Value()
+	}
+
+	credential, err := serviceaccounts.ParseCredential(value)
+	if err != nil {
+		commons.Log.Fatal(err)
+	}
+
+	//	Don't attach the token of a previous session.
+	client := clients.NewHTTPClient(&clients.HTTPConfig{
+		BaseURL: clients.API + "/v1",
+		Logger:  commons.Log,
+	})
+
+	session, err := internal.SigninServiceAccount(commons.DefaultContext, client, credential.PAT)
+	if err != nil {
+		commons.Log.Debug(err)
+		commons.Log.Fatal("Login failed. The credential is invalid, expired or has been rotated.")
+	}
+
+	saveSession(session)
+	unlockKeys(session, credential.Passphrase)
+}
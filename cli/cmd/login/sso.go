@@ -0,0 +1,242 @@
+/*
+Copyright © 2023 Mrinal Wahal <mrinalwahal@gmail.com>
+All rights reserved.
+
+Redistribution and use in source and binary forms, with or without
+modification, are permitted provided that the following conditions are met:
+
+ 1. Redistributions of source code must retain the above copyright notice,
+    this list of conditions and the following disclaimer.
+
+ 2. Redistributions in binary form must reproduce the above copyright notice,
+    this list of conditions and the following disclaimer in the documentation
+    and/or other materials provided with the distribution.
+
+ 3. Neither the name of the copyright holder nor the names of its contributors
+    may be used to endorse or promote products derived from this software
+    without specific prior written permission.
+
+THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+POSSIBILITY OF SUCH DAMAGE.
+*/
+package login
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/envsecrets/envsecrets/cli/clients"
+	"github.com/envsecrets/envsecrets/cli/commons"
+	"github.com/envsecrets/envsecrets/cli/internal"
+	"github.com/envsecrets/envsecrets/internal/auth"
+	"github.com/envsecrets/envsecrets/internal/oidc"
+	"github.com/envsecrets/envsecrets/internal/sso"
+	"github.com/spf13/cobra"
+)
+
+const (
+	CALLBACK_PATH = "/callback"
+
+	//	How long to wait for the user to complete the sign in with their identity provider.
+	CALLBACK_TIMEOUT = 5 * time.Minute
+)
+
+// --- Flow ---
+//
+//  1. Find the identity provider of the user's organisation from their email.
+//  2. Run the authorization code flow with PKCE, receiving the code on a loopback server.
+//  3. Exchange the code for a session with the API, and save it.
+//     If the account existed before the identity, confirm its password and sign in again to link them.
+//  4. On the first sign in, ask the user to choose the passphrase their keys will be unlocked with.
+//  5. Unlock the user's keys with their passphrase.
+func signinWithSSO() {
+
+	//	The user isn't logged in yet, so don't attach any stale token.
+	client := clients.NewHTTPClient(&clients.HTTPConfig{
+		BaseURL: clients.API + "/v1",
+		Logger:  commons.Log,
+	})
+
+	connection, err := internal.GetSSOConnection(commons.DefaultContext, client, email)
+	if err != nil {
+		commons.Log.Debug(err)
+		commons.Log.Fatal("No identity provider is configured for your email. Login with your password instead.")
+	}
+
+	provider, err := oidc.Discover(commons.DefaultContext, connection.Issuer)
+	if err != nil {
+		commons.Log.Debug(err)
+		commons.Log.Fatal("Failed to reach your identity provider")
+	}
+
+	response, err := authorize(client, connection, provider, "")
+
+	//	The organisation's identity provider has claimed an account which already existed.
+	//	Prove that it's the user's, and repeat the sign in to link it.
+	if err != nil && err.Error() == string(clients.ErrorTypeAccountLinkRequired) {
+		commons.Log.Warn("Your envsecrets account already exists. Confirm it's yours to link it to your identity provider.")
+		response, err = authorize(client, connection, provider, getPassphrase("Your envsecrets password, or unlock passphrase"))
+	}
+	if err != nil {
+		commons.Log.Debug(err)
+		commons.Log.Fatal("Login failed. ", err)
+	}
+
+	saveSession(response.Session)
+
+	var passphrase string
+	if !response.HasKeys {
+
+		commons.Log.Info("Choose the passphrase your keys will be unlocked with. It never leaves this device, and can't be recovered if lost.")
+
+		passphrase = getPassphrase("Your new unlock passphrase")
+		if len(passphrase) < auth.MIN_PASSPHRASE_LENGTH {
+			commons.Log.Fatalf("The passphrase must be at least %d characters long", auth.MIN_PASSPHRASE_LENGTH)
+		}
+
+		if getPassphrase("Confirm your unlock passphrase") != passphrase {
+			commons.Log.Fatal("The passphrases don't match")
+		}
+
+		if err := internal.SetPassphrase(commons.DefaultContext, commons.HTTPClient, passphrase); err != nil {
+			commons.Log.Debug(err)
+			commons.Log.Fatal("Failed to set your unlock passphrase")
+		}
+
+	} else {
+		passphrase = getPassphrase("Your unlock passphrase")
+	}
+
+	unlockKeys(response.Session, passphrase)
+}
+
+// Runs the authorization code flow with PKCE, receiving the code on a loopback server,
+// and exchanges the code for a session. The password links the identity to an existing account.
+func authorize(client *clients.HTTPClient, connection *sso.Connection, provider *oidc.Provider, password string) (*auth.SigninWithSSOResponse, error) {
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("failed to start the local server to receive your sign in: %w", err)
+	}
+
+	redirectURI := "http://" + listener.Addr().String() + CALLBACK_PATH
+
+	state, err := oidc.GenerateRandomValue()
+	if err != nil {
+		return nil, err
+	}
+
+	nonce, err := oidc.GenerateRandomValue()
+	if err != nil {
+		return nil, err
+	}
+
+	verifier, err := oidc.GenerateRandomValue()
+	if err != nil {
+		return nil, err
+	}
+
+	commons.Log.Info("Complete your login in the browser: ", provider.AuthCodeURL(&oidc.AuthCodeURLOptions{
+		ClientID:      connection.ClientID,
+		RedirectURI:   redirectURI,
+		State:         state,
+		Nonce:         nonce,
+		CodeChallenge: oidc.CodeChallenge(verifier),
+	}))
+
+	code, err := waitForCode(listener, state)
+	if err != nil {
+		return nil, err
+	}
+
+	return internal.SigninWithSSO(commons.DefaultContext, client, &internal.SSOSigninOptions{
+		OrgID:        connection.OrgID,
+		Code:         code,
+		CodeVerifier: verifier,
+		RedirectURI:  redirectURI,
+		Nonce:        nonce,
+		Password:     password,
+	})
+}
+
+// Serves the redirect from the identity provider on the loopback listener,
+// and returns the authorization code once it's received.
+func waitForCode(listener net.Listener, state string) (string, error) {
+
+	type result struct {
+		code string
+		err  error
+	}
+
+	results := make(chan result, 1)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(CALLBACK_PATH, func(w http.ResponseWriter, r *http.Request) {
+
+		query := r.URL.Query()
+
+		var item result
+		switch {
+		case query.Get("state") != state:
+			item.err = errors.New("the state of the response doesn't match the request")
+		case query.Get("error") != "":
+			item.err = fmt.Errorf("%s: %s", query.Get("error"), query.Get("error_description"))
+		case query.Get("code") == "":
+			item.err = errors.New("no authorization code was received")
+		default:
+			item.code = query.Get("code")
+		}
+
+		if item.err != nil {
+			http.Error(w, "Login failed. You can close this window and return to your terminal.", http.StatusBadRequest)
+		} else {
+			fmt.Fprintln(w, "You are logged in! You can close this window and return to your terminal.")
+		}
+
+		select {
+		case results <- item:
+		default:
+		}
+	})
+
+	server := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go server.Serve(listener)
+	defer server.Shutdown(context.Background())
+
+	select {
+	case item := <-results:
+		return item.code, item.err
+	case <-time.After(CALLBACK_TIMEOUT):
+		return "", errors.New("timed out waiting for your identity provider")
+	}
+}
+
+func getPassphrase(heading string) string {
+
+	i := getPasswordInput()
+	i.Placeholder = "Passphrase"
+	m := model{input: &i, heading: heading}
+	if _, err := tea.NewProgram(m).Run(); err != nil {
+		cobra.CheckErr(err)
+	}
+
+	return i.Value()
+}
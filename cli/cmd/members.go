@@ -0,0 +1,443 @@
+/*
+Copyright © 2023 Mrinal Wahal <mrinalwahal@gmail.com>
+All rights reserved.
+
+Redistribution and use in source and binary forms, with or without
+modification, are permitted provided that the following conditions are met:
+
+ 1. Redistributions of source code must retain the above copyright notice,
+    this list of conditions and the following disclaimer.
+
+ 2. Redistributions in binary form must reproduce the above copyright notice,
+    this list of conditions and the following disclaimer in the documentation
+    and/or other materials provided with the distribution.
+
+ 3. Neither the name of the copyright holder nor the names of its contributors
+    may be used to endorse or promote products derived from this software
+    without specific prior written permission.
+
+THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+POSSIBILITY OF SUCH DAMAGE.
+*/
+package cmd
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/envsecrets/envsecrets/cli/clients"
+	"github.com/envsecrets/envsecrets/cli/commons"
+	"github.com/envsecrets/envsecrets/cli/internal"
+	"github.com/envsecrets/envsecrets/internal/keys"
+	"github.com/envsecrets/envsecrets/internal/memberships"
+	"github.com/envsecrets/envsecrets/internal/roles"
+	"github.com/manifoldco/promptui"
+	"github.com/spf13/cobra"
+)
+
+var rotateKey, yes bool
+var roleName string
+
+// membersCmd represents the members command
+var membersCmd = &cobra.Command{
+	Use:               "members",
+	Short:             "Manage the members of your organisation",
+	PersistentPreRunE: authenticate,
+}
+
+// membersListCmd represents the members list command
+var membersListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List the members of your organisation",
+	Args:    cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		members, err := memberships.List(commons.DefaultContext, commons.GQLClient.GQLClient, getOrganisationID())
+		if err != nil {
+			commons.Log.Debug(err)
+			commons.Log.Fatal("Failed to fetch the members")
+		}
+
+		type item struct {
+			ID    string `json:"id"`
+			Email string `json:"email"`
+			Name  string `json:"name,omitempty"`
+			Role  string `json:"role"`
+		}
+
+		var result []item
+		var rows [][]string
+		for _, member := range members {
+			var entry item
+			entry.ID = member.UserID
+			if member.User != nil {
+				entry.Email = member.User.Email
+				entry.Name = member.User.DisplayName
+			}
+			if member.Role != nil {
+				entry.Role = member.Role.Name
+			}
+
+			result = append(result, entry)
+			rows = append(rows, []string{entry.Email, entry.Name, entry.Role})
+		}
+
+		printOutput(result, []string{"EMAIL", "NAME", "ROLE"}, rows)
+	},
+}
+
+// membersRolesCmd represents the members roles command
+var membersRolesCmd = &cobra.Command{
+	Use:   "roles",
+	Short: "List the roles available in your organisation",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		list, err := roles.GetRolesByOrgID(commons.DefaultContext, commons.GQLClient.GQLClient, getOrganisationID())
+		if err != nil {
+			commons.Log.Debug(err)
+			commons.Log.Fatal("Failed to fetch the roles")
+		}
+
+		var rows [][]string
+		for _, role := range *list {
+			rows = append(rows, []string{role.Name, role.ID})
+		}
+
+		printOutput(list, []string{"NAME", "ID"}, rows)
+	},
+}
+
+// membersInviteCmd represents the members invite command
+var membersInviteCmd = &cobra.Command{
+	Use:   "invite [email]",
+	Short: "Invite someone to your organisation",
+	Long: `This command invites someone to your organisation with the role passed using --role.
+Your password is required to share a copy of the organisation's encryption key with them.
+
+If they do not have an envsecrets account yet, run ` + "`envs members grants`" + `
+to grant them the key once they have signed up.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+
+		orgID := getOrganisationID()
+		role := getRole(orgID, roleName)
+
+		body, err := json.Marshal(map[string]interface{}{
+			"org_id":           orgID,
+			"role_id":          role.ID,
+			"invitee_email":    args[0],
+			"inviter_password": getPassword(),
+		})
+		if err != nil {
+			commons.Log.Debug(err)
+			commons.Log.Fatal("failed to marshal your HTTP request body")
+		}
+
+		req, err := http.NewRequestWithContext(commons.DefaultContext, http.MethodPost, clients.API+"/v1/invites", bytes.NewBuffer(body))
+		if err != nil {
+			commons.Log.Debug(err)
+			commons.Log.Fatal("failed to create your HTTP request")
+		}
+
+		var response clients.APIResponse
+		if err := commons.HTTPClient.Run(commons.DefaultContext, req, &response); err != nil {
+			commons.Log.Fatal(err)
+		}
+
+		if response.Error != "" {
+			commons.Log.Fatal(response.Message, ": ", response.Error)
+		}
+
+		if outputFormat == "json" {
+			printOutput(map[string]string{
+				"email": args[0],
+				"role":  role.Name,
+			}, nil, nil)
+			return
+		}
+
+		commons.Log.Info("Invited ", args[0], " as ", role.Name)
+	},
+}
+
+// membersGrantsCmd represents the members grants command
+var membersGrantsCmd = &cobra.Command{
+	Use:   "grants",
+	Short: "Grant the organisation's key to invitees who have signed up",
+	Long: `This command lists the invitees who have signed up after being invited to your organisation,
+and shares a copy of the organisation's encryption key with the ones you confirm.
+
+Use --yes to grant the key to all of them without confirmation.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		if commons.KeysConfig == nil || commons.AccountConfig == nil {
+			commons.Log.Fatal("Your keys were not found; run `envs login` first")
+		}
+
+		orgID := getOrganisationID()
+
+		grants, err := internal.ListPendingGrants(commons.DefaultContext, commons.HTTPClient, orgID)
+		if err != nil {
+			commons.Log.Debug(err)
+			commons.Log.Fatal("Failed to fetch the invitees waiting for access")
+		}
+
+		if len(grants) == 0 {
+			if outputFormat == "json" {
+				printOutput([]string{}, nil, nil)
+				return
+			}
+			commons.Log.Info("Nobody is waiting for access to your organisation")
+			return
+		}
+
+		//	Decrypt the organisation key.
+		key, err := memberships.GetKey(commons.DefaultContext, commons.GQLClient.GQLClient, &memberships.GetKeyOptions{
+			OrgID:  orgID,
+			UserID: commons.AccountConfig.User.ID,
+		})
+		if err != nil {
+			commons.Log.Debug(err)
+			commons.Log.Fatal("Failed to fetch your copy of the organisation's key")
+		}
+
+		orgKey, err := keys.DecryptAsymmetricallyAnonymous(commons.KeysConfig.Public, commons.KeysConfig.Private, key)
+		if err != nil {
+			commons.Log.Debug(err)
+			commons.Log.Fatal("Failed to decrypt the organisation's encryption key")
+		}
+
+		granted := []string{}
+		for _, grant := range grants {
+
+			if !yes {
+				prompt := promptui.Prompt{
+					Label:     fmt.Sprintf("%s has joined envsecrets and is waiting for access to your organisation. Grant it", grant.Email),
+					IsConfirm: true,
+				}
+
+				if _, err := prompt.Run(); err != nil {
+					continue
+				}
+			}
+
+			publicKey, err := base64.StdEncoding.DecodeString(grant.PublicKey)
+			if err != nil {
+				commons.Log.Debug(err)
+				commons.Log.Error("Failed to decode the public key of ", grant.Email)
+				continue
+			}
+
+			//	Encrypt the organisation key with the invitee's public key.
+			var inviteeKey [32]byte
+			copy(inviteeKey[:], publicKey)
+			sealed, err := keys.SealAsymmetricallyAnonymous(orgKey, inviteeKey)
+			if err != nil {
+				commons.Log.Debug(err)
+				commons.Log.Error("Failed to seal the organisation's key for ", grant.Email)
+				continue
+			}
+
+			if err := internal.Grant(commons.DefaultContext, commons.HTTPClient, grant.ID, base64.StdEncoding.EncodeToString(sealed)); err != nil {
+				commons.Log.Debug(err)
+				commons.Log.Error("Failed to grant access to ", grant.Email)
+				continue
+			}
+
+			granted = append(granted, grant.Email)
+
+			if outputFormat != "json" {
+				commons.Log.Info("Granted access to ", grant.Email)
+			}
+		}
+
+		if outputFormat == "json" {
+			printOutput(granted, nil, nil)
+		}
+	},
+}
+
+// membersRoleCmd represents the members role command
+var membersRoleCmd = &cobra.Command{
+	Use:   "role [email] [role]",
+	Short: "Change the role of a member in your organisation",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+
+		orgID := getOrganisationID()
+		member := getMember(orgID, args[0])
+		role := getRole(orgID, args[1])
+
+		if err := memberships.UpdateRole(commons.DefaultContext, commons.GQLClient.GQLClient, &memberships.UpdateRoleOptions{
+			OrgID:  orgID,
+			UserID: member.UserID,
+			RoleID: role.ID,
+		}); err != nil {
+			commons.Log.Debug(err)
+			commons.Log.Fatal("Failed to change the role of the member")
+		}
+
+		if outputFormat == "json" {
+			printOutput(map[string]string{
+				"email": args[0],
+				"role":  role.Name,
+			}, nil, nil)
+			return
+		}
+
+		commons.Log.Info("Changed the role of ", args[0], " to ", role.Name)
+	},
+}
+
+// membersRemoveCmd represents the members remove command
+var membersRemoveCmd = &cobra.Command{
+	Use:   "remove [email]",
+	Short: "Remove a member from your organisation",
+	Long: `This command removes the member from your organisation,
+deletes their copy of the organisation's encryption key,
+and revokes the environment tokens they had created.
+
+Since the member could have cached the decrypted key,
+you can rotate the organisation's key right away using --rotate-key.
+If your organisation's policy requires it, the key is always rotated.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+
+		email := args[0]
+
+		orgID := getOrganisationID()
+
+		confirm(fmt.Sprintf("Remove %s from the organisation", email))
+
+		var password string
+		if rotateKey {
+			password = getPassword()
+		}
+
+		response, err := removeMember(orgID, email, password)
+		if err != nil {
+			commons.Log.Fatal(err)
+		}
+
+		//	The organisation's policy requires the key to be rotated.
+		if response.Error == string(clients.ErrorTypeKeyRotationRequired) {
+			commons.Log.Warn("Your organisation requires its key to be rotated when a member is removed")
+			response, err = removeMember(orgID, email, getPassword())
+			if err != nil {
+				commons.Log.Fatal(err)
+			}
+		}
+
+		if response.Error != "" {
+			commons.Log.Fatal(response.Message, ": ", response.Error)
+		}
+
+		//	Pull the new copy of the organisation's key, if it was rotated.
+		if data, ok := response.Data.(map[string]interface{}); ok && data["rotation"] != nil {
+			refreshProjectKey()
+		}
+
+		if outputFormat == "json" {
+			printOutput(response.Data, nil, nil)
+			return
+		}
+
+		commons.Log.Info(response.Message)
+	},
+}
+
+func removeMember(orgID, email, password string) (*clients.APIResponse, error) {
+
+	body, err := json.Marshal(map[string]interface{}{
+		"email":      email,
+		"rotate_key": password != "",
+		"password":   password,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(commons.DefaultContext, http.MethodDelete, clients.API+"/v1/organisations/"+orgID+"/members", bytes.NewBuffer(body))
+	if err != nil {
+		return nil, err
+	}
+
+	var response clients.APIResponse
+	if err := commons.HTTPClient.Run(commons.DefaultContext, req, &response); err != nil {
+		return nil, err
+	}
+
+	return &response, nil
+}
+
+// Fetches a member of the organisation by their email.
+func getMember(orgID, email string) *memberships.Membership {
+
+	members, err := memberships.List(commons.DefaultContext, commons.GQLClient.GQLClient, orgID)
+	if err != nil {
+		commons.Log.Debug(err)
+		commons.Log.Fatal("Failed to fetch the members")
+	}
+
+	for _, member := range members {
+		if member.User != nil && member.User.Email == email {
+			return &member
+		}
+	}
+
+	commons.Log.Fatal("Member not found: ", email)
+	return nil
+}
+
+// Fetches a role of the organisation by its name or ID.
+func getRole(orgID, name string) *roles.Role {
+
+	if name == "" {
+		commons.Log.Fatal("Role is required; use `envs members roles` to list the available ones")
+	}
+
+	list, err := roles.GetRolesByOrgID(commons.DefaultContext, commons.GQLClient.GQLClient, orgID)
+	if err != nil {
+		commons.Log.Debug(err)
+		commons.Log.Fatal("Failed to fetch the roles")
+	}
+
+	for _, role := range *list {
+		if role.Name == name || role.ID == name {
+			return &role
+		}
+	}
+
+	commons.Log.Fatal("Role not found: ", name)
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(membersCmd)
+	membersCmd.AddCommand(membersListCmd, membersRolesCmd, membersInviteCmd, membersGrantsCmd, membersRoleCmd, membersRemoveCmd)
+
+	membersCmd.PersistentFlags().StringVarP(&organisationID, "organisation", "w", "", "Your envsecrets organisation; defaults to the one of your current project")
+	addOutputFlag(membersCmd)
+
+	membersInviteCmd.Flags().StringVarP(&roleName, "role", "r", "viewer", "Role of the invitee in the organisation")
+
+	membersRemoveCmd.Flags().BoolVar(&rotateKey, "rotate-key", false, "Rotate the organisation's key right after removing the member")
+	membersGrantsCmd.Flags().BoolVarP(&yes, "yes", "y", false, "Grant the key to every invitee without confirmation")
+
+	membersRemoveCmd.Flags().BoolVarP(&yes, "yes", "y", false, "Skip the confirmation")
+}
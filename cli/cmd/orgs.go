@@ -0,0 +1,206 @@
+/*
+Copyright © 2023 Mrinal Wahal <mrinalwahal@gmail.com>
+All rights reserved.
+
+Redistribution and use in source and binary forms, with or without
+modification, are permitted provided that the following conditions are met:
+
+ 1. Redistributions of source code must retain the above copyright notice,
+    this list of conditions and the following disclaimer.
+
+ 2. Redistributions in binary form must reproduce the above copyright notice,
+    this list of conditions and the following disclaimer in the documentation
+    and/or other materials provided with the distribution.
+
+ 3. Neither the name of the copyright holder nor the names of its contributors
+    may be used to endorse or promote products derived from this software
+    without specific prior written permission.
+
+THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+POSSIBILITY OF SUCH DAMAGE.
+*/
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+
+	"github.com/envsecrets/envsecrets/cli/clients"
+	"github.com/envsecrets/envsecrets/cli/commons"
+	"github.com/envsecrets/envsecrets/internal/organisations"
+	"github.com/spf13/cobra"
+)
+
+// orgsCmd represents the orgs command
+var orgsCmd = &cobra.Command{
+	Use:               "orgs",
+	Aliases:           []string{"organisations"},
+	Short:             "Manage your organisations",
+	PersistentPreRunE: authenticate,
+}
+
+// orgsListCmd represents the orgs list command
+var orgsListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List the organisations you have access to",
+	Args:    cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		orgs, err := organisations.GetService().List(commons.DefaultContext, commons.GQLClient.GQLClient)
+		if err != nil {
+			commons.Log.Debug(err)
+			commons.Log.Fatal("Failed to fetch your organisations")
+		}
+
+		type item struct {
+			ID   string `json:"id"`
+			Name string `json:"name"`
+		}
+
+		var result []item
+		var rows [][]string
+		for _, org := range *orgs {
+			result = append(result, item{ID: org.ID, Name: org.Name})
+			rows = append(rows, []string{org.Name, org.ID})
+		}
+
+		printOutput(result, []string{"NAME", "ID"}, rows)
+	},
+}
+
+// orgsCreateCmd represents the orgs create command
+var orgsCreateCmd = &cobra.Command{
+	Use:   "create [name]",
+	Short: "Create a new organisation",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+
+		body, err := json.Marshal(map[string]string{
+			"name": args[0],
+		})
+		if err != nil {
+			commons.Log.Debug(err)
+			commons.Log.Fatal("failed to marshal your HTTP request body")
+		}
+
+		//	Organisations are created by the API,
+		//	since their encryption key has to be generated along with them.
+		req, err := http.NewRequestWithContext(commons.DefaultContext, http.MethodPost, clients.API+"/v1/organisations", bytes.NewBuffer(body))
+		if err != nil {
+			commons.Log.Debug(err)
+			commons.Log.Fatal("failed to create your HTTP request")
+		}
+
+		var response clients.APIResponse
+		if err := commons.HTTPClient.Run(commons.DefaultContext, req, &response); err != nil {
+			commons.Log.Fatal(err)
+		}
+
+		if response.Error != "" {
+			commons.Log.Fatal(response.Message, ": ", response.Error)
+		}
+
+		if outputFormat == "json" {
+			printOutput(response.Data, nil, nil)
+			return
+		}
+
+		commons.Log.Info("Created the organisation ", args[0])
+	},
+}
+
+// orgsRenameCmd represents the orgs rename command
+var orgsRenameCmd = &cobra.Command{
+	Use:   "rename [new-name]",
+	Short: "Rename your organisation",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+
+		organisation, err := organisations.GetService().Update(commons.DefaultContext, commons.GQLClient.GQLClient, getOrganisationID(), &organisations.UpdateOptions{
+			Name: args[0],
+		})
+		if err != nil {
+			commons.Log.Debug(err)
+			commons.Log.Fatal("Failed to rename the organisation")
+		}
+
+		if outputFormat == "json" {
+			printOutput(organisation, nil, nil)
+			return
+		}
+
+		commons.Log.Info("Renamed the organisation to ", organisation.Name)
+	},
+}
+
+// orgsRotateKeyCmd represents the orgs rotate-key command
+var orgsRotateKeyCmd = &cobra.Command{
+	Use:   "rotate-key",
+	Short: "Rotate the encryption key of your organisation",
+	Long: `This command generates a new encryption key for your organisation,
+re-encrypts all of its secrets with it and shares it with all the members.
+
+All the environment tokens of the organisation are revoked,
+since they carry a copy of the old key.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		orgID := getOrganisationID()
+
+		confirm("Rotate the organisation's key and revoke all of its tokens")
+
+		body, err := json.Marshal(map[string]string{
+			"password": getPassword(),
+		})
+		if err != nil {
+			commons.Log.Debug(err)
+			commons.Log.Fatal("failed to marshal your HTTP request body")
+		}
+
+		req, err := http.NewRequestWithContext(commons.DefaultContext, http.MethodPost, clients.API+"/v1/organisations/"+orgID+"/rotate-key", bytes.NewBuffer(body))
+		if err != nil {
+			commons.Log.Debug(err)
+			commons.Log.Fatal("failed to create your HTTP request")
+		}
+
+		var response clients.APIResponse
+		if err := commons.HTTPClient.Run(commons.DefaultContext, req, &response); err != nil {
+			commons.Log.Fatal(err)
+		}
+
+		if response.Error != "" {
+			commons.Log.Fatal(response.Message, ": ", response.Error)
+		}
+
+		//	Pull the new copy of the organisation's key.
+		refreshProjectKey()
+
+		if outputFormat == "json" {
+			printOutput(response.Data, nil, nil)
+			return
+		}
+
+		commons.Log.Info(response.Message)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(orgsCmd)
+	orgsCmd.AddCommand(orgsListCmd, orgsCreateCmd, orgsRenameCmd, orgsRotateKeyCmd)
+
+	orgsCmd.PersistentFlags().StringVarP(&organisationID, "organisation", "w", "", "Your envsecrets organisation; defaults to the one of your current project")
+	addOutputFlag(orgsCmd)
+
+	orgsRotateKeyCmd.Flags().BoolVarP(&yes, "yes", "y", false, "Skip the confirmation")
+}
@@ -0,0 +1,189 @@
+/*
+Copyright © 2023 Mrinal Wahal <mrinalwahal@gmail.com>
+All rights reserved.
+
+Redistribution and use in source and binary forms, with or without
+modification, are permitted provided that the following conditions are met:
+
+ 1. Redistributions of source code must retain the above copyright notice,
+    this list of conditions and the following disclaimer.
+
+ 2. Redistributions in binary form must reproduce the above copyright notice,
+    this list of conditions and the following disclaimer in the documentation
+    and/or other materials provided with the distribution.
+
+ 3. Neither the name of the copyright holder nor the names of its contributors
+    may be used to endorse or promote products derived from this software
+    without specific prior written permission.
+
+THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+POSSIBILITY OF SUCH DAMAGE.
+*/
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/envsecrets/envsecrets/cli/commons"
+	"github.com/envsecrets/envsecrets/internal/projects"
+	"github.com/spf13/cobra"
+)
+
+// projectsCmd represents the projects command
+var projectsCmd = &cobra.Command{
+	Use:               "projects",
+	Short:             "Manage the projects of your organisation",
+	PersistentPreRunE: authenticate,
+}
+
+// projectsListCmd represents the projects list command
+var projectsListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List the projects of your organisation",
+	Args:    cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		list, err := projects.GetService().List(commons.DefaultContext, commons.GQLClient.GQLClient, &projects.ListOptions{
+			OrgID: getOrganisationID(),
+		})
+		if err != nil {
+			commons.Log.Debug(err)
+			commons.Log.Fatal("Failed to fetch the projects")
+		}
+
+		type item struct {
+			ID   string `json:"id"`
+			Name string `json:"name"`
+		}
+
+		var result []item
+		var rows [][]string
+		for _, project := range list {
+			result = append(result, item{ID: project.ID, Name: project.Name})
+			rows = append(rows, []string{project.Name, project.ID})
+		}
+
+		printOutput(result, []string{"NAME", "ID"}, rows)
+	},
+}
+
+// projectsCreateCmd represents the projects create command
+var projectsCreateCmd = &cobra.Command{
+	Use:   "create [name]",
+	Short: "Create a new project in your organisation",
+	Long: `This command creates a new project in your organisation,
+along with its default environments.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+
+		if err := validateSlug(args[0]); err != nil {
+			commons.Log.Fatal(err)
+		}
+
+		project, err := projects.GetService().Create(commons.DefaultContext, commons.GQLClient.GQLClient, &projects.CreateOptions{
+			OrgID: getOrganisationID(),
+			Name:  args[0],
+		})
+		if err != nil {
+			commons.Log.Debug(err)
+			commons.Log.Fatal("Failed to create the project")
+		}
+
+		if outputFormat == "json" {
+			printOutput(map[string]string{
+				"id":   project.ID,
+				"name": project.Name,
+			}, nil, nil)
+			return
+		}
+
+		commons.Log.Info("Created the project ", project.Name)
+	},
+}
+
+// projectsRenameCmd represents the projects rename command
+var projectsRenameCmd = &cobra.Command{
+	Use:   "rename [new-name]",
+	Short: "Rename a project of your organisation",
+	Long: `This command renames the project passed using --project,
+or the one configured in your current directory.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+
+		if err := validateSlug(args[0]); err != nil {
+			commons.Log.Fatal(err)
+		}
+
+		project, err := projects.GetService().Update(commons.DefaultContext, commons.GQLClient.GQLClient, getProjectID(), &projects.UpdateOptions{
+			Name: args[0],
+		})
+		if err != nil {
+			commons.Log.Debug(err)
+			commons.Log.Fatal("Failed to rename the project")
+		}
+
+		if outputFormat == "json" {
+			printOutput(map[string]string{
+				"id":   project.ID,
+				"name": project.Name,
+			}, nil, nil)
+			return
+		}
+
+		commons.Log.Info("Renamed the project to ", project.Name)
+	},
+}
+
+// projectsDeleteCmd represents the projects delete command
+var projectsDeleteCmd = &cobra.Command{
+	Use:   "delete",
+	Short: "Delete a project of your organisation",
+	Long: `This command deletes the project passed using --project,
+along with all of its environments and their secrets.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		if projectID == "" {
+			commons.Log.Fatal("Pass the project to delete using --project")
+		}
+
+		id := getProjectID()
+
+		confirm(fmt.Sprintf("Delete the project %s and all of its secrets", projectID))
+
+		if err := projects.GetService().Delete(commons.DefaultContext, commons.GQLClient.GQLClient, id); err != nil {
+			commons.Log.Debug(err)
+			commons.Log.Fatal("Failed to delete the project")
+		}
+
+		if outputFormat == "json" {
+			printOutput(map[string]string{
+				"id": id,
+			}, nil, nil)
+			return
+		}
+
+		commons.Log.Info("Deleted the project ", projectID)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(projectsCmd)
+	projectsCmd.AddCommand(projectsListCmd, projectsCreateCmd, projectsRenameCmd, projectsDeleteCmd)
+
+	projectsCmd.PersistentFlags().StringVarP(&organisationID, "organisation", "w", "", "Your envsecrets organisation; defaults to the one of your current project")
+	projectsCmd.PersistentFlags().StringVarP(&projectID, "project", "p", "", "Name or ID of your envsecrets project; defaults to your current project")
+	addOutputFlag(projectsCmd)
+
+	projectsDeleteCmd.Flags().BoolVarP(&yes, "yes", "y", false, "Skip the confirmation")
+}
@@ -246,6 +246,9 @@ func InitializeSecret(log *logrus.Logger) {
 			commons.Log.Fatal("Project configuration not found")
 		}
 
+		//	The organisation's key may have been rotated since the project was initialized.
+		refreshProjectKey()
+
 		remoteConfig = &secrets.RemoteConfig{
 			EnvironmentName: environmentName,
 			ProjectID:       commons.ProjectConfig.ProjectID,
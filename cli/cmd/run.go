@@ -53,6 +53,9 @@ var runCmd = &cobra.Command{
 envs run --command "YOUR_COMMAND && YOUR_OTHER_COMMAND"`,
 	PreRun: func(cmd *cobra.Command, args []string) {
 
+		//	Inside a trusted CI job, use the job's identity instead of a token.
+		useCIIdentity()
+
 		//	If the user has passed a token,
 		//	avoid using email+password to authenticate them against the API.
 		if XTokenHeader != "" {
@@ -82,35 +85,42 @@ envs run --command "YOUR_COMMAND && YOUR_OTHER_COMMAND"`,
 	},
 	Run: func(cmd *cobra.Command, args []string) {
 
-		//	Fetch only the required values.
-		getOptions := secrets.GetOptions{
-			EnvID: commons.Secret.EnvID,
-		}
+		if XTokenHeader != "" {
+			fetchWithToken()
+		} else {
 
-		if version > -1 {
-			getOptions.Version = &version
-		}
+			//	Fetch only the required values.
+			getOptions := secrets.GetOptions{
+				EnvID: commons.Secret.EnvID,
+			}
 
-		result, err := secrets.GetService().Get(commons.DefaultContext, commons.GQLClient.GQLClient, &getOptions)
-		if err != nil {
-			commons.Log.Debug(err)
-			if strings.Compare(err.Error(), string(clients.ErrorTypeRecordNotFound)) == 0 {
-				commons.Log.Warn("You haven't set any secrets in this environment")
-				commons.Log.Info("Use `envs set --help` for more information")
-			} else {
-				commons.Log.Fatal("Failed to fetch the secrets")
+			if version > -1 {
+				getOptions.Version = &version
 			}
-		}
 
-		commons.Secret = result
+			result, err := secrets.GetService().Get(commons.DefaultContext, commons.GQLClient.GQLClient, &getOptions)
+			if err != nil {
+				commons.Log.Debug(err)
+				if strings.Compare(err.Error(), string(clients.ErrorTypeRecordNotFound)) == 0 {
+					commons.Log.Warn("You haven't set any secrets in this environment")
+					commons.Log.Info("Use `envs set --help` for more information")
+				} else {
+					commons.Log.Fatal("Failed to fetch the secrets")
+				}
+			}
 
-		//	Decrypt and decode the common secret.
-		DecryptAndDecode()
+			commons.Secret = result
+
+			//	Decrypt and decode the common secret.
+			DecryptAndDecode()
+		}
 
 		//	Initialize a new buffer to store key=value lines
 		variables := commons.Secret.Data.FmtStrings()
 
-		if environmentName != "" {
+		if XTokenHeader != "" {
+			commons.Log.Info("Injecting secrets in your process from the environment of your token...")
+		} else if environmentName != "" {
 			commons.Log.Infof("Injecting secret version %d in your process from remote environment `%s`", *commons.Secret.Version, environmentName)
 		} else {
 			commons.Log.Info("Injecting secrets in your process from local environment...")
@@ -173,4 +183,5 @@ func init() {
 	runCmd.Flags().IntVarP(&version, "version", "v", -1, "Version of your secret")
 	runCmd.Flags().StringP("command", "c", "", "Command to run. Example: npm run dev")
 	runCmd.Flags().StringVarP(&environmentName, "env", "e", "", "Remote environment to set the secrets in. Defaults to the local environment.")
+	runCmd.Flags().StringVarP(&XTokenHeader, "token", "t", "", "Environment Token")
 }
@@ -0,0 +1,219 @@
+/*
+Copyright © 2023 Mrinal Wahal <mrinalwahal@gmail.com>
+All rights reserved.
+
+Redistribution and use in source and binary forms, with or without
+modification, are permitted provided that the following conditions are met:
+
+ 1. Redistributions of source code must retain the above copyright notice,
+    this list of conditions and the following disclaimer.
+
+ 2. Redistributions in binary form must reproduce the above copyright notice,
+    this list of conditions and the following disclaimer in the documentation
+    and/or other materials provided with the distribution.
+
+ 3. Neither the name of the copyright holder nor the names of its contributors
+    may be used to endorse or promote products derived from this software
+    without specific prior written permission.
+
+THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+POSSIBILITY OF SUCH DAMAGE.
+*/
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/envsecrets/envsecrets/cli/commons"
+	"github.com/envsecrets/envsecrets/cli/internal"
+	"github.com/envsecrets/envsecrets/internal/serviceaccounts"
+	"github.com/spf13/cobra"
+)
+
+var serviceAccountDescription, serviceAccountExpiry string
+
+// serviceAccountsCmd represents the service-accounts command
+var serviceAccountsCmd = &cobra.Command{
+	Use:     "service-accounts",
+	Aliases: []string{"sa"},
+	Short:   "Manage the service accounts of your organisation",
+	Long: `Service accounts are members of your organisation for automation, like CI pipelines or Terraform.
+Each one has its own keys and role, and keeps working when the member who created it leaves.
+
+Login as a service account with ` + "`envs login --service-account`" + `,
+reading its credential from the $ENVS_SERVICE_ACCOUNT environment variable.`,
+	PersistentPreRunE: authenticate,
+}
+
+// serviceAccountsListCmd represents the service-accounts list command
+var serviceAccountsListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List the service accounts of your organisation",
+	Args:    cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		list, err := internal.ListServiceAccounts(commons.DefaultContext, commons.HTTPClient, getOrganisationID())
+		if err != nil {
+			commons.Log.Debug(err)
+			commons.Log.Fatal("Failed to fetch the service accounts: ", err)
+		}
+
+		var rows [][]string
+		for _, item := range list {
+
+			lastUsed := "never"
+			if item.LastUsedAt != nil {
+				lastUsed = item.LastUsedAt.Local().Format("2006-01-02 15:04")
+			}
+
+			rows = append(rows, []string{item.Name, item.Description, lastUsed})
+		}
+
+		printOutput(list, []string{"NAME", "DESCRIPTION", "LAST USED"}, rows)
+	},
+}
+
+// serviceAccountsCreateCmd represents the service-accounts create command
+var serviceAccountsCreateCmd = &cobra.Command{
+	Use:   "create [name]",
+	Short: "Create a service account in your organisation",
+	Long: `This command creates a service account with the role passed using --role.
+Your password is required to share a copy of the organisation's encryption key with it.
+
+The credential of the service account is only shown once.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+
+		orgID := getOrganisationID()
+		role := getRole(orgID, roleName)
+
+		result, err := internal.CreateServiceAccount(commons.DefaultContext, commons.HTTPClient, orgID, &internal.CreateServiceAccountOptions{
+			Name:        args[0],
+			Description: serviceAccountDescription,
+			RoleID:      role.ID,
+			Expiry:      serviceAccountExpiry,
+			Password:    getPassword(),
+		})
+		if err != nil {
+			commons.Log.Debug(err)
+			commons.Log.Fatal("Failed to create the service account: ", err)
+		}
+
+		if outputFormat == "json" {
+			printOutput(result, nil, nil)
+			return
+		}
+
+		commons.Log.Info("Created ", args[0], " as ", role.Name, ". Save its credential, since it won't be shown again:")
+		fmt.Println(result.Credential)
+	},
+}
+
+// serviceAccountsRotateCmd represents the service-accounts rotate command
+var serviceAccountsRotateCmd = &cobra.Command{
+	Use:   "rotate [name]",
+	Short: "Rotate the credential of a service account",
+	Long: `This command issues a new credential and key pair for the service account.
+Its previous credential and sessions stop working immediately.
+
+If the previous credential could have leaked, rotate the organisation's key as well.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+
+		orgID := getOrganisationID()
+		account := getServiceAccount(orgID, args[0])
+
+		confirm(fmt.Sprintf("Rotate the credential of %s", account.Name))
+
+		result, err := internal.RotateServiceAccount(commons.DefaultContext, commons.HTTPClient, orgID, account.ID, serviceAccountExpiry, getPassword())
+		if err != nil {
+			commons.Log.Debug(err)
+			commons.Log.Fatal("Failed to rotate the credential: ", err)
+		}
+
+		if outputFormat == "json" {
+			printOutput(result, nil, nil)
+			return
+		}
+
+		commons.Log.Info("Rotated the credential of ", account.Name, ". Save it, since it won't be shown again:")
+		fmt.Println(result.Credential)
+	},
+}
+
+// serviceAccountsDeleteCmd represents the service-accounts delete command
+var serviceAccountsDeleteCmd = &cobra.Command{
+	Use:   "delete [name]",
+	Short: "Delete a service account",
+	Long: `This command deletes the service account, disables its credential,
+and revokes the environment tokens it created.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+
+		orgID := getOrganisationID()
+		account := getServiceAccount(orgID, args[0])
+
+		confirm(fmt.Sprintf("Delete %s", account.Name))
+
+		result, err := internal.DeleteServiceAccount(commons.DefaultContext, commons.HTTPClient, orgID, account.ID)
+		if err != nil {
+			commons.Log.Debug(err)
+			commons.Log.Fatal("Failed to delete the service account: ", err)
+		}
+
+		if outputFormat == "json" {
+			printOutput(result, nil, nil)
+			return
+		}
+
+		commons.Log.Info("Deleted ", account.Name, " and revoked ", result.RevokedTokens, " environment tokens")
+	},
+}
+
+// Fetches a service account of the organisation by its name or ID.
+func getServiceAccount(orgID, name string) *serviceaccounts.ServiceAccount {
+
+	list, err := internal.ListServiceAccounts(commons.DefaultContext, commons.HTTPClient, orgID)
+	if err != nil {
+		commons.Log.Debug(err)
+		commons.Log.Fatal("Failed to fetch the service accounts: ", err)
+	}
+
+	for _, item := range list {
+		if item.Name == name || item.ID == name {
+			return &item
+		}
+	}
+
+	commons.Log.Fatal("Service account not found: ", name)
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(serviceAccountsCmd)
+	serviceAccountsCmd.AddCommand(serviceAccountsListCmd, serviceAccountsCreateCmd, serviceAccountsRotateCmd, serviceAccountsDeleteCmd)
+
+	serviceAccountsCmd.PersistentFlags().StringVarP(&organisationID, "organisation", "w", "", "Your envsecrets organisation; defaults to the one of your current project")
+	addOutputFlag(serviceAccountsCmd)
+
+	serviceAccountsCreateCmd.Flags().StringVarP(&roleName, "role", "r", "viewer", "Role of the service account in the organisation")
+	serviceAccountsCreateCmd.Flags().StringVarP(&serviceAccountDescription, "description", "d", "", "What the service account is used for")
+
+	for _, item := range []*cobra.Command{serviceAccountsCreateCmd, serviceAccountsRotateCmd} {
+		item.Flags().StringVar(&serviceAccountExpiry, "expiry", "", "Lifetime of the credential, like 720h; defaults to 90 days")
+	}
+
+	for _, item := range []*cobra.Command{serviceAccountsRotateCmd, serviceAccountsDeleteCmd} {
+		item.Flags().BoolVarP(&yes, "yes", "y", false, "Skip the confirmation")
+	}
+}
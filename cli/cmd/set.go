@@ -54,6 +54,9 @@ var setCmd = &cobra.Command{
 
 You can also load your variables directly from files: envs set --file .env
 
+If the remote environment is protected, a change request is created instead,
+which is applied once it has been approved: envs changes approve [id]
+
 NOTE: This command auto-capitalizes your keys.`,
 	PreRun: func(cmd *cobra.Command, args []string) {
 
@@ -120,6 +123,12 @@ NOTE: This command auto-capitalizes your keys.`,
 		//	Encrypt the values.
 		Encrypt()
 
+		//	Protected environments only accept changes through change requests.
+		if commons.Secret.EnvID != "" && isProtected(commons.Secret.EnvID) {
+			requestChange(commons.Secret.EnvID, encryptedPayloads(), nil)
+			return
+		}
+
 		if err := secrets.GetService().Set(commons.DefaultContext, commons.GQLClient.GQLClient, commons.Secret); err != nil {
 			commons.Log.Debug(err)
 			commons.Log.Fatal("Failed to set the secrets")
@@ -148,4 +157,6 @@ func init() {
 	// is called directly, e.g.:
 	setCmd.Flags().StringVarP(&importFile, "file", "f", "", "Export secret key-values from a file {.env | .json | .yaml | .txt}")
 	setCmd.Flags().StringVarP(&environmentName, "env", "e", "", "Remote environment to set the secrets in. Defaults to the local environment.")
+	setCmd.Flags().StringVarP(&changeMessage, "message", "m", "", "Describe the change request, if the remote environment is protected")
+	setCmd.Flags().BoolVar(&syncOnApproval, "sync", false, "Sync the secrets with the environment's integrations once the change request is approved")
 }
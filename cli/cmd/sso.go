@@ -0,0 +1,340 @@
+/*
+Copyright © 2023 Mrinal Wahal <mrinalwahal@gmail.com>
+All rights reserved.
+
+Redistribution and use in source and binary forms, with or without
+modification, are permitted provided that the following conditions are met:
+
+ 1. Redistributions of source code must retain the above copyright notice,
+    this list of conditions and the following disclaimer.
+
+ 2. Redistributions in binary form must reproduce the above copyright notice,
+    this list of conditions and the following disclaimer in the documentation
+    and/or other materials provided with the distribution.
+
+ 3. Neither the name of the copyright holder nor the names of its contributors
+    may be used to endorse or promote products derived from this software
+    without specific prior written permission.
+
+THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+POSSIBILITY OF SUCH DAMAGE.
+*/
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/envsecrets/envsecrets/cli/commons"
+	"github.com/envsecrets/envsecrets/cli/internal"
+	"github.com/envsecrets/envsecrets/internal/sso"
+	"github.com/spf13/cobra"
+)
+
+var ssoIssuer, ssoClientID, ssoClientSecret, ssoDefaultRole string
+var ssoDomains []string
+var ssoEnable, ssoDisable bool
+var ssoPriority int
+
+// ssoCmd represents the sso command
+var ssoCmd = &cobra.Command{
+	Use:   "sso",
+	Short: "Manage the single sign-on of your organisation",
+	Long: `Members of an organisation with single sign-on login using ` + "`envs login --sso`" + `.
+Their identity provider can also provision and deprovision them over SCIM.
+
+Only the emails of the domains verified with ` + "`envs sso verify-domain`" + ` can login or be provisioned.
+Members whose envsecrets account already existed confirm it with its password on their first login.
+
+Provisioned members are granted the organisation's key by an admin,
+like any other invitee whose account didn't exist yet.`,
+	PersistentPreRunE: authenticate,
+}
+
+// ssoShowCmd represents the sso show command
+var ssoShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Show the single sign-on configuration of your organisation",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		connection, err := internal.GetOrganisationSSO(commons.DefaultContext, commons.HTTPClient, getOrganisationID())
+		if err != nil {
+			commons.Log.Debug(err)
+			commons.Log.Fatal("Failed to fetch the single sign-on configuration: ", err)
+		}
+
+		printOutput(connection, []string{"ISSUER", "CLIENT ID", "DOMAINS", "VERIFIED DOMAINS", "ENABLED"}, [][]string{
+			{connection.Issuer, connection.ClientID, strings.Join(connection.Domains, ","), strings.Join(connection.VerifiedDomains, ","), strconv.FormatBool(connection.Enabled)},
+		})
+	},
+}
+
+// ssoVerifyDomainCmd represents the sso verify-domain command
+var ssoVerifyDomainCmd = &cobra.Command{
+	Use:   "verify-domain [domain]",
+	Short: "Prove that your organisation owns an email domain of its identity provider",
+	Long: `This command verifies one of the domains passed to ` + "`envs sso configure --domain`" + `
+from a TXT record holding the verification token of your organisation.
+
+Run it once to print the record to publish in your DNS, and again once it's published.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+
+		orgID := getOrganisationID()
+
+		connection, err := internal.GetOrganisationSSO(commons.DefaultContext, commons.HTTPClient, orgID)
+		if err != nil {
+			commons.Log.Debug(err)
+			commons.Log.Fatal("Failed to fetch the single sign-on configuration: ", err)
+		}
+
+		result, err := internal.VerifySSODomain(commons.DefaultContext, commons.HTTPClient, orgID, args[0])
+		if err != nil {
+			name, value := connection.VerificationRecord(args[0])
+			commons.Log.Debug(err)
+			commons.Log.Error("Failed to verify the domain: ", err)
+			commons.Log.Fatalf("Publish a TXT record named %s with the value %s, and try again", name, value)
+		}
+
+		if outputFormat == "json" {
+			printOutput(result, nil, nil)
+			return
+		}
+
+		commons.Log.Info("Verified ", args[0], "; its members can now login with your identity provider")
+	},
+}
+
+// ssoConfigureCmd represents the sso configure command
+var ssoConfigureCmd = &cobra.Command{
+	Use:   "configure",
+	Short: "Configure the OIDC identity provider of your organisation",
+	Long: `This command creates or updates the single sign-on connection of your organisation.
+Register "http://127.0.0.1" as a redirect URI of the client in your identity provider,
+since the CLI receives the sign in on a loopback address.
+
+Only the flags you pass are updated.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		orgID := getOrganisationID()
+
+		options := sso.SaveConnectionOptions{
+			Issuer:       ssoIssuer,
+			ClientID:     ssoClientID,
+			ClientSecret: ssoClientSecret,
+		}
+
+		if cmd.Flags().Changed("domain") {
+			options.Domains = ssoDomains
+		}
+
+		if ssoDefaultRole != "" {
+			options.DefaultRoleID = getRole(orgID, ssoDefaultRole).ID
+		}
+
+		if ssoEnable && ssoDisable {
+			commons.Log.Fatal("Pass either --enable or --disable")
+		} else if ssoEnable || ssoDisable {
+			options.Enabled = &ssoEnable
+		}
+
+		connection, err := internal.SaveOrganisationSSO(commons.DefaultContext, commons.HTTPClient, orgID, &options)
+		if err != nil {
+			commons.Log.Debug(err)
+			commons.Log.Fatal("Failed to configure single sign-on: ", err)
+		}
+
+		if outputFormat == "json" {
+			printOutput(connection, nil, nil)
+			return
+		}
+
+		commons.Log.Info("Configured single sign-on with ", connection.Issuer)
+
+		for _, domain := range connection.Domains {
+			if !connection.Allows("@" + domain) {
+				name, value := connection.VerificationRecord(domain)
+				commons.Log.Warnf("Publish a TXT record named %s with the value %s, and run `envs sso verify-domain %s`", name, value, domain)
+			}
+		}
+	},
+}
+
+// ssoSCIMTokenCmd represents the sso scim-token command
+var ssoSCIMTokenCmd = &cobra.Command{
+	Use:   "scim-token",
+	Short: "Rotate the token your identity provider provisions members with",
+	Long: `This command issues a new SCIM token for your identity provider.
+The previous token stops working immediately, and the new one is only shown once.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		orgID := getOrganisationID()
+
+		confirm("Rotate the SCIM token of the organisation")
+
+		token, err := internal.RotateSCIMToken(commons.DefaultContext, commons.HTTPClient, orgID)
+		if err != nil {
+			commons.Log.Debug(err)
+			commons.Log.Fatal("Failed to rotate the SCIM token: ", err)
+		}
+
+		if outputFormat == "json" {
+			printOutput(map[string]string{
+				"token": token,
+			}, nil, nil)
+			return
+		}
+
+		fmt.Println(token)
+	},
+}
+
+// ssoUsersCmd represents the sso users command
+var ssoUsersCmd = &cobra.Command{
+	Use:   "users",
+	Short: "List the members provisioned by your identity provider",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		identities, err := internal.ListSSOIdentities(commons.DefaultContext, commons.HTTPClient, getOrganisationID())
+		if err != nil {
+			commons.Log.Debug(err)
+			commons.Log.Fatal("Failed to fetch the provisioned members: ", err)
+		}
+
+		var rows [][]string
+		for _, identity := range identities {
+
+			var groups []string
+			for _, item := range identity.Groups {
+				if item.Group != nil {
+					groups = append(groups, item.Group.DisplayName)
+				}
+			}
+
+			rows = append(rows, []string{identity.Email, identity.ExternalID, strconv.FormatBool(identity.Active), strconv.FormatBool(identity.Linked), strings.Join(groups, ",")})
+		}
+
+		printOutput(identities, []string{"EMAIL", "EXTERNAL ID", "ACTIVE", "LINKED", "GROUPS"}, rows)
+	},
+}
+
+// ssoGroupsCmd represents the sso groups command
+var ssoGroupsCmd = &cobra.Command{
+	Use:   "groups",
+	Short: "List the groups provisioned by your identity provider",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		groups, err := internal.ListSSOGroups(commons.DefaultContext, commons.HTTPClient, getOrganisationID())
+		if err != nil {
+			commons.Log.Debug(err)
+			commons.Log.Fatal("Failed to fetch the provisioned groups: ", err)
+		}
+
+		var rows [][]string
+		for _, group := range groups {
+
+			var role string
+			if group.Role != nil {
+				role = group.Role.Name
+			}
+
+			rows = append(rows, []string{group.DisplayName, role, strconv.Itoa(group.Priority), strconv.Itoa(len(group.Members))})
+		}
+
+		printOutput(groups, []string{"NAME", "ROLE", "PRIORITY", "MEMBERS"}, rows)
+	},
+}
+
+// ssoMapCmd represents the sso map command
+var ssoMapCmd = &cobra.Command{
+	Use:   "map [group] [role]",
+	Short: "Assign a role to the members of a provisioned group",
+	Long: `This command assigns the role to every member of the group provisioned by your identity provider.
+When a member is in several mapped groups, the group with the highest --priority wins.
+
+Pass "none" as the role to stop assigning a role through the group.`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+
+		orgID := getOrganisationID()
+
+		groups, err := internal.ListSSOGroups(commons.DefaultContext, commons.HTTPClient, orgID)
+		if err != nil {
+			commons.Log.Debug(err)
+			commons.Log.Fatal("Failed to fetch the provisioned groups: ", err)
+		}
+
+		var group *sso.Group
+		for i := range groups {
+			if groups[i].DisplayName == args[0] || groups[i].ID == args[0] {
+				group = &groups[i]
+				break
+			}
+		}
+
+		if group == nil {
+			commons.Log.Fatal("Group not found: ", args[0])
+		}
+
+		options := sso.MapGroupOptions{
+			Priority: ssoPriority,
+		}
+
+		if args[1] != "none" {
+			options.RoleID = getRole(orgID, args[1]).ID
+		}
+
+		result, err := internal.MapSSOGroup(commons.DefaultContext, commons.HTTPClient, orgID, group.ID, &options)
+		if err != nil {
+			commons.Log.Debug(err)
+			commons.Log.Fatal("Failed to map the group: ", err)
+		}
+
+		if outputFormat == "json" {
+			printOutput(result, nil, nil)
+			return
+		}
+
+		if options.RoleID == "" {
+			commons.Log.Info("Stopped assigning a role through ", group.DisplayName)
+			return
+		}
+
+		commons.Log.Info("Members of ", group.DisplayName, " are now assigned ", args[1])
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(ssoCmd)
+	ssoCmd.AddCommand(ssoShowCmd, ssoConfigureCmd, ssoVerifyDomainCmd, ssoSCIMTokenCmd, ssoUsersCmd, ssoGroupsCmd, ssoMapCmd)
+
+	ssoCmd.PersistentFlags().StringVarP(&organisationID, "organisation", "w", "", "Your envsecrets organisation; defaults to the one of your current project")
+	addOutputFlag(ssoCmd)
+
+	ssoConfigureCmd.Flags().StringVar(&ssoIssuer, "issuer", "", "Issuer URL of your OIDC identity provider")
+	ssoConfigureCmd.Flags().StringVar(&ssoClientID, "client-id", "", "ID of the envsecrets client in your identity provider")
+	ssoConfigureCmd.Flags().StringVar(&ssoClientSecret, "client-secret", "", "Secret of the envsecrets client in your identity provider")
+	ssoConfigureCmd.Flags().StringSliceVar(&ssoDomains, "domain", nil, "Email domains allowed to login, once verified with envs sso verify-domain")
+	ssoConfigureCmd.Flags().StringVar(&ssoDefaultRole, "default-role", "", "Role of members who aren't in any mapped group; defaults to viewer")
+	ssoConfigureCmd.Flags().BoolVar(&ssoEnable, "enable", false, "Enable single sign-on")
+	ssoConfigureCmd.Flags().BoolVar(&ssoDisable, "disable", false, "Disable single sign-on, without deleting its configuration")
+
+	ssoSCIMTokenCmd.Flags().BoolVarP(&yes, "yes", "y", false, "Skip the confirmation")
+
+	ssoMapCmd.Flags().IntVar(&ssoPriority, "priority", 0, "Priority of the group over the other mapped groups of its members")
+}
@@ -106,7 +106,8 @@ You can activate your connected integrations on the "integrations" page of your
 		kpMap.MarkAllEncoded()
 
 		options := environments.SyncOptions{
-			Pairs: &kpMap,
+			Pairs:   &kpMap,
+			Version: commons.Secret.Version,
 		}
 
 		//	Fetch the list of events with their respective type of integrations.
@@ -0,0 +1,305 @@
+/*
+Copyright © 2023 Mrinal Wahal <mrinalwahal@gmail.com>
+All rights reserved.
+
+Redistribution and use in source and binary forms, with or without
+modification, are permitted provided that the following conditions are met:
+
+ 1. Redistributions of source code must retain the above copyright notice,
+    this list of conditions and the following disclaimer.
+
+ 2. Redistributions in binary form must reproduce the above copyright notice,
+    this list of conditions and the following disclaimer in the documentation
+    and/or other materials provided with the distribution.
+
+ 3. Neither the name of the copyright holder nor the names of its contributors
+    may be used to endorse or promote products derived from this software
+    without specific prior written permission.
+
+THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+POSSIBILITY OF SUCH DAMAGE.
+*/
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/envsecrets/envsecrets/cli/auth"
+	"github.com/envsecrets/envsecrets/cli/clients"
+	"github.com/envsecrets/envsecrets/cli/commons"
+	"github.com/envsecrets/envsecrets/cli/internal"
+	"github.com/envsecrets/envsecrets/internal/environments"
+	"github.com/envsecrets/envsecrets/internal/federation"
+	"github.com/spf13/cobra"
+)
+
+var trustPolicyProvider, trustPolicyIssuer, trustPolicyAudience, trustPolicyTokenExpiry string
+var trustPolicyRepository, trustPolicyBranch, trustPolicyEnvironment string
+var trustPolicyRepositoryOwnerID, trustPolicyRepositoryID string
+var trustPolicyClaims map[string]string
+
+// trustPoliciesCmd represents the trust-policies command
+var trustPoliciesCmd = &cobra.Command{
+	Use:     "trust-policies",
+	Aliases: []string{"tp"},
+	Short:   "Manage the CI pipelines trusted to read your environments",
+	Long: `Trust policies let CI pipelines exchange the OIDC ID tokens of their jobs
+for short-lived environment tokens, instead of keeping long-lived tokens in their secrets.
+
+Inside a trusted job, ` + "`envs export`" + ` and ` + "`envs run`" + ` exchange the ID token on their own:
+  - GitHub Actions: grant the job the ` + "`id-token: write`" + ` permission.
+  - GitLab CI: request an ID token with ` + "`id_tokens: { " + internal.ID_TOKEN_ENV + ": { aud: " + federation.DEFAULT_AUDIENCE + " } }`" + `.
+  - Other issuers: pass the ID token in $` + internal.ID_TOKEN_ENV + `.
+
+Set $` + internal.OIDC_ENV_ENV + ` to the ID of the environment to read, or $` + internal.OIDC_POLICY_ENV + ` to the ID
+of the policy to use, which also settles which policy applies when more than one of them could match the job.`,
+	PersistentPreRunE: authenticate,
+}
+
+// trustPoliciesListCmd represents the trust-policies list command
+var trustPoliciesListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List the trust policies of your organisation",
+	Args:    cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		list, err := internal.ListTrustPolicies(commons.DefaultContext, commons.HTTPClient, getOrganisationID())
+		if err != nil {
+			commons.Log.Debug(err)
+			commons.Log.Fatal("Failed to fetch the trust policies: ", err)
+		}
+
+		var rows [][]string
+		for _, item := range list {
+
+			environment := item.EnvID
+			if env, err := environments.GetService().Get(commons.DefaultContext, commons.GQLClient.GQLClient, item.EnvID); err == nil {
+				environment = env.Name
+			}
+
+			expiry := (time.Duration(item.TokenExpiry) * time.Second).String()
+			rows = append(rows, []string{item.Name, environment, string(item.Provider), describeConditions(&item), expiry})
+		}
+
+		printOutput(list, []string{"NAME", "ENVIRONMENT", "PROVIDER", "CONDITIONS", "TOKEN EXPIRY"}, rows)
+	},
+}
+
+// trustPoliciesCreateCmd represents the trust-policies create command
+var trustPoliciesCreateCmd = &cobra.Command{
+	Use:   "create [name]",
+	Short: "Trust a CI pipeline to read the secrets of an environment",
+	Long: `This command trusts the jobs whose ID tokens match every condition of the policy
+to read the secrets of the environment passed using --env.
+
+Conditions are glob patterns, like "acme/*" or "release-*", where "*" doesn't match a "/".
+Policies of GitHub and GitLab must restrict the repository, whose owner can't be a pattern,
+and generic ones at least one claim. Pin the IDs of the owner and of the repository
+with --repository-owner-id and --repository-id, so that renamed repositories stop matching.
+
+Your password is required to share a copy of the organisation's encryption key with the server,
+since nobody is around to decrypt one when the pipeline runs. With that copy, the server can decrypt
+every secret of the organisation on its own, not just those of the environment, so you're asked
+to allow it first. Pass --yes to allow it without being asked.`,
+	Example: `envs trust-policies create deploy --env prod --provider github --repository acme/api --branch main
+envs trust-policies create nightly --env staging --provider generic --issuer https://ci.acme.dev --claim sub=pipeline:nightly`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+
+		if environmentName == "" {
+			commons.Log.Fatal("Pass the environment to trust the pipeline with, using --env")
+		}
+
+		orgID := getOrganisationID()
+		environment := getEnvironment(environmentName)
+
+		if !yes {
+			commons.Log.Warn("The server will keep a copy of your organisation's encryption key, and be able to decrypt all of its secrets without you")
+		}
+		confirm("Allow the server to decrypt your organisation's secrets")
+
+		policy, err := internal.CreateTrustPolicy(commons.DefaultContext, commons.HTTPClient, orgID, &internal.CreateTrustPolicyOptions{
+			EnvID:       environment.ID,
+			Name:        args[0],
+			Provider:    federation.Provider(trustPolicyProvider),
+			Issuer:      trustPolicyIssuer,
+			Audience:    trustPolicyAudience,
+			Repository:  trustPolicyRepository,
+			Branch:      trustPolicyBranch,
+			Environment: trustPolicyEnvironment,
+			Claims:      trustPolicyClaims,
+			TokenExpiry: trustPolicyTokenExpiry,
+			Password:    getPassword(),
+
+			RepositoryOwnerID: trustPolicyRepositoryOwnerID,
+			RepositoryID:      trustPolicyRepositoryID,
+
+			AllowServerDecryption: true,
+		})
+		if err != nil {
+			commons.Log.Debug(err)
+			commons.Log.Fatal("Failed to create the trust policy: ", err)
+		}
+
+		if outputFormat == "json" {
+			printOutput(policy, nil, nil)
+			return
+		}
+
+		commons.Log.Info("Trusted the jobs matching ", describeConditions(policy), " to read ", environment.Name)
+		commons.Log.Info("Set $", internal.OIDC_POLICY_ENV, " to ", policy.ID, " in the pipeline to exchange its ID tokens")
+	},
+}
+
+// trustPoliciesDeleteCmd represents the trust-policies delete command
+var trustPoliciesDeleteCmd = &cobra.Command{
+	Use:   "delete [name]",
+	Short: "Delete a trust policy",
+	Long:  `This command deletes the trust policy, and revokes the environment tokens issued through it.`,
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+
+		orgID := getOrganisationID()
+		policy := getTrustPolicy(orgID, args[0])
+
+		confirm(fmt.Sprintf("Delete %s", policy.Name))
+
+		revoked, err := internal.DeleteTrustPolicy(commons.DefaultContext, commons.HTTPClient, orgID, policy.ID)
+		if err != nil {
+			commons.Log.Debug(err)
+			commons.Log.Fatal("Failed to delete the trust policy: ", err)
+		}
+
+		if outputFormat == "json" {
+			printOutput(map[string]int{"revoked_tokens": revoked}, nil, nil)
+			return
+		}
+
+		commons.Log.Info("Deleted ", policy.Name, " and revoked ", revoked, " environment tokens")
+	},
+}
+
+// Fetches a trust policy of the organisation by its name or ID.
+func getTrustPolicy(orgID, name string) *federation.TrustPolicy {
+
+	list, err := internal.ListTrustPolicies(commons.DefaultContext, commons.HTTPClient, orgID)
+	if err != nil {
+		commons.Log.Debug(err)
+		commons.Log.Fatal("Failed to fetch the trust policies: ", err)
+	}
+
+	for _, item := range list {
+		if item.Name == name || item.ID == name {
+			return &item
+		}
+	}
+
+	commons.Log.Fatal("Trust policy not found: ", name)
+	return nil
+}
+
+func describeConditions(policy *federation.TrustPolicy) string {
+
+	var conditions []string
+	for _, item := range [][2]string{
+		{"repository", policy.Repository},
+		{"branch", policy.Branch},
+		{"environment", policy.Environment},
+		{"repository_owner_id", policy.RepositoryOwnerID},
+		{"repository_id", policy.RepositoryID},
+	} {
+		if item[1] != "" {
+			conditions = append(conditions, item[0]+"="+item[1])
+		}
+	}
+
+	var claims []string
+	for key, pattern := range policy.Claims {
+		claims = append(claims, key+"="+pattern)
+	}
+	sort.Strings(claims)
+
+	return strings.Join(append(conditions, claims...), ", ")
+}
+
+// ID token of the CI job the command runs in, if any.
+var ciIDToken string
+
+// Exchanges the ID token of the CI job the command runs in for a short-lived environment token,
+// unless a token was passed with --token, or the CLI is logged in, like with a service account.
+// The ID token is kept either way, for tokens bound to the job's identity.
+func useCIIdentity() {
+
+	if auth.IsLoggedIn() {
+		return
+	}
+
+	idToken, err := internal.GetCIIDToken(commons.DefaultContext)
+	if err != nil {
+		commons.Log.Debug(err)
+		commons.Log.Fatal("Failed to fetch the ID token of your CI job")
+	}
+
+	if idToken == "" {
+		return
+	}
+
+	//	Sent along with the environment token, in case it's bound to the job's identity.
+	ciIDToken = idToken
+
+	if XTokenHeader != "" {
+		return
+	}
+
+	client := clients.NewHTTPClient(&clients.HTTPConfig{
+		BaseURL: clients.API + "/v1",
+		Logger:  commons.Log,
+	})
+
+	result, err := internal.ExchangeIDToken(commons.DefaultContext, client, idToken)
+	if err != nil {
+		commons.Log.Debug(err)
+		commons.Log.Fatal("Failed to exchange the ID token of your CI job: ", err)
+	}
+
+	commons.Log.Debug("Exchanged the ID token of your CI job for an environment token valid until ", result.ExpiresAt)
+
+	XTokenHeader = result.Token
+}
+
+func init() {
+	rootCmd.AddCommand(trustPoliciesCmd)
+	trustPoliciesCmd.AddCommand(trustPoliciesListCmd, trustPoliciesCreateCmd, trustPoliciesDeleteCmd)
+
+	trustPoliciesCmd.PersistentFlags().StringVarP(&organisationID, "organisation", "w", "", "Your envsecrets organisation; defaults to the one of your current project")
+	trustPoliciesCmd.PersistentFlags().StringVarP(&projectID, "project", "p", "", "Name or ID of your envsecrets project; defaults to your current project")
+	addOutputFlag(trustPoliciesCmd)
+
+	trustPoliciesCreateCmd.Flags().StringVarP(&environmentName, "env", "e", "", "Environment whose secrets the pipeline can read")
+	trustPoliciesCreateCmd.Flags().StringVar(&trustPolicyProvider, "provider", string(federation.GitHubProvider), "CI provider: github, gitlab or generic")
+	trustPoliciesCreateCmd.Flags().StringVar(&trustPolicyIssuer, "issuer", "", "Issuer of the ID tokens; defaults to the one of github or gitlab")
+	trustPoliciesCreateCmd.Flags().StringVar(&trustPolicyAudience, "audience", "", "Audience of the ID tokens; defaults to "+federation.DEFAULT_AUDIENCE)
+	trustPoliciesCreateCmd.Flags().StringVar(&trustPolicyRepository, "repository", "", "Repository of the job, like acme/api")
+	trustPoliciesCreateCmd.Flags().StringVar(&trustPolicyRepositoryOwnerID, "repository-owner-id", "", "ID of the owner of the repository, like GitHub's repository_owner_id claim")
+	trustPoliciesCreateCmd.Flags().StringVar(&trustPolicyRepositoryID, "repository-id", "", "ID of the repository, like GitHub's repository_id claim")
+	trustPoliciesCreateCmd.Flags().StringVar(&trustPolicyBranch, "branch", "", "Branch of the job, like main")
+	trustPoliciesCreateCmd.Flags().StringVar(&trustPolicyEnvironment, "ci-environment", "", "Deployment environment of the job, like production")
+	trustPoliciesCreateCmd.Flags().StringToStringVar(&trustPolicyClaims, "claim", nil, "Any other claim of the ID token, like workflow=deploy")
+	trustPoliciesCreateCmd.Flags().StringVar(&trustPolicyTokenExpiry, "token-expiry", "", "Lifetime of the issued environment tokens, like 30m; defaults to 15 minutes")
+	trustPoliciesCreateCmd.Flags().BoolVarP(&yes, "yes", "y", false, "Allow the server to decrypt your organisation's secrets without confirmation")
+
+	trustPoliciesDeleteCmd.Flags().BoolVarP(&yes, "yes", "y", false, "Skip the confirmation")
+}
@@ -1,10 +1,34 @@
 package cmd
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/envsecrets/envsecrets/cli/auth"
+	"github.com/envsecrets/envsecrets/cli/cmd/login"
 	"github.com/envsecrets/envsecrets/cli/commons"
+	"github.com/envsecrets/envsecrets/cli/config"
+	configCommons "github.com/envsecrets/envsecrets/cli/config/commons"
+	projectConfig "github.com/envsecrets/envsecrets/cli/config/project"
 	"github.com/envsecrets/envsecrets/internal/keys"
+	"github.com/envsecrets/envsecrets/internal/memberships"
+	"github.com/envsecrets/envsecrets/internal/organisations"
+	"github.com/envsecrets/envsecrets/internal/projects"
+	"github.com/manifoldco/promptui"
+	"github.com/spf13/cobra"
 )
 
+var outputFormat string
+
+// All names entered by the user must be slugs.
+var slugRegex = regexp.MustCompile(`^[a-z0-9]+(?:-[a-z0-9]+)*$`)
+
 func Encrypt() {
 
 	if commons.Secret.EnvID == "" {
@@ -60,3 +84,207 @@ func DecryptAndDecode() {
 		commons.Log.Fatal("Failed to decode the secret")
 	}
 }
+
+// Fetches the organisation passed with --organisation, either by its name or ID.
+// Defaults to the organisation of the project configured in the current directory.
+func getOrganisationID() string {
+
+	if organisationID != "" {
+
+		orgs, err := organisations.GetService().List(commons.DefaultContext, commons.GQLClient.GQLClient)
+		if err != nil {
+			commons.Log.Debug(err)
+			commons.Log.Fatal("Failed to fetch your organisations")
+		}
+
+		for _, item := range *orgs {
+			if item.ID == organisationID || item.Name == organisationID {
+				return item.ID
+			}
+		}
+
+		commons.Log.Fatal("Organisation not found: ", organisationID)
+	}
+
+	project, err := projects.GetService().Get(commons.DefaultContext, commons.GQLClient.GQLClient, loadProjectConfig().ProjectID)
+	if err != nil {
+		commons.Log.Debug(err)
+		commons.Log.Fatal("Failed to fetch your project")
+	}
+
+	return project.OrgID
+}
+
+// Fetches the project passed with --project, either by its name or ID.
+// Defaults to the project configured in the current directory.
+func getProjectID() string {
+
+	if projectID == "" {
+		return loadProjectConfig().ProjectID
+	}
+
+	list, err := projects.GetService().List(commons.DefaultContext, commons.GQLClient.GQLClient, &projects.ListOptions{
+		OrgID: getOrganisationID(),
+	})
+	if err != nil {
+		commons.Log.Debug(err)
+		commons.Log.Fatal("Failed to fetch your projects")
+	}
+
+	for _, item := range list {
+		if item.ID == projectID || item.Name == projectID {
+			return item.ID
+		}
+	}
+
+	commons.Log.Fatal("Project not found: ", projectID)
+	return ""
+}
+
+// Loads the project config of the current directory.
+func loadProjectConfig() *configCommons.Project {
+
+	if commons.ProjectConfig != nil {
+		return commons.ProjectConfig
+	}
+
+	config, err := config.GetService().Load(configCommons.ProjectConfig)
+	if err != nil {
+		commons.Log.Debug(err)
+		commons.Log.Fatal("Either pass your organisation and project using flags or run `envs init` first")
+	}
+
+	commons.ProjectConfig = config.(*configCommons.Project)
+	return commons.ProjectConfig
+}
+
+func getPassword() string {
+
+	prompt := promptui.Prompt{
+		Label: "Your envsecrets account password",
+		Mask:  '*',
+	}
+
+	password, err := prompt.Run()
+	if err != nil {
+		os.Exit(1)
+	}
+
+	return password
+}
+
+// Replaces the copy of the organisation's key saved in the project config,
+// in case the organisation's key has been rotated since it was saved.
+func refreshProjectKey() {
+
+	if commons.AccountConfig == nil {
+		return
+	}
+
+	if commons.ProjectConfig == nil {
+		config, err := config.GetService().Load(configCommons.ProjectConfig)
+		if err != nil {
+			return
+		}
+		commons.ProjectConfig = config.(*configCommons.Project)
+	}
+
+	project, err := projects.GetService().Get(commons.DefaultContext, commons.GQLClient.GQLClient, commons.ProjectConfig.ProjectID)
+	if err != nil {
+		commons.Log.Debug(err)
+		return
+	}
+
+	key, err := memberships.GetKey(commons.DefaultContext, commons.GQLClient.GQLClient, &memberships.GetKeyOptions{
+		OrgID:  project.OrgID,
+		UserID: commons.AccountConfig.User.ID,
+	})
+	if err != nil {
+		commons.Log.Debug(err)
+		return
+	}
+
+	if bytes.Equal(key, commons.ProjectConfig.Key) {
+		return
+	}
+
+	commons.Log.Debug("Updating the organisation's key in the project config")
+
+	commons.ProjectConfig.Key = key
+	if err := projectConfig.Save(commons.ProjectConfig); err != nil {
+		commons.Log.Debug(err)
+		commons.Log.Warn("Failed to save the new encryption key; run `envs init` again")
+	}
+}
+
+// Registers the --output flag on a command and all of its subcommands.
+func addOutputFlag(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", "text", "Output format; either text or json")
+}
+
+// Prints the result of a command.
+// In JSON output, the data is printed as it is, for scripting.
+// Otherwise, the rows are printed as a table under the headers.
+func printOutput(data interface{}, headers []string, rows [][]string) {
+
+	if outputFormat == "json" {
+		result, err := json.MarshalIndent(data, "", "  ")
+		if err != nil {
+			commons.Log.Debug(err)
+			commons.Log.Fatal("Failed to marshal the output")
+		}
+
+		fmt.Println(string(result))
+		return
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(writer, strings.Join(headers, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(writer, strings.Join(row, "\t"))
+	}
+	writer.Flush()
+}
+
+// Asks the user to confirm a destructive action, unless --yes was passed.
+func confirm(label string) {
+
+	if yes {
+		return
+	}
+
+	prompt := promptui.Prompt{
+		Label:     label,
+		IsConfirm: true,
+	}
+
+	if _, err := prompt.Run(); err != nil {
+		os.Exit(1)
+	}
+}
+
+// Logs the user in first, if they are not already authenticated.
+// Used as the persistent pre-run of the command trees which require an account.
+func authenticate(cmd *cobra.Command, args []string) error {
+
+	//	Run the root command's pre-run first,
+	//	since cobra only runs the closest persistent pre-run.
+	if err := rootCmd.PersistentPreRunE(cmd, args); err != nil {
+		return err
+	}
+
+	if !auth.IsLoggedIn() {
+		login.Cmd.PreRunE(cmd, args)
+		login.Cmd.Run(cmd, args)
+	}
+
+	return nil
+}
+
+func validateSlug(input string) error {
+	if len(slugRegex.FindAllString(input, -1)) == 0 {
+		return errors.New("should be a slug; example: my-new-idea")
+	}
+
+	return nil
+}
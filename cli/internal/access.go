@@ -0,0 +1,78 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+
+	"github.com/envsecrets/envsecrets/cli/clients"
+	"github.com/envsecrets/envsecrets/cli/commons"
+	"github.com/envsecrets/envsecrets/internal/access"
+	"github.com/envsecrets/envsecrets/internal/context"
+)
+
+// Requests time-boxed access to read the secrets of an environment.
+func RequestAccess(ctx context.ServiceContext, client *clients.HTTPClient, envID, duration, reason string) (*access.Grant, error) {
+
+	body, err := json.Marshal(map[string]string{
+		"env_id":   envID,
+		"duration": duration,
+		"reason":   reason,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(commons.DefaultContext, http.MethodPost, clients.API+"/v1/access", bytes.NewBuffer(body))
+	if err != nil {
+		return nil, err
+	}
+
+	var result access.Grant
+	if err := run(client, req, &result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// Fetches the access grants of an organisation visible to the user.
+func ListAccessGrants(ctx context.ServiceContext, client *clients.HTTPClient, orgID string, status access.Status) ([]access.Grant, error) {
+
+	req, err := http.NewRequestWithContext(commons.DefaultContext, http.MethodGet, clients.API+"/v1/access", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	//	Initialize the query values.
+	query := req.URL.Query()
+	query.Set("org_id", orgID)
+	if status != "" {
+		query.Set("status", string(status))
+	}
+
+	req.URL.RawQuery = query.Encode()
+
+	var result []access.Grant
+	if err := run(client, req, &result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// Approves, rejects or revokes an access grant.
+func ReviewAccessGrant(ctx context.ServiceContext, client *clients.HTTPClient, id, action string) (*access.Grant, error) {
+
+	req, err := http.NewRequestWithContext(commons.DefaultContext, http.MethodPost, clients.API+"/v1/access/"+id+"/"+action, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var result access.Grant
+	if err := run(client, req, &result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
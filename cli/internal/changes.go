@@ -0,0 +1,141 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/envsecrets/envsecrets/cli/clients"
+	"github.com/envsecrets/envsecrets/cli/commons"
+	"github.com/envsecrets/envsecrets/internal/changes"
+	"github.com/envsecrets/envsecrets/internal/context"
+)
+
+// Proposes changes to the secrets of a protected environment.
+func CreateChangeRequest(ctx context.ServiceContext, client *clients.HTTPClient, envID string, options *changes.CreateOptions) (*changes.ChangeRequest, error) {
+
+	body, err := json.Marshal(options)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(commons.DefaultContext, http.MethodPost, clients.API+"/v1/environments/"+envID+"/changes", bytes.NewBuffer(body))
+	if err != nil {
+		return nil, err
+	}
+
+	var result changes.ChangeRequest
+	if err := run(client, req, &result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// Fetches the change requests of an environment.
+func ListChangeRequests(ctx context.ServiceContext, client *clients.HTTPClient, envID string, status changes.Status) ([]changes.ChangeRequest, error) {
+
+	req, err := http.NewRequestWithContext(commons.DefaultContext, http.MethodGet, clients.API+"/v1/environments/"+envID+"/changes", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if status != "" {
+		query := req.URL.Query()
+		query.Set("status", string(status))
+		req.URL.RawQuery = query.Encode()
+	}
+
+	var result []changes.ChangeRequest
+	if err := run(client, req, &result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// Fetches a change request along with its encrypted data.
+func GetChangeRequest(ctx context.ServiceContext, client *clients.HTTPClient, id string) (*changes.ChangeRequest, error) {
+
+	req, err := http.NewRequestWithContext(commons.DefaultContext, http.MethodGet, clients.API+"/v1/changes/"+id, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var result changes.ChangeRequest
+	if err := run(client, req, &result); err != nil {
+		return nil, err
+	}
+
+	result.Data.MarkAllEncoded()
+	return &result, nil
+}
+
+// Approves or rejects a change request.
+// The password is only used to sync the secrets once the change is applied.
+func ReviewChangeRequest(ctx context.ServiceContext, client *clients.HTTPClient, id string, decision changes.Decision, comment, password string) (*changes.ReviewResponse, error) {
+
+	body, err := json.Marshal(map[string]string{
+		"comment":  comment,
+		"password": password,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(commons.DefaultContext, http.MethodPost, clients.API+"/v1/changes/"+id+"/"+string(decision), bytes.NewBuffer(body))
+	if err != nil {
+		return nil, err
+	}
+
+	var result changes.ReviewResponse
+	if err := run(client, req, &result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// Turns the protected mode of an environment on or off.
+func ProtectEnvironment(ctx context.ServiceContext, client *clients.HTTPClient, envID string, protected bool, approvals int) error {
+
+	body, err := json.Marshal(map[string]interface{}{
+		"protected":          protected,
+		"required_approvals": approvals,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(commons.DefaultContext, http.MethodPut, clients.API+"/v1/environments/"+envID+"/protection", bytes.NewBuffer(body))
+	if err != nil {
+		return err
+	}
+
+	return run(client, req, nil)
+}
+
+// Runs the request and unmarshals the data of the API response into the result, if any.
+func run(client *clients.HTTPClient, req *http.Request, result interface{}) error {
+
+	var response clients.APIResponse
+	if err := client.Run(commons.DefaultContext, req, &response); err != nil {
+		return err
+	}
+
+	if response.Error != "" {
+		return errors.New(response.Error)
+	}
+
+	if result == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(response.Data)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, result)
+}
@@ -3,6 +3,7 @@ package internal
 type GetValuesOptions struct {
 	EnvID   string
 	Token   string
+	IDToken string
 	Key     *string
 	Version *int
 }
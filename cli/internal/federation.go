@@ -0,0 +1,199 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+
+	"github.com/envsecrets/envsecrets/cli/clients"
+	"github.com/envsecrets/envsecrets/cli/commons"
+	"github.com/envsecrets/envsecrets/internal/context"
+	"github.com/envsecrets/envsecrets/internal/federation"
+)
+
+const (
+
+	//	ID token passed by the pipeline itself, like GitLab's `id_tokens` or any generic issuer's.
+	ID_TOKEN_ENV = "ENVS_ID_TOKEN"
+
+	//	Environment to exchange the ID token for.
+	OIDC_ENV_ENV = "ENVS_OIDC_ENV"
+
+	//	Trust policy to exchange the ID token with, instead of, or along with, the environment.
+	OIDC_POLICY_ENV = "ENVS_OIDC_POLICY"
+
+	//	Audience to request GitHub Actions' ID tokens for.
+	OIDC_AUDIENCE_ENV = "ENVS_OIDC_AUDIENCE"
+
+	//	Set by GitHub Actions in jobs with the `id-token: write` permission.
+	GITHUB_REQUEST_URL_ENV   = "ACTIONS_ID_TOKEN_REQUEST_URL"
+	GITHUB_REQUEST_TOKEN_ENV = "ACTIONS_ID_TOKEN_REQUEST_TOKEN"
+)
+
+// Fetches the ID token of the CI job the CLI is running in.
+// Returns an empty string outside of CI, or when the job isn't allowed to request ID tokens.
+func GetCIIDToken(ctx context.ServiceContext) (string, error) {
+
+	if token := os.Getenv(ID_TOKEN_ENV); token != "" {
+		return token, nil
+	}
+
+	requestURL := os.Getenv(GITHUB_REQUEST_URL_ENV)
+	requestToken := os.Getenv(GITHUB_REQUEST_TOKEN_ENV)
+	if requestURL == "" || requestToken == "" {
+		return "", nil
+	}
+
+	audience := os.Getenv(OIDC_AUDIENCE_ENV)
+	if audience == "" {
+		audience = federation.DEFAULT_AUDIENCE
+	}
+
+	parsed, err := url.Parse(requestURL)
+	if err != nil {
+		return "", err
+	}
+
+	query := parsed.Query()
+	query.Set("audience", audience)
+	parsed.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, parsed.String(), nil)
+	if err != nil {
+		return "", err
+	}
+
+	req.Header.Set("Authorization", "bearer "+requestToken)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to request an ID token from GitHub Actions: %s", resp.Status)
+	}
+
+	var result struct {
+		Value string `json:"value"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", err
+	}
+
+	if result.Value == "" {
+		return "", errors.New("GitHub Actions returned an empty ID token")
+	}
+
+	return result.Value, nil
+}
+
+// Exchanges the ID token of a CI job for a short-lived environment token.
+func ExchangeIDToken(ctx context.ServiceContext, client *clients.HTTPClient, idToken string) (*federation.ExchangeResponse, error) {
+
+	options := federation.ExchangeOptions{
+		IDToken:  idToken,
+		EnvID:    os.Getenv(OIDC_ENV_ENV),
+		PolicyID: os.Getenv(OIDC_POLICY_ENV),
+	}
+
+	if options.EnvID == "" && options.PolicyID == "" {
+		return nil, errors.New("set $" + OIDC_ENV_ENV + " to the ID of the environment, or $" + OIDC_POLICY_ENV + " to the ID of the trust policy")
+	}
+
+	body, err := json.Marshal(&options)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(commons.DefaultContext, http.MethodPost, clients.API+"/v1/tokens/oidc", bytes.NewBuffer(body))
+	if err != nil {
+		return nil, err
+	}
+
+	var result federation.ExchangeResponse
+	if err := run(client, req, &result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// Fetches the trust policies of an organisation.
+func ListTrustPolicies(ctx context.ServiceContext, client *clients.HTTPClient, orgID string) ([]federation.TrustPolicy, error) {
+
+	req, err := http.NewRequestWithContext(commons.DefaultContext, http.MethodGet, clients.API+"/v1/organisations/"+orgID+"/trust-policies", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []federation.TrustPolicy
+	if err := run(client, req, &result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+type CreateTrustPolicyOptions struct {
+	EnvID       string              `json:"env_id"`
+	Name        string              `json:"name"`
+	Provider    federation.Provider `json:"provider"`
+	Issuer      string              `json:"issuer,omitempty"`
+	Audience    string              `json:"audience,omitempty"`
+	Repository  string              `json:"repository,omitempty"`
+	Branch      string              `json:"branch,omitempty"`
+	Environment string              `json:"environment,omitempty"`
+	Claims      map[string]string   `json:"claims,omitempty"`
+	TokenExpiry string              `json:"token_expiry,omitempty"`
+	Password    string              `json:"password"`
+
+	RepositoryOwnerID string `json:"repository_owner_id,omitempty"`
+	RepositoryID      string `json:"repository_id,omitempty"`
+
+	AllowServerDecryption bool `json:"allow_server_decryption"`
+}
+
+// Creates a trust policy for an environment of the organisation.
+func CreateTrustPolicy(ctx context.ServiceContext, client *clients.HTTPClient, orgID string, options *CreateTrustPolicyOptions) (*federation.TrustPolicy, error) {
+
+	body, err := json.Marshal(options)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(commons.DefaultContext, http.MethodPost, clients.API+"/v1/organisations/"+orgID+"/trust-policies", bytes.NewBuffer(body))
+	if err != nil {
+		return nil, err
+	}
+
+	var result federation.TrustPolicy
+	if err := run(client, req, &result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// Deletes a trust policy, and returns the number of environment tokens revoked with it.
+func DeleteTrustPolicy(ctx context.ServiceContext, client *clients.HTTPClient, orgID, id string) (int, error) {
+
+	req, err := http.NewRequestWithContext(commons.DefaultContext, http.MethodDelete, clients.API+"/v1/organisations/"+orgID+"/trust-policies/"+id, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	var result struct {
+		RevokedTokens int `json:"revoked_tokens"`
+	}
+	if err := run(client, req, &result); err != nil {
+		return 0, err
+	}
+
+	return result.RevokedTokens, nil
+}
@@ -0,0 +1,76 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/envsecrets/envsecrets/cli/clients"
+	"github.com/envsecrets/envsecrets/cli/commons"
+	"github.com/envsecrets/envsecrets/internal/context"
+	"github.com/envsecrets/envsecrets/internal/invites"
+)
+
+// Fetches the invitees of the organisation who are waiting for the organisation's key.
+func ListPendingGrants(ctx context.ServiceContext, client *clients.HTTPClient, orgID string) ([]invites.PendingGrant, error) {
+
+	req, err := http.NewRequestWithContext(commons.DefaultContext, http.MethodGet, clients.API+"/v1/invites/pending-grants", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	//	Initialize the query values.
+	query := req.URL.Query()
+	query.Set("org_id", orgID)
+
+	req.URL.RawQuery = query.Encode()
+
+	var response clients.APIResponse
+	if err := client.Run(commons.DefaultContext, req, &response); err != nil {
+		return nil, err
+	}
+
+	if response.Error != "" {
+		return nil, errors.New(response.Error)
+	}
+
+	data, err := json.Marshal(response.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []invites.PendingGrant
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// Saves the copy of the organisation's key, sealed with the invitee's public key.
+func Grant(ctx context.ServiceContext, client *clients.HTTPClient, inviteID, key string) error {
+
+	body, err := json.Marshal(map[string]string{
+		"key": key,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(commons.DefaultContext, http.MethodPost, clients.API+"/v1/invites/"+inviteID+"/grant", bytes.NewBuffer(body))
+	if err != nil {
+		return err
+	}
+
+	var response clients.APIResponse
+	if err := client.Run(commons.DefaultContext, req, &response); err != nil {
+		return err
+	}
+
+	if response.Error != "" {
+		return errors.New(response.Error)
+	}
+
+	return nil
+}
@@ -32,14 +32,25 @@ func GetSecret(ctx context.ServiceContext, client *clients.HTTPClient, options *
 	//	If the environment token is passed,
 	//	create a new HTTP client and attach it in the header.
 	if options.Token != "" {
-		client = clients.NewHTTPClient(&clients.HTTPConfig{
-			BaseURL: clients.API + "/v1",
-			CustomHeaders: []clients.CustomHeader{
-				{
-					Key:   string(clients.TokenHeader),
-					Value: options.Token,
-				},
+
+		headers := []clients.CustomHeader{
+			{
+				Key:   string(clients.TokenHeader),
+				Value: options.Token,
 			},
+		}
+
+		//	Tokens bound to a CI identity need a fresh ID token of the job with every request.
+		if options.IDToken != "" {
+			headers = append(headers, clients.CustomHeader{
+				Key:   string(clients.IDTokenHeader),
+				Value: options.IDToken,
+			})
+		}
+
+		client = clients.NewHTTPClient(&clients.HTTPConfig{
+			BaseURL:       clients.API + "/v1",
+			CustomHeaders: headers,
 		})
 
 	} else {
@@ -55,6 +66,11 @@ func GetSecret(ctx context.ServiceContext, client *clients.HTTPClient, options *
 		return nil, err
 	}
 
+	//	Keep the reason, since the token itself is valid.
+	if response.Error == string(clients.ErrorTypeTokenRestricted) {
+		return nil, fmt.Errorf("%s: %s", response.Error, response.Message)
+	}
+
 	var data secretCommons.GetResponse
 	if err := utils.MapToStruct(response.Data, &data); err != nil {
 		return nil, err
@@ -0,0 +1,122 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+
+	"github.com/envsecrets/envsecrets/cli/clients"
+	"github.com/envsecrets/envsecrets/cli/commons"
+	"github.com/envsecrets/envsecrets/internal/context"
+	"github.com/envsecrets/envsecrets/internal/serviceaccounts"
+)
+
+// Fetches the service accounts of an organisation.
+func ListServiceAccounts(ctx context.ServiceContext, client *clients.HTTPClient, orgID string) ([]serviceaccounts.ServiceAccount, error) {
+
+	req, err := http.NewRequestWithContext(commons.DefaultContext, http.MethodGet, clients.API+"/v1/organisations/"+orgID+"/service-accounts", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []serviceaccounts.ServiceAccount
+	if err := run(client, req, &result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+type CreateServiceAccountOptions struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	RoleID      string `json:"role_id"`
+	Expiry      string `json:"expiry,omitempty"`
+	Password    string `json:"password"`
+}
+
+// Creates a service account in the organisation, and returns it along with its credential.
+func CreateServiceAccount(ctx context.ServiceContext, client *clients.HTTPClient, orgID string, options *CreateServiceAccountOptions) (*serviceaccounts.CreateResponse, error) {
+
+	body, err := json.Marshal(options)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(commons.DefaultContext, http.MethodPost, clients.API+"/v1/organisations/"+orgID+"/service-accounts", bytes.NewBuffer(body))
+	if err != nil {
+		return nil, err
+	}
+
+	var result serviceaccounts.CreateResponse
+	if err := run(client, req, &result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// Rotates the credential of a service account, and returns the new one.
+func RotateServiceAccount(ctx context.ServiceContext, client *clients.HTTPClient, orgID, id, expiry, password string) (*serviceaccounts.RotateResponse, error) {
+
+	body, err := json.Marshal(map[string]string{
+		"expiry":   expiry,
+		"password": password,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(commons.DefaultContext, http.MethodPost, clients.API+"/v1/organisations/"+orgID+"/service-accounts/"+id+"/rotate", bytes.NewBuffer(body))
+	if err != nil {
+		return nil, err
+	}
+
+	var result serviceaccounts.RotateResponse
+	if err := run(client, req, &result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// Deletes a service account.
+func DeleteServiceAccount(ctx context.ServiceContext, client *clients.HTTPClient, orgID, id string) (*serviceaccounts.DeleteResponse, error) {
+
+	req, err := http.NewRequestWithContext(commons.DefaultContext, http.MethodDelete, clients.API+"/v1/organisations/"+orgID+"/service-accounts/"+id, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var result serviceaccounts.DeleteResponse
+	if err := run(client, req, &result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// Exchanges the personal access token of a service account for a session.
+func SigninServiceAccount(ctx context.ServiceContext, client *clients.HTTPClient, pat string) (map[string]interface{}, error) {
+
+	body, err := json.Marshal(map[string]string{
+		"pat": pat,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(commons.DefaultContext, http.MethodPost, clients.API+"/v1/auth/service-accounts/signin", bytes.NewBuffer(body))
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Session map[string]interface{} `json:"session"`
+	}
+	if err := run(client, req, &result); err != nil {
+		return nil, err
+	}
+
+	return result.Session, nil
+}
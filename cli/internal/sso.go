@@ -0,0 +1,206 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/url"
+
+	"github.com/envsecrets/envsecrets/cli/clients"
+	"github.com/envsecrets/envsecrets/cli/commons"
+	"github.com/envsecrets/envsecrets/internal/auth"
+	"github.com/envsecrets/envsecrets/internal/context"
+	"github.com/envsecrets/envsecrets/internal/sso"
+)
+
+// Fetches the identity provider configured for the email's domain.
+func GetSSOConnection(ctx context.ServiceContext, client *clients.HTTPClient, email string) (*sso.Connection, error) {
+
+	req, err := http.NewRequestWithContext(commons.DefaultContext, http.MethodGet, clients.API+"/v1/auth/sso/connection", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	query := req.URL.Query()
+	query.Set("email", email)
+	req.URL.RawQuery = query.Encode()
+
+	var result sso.Connection
+	if err := run(client, req, &result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+type SSOSigninOptions struct {
+	OrgID        string `json:"org_id"`
+	Code         string `json:"code"`
+	CodeVerifier string `json:"code_verifier"`
+	RedirectURI  string `json:"redirect_uri"`
+	Nonce        string `json:"nonce"`
+	Password     string `json:"password,omitempty"`
+}
+
+// Exchanges the authorization code from the identity provider for a session.
+func SigninWithSSO(ctx context.ServiceContext, client *clients.HTTPClient, options *SSOSigninOptions) (*auth.SigninWithSSOResponse, error) {
+
+	body, err := json.Marshal(options)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(commons.DefaultContext, http.MethodPost, clients.API+"/v1/auth/sso/signin", bytes.NewBuffer(body))
+	if err != nil {
+		return nil, err
+	}
+
+	var result auth.SigninWithSSOResponse
+	if err := run(client, req, &result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// Generates the user's key pair, protected by their unlock passphrase.
+func SetPassphrase(ctx context.ServiceContext, client *clients.HTTPClient, passphrase string) error {
+
+	body, err := json.Marshal(map[string]string{
+		"passphrase": passphrase,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(commons.DefaultContext, http.MethodPost, clients.API+"/v1/auth/sso/passphrase", bytes.NewBuffer(body))
+	if err != nil {
+		return err
+	}
+
+	return run(client, req, nil)
+}
+
+// Fetches the single sign-on connection of an organisation.
+func GetOrganisationSSO(ctx context.ServiceContext, client *clients.HTTPClient, orgID string) (*sso.Connection, error) {
+
+	req, err := http.NewRequestWithContext(commons.DefaultContext, http.MethodGet, clients.API+"/v1/organisations/"+orgID+"/sso", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var result sso.Connection
+	if err := run(client, req, &result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// Creates or updates the single sign-on connection of an organisation.
+func SaveOrganisationSSO(ctx context.ServiceContext, client *clients.HTTPClient, orgID string, options *sso.SaveConnectionOptions) (*sso.Connection, error) {
+
+	body, err := json.Marshal(options)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(commons.DefaultContext, http.MethodPut, clients.API+"/v1/organisations/"+orgID+"/sso", bytes.NewBuffer(body))
+	if err != nil {
+		return nil, err
+	}
+
+	var result sso.Connection
+	if err := run(client, req, &result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// Verifies the ownership of one of the domains of an organisation's single sign-on connection.
+func VerifySSODomain(ctx context.ServiceContext, client *clients.HTTPClient, orgID, domain string) (*sso.Connection, error) {
+
+	req, err := http.NewRequestWithContext(commons.DefaultContext, http.MethodPost, clients.API+"/v1/organisations/"+orgID+"/sso/domains/"+url.PathEscape(domain)+"/verify", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var result sso.Connection
+	if err := run(client, req, &result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// Rotates the organisation's SCIM token, and returns the new one.
+func RotateSCIMToken(ctx context.ServiceContext, client *clients.HTTPClient, orgID string) (string, error) {
+
+	req, err := http.NewRequestWithContext(commons.DefaultContext, http.MethodPost, clients.API+"/v1/organisations/"+orgID+"/sso/scim-token", nil)
+	if err != nil {
+		return "", err
+	}
+
+	var result struct {
+		Token string `json:"token"`
+	}
+	if err := run(client, req, &result); err != nil {
+		return "", err
+	}
+
+	return result.Token, nil
+}
+
+// Fetches the users provisioned in an organisation by its identity provider.
+func ListSSOIdentities(ctx context.ServiceContext, client *clients.HTTPClient, orgID string) ([]sso.Identity, error) {
+
+	req, err := http.NewRequestWithContext(commons.DefaultContext, http.MethodGet, clients.API+"/v1/organisations/"+orgID+"/sso/identities", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []sso.Identity
+	if err := run(client, req, &result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// Fetches the groups provisioned in an organisation by its identity provider.
+func ListSSOGroups(ctx context.ServiceContext, client *clients.HTTPClient, orgID string) ([]sso.Group, error) {
+
+	req, err := http.NewRequestWithContext(commons.DefaultContext, http.MethodGet, clients.API+"/v1/organisations/"+orgID+"/sso/groups", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []sso.Group
+	if err := run(client, req, &result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// Maps a provisioned group to a role of the organisation.
+func MapSSOGroup(ctx context.ServiceContext, client *clients.HTTPClient, orgID, groupID string, options *sso.MapGroupOptions) (*sso.Group, error) {
+
+	body, err := json.Marshal(options)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(commons.DefaultContext, http.MethodPut, clients.API+"/v1/organisations/"+orgID+"/sso/groups/"+groupID+"/role", bytes.NewBuffer(body))
+	if err != nil {
+		return nil, err
+	}
+
+	var result sso.Group
+	if err := run(client, req, &result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
@@ -0,0 +1,21 @@
+package access
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	DEFAULT_DURATION = time.Hour
+
+	//	Grants are meant for incidents, not standing access.
+	MAX_DURATION = 24 * time.Hour
+)
+
+var (
+	ErrInvalidDuration = errors.New("duration must be between 1 minute and 24 hours")
+	ErrNotPending      = errors.New("access request has already been reviewed")
+	ErrNotApproved     = errors.New("access grant is not active")
+	ErrSelfApproval    = errors.New("you cannot approve your own access request")
+	ErrNotFound        = errors.New("access grant not found")
+)
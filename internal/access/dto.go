@@ -0,0 +1,86 @@
+package access
+
+import (
+	"time"
+)
+
+type Status string
+
+const (
+	PendingStatus  Status = "pending"
+	ApprovedStatus Status = "approved"
+	RejectedStatus Status = "rejected"
+	RevokedStatus  Status = "revoked"
+	ExpiredStatus  Status = "expired"
+)
+
+// Time-boxed access of a member to read the secrets of an environment.
+type Grant struct {
+	ID        string    `json:"id,omitempty"`
+	CreatedAt time.Time `json:"created_at,omitempty"`
+	UpdatedAt time.Time `json:"updated_at,omitempty"`
+	OrgID     string    `json:"org_id,omitempty"`
+	EnvID     string    `json:"env_id,omitempty"`
+
+	//	Member who requested the access.
+	UserID string `json:"user_id,omitempty"`
+	Reason string `json:"reason,omitempty"`
+
+	//	Requested duration of the access, in seconds.
+	Duration int `json:"duration,omitempty"`
+
+	Status     Status     `json:"status,omitempty"`
+	ReviewerID string     `json:"reviewer_id,omitempty"`
+	ApprovedAt *time.Time `json:"approved_at,omitempty"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+
+	Environment *struct {
+		Name string `json:"name,omitempty"`
+	} `json:"environment,omitempty"`
+
+	User *struct {
+		Email string `json:"email,omitempty"`
+	} `json:"user,omitempty"`
+}
+
+// Checks whether the grant currently allows access.
+func (g *Grant) IsActive() bool {
+	return g.Status == ApprovedStatus && g.ExpiresAt != nil && g.ExpiresAt.After(time.Now())
+}
+
+// Returns the details of the grant recorded in the audit logs.
+func (g *Grant) Metadata() map[string]interface{} {
+
+	result := map[string]interface{}{
+		"env_id":   g.EnvID,
+		"user_id":  g.UserID,
+		"reason":   g.Reason,
+		"duration": g.Duration,
+	}
+
+	if g.ExpiresAt != nil {
+		result["expires_at"] = g.ExpiresAt
+	}
+
+	return result
+}
+
+type CreateOptions struct {
+	OrgID    string
+	EnvID    string
+	UserID   string
+	Reason   string
+	Duration time.Duration
+}
+
+type ListOptions struct {
+	OrgID  string
+	EnvID  string
+	UserID string
+	Status Status
+}
+
+type ReviewOptions struct {
+	ID         string
+	ReviewerID string
+}
@@ -0,0 +1,5 @@
+package access
+
+func init() {
+	SetService(&DefaultService{})
+}
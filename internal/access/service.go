@@ -0,0 +1,289 @@
+package access
+
+import (
+	"time"
+
+	"github.com/envsecrets/envsecrets/internal/clients"
+	"github.com/envsecrets/envsecrets/internal/context"
+	"github.com/machinebox/graphql"
+)
+
+type Service interface {
+	Create(context.ServiceContext, *clients.GQLClient, *CreateOptions) (*Grant, error)
+	Get(context.ServiceContext, *clients.GQLClient, string) (*Grant, error)
+	List(context.ServiceContext, *clients.GQLClient, *ListOptions) ([]Grant, error)
+	Approve(context.ServiceContext, *clients.GQLClient, *ReviewOptions) (*Grant, error)
+	Reject(context.ServiceContext, *clients.GQLClient, *ReviewOptions) (*Grant, error)
+	Revoke(context.ServiceContext, *clients.GQLClient, *ReviewOptions) (*Grant, error)
+	Expire(context.ServiceContext, *clients.GQLClient) ([]Grant, error)
+	IsActive(context.ServiceContext, *clients.GQLClient, string, string) (bool, error)
+}
+
+type DefaultService struct{}
+
+const fields = `
+	id
+	created_at
+	updated_at
+	org_id
+	env_id
+	user_id
+	reason
+	duration
+	status
+	reviewer_id
+	approved_at
+	expires_at
+	environment {
+	  name
+	}
+	user {
+	  email
+	}
+`
+
+// Requests access to the secrets of an environment.
+// Access grants can only be inserted by the server.
+func (*DefaultService) Create(ctx context.ServiceContext, client *clients.GQLClient, options *CreateOptions) (*Grant, error) {
+
+	duration := options.Duration
+	if duration == 0 {
+		duration = DEFAULT_DURATION
+	}
+
+	if duration < time.Minute || duration > MAX_DURATION {
+		return nil, ErrInvalidDuration
+	}
+
+	req := graphql.NewRequest(`
+	mutation MyMutation($object: access_grants_insert_input!) {
+		insert_access_grants_one(object: $object) {` + fields + `}
+	  }
+	`)
+
+	req.Var("object", map[string]interface{}{
+		"org_id":   options.OrgID,
+		"env_id":   options.EnvID,
+		"user_id":  options.UserID,
+		"reason":   options.Reason,
+		"duration": int(duration.Seconds()),
+	})
+
+	var response struct {
+		Grant Grant `json:"insert_access_grants_one"`
+	}
+
+	if err := client.Do(ctx, req, &response); err != nil {
+		return nil, err
+	}
+
+	return &response.Grant, nil
+}
+
+// Fetches an access grant by its ID.
+func (*DefaultService) Get(ctx context.ServiceContext, client *clients.GQLClient, id string) (*Grant, error) {
+
+	req := graphql.NewRequest(`
+	query MyQuery($id: uuid!) {
+		access_grants_by_pk(id: $id) {` + fields + `}
+	  }
+	`)
+
+	req.Var("id", id)
+
+	var response struct {
+		Grant *Grant `json:"access_grants_by_pk"`
+	}
+
+	if err := client.Do(ctx, req, &response); err != nil {
+		return nil, err
+	}
+
+	if response.Grant == nil {
+		return nil, ErrNotFound
+	}
+
+	return response.Grant, nil
+}
+
+// Lists the access grants, latest first.
+func (*DefaultService) List(ctx context.ServiceContext, client *clients.GQLClient, options *ListOptions) ([]Grant, error) {
+
+	where := make(map[string]interface{})
+
+	if options.OrgID != "" {
+		where["org_id"] = map[string]interface{}{
+			"_eq": options.OrgID,
+		}
+	}
+
+	if options.EnvID != "" {
+		where["env_id"] = map[string]interface{}{
+			"_eq": options.EnvID,
+		}
+	}
+
+	if options.UserID != "" {
+		where["user_id"] = map[string]interface{}{
+			"_eq": options.UserID,
+		}
+	}
+
+	if options.Status != "" {
+		where["status"] = map[string]interface{}{
+			"_eq": options.Status,
+		}
+	}
+
+	req := graphql.NewRequest(`
+	query MyQuery($where: access_grants_bool_exp!) {
+		access_grants(where: $where, order_by: {created_at: desc}) {` + fields + `}
+	  }
+	`)
+
+	req.Var("where", where)
+
+	var response struct {
+		Grants []Grant `json:"access_grants"`
+	}
+
+	if err := client.Do(ctx, req, &response); err != nil {
+		return nil, err
+	}
+
+	return response.Grants, nil
+}
+
+// Approves a pending access request.
+// The access expires once the requested duration has passed since the approval.
+func (d *DefaultService) Approve(ctx context.ServiceContext, client *clients.GQLClient, options *ReviewOptions) (*Grant, error) {
+
+	grant, err := d.Get(ctx, client, options.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if grant.UserID == options.ReviewerID {
+		return nil, ErrSelfApproval
+	}
+
+	now := time.Now().UTC()
+	return update(ctx, client, options.ID, PendingStatus, map[string]interface{}{
+		"status":      ApprovedStatus,
+		"reviewer_id": options.ReviewerID,
+		"approved_at": now,
+		"expires_at":  now.Add(time.Duration(grant.Duration) * time.Second),
+	})
+}
+
+// Rejects a pending access request.
+func (*DefaultService) Reject(ctx context.ServiceContext, client *clients.GQLClient, options *ReviewOptions) (*Grant, error) {
+	return update(ctx, client, options.ID, PendingStatus, map[string]interface{}{
+		"status":      RejectedStatus,
+		"reviewer_id": options.ReviewerID,
+	})
+}
+
+// Ends an approved access grant before it expires.
+func (*DefaultService) Revoke(ctx context.ServiceContext, client *clients.GQLClient, options *ReviewOptions) (*Grant, error) {
+	return update(ctx, client, options.ID, ApprovedStatus, map[string]interface{}{
+		"status":     RevokedStatus,
+		"expires_at": time.Now().UTC(),
+	})
+}
+
+// Marks the approved grants which have passed their expiry as expired.
+// Access already ends at the expiry, since it is checked on every request;
+// this only records it.
+func (*DefaultService) Expire(ctx context.ServiceContext, client *clients.GQLClient) ([]Grant, error) {
+
+	req := graphql.NewRequest(`
+	mutation MyMutation($now: timestamptz!) {
+		update_access_grants(where: {status: {_eq: "approved"}, expires_at: {_lte: $now}}, _set: {status: "expired"}) {
+		  returning {` + fields + `}
+		}
+	  }
+	`)
+
+	req.Var("now", time.Now().UTC())
+
+	var response struct {
+		Result struct {
+			Returning []Grant `json:"returning"`
+		} `json:"update_access_grants"`
+	}
+
+	if err := client.Do(ctx, req, &response); err != nil {
+		return nil, err
+	}
+
+	return response.Result.Returning, nil
+}
+
+// Checks whether the user has an approved and unexpired access grant for the environment.
+func (*DefaultService) IsActive(ctx context.ServiceContext, client *clients.GQLClient, envID, userID string) (bool, error) {
+
+	req := graphql.NewRequest(`
+	query MyQuery($env_id: uuid!, $user_id: uuid!, $now: timestamptz!) {
+		access_grants_aggregate(where: {env_id: {_eq: $env_id}, user_id: {_eq: $user_id}, status: {_eq: "approved"}, expires_at: {_gt: $now}}) {
+		  aggregate {
+			count
+		  }
+		}
+	  }
+	`)
+
+	req.Var("env_id", envID)
+	req.Var("user_id", userID)
+	req.Var("now", time.Now().UTC())
+
+	var response struct {
+		Result struct {
+			Aggregate struct {
+				Count int `json:"count"`
+			} `json:"aggregate"`
+		} `json:"access_grants_aggregate"`
+	}
+
+	if err := client.Do(ctx, req, &response); err != nil {
+		return false, err
+	}
+
+	return response.Result.Aggregate.Count > 0, nil
+}
+
+// Updates the grant only if it is still in the expected status,
+// so that concurrent reviews can't overwrite each other.
+func update(ctx context.ServiceContext, client *clients.GQLClient, id string, from Status, set map[string]interface{}) (*Grant, error) {
+
+	req := graphql.NewRequest(`
+	mutation MyMutation($id: uuid!, $from: String!, $set: access_grants_set_input!) {
+		update_access_grants(where: {id: {_eq: $id}, status: {_eq: $from}}, _set: $set) {
+		  returning {` + fields + `}
+		}
+	  }
+	`)
+
+	req.Var("id", id)
+	req.Var("from", from)
+	req.Var("set", set)
+
+	var response struct {
+		Result struct {
+			Returning []Grant `json:"returning"`
+		} `json:"update_access_grants"`
+	}
+
+	if err := client.Do(ctx, req, &response); err != nil {
+		return nil, err
+	}
+
+	if len(response.Result.Returning) == 0 {
+		if from == ApprovedStatus {
+			return nil, ErrNotApproved
+		}
+		return nil, ErrNotPending
+	}
+
+	return &response.Result.Returning[0], nil
+}
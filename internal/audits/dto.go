@@ -0,0 +1,83 @@
+package audits
+
+import (
+	"time"
+)
+
+type Action string
+
+const (
+	MemberRemovedAction Action = "member.removed"
+	OrgKeyRotatedAction Action = "organisation.key_rotated"
+
+	ChangeRequestedAction Action = "secrets.change_requested"
+	ChangeApprovedAction  Action = "secrets.change_approved"
+	ChangeRejectedAction  Action = "secrets.change_rejected"
+
+	AccessRequestedAction Action = "access.requested"
+	AccessApprovedAction  Action = "access.approved"
+	AccessRejectedAction  Action = "access.rejected"
+	AccessRevokedAction   Action = "access.revoked"
+	AccessExpiredAction   Action = "access.expired"
+
+	SSOConfiguredAction       Action = "sso.configured"
+	SSODomainVerifiedAction   Action = "sso.domain_verified"
+	SCIMTokenRotatedAction    Action = "sso.scim_token_rotated"
+	SSOGroupMappedAction      Action = "sso.group_mapped"
+	MemberProvisionedAction   Action = "member.provisioned"
+	MemberDeprovisionedAction Action = "member.deprovisioned"
+
+	ServiceAccountCreatedAction Action = "service_account.created"
+	ServiceAccountRotatedAction Action = "service_account.rotated"
+	ServiceAccountDeletedAction Action = "service_account.deleted"
+
+	TrustPolicyCreatedAction Action = "trust_policy.created"
+	TrustPolicyDeletedAction Action = "trust_policy.deleted"
+	TokenExchangedAction     Action = "token.exchanged"
+
+	TokenRestrictionViolatedAction Action = "token.restriction_violated"
+)
+
+type EntityType string
+
+const (
+	UserEntity           EntityType = "user"
+	OrganisationEntity   EntityType = "organisation"
+	ChangeRequestEntity  EntityType = "change_request"
+	AccessGrantEntity    EntityType = "access_grant"
+	SSOConnectionEntity  EntityType = "sso_connection"
+	SSOGroupEntity       EntityType = "sso_group"
+	ServiceAccountEntity EntityType = "service_account"
+	TrustPolicyEntity    EntityType = "trust_policy"
+	TokenEntity          EntityType = "token"
+)
+
+type Log struct {
+	ID        string    `json:"id,omitempty"`
+	CreatedAt time.Time `json:"created_at,omitempty"`
+
+	OrgID string `json:"org_id,omitempty"`
+
+	//	User who performed the action.
+	UserID string `json:"user_id,omitempty"`
+
+	Action     Action                 `json:"action,omitempty"`
+	EntityType EntityType             `json:"entity_type,omitempty"`
+	EntityID   string                 `json:"entity_id,omitempty"`
+	Metadata   map[string]interface{} `json:"metadata,omitempty"`
+}
+
+type CreateOptions struct {
+	OrgID      string                 `json:"org_id"`
+	UserID     string                 `json:"user_id,omitempty"`
+	Action     Action                 `json:"action"`
+	EntityType EntityType             `json:"entity_type,omitempty"`
+	EntityID   string                 `json:"entity_id,omitempty"`
+	Metadata   map[string]interface{} `json:"metadata,omitempty"`
+}
+
+type ListOptions struct {
+	OrgID  string
+	Action Action
+	Limit  int
+}
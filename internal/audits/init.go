@@ -0,0 +1,5 @@
+package audits
+
+func init() {
+	SetService(&DefaultService{})
+}
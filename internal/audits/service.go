@@ -0,0 +1,89 @@
+package audits
+
+import (
+	"github.com/envsecrets/envsecrets/internal/clients"
+	"github.com/envsecrets/envsecrets/internal/context"
+	"github.com/machinebox/graphql"
+)
+
+type Service interface {
+	Create(context.ServiceContext, *clients.GQLClient, *CreateOptions) (*Log, error)
+	List(context.ServiceContext, *clients.GQLClient, *ListOptions) ([]Log, error)
+}
+
+type DefaultService struct{}
+
+// Records an action performed in the organisation.
+// Audit logs can only be inserted by the server.
+func (*DefaultService) Create(ctx context.ServiceContext, client *clients.GQLClient, options *CreateOptions) (*Log, error) {
+
+	req := graphql.NewRequest(`
+	mutation MyMutation($object: audit_logs_insert_input!) {
+		insert_audit_logs_one(object: $object) {
+		  id
+		  created_at
+		}
+	  }
+	`)
+
+	req.Var("object", options)
+
+	var response struct {
+		Log Log `json:"insert_audit_logs_one"`
+	}
+
+	if err := client.Do(ctx, req, &response); err != nil {
+		return nil, err
+	}
+
+	return &response.Log, nil
+}
+
+// Lists the latest audit logs of an organisation.
+func (*DefaultService) List(ctx context.ServiceContext, client *clients.GQLClient, options *ListOptions) ([]Log, error) {
+
+	where := map[string]interface{}{
+		"org_id": map[string]interface{}{
+			"_eq": options.OrgID,
+		},
+	}
+
+	if options.Action != "" {
+		where["action"] = map[string]interface{}{
+			"_eq": options.Action,
+		}
+	}
+
+	limit := options.Limit
+	if limit == 0 {
+		limit = 100
+	}
+
+	req := graphql.NewRequest(`
+	query MyQuery($where: audit_logs_bool_exp!, $limit: Int!) {
+		audit_logs(where: $where, limit: $limit, order_by: {created_at: desc}) {
+		  id
+		  created_at
+		  org_id
+		  user_id
+		  action
+		  entity_type
+		  entity_id
+		  metadata
+		}
+	  }
+	`)
+
+	req.Var("where", where)
+	req.Var("limit", limit)
+
+	var response struct {
+		Logs []Log `json:"audit_logs"`
+	}
+
+	if err := client.Do(ctx, req, &response); err != nil {
+		return nil, err
+	}
+
+	return response.Logs, nil
+}
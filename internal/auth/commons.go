@@ -1,5 +1,15 @@
 package auth
 
+import "time"
+
 type MFAType string
 
 const TOTP MFAType = "totp"
+
+// Lifetime of the personal access tokens issued to sign in users
+// who were authenticated by their identity provider.
+const PAT_EXPIRY = time.Minute
+
+// Minimum length of the unlock passphrase which protects the private key
+// of users who sign in through their identity provider.
+const MIN_PASSPHRASE_LENGTH = 8
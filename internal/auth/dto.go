@@ -3,6 +3,7 @@ package auth
 import (
 	"encoding/json"
 	"errors"
+	"time"
 
 	"github.com/envsecrets/envsecrets/internal/users"
 )
@@ -11,6 +12,18 @@ type SigninWithPATOptions struct {
 	PAT string `json:"personalAccessToken"`
 }
 
+type CreatePATOptions struct {
+	UserID   string
+	Expiry   time.Duration
+	Metadata map[string]interface{}
+}
+
+// Personal access token, which is only ever returned when created.
+type PAT struct {
+	ID    string
+	Token string
+}
+
 type SigninWithPasswordOptions struct {
 	Email    string `json:"email"`
 	Password string `json:"password"`
@@ -96,3 +109,11 @@ type DecryptKeysFromSessionOptions struct {
 type RefreshTokenOptions struct {
 	RefreshToken string `json:"refreshToken"`
 }
+
+type SigninWithSSOResponse struct {
+	Session map[string]interface{} `json:"session"`
+
+	//	Whether the user has already set their unlock passphrase,
+	//	i.e. generated their key pair.
+	HasKeys bool `json:"has_keys"`
+}
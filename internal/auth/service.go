@@ -2,12 +2,15 @@ package auth
 
 import (
 	"bytes"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/envsecrets/envsecrets/internal/clients"
 	"github.com/envsecrets/envsecrets/internal/context"
@@ -16,6 +19,8 @@ import (
 	"github.com/envsecrets/envsecrets/internal/nhost"
 	"github.com/envsecrets/envsecrets/internal/organisations"
 	"github.com/envsecrets/envsecrets/internal/users"
+	"github.com/envsecrets/envsecrets/utils"
+	"github.com/machinebox/graphql"
 )
 
 type Service interface {
@@ -24,6 +29,7 @@ type Service interface {
 	SigninWithMFA(context.ServiceContext, *clients.NhostClient, *SigninWithMFAOptions) (*SigninResponse, error)
 	SigninWithPassword(context.ServiceContext, *clients.NhostClient, *SigninWithPasswordOptions) (*SigninResponse, error)
 	SigninWithPAT(context.ServiceContext, *clients.NhostClient, *SigninWithPATOptions) (*SigninResponse, error)
+	SigninWithUserID(context.ServiceContext, *clients.NhostClient, *clients.GQLClient, string) (*SigninResponse, error)
 	RefreshToken(context.ServiceContext, *clients.NhostClient, *RefreshTokenOptions) (*RefreshTokenResponse, error)
 	DecryptKeysFromSession(context.ServiceContext, *clients.GQLClient, *DecryptKeysFromSessionOptions) (*keyCommons.Payload, error)
 }
@@ -153,6 +159,37 @@ func (*DefaultService) SigninWithPAT(ctx context.ServiceContext, client *clients
 	}, nil
 }
 
+// Issues a regular Nhost session for a user who has already been authenticated
+// by other means, i.e. their organisation's identity provider.
+// The GQL client must have admin privileges.
+//
+// --- Flow ---
+//
+//  1. Insert a personal access token for the user which expires within a minute.
+//     Nhost only stores the hash of the token.
+//  2. Sign in with it, which returns a session with its own refresh token.
+//  3. Delete the personal access token right away.
+func (d *DefaultService) SigninWithUserID(ctx context.ServiceContext, client *clients.NhostClient, gqlClient *clients.GQLClient, userID string) (*SigninResponse, error) {
+
+	pat, err := CreatePAT(ctx, gqlClient, &CreatePATOptions{
+		UserID: userID,
+		Expiry: PAT_EXPIRY,
+		Metadata: map[string]interface{}{
+			"source": "sso",
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	//	The token is only needed for this one sign in.
+	defer DeletePAT(ctx, gqlClient, pat.ID)
+
+	return d.SigninWithPAT(ctx, client, &SigninWithPATOptions{
+		PAT: pat.Token,
+	})
+}
+
 func (*DefaultService) RefreshToken(ctx context.ServiceContext, client *clients.NhostClient, options *RefreshTokenOptions) (*RefreshTokenResponse, error) {
 
 	body, err := json.Marshal(options)
@@ -288,3 +325,119 @@ func (*DefaultService) DecryptKeysFromSession(ctx context.ServiceContext, client
 
 	return pair, nil
 }
+
+// Inserts a personal access token for the user, which can be exchanged for a session.
+// Nhost only stores the hash of the token, so it can't be fetched again.
+// The GQL client must have admin privileges.
+func CreatePAT(ctx context.ServiceContext, client *clients.GQLClient, options *CreatePATOptions) (*PAT, error) {
+
+	token, object, err := NewPAT(options)
+	if err != nil {
+		return nil, err
+	}
+
+	req := graphql.NewRequest(`
+	mutation MyMutation($object: authRefreshTokens_insert_input!) {
+		insertAuthRefreshToken(object: $object) {
+			id
+		}
+	  }
+	`)
+
+	req.Var("object", object)
+
+	var response struct {
+		Token struct {
+			ID string `json:"id"`
+		} `json:"insertAuthRefreshToken"`
+	}
+
+	if err := client.Do(ctx, req, &response); err != nil {
+		return nil, err
+	}
+
+	return &PAT{
+		ID:    response.Token.ID,
+		Token: token,
+	}, nil
+}
+
+// Generates a personal access token for the user without inserting it.
+// Returns the token, and the authRefreshTokens_insert_input to insert it with,
+// for callers which insert it along with other changes in a single mutation.
+func NewPAT(options *CreatePATOptions) (string, map[string]interface{}, error) {
+
+	token, err := generateUUID()
+	if err != nil {
+		return "", nil, err
+	}
+
+	hash := sha256.Sum256([]byte(token))
+
+	return token, map[string]interface{}{
+		"userId":           options.UserID,
+		"type":             "pat",
+		"refreshTokenHash": "\\x" + hex.EncodeToString(hash[:]),
+		"expiresAt":        time.Now().Add(options.Expiry).UTC(),
+		"metadata":         options.Metadata,
+	}, nil
+}
+
+// Deletes a personal access token by its ID.
+// The GQL client must have admin privileges.
+func DeletePAT(ctx context.ServiceContext, client *clients.GQLClient, id string) error {
+
+	req := graphql.NewRequest(`
+	mutation MyMutation($id: uuid!) {
+		deleteAuthRefreshToken(id: $id) {
+			id
+		}
+	  }
+	`)
+
+	req.Var("id", id)
+
+	return client.Do(ctx, req, nil)
+}
+
+// Deletes all the refresh tokens of the user, including their personal access tokens,
+// which signs them out of every session once their access tokens expire.
+// The GQL client must have admin privileges.
+func RevokeRefreshTokens(ctx context.ServiceContext, client *clients.GQLClient, userID string) (int, error) {
+
+	req := graphql.NewRequest(`
+	mutation MyMutation($user_id: uuid!) {
+		deleteAuthRefreshTokens(where: {userId: {_eq: $user_id}}) {
+			affected_rows
+		}
+	  }
+	`)
+
+	req.Var("user_id", userID)
+
+	var response struct {
+		Tokens struct {
+			AffectedRows int `json:"affected_rows"`
+		} `json:"deleteAuthRefreshTokens"`
+	}
+
+	if err := client.Do(ctx, req, &response); err != nil {
+		return 0, err
+	}
+
+	return response.Tokens.AffectedRows, nil
+}
+
+// Generates a random version 4 UUID, the format Nhost expects personal access tokens in.
+func generateUUID() (string, error) {
+
+	b, err := utils.GenerateRandomBytes(16)
+	if err != nil {
+		return "", err
+	}
+
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
@@ -0,0 +1,12 @@
+package changes
+
+import "errors"
+
+var (
+	ErrNotPending   = errors.New("change request has already been reviewed")
+	ErrSelfApproval = errors.New("you cannot approve your own change request")
+	ErrAlreadyVoted = errors.New("you have already reviewed this change request")
+	ErrEmptyChange  = errors.New("change request does not change anything")
+	ErrNotProtected = errors.New("environment is not protected")
+	ErrNotFound     = errors.New("change request not found")
+)
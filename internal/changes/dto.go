@@ -0,0 +1,109 @@
+package changes
+
+import (
+	"time"
+
+	"github.com/envsecrets/envsecrets/internal/secrets/pkg/keypayload"
+)
+
+type Status string
+
+const (
+	PendingStatus  Status = "pending"
+	ApprovedStatus Status = "approved"
+	RejectedStatus Status = "rejected"
+)
+
+type Decision string
+
+const (
+	ApproveDecision Decision = "approve"
+	RejectDecision  Decision = "reject"
+)
+
+type Change string
+
+const (
+	AddedChange    Change = "added"
+	ModifiedChange Change = "modified"
+	RemovedChange  Change = "removed"
+)
+
+// Proposed secret changes of a protected environment.
+// The data is encrypted by the requester, so the diff only records which keys change.
+type ChangeRequest struct {
+	ID        string    `json:"id,omitempty"`
+	CreatedAt time.Time `json:"created_at,omitempty"`
+	UpdatedAt time.Time `json:"updated_at,omitempty"`
+	EnvID     string    `json:"env_id,omitempty"`
+
+	//	User who requested the change.
+	UserID string `json:"user_id,omitempty"`
+
+	//	Latest version of the secrets when the change was requested.
+	BaseVersion *int `json:"base_version,omitempty"`
+
+	Data    keypayload.KPMap  `json:"data,omitempty"`
+	Removed []string          `json:"removed,omitempty"`
+	Diff    map[string]Change `json:"diff,omitempty"`
+	Message string            `json:"message,omitempty"`
+
+	//	Sync the secrets with the environment's integrations once approved.
+	Sync bool `json:"sync,omitempty"`
+
+	Status Status `json:"status,omitempty"`
+
+	//	Version of the secrets created on approval.
+	Version *int `json:"version,omitempty"`
+
+	Reviews []Review `json:"reviews,omitempty"`
+}
+
+// Returns the number of approvals the change request has received.
+func (c *ChangeRequest) Approvals() int {
+	var count int
+	for _, item := range c.Reviews {
+		if item.Decision == ApproveDecision {
+			count++
+		}
+	}
+	return count
+}
+
+type Review struct {
+	ID              string    `json:"id,omitempty"`
+	CreatedAt       time.Time `json:"created_at,omitempty"`
+	ChangeRequestID string    `json:"change_request_id,omitempty"`
+	UserID          string    `json:"user_id,omitempty"`
+	Decision        Decision  `json:"decision,omitempty"`
+	Comment         string    `json:"comment,omitempty"`
+}
+
+type CreateOptions struct {
+	EnvID   string           `json:"env_id"`
+	UserID  string           `json:"user_id"`
+	Data    keypayload.KPMap `json:"data,omitempty"`
+	Removed []string         `json:"removed,omitempty"`
+	Message string           `json:"message,omitempty"`
+	Sync    bool             `json:"sync,omitempty"`
+}
+
+type ListOptions struct {
+	EnvID  string
+	Status Status
+}
+
+type ReviewOptions struct {
+	ID       string
+	UserID   string
+	Decision Decision
+	Comment  string
+}
+
+type ReviewResponse struct {
+	ChangeRequest *ChangeRequest `json:"change_request"`
+	Synced        bool           `json:"synced"`
+
+	//	Reason the approved change couldn't be synced, if any.
+	SyncError string `json:"sync_error,omitempty"`
+}
@@ -0,0 +1,5 @@
+package changes
+
+func init() {
+	SetService(&DefaultService{})
+}
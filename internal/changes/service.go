@@ -0,0 +1,410 @@
+package changes
+
+import (
+	"strings"
+
+	"github.com/envsecrets/envsecrets/internal/clients"
+	"github.com/envsecrets/envsecrets/internal/context"
+	"github.com/envsecrets/envsecrets/internal/environments"
+	"github.com/envsecrets/envsecrets/internal/secrets"
+	secretCommons "github.com/envsecrets/envsecrets/internal/secrets/commons"
+	"github.com/envsecrets/envsecrets/internal/secrets/pkg/keypayload"
+	"github.com/machinebox/graphql"
+)
+
+type Service interface {
+	Create(context.ServiceContext, *clients.GQLClient, *CreateOptions) (*ChangeRequest, error)
+	Get(context.ServiceContext, *clients.GQLClient, string) (*ChangeRequest, error)
+	List(context.ServiceContext, *clients.GQLClient, *ListOptions) ([]ChangeRequest, error)
+	Review(context.ServiceContext, *clients.GQLClient, *ReviewOptions) (*ChangeRequest, error)
+}
+
+type DefaultService struct{}
+
+// Saves the proposed changes of a protected environment without writing them.
+// Change requests can only be inserted by the server.
+//
+// --- Flow ---
+//
+//  1. Validate that the environment is protected.
+//  2. Compute the key level diff against the latest version of the secrets.
+//  3. Save the encrypted data along with the diff.
+func (*DefaultService) Create(ctx context.ServiceContext, client *clients.GQLClient, options *CreateOptions) (*ChangeRequest, error) {
+
+	environment, err := environments.GetService().Get(ctx, client, options.EnvID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !environment.Protected {
+		return nil, ErrNotProtected
+	}
+
+	latest, err := latestSecret(ctx, client, options.EnvID)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := make(map[string]Change)
+	for key := range options.Data {
+		if _, ok := latest.Data[key]; ok {
+			diff[key] = ModifiedChange
+		} else {
+			diff[key] = AddedChange
+		}
+	}
+
+	//	Removing a key which doesn't exist changes nothing.
+	removed := []string{}
+	for _, key := range options.Removed {
+		if _, ok := latest.Data[key]; ok {
+			diff[key] = RemovedChange
+			removed = append(removed, key)
+		}
+	}
+
+	if len(diff) == 0 {
+		return nil, ErrEmptyChange
+	}
+
+	data := options.Data
+	if data == nil {
+		data = keypayload.KPMap{}
+	}
+
+	object := map[string]interface{}{
+		"env_id":  options.EnvID,
+		"user_id": options.UserID,
+		"data":    data,
+		"removed": removed,
+		"diff":    diff,
+		"sync":    options.Sync,
+	}
+
+	//	The base version is only absent when the environment has no secrets yet.
+	if latest.ID != "" {
+		object["base_version"] = latest.Version
+	}
+
+	if options.Message != "" {
+		object["message"] = options.Message
+	}
+
+	req := graphql.NewRequest(`
+	mutation MyMutation($object: change_requests_insert_input!) {
+		insert_change_requests_one(object: $object) {
+		  id
+		  created_at
+		  env_id
+		  user_id
+		  base_version
+		  diff
+		  message
+		  sync
+		  status
+		}
+	  }
+	`)
+
+	req.Var("object", object)
+
+	var response struct {
+		ChangeRequest ChangeRequest `json:"insert_change_requests_one"`
+	}
+
+	if err := client.Do(ctx, req, &response); err != nil {
+		return nil, err
+	}
+
+	return &response.ChangeRequest, nil
+}
+
+// Fetches a change request along with its encrypted data and reviews.
+func (*DefaultService) Get(ctx context.ServiceContext, client *clients.GQLClient, id string) (*ChangeRequest, error) {
+
+	req := graphql.NewRequest(`
+	query MyQuery($id: uuid!) {
+		change_requests_by_pk(id: $id) {
+		  id
+		  created_at
+		  updated_at
+		  env_id
+		  user_id
+		  base_version
+		  data
+		  removed
+		  diff
+		  message
+		  sync
+		  status
+		  version
+		  reviews(order_by: {created_at: asc}) {
+			id
+			created_at
+			user_id
+			decision
+			comment
+		  }
+		}
+	  }
+	`)
+
+	req.Var("id", id)
+
+	var response struct {
+		ChangeRequest *ChangeRequest `json:"change_requests_by_pk"`
+	}
+
+	if err := client.Do(ctx, req, &response); err != nil {
+		return nil, err
+	}
+
+	if response.ChangeRequest == nil {
+		return nil, ErrNotFound
+	}
+
+	response.ChangeRequest.Data.MarkAllEncoded()
+	return response.ChangeRequest, nil
+}
+
+// Lists the change requests of an environment, latest first.
+// The encrypted data is left out.
+func (*DefaultService) List(ctx context.ServiceContext, client *clients.GQLClient, options *ListOptions) ([]ChangeRequest, error) {
+
+	where := map[string]interface{}{
+		"env_id": map[string]interface{}{
+			"_eq": options.EnvID,
+		},
+	}
+
+	if options.Status != "" {
+		where["status"] = map[string]interface{}{
+			"_eq": options.Status,
+		}
+	}
+
+	req := graphql.NewRequest(`
+	query MyQuery($where: change_requests_bool_exp!) {
+		change_requests(where: $where, order_by: {created_at: desc}) {
+		  id
+		  created_at
+		  updated_at
+		  env_id
+		  user_id
+		  base_version
+		  removed
+		  diff
+		  message
+		  sync
+		  status
+		  version
+		  reviews(order_by: {created_at: asc}) {
+			id
+			created_at
+			user_id
+			decision
+			comment
+		  }
+		}
+	  }
+	`)
+
+	req.Var("where", where)
+
+	var response struct {
+		ChangeRequests []ChangeRequest `json:"change_requests"`
+	}
+
+	if err := client.Do(ctx, req, &response); err != nil {
+		return nil, err
+	}
+
+	return response.ChangeRequests, nil
+}
+
+// Records the decision of a reviewer on a change request.
+// The client must have admin privileges.
+//
+// --- Flow ---
+//
+//  1. Only pending change requests can be reviewed, and requesters can't approve their own.
+//  2. Record the review.
+//  3. A rejection closes the change request right away.
+//  4. Once the environment's required approvals are reached,
+//     apply the change on top of the latest version of the secrets.
+func (d *DefaultService) Review(ctx context.ServiceContext, client *clients.GQLClient, options *ReviewOptions) (*ChangeRequest, error) {
+
+	change, err := d.Get(ctx, client, options.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if change.Status != PendingStatus {
+		return nil, ErrNotPending
+	}
+
+	if options.Decision == ApproveDecision && change.UserID == options.UserID {
+		return nil, ErrSelfApproval
+	}
+
+	for _, item := range change.Reviews {
+		if item.UserID == options.UserID {
+			return nil, ErrAlreadyVoted
+		}
+	}
+
+	req := graphql.NewRequest(`
+	mutation MyMutation($object: change_request_reviews_insert_input!) {
+		insert_change_request_reviews_one(object: $object) {
+		  id
+		  created_at
+		  user_id
+		  decision
+		  comment
+		}
+	  }
+	`)
+
+	object := map[string]interface{}{
+		"change_request_id": change.ID,
+		"user_id":           options.UserID,
+		"decision":          options.Decision,
+	}
+	if options.Comment != "" {
+		object["comment"] = options.Comment
+	}
+
+	req.Var("object", object)
+
+	var response struct {
+		Review Review `json:"insert_change_request_reviews_one"`
+	}
+
+	if err := client.Do(ctx, req, &response); err != nil {
+		return nil, err
+	}
+
+	change.Reviews = append(change.Reviews, response.Review)
+
+	switch options.Decision {
+	case RejectDecision:
+		if err := setStatus(ctx, client, change.ID, RejectedStatus); err != nil {
+			return nil, err
+		}
+		change.Status = RejectedStatus
+
+	case ApproveDecision:
+		environment, err := environments.GetService().Get(ctx, client, change.EnvID)
+		if err != nil {
+			return nil, err
+		}
+
+		required := environment.RequiredApprovals
+		if required < 1 {
+			required = 1
+		}
+
+		if change.Approvals() >= required {
+			if err := apply(ctx, client, change); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return change, nil
+}
+
+// Writes the change as a new version of the environment's secrets and marks it approved.
+// Both happen in a single mutation, so a change is never applied twice.
+func apply(ctx context.ServiceContext, client *clients.GQLClient, change *ChangeRequest) error {
+
+	secret, err := latestSecret(ctx, client, change.EnvID)
+	if err != nil {
+		return err
+	}
+
+	if secret.ID != "" {
+
+		//	We need to create an incremented version.
+		secret.IncrementVersion()
+	}
+
+	secret.Data.Overwrite(&change.Data)
+	for _, key := range change.Removed {
+		secret.Delete(key)
+	}
+
+	req := graphql.NewRequest(`
+	mutation MyMutation($id: uuid!, $env_id: uuid!, $user_id: uuid, $data: jsonb!, $version: Int!) {
+		insert_secrets_one(object: {env_id: $env_id, user_id: $user_id, data: $data, version: $version}) {
+		  version
+		}
+		update_change_requests_by_pk(pk_columns: {id: $id}, _set: {status: "approved", version: $version}) {
+		  id
+		}
+	  }
+	`)
+
+	req.Var("id", change.ID)
+	req.Var("env_id", change.EnvID)
+	req.Var("data", secret.Data)
+	req.Var("version", secret.Version)
+	if change.UserID != "" {
+		req.Var("user_id", change.UserID)
+	}
+
+	if err := client.Do(ctx, req, nil); err != nil {
+		return err
+	}
+
+	change.Status = ApprovedStatus
+	change.Version = secret.Version
+	return nil
+}
+
+func setStatus(ctx context.ServiceContext, client *clients.GQLClient, id string, status Status) error {
+
+	req := graphql.NewRequest(`
+	mutation MyMutation($id: uuid!, $status: String!) {
+		update_change_requests_by_pk(pk_columns: {id: $id}, _set: {status: $status}) {
+		  id
+		}
+	  }
+	`)
+
+	req.Var("id", id)
+	req.Var("status", status)
+
+	var response struct {
+		Result *struct {
+			ID string `json:"id"`
+		} `json:"update_change_requests_by_pk"`
+	}
+
+	if err := client.Do(ctx, req, &response); err != nil {
+		return err
+	}
+
+	if response.Result == nil {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// Returns the latest version of the environment's secrets,
+// or a new empty one if the environment has no secrets yet.
+func latestSecret(ctx context.ServiceContext, client *clients.GQLClient, envID string) (*secretCommons.Secret, error) {
+
+	secret, err := secrets.Get(ctx, client, &secretCommons.GetOptions{
+		EnvID: envID,
+	})
+	if err != nil {
+		if strings.Compare(err.Error(), string(clients.ErrorTypeRecordNotFound)) == 0 {
+			return secrets.New(), nil
+		}
+		return nil, err
+	}
+
+	return secret, nil
+}
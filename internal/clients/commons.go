@@ -41,6 +41,7 @@ const (
 	AuthorizationHeader Header = "Authorization"
 	ContentTypeHeader   Header = "Content-Type"
 	TokenHeader         Header = "x-envsecrets-token"
+	IDTokenHeader       Header = "x-envsecrets-id-token"
 	OrgIDHeader         Header = "x-envsecrets-org-id"
 	HasuraWebhookSecret Header = "X-Hasura-Webhook-Secret"
 
@@ -39,7 +39,11 @@ const (
 	ErrorTypeKeyNotFound    ErrorType = "KeyNotFound"
 	ErrorTypeRecordNotFound ErrorType = "RecordNotFound"
 
-	ErrorTypeInvalidToken ErrorType = "InvalidToken"
+	ErrorTypeInvalidToken    ErrorType = "InvalidToken"
+	ErrorTypeTokenRestricted ErrorType = "TokenRestricted"
+
+	ErrorTypeKeyRotationRequired ErrorType = "KeyRotationRequired"
+	ErrorTypeAccountLinkRequired ErrorType = "AccountLinkRequired"
 
 	ErrorTypeInvalidAccountConfiguration ErrorType = "InvalidAccountConfiguration"
 	ErrorTypeInvalidProjectConfiguration ErrorType = "InvalidProjectConfiguration"
@@ -68,7 +72,11 @@ var ResponseCodeMap = map[ErrorType]int{
 	ErrorTypeInvalidAccountConfiguration: http.StatusBadRequest,
 	ErrorTypeInvalidProjectConfiguration: http.StatusBadRequest,
 
-	ErrorTypeInvalidToken: http.StatusBadRequest,
+	ErrorTypeInvalidToken:    http.StatusBadRequest,
+	ErrorTypeTokenRestricted: http.StatusForbidden,
+
+	ErrorTypeKeyRotationRequired: http.StatusBadRequest,
+	ErrorTypeAccountLinkRequired: http.StatusForbidden,
 
 	ErrorTypeEmailFailed: http.StatusInternalServerError,
 }
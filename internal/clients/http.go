@@ -96,6 +96,7 @@ func (c *HTTPClient) Run(ctx context.ServiceContext, req *http.Request, response
 	if err != nil {
 		return err
 	}
+	defer resp.Body.Close()
 
 	//	If the request failed due to expired JWT,
 	//	refresh the token and re-do the request.
@@ -103,9 +104,14 @@ func (c *HTTPClient) Run(ctx context.ServiceContext, req *http.Request, response
 		return errors.New("you do not have permission to perform this action")
 	}
 
-	if response != nil {
+	//	Let the caller validate the response before it is decoded.
+	if c.ResponseHandler != nil {
+		if err := c.ResponseHandler(resp); err != nil {
+			return err
+		}
+	}
 
-		defer resp.Body.Close()
+	if response != nil {
 
 		result, err := io.ReadAll(resp.Body)
 		if err != nil {
@@ -14,6 +14,13 @@ type Environment struct {
 	Name      string    `json:"name,omitempty"`
 	ProjectID string    `json:"project_id,omitempty"`
 	UserID    string    `json:"user_id"`
+
+	//	Secret changes of protected environments must be approved before they are written.
+	Protected         bool `json:"protected,omitempty"`
+	RequiredApprovals int  `json:"required_approvals,omitempty"`
+
+	//	Networks the environment's tokens can be used from, on top of the tokens' own allow-lists.
+	AllowedCIDRs []string `json:"allowed_cidrs,omitempty"`
 }
 
 type CreateOptions struct {
@@ -26,6 +33,13 @@ type UpdateOptions struct {
 	Name string `json:"name"`
 }
 
+type ProtectOptions struct {
+	Protected bool `json:"protected"`
+
+	//	Number of approvals a change request needs before it is applied.
+	RequiredApprovals int `json:"required_approvals,omitempty"`
+}
+
 type ListOptions struct {
 	ProjectID string `json:"project_id,omitempty"`
 }
@@ -47,4 +61,5 @@ type SyncOptions struct {
 	EnvID    string
 	EventIDs []string
 	Pairs    *keypayload.KPMap
+	Version  *int
 }
@@ -19,6 +19,8 @@ type Service interface {
 	List(context.ServiceContext, *clients.GQLClient, *ListOptions) ([]*Environment, error)
 	Update(context.ServiceContext, *clients.GQLClient, string, *UpdateOptions) (*Environment, error)
 	Delete(context.ServiceContext, *clients.GQLClient, string) error
+	Protect(context.ServiceContext, *clients.GQLClient, string, *ProtectOptions) (*Environment, error)
+	SetAllowedCIDRs(context.ServiceContext, *clients.GQLClient, string, []string) (*Environment, error)
 	Sync(context.ServiceContext, *clients.GQLClient, *SyncOptions) error
 }
 
@@ -106,6 +108,10 @@ func (*DefaultService) Get(ctx context.ServiceContext, client *clients.GQLClient
 		environments_by_pk(id: $id) {
 			id
 			name
+			project_id
+			protected
+			required_approvals
+			allowed_cidrs
 		}
 	  }	  
 	`)
@@ -168,6 +174,7 @@ func (*DefaultService) List(ctx context.ServiceContext, client *clients.GQLClien
 		environments(where: $where) {
 		  id
 		  name
+		  protected
 		}
 	  }	  
 	`)
@@ -220,9 +227,109 @@ func (*DefaultService) Update(ctx context.ServiceContext, client *clients.GQLCli
 
 // Delete a environment by ID
 func (*DefaultService) Delete(ctx context.ServiceContext, client *clients.GQLClient, id string) error {
+
+	req := graphql.NewRequest(`
+	mutation MyMutation($id: uuid!) {
+		delete_environments_by_pk(id: $id) {
+		  id
+		}
+	  }
+	`)
+
+	req.Var("id", id)
+
+	var response struct {
+		Result *struct {
+			ID string `json:"id"`
+		} `json:"delete_environments_by_pk"`
+	}
+
+	if err := client.Do(ctx, req, &response); err != nil {
+		return err
+	}
+
+	if response.Result == nil {
+		return errors.New("environment not found")
+	}
+
 	return nil
 }
 
+// Turns the protected mode of an environment on or off.
+func (*DefaultService) Protect(ctx context.ServiceContext, client *clients.GQLClient, id string, options *ProtectOptions) (*Environment, error) {
+
+	set := map[string]interface{}{
+		"protected": options.Protected,
+	}
+	if options.RequiredApprovals > 0 {
+		set["required_approvals"] = options.RequiredApprovals
+	}
+
+	req := graphql.NewRequest(`
+	mutation MyMutation($id: uuid!, $set: environments_set_input!) {
+		update_environments_by_pk(pk_columns: {id: $id}, _set: $set) {
+		  id
+		  name
+		  protected
+		  required_approvals
+		}
+	  }
+	`)
+
+	req.Var("id", id)
+	req.Var("set", set)
+
+	var response struct {
+		Result *Environment `json:"update_environments_by_pk"`
+	}
+
+	if err := client.Do(ctx, req, &response); err != nil {
+		return nil, err
+	}
+
+	if response.Result == nil {
+		return nil, errors.New("environment not found")
+	}
+
+	return response.Result, nil
+}
+
+// Replaces the networks the tokens of an environment can be used from.
+// An empty list allows any network.
+func (*DefaultService) SetAllowedCIDRs(ctx context.ServiceContext, client *clients.GQLClient, id string, cidrs []string) (*Environment, error) {
+
+	if cidrs == nil {
+		cidrs = []string{}
+	}
+
+	req := graphql.NewRequest(`
+	mutation MyMutation($id: uuid!, $allowed_cidrs: jsonb!) {
+		update_environments_by_pk(pk_columns: {id: $id}, _set: {allowed_cidrs: $allowed_cidrs}) {
+		  id
+		  name
+		  allowed_cidrs
+		}
+	  }
+	`)
+
+	req.Var("id", id)
+	req.Var("allowed_cidrs", cidrs)
+
+	var response struct {
+		Result *Environment `json:"update_environments_by_pk"`
+	}
+
+	if err := client.Do(ctx, req, &response); err != nil {
+		return nil, err
+	}
+
+	if response.Result == nil {
+		return nil, errors.New("environment not found")
+	}
+
+	return response.Result, nil
+}
+
 // This function syncs the secrets of an environment with it's connected integrations.
 // This function assumed that the secrets being supplied are already decrypted.
 func (*DefaultService) Sync(ctx context.ServiceContext, client *clients.GQLClient, options *SyncOptions) error {
@@ -255,8 +362,10 @@ func (*DefaultService) Sync(ctx context.ServiceContext, client *clients.GQLClien
 		if err := integrationService.Sync(ctx, client, &integrations.SyncOptions{
 			IntegrationID: event.Integration.ID,
 			EventID:       event.ID,
+			EnvID:         options.EnvID,
 			EntityDetails: event.EntityDetails,
 			Data:          options.Pairs,
+			Version:       options.Version,
 		}); err != nil {
 			return err
 		}
@@ -2,6 +2,7 @@ package events
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/envsecrets/envsecrets/internal/integrations"
@@ -27,13 +28,25 @@ type Event struct {
 func (e *Event) GetEntityLink() string {
 	switch e.Integration.Type {
 	case integrations.Github:
-		return fmt.Sprintf("https://github.com/%s/settings/secrets/actions", e.EntityDetails["full_name"])
+		switch e.EntityDetails["scope"] {
+		case "environment":
+			return fmt.Sprintf("https://github.com/%s/settings/environments", e.EntityDetails["full_name"])
+		case "organisation":
+			return fmt.Sprintf("https://github.com/organizations/%s/settings/secrets/%s", e.getGithubOrg(), e.getGithubApplication())
+		default:
+			return fmt.Sprintf("https://github.com/%s/settings/secrets/%s", e.EntityDetails["full_name"], e.getGithubApplication())
+		}
 	case integrations.Gitlab:
 		return fmt.Sprintf("%s/-/settings/ci_cd", e.EntityDetails["web_url"])
 	case integrations.Vercel:
 		return fmt.Sprintf("https://vercel.com/%s/%s/settings/environment-variables", e.EntityDetails["username"], e.EntityDetails["name"])
 	case integrations.ASM:
+		if e.EntityDetails["mode"] == "keys" {
+			return fmt.Sprintf("https://console.aws.amazon.com/secretsmanager/listsecrets?region=%s&search=name%%3D%s", e.EntityDetails["region"], e.EntityDetails["prefix"])
+		}
 		return fmt.Sprintf("https://console.aws.amazon.com/secretsmanager/home?region=%s#/secret?name=%s", e.EntityDetails["region"], e.EntityDetails["name"])
+	case integrations.SSM:
+		return fmt.Sprintf("https://console.aws.amazon.com/systems-manager/parameters/?region=%s&tab=Table#list_parameter_filters=Path:Recursive:%s", e.EntityDetails["region"], e.EntityDetails["path"])
 	case integrations.GSM:
 		return fmt.Sprintf("https://console.cloud.google.com/security/secret-manager/secret/%s/versions", e.EntityDetails["name"])
 	case integrations.CircleCI:
@@ -52,7 +65,37 @@ func (e *Event) GetEntityLink() string {
 	case integrations.Nhost:
 		return fmt.Sprintf("https://app.nhost.io/%s/settings/secrets", e.EntityDetails["name"])
 	case integrations.Heroku:
+		if pipeline, ok := e.EntityDetails["pipeline"].(map[string]interface{}); ok {
+			return fmt.Sprintf("https://dashboard.heroku.com/pipelines/%s", pipeline["id"])
+		}
 		return fmt.Sprintf("https://dashboard.heroku.com/apps/%s/settings", e.EntityDetails["name"])
+	case integrations.Bitbucket:
+		if e.EntityDetails["environment_uuid"] != nil {
+			return fmt.Sprintf("https://bitbucket.org/%s/admin/pipelines/deployment-settings", e.EntityDetails["full_name"])
+		}
+		return fmt.Sprintf("https://bitbucket.org/%s/admin/pipelines/repository-variables", e.EntityDetails["full_name"])
+	case integrations.Fly:
+		return fmt.Sprintf("https://fly.io/apps/%s/secrets", e.EntityDetails["name"])
+	case integrations.Cloudflare:
+		if e.EntityDetails["type"] == "pages" {
+			return fmt.Sprintf("https://dash.cloudflare.com/?to=/:account/pages/view/%s/settings/environment-variables", e.EntityDetails["name"])
+		}
+		return fmt.Sprintf("https://dash.cloudflare.com/?to=/:account/workers/services/view/%s/production/settings", e.EntityDetails["name"])
+	case integrations.Terraform:
+		if e.EntityDetails["type"] == "varset" {
+			return fmt.Sprintf("https://app.terraform.io/app/%s/settings/varsets/%s", e.EntityDetails["organization"], e.EntityDetails["id"])
+		}
+		return fmt.Sprintf("https://app.terraform.io/app/%s/workspaces/%s/variables", e.EntityDetails["organization"], e.EntityDetails["name"])
+	case integrations.Render:
+		if e.EntityDetails["type"] == "env_group" {
+			return fmt.Sprintf("https://dashboard.render.com/env-group/%s", e.EntityDetails["id"])
+		}
+		return fmt.Sprintf("https://dashboard.render.com/web/%s/env", e.EntityDetails["id"])
+	case integrations.Vault:
+		if e.EntityDetails["address"] == nil {
+			return ""
+		}
+		return fmt.Sprintf("%s/ui/vault/secrets/%s/kv/list/%s", e.EntityDetails["address"], e.EntityDetails["mount"], e.EntityDetails["path"])
 	default:
 		return ""
 	}
@@ -62,14 +105,22 @@ func (e *Event) GetEntityLink() string {
 func (e *Event) GetEntityTitle() string {
 	switch e.Integration.Type {
 	case integrations.Github:
-		return e.EntityDetails["full_name"].(string)
+		switch e.EntityDetails["scope"] {
+		case "environment":
+			return e.EntityDetails["full_name"].(string) + "/" + e.EntityDetails["environment"].(string)
+		case "organisation":
+			return e.getGithubOrg()
+		default:
+			return e.EntityDetails["full_name"].(string)
+		}
 	case integrations.Gitlab:
 		return e.EntityDetails["name"].(string)
 	case integrations.Vercel:
 		return e.EntityDetails["username"].(string) + "/" + e.EntityDetails["name"].(string)
-	case integrations.ASM:
-		return e.EntityDetails["name"].(string)
-	case integrations.GSM:
+	case integrations.ASM, integrations.GSM:
+		if e.EntityDetails["mode"] == "keys" {
+			return fmt.Sprint(e.EntityDetails["prefix"]) + "*"
+		}
 		return e.EntityDetails["name"].(string)
 	case integrations.CircleCI:
 		return e.EntityDetails["project_slug"].(string)
@@ -82,7 +133,32 @@ func (e *Event) GetEntityTitle() string {
 	case integrations.Nhost:
 		return e.EntityDetails["name"].(string)
 	case integrations.Heroku:
+		if pipeline, ok := e.EntityDetails["pipeline"].(map[string]interface{}); ok {
+			return fmt.Sprint(pipeline["name"]) + "/" + fmt.Sprint(e.EntityDetails["stage"])
+		}
 		return e.EntityDetails["name"].(string)
+	case integrations.Webhook:
+		return fmt.Sprint(e.EntityDetails["host"])
+	case integrations.Bitbucket:
+		if e.EntityDetails["environment_name"] != nil {
+			return fmt.Sprint(e.EntityDetails["full_name"]) + "/" + fmt.Sprint(e.EntityDetails["environment_name"])
+		}
+		return fmt.Sprint(e.EntityDetails["full_name"])
+	case integrations.Fly, integrations.Render:
+		return fmt.Sprint(e.EntityDetails["name"])
+	case integrations.Cloudflare:
+		if e.EntityDetails["type"] == "pages" && e.EntityDetails["environment"] != nil {
+			return fmt.Sprint(e.EntityDetails["name"]) + "/" + fmt.Sprint(e.EntityDetails["environment"])
+		}
+		return fmt.Sprint(e.EntityDetails["name"])
+	case integrations.Terraform:
+		return fmt.Sprint(e.EntityDetails["organization"]) + "/" + fmt.Sprint(e.EntityDetails["name"])
+	case integrations.SSM:
+		return "/" + strings.Trim(fmt.Sprint(e.EntityDetails["path"]), "/") + "/*"
+	case integrations.Kubernetes:
+		return fmt.Sprint(e.EntityDetails["namespace"]) + "/" + fmt.Sprint(e.EntityDetails["name"])
+	case integrations.Vault:
+		return strings.Trim(fmt.Sprint(e.EntityDetails["mount"])+"/"+fmt.Sprint(e.EntityDetails["path"]), "/")
 	case integrations.Railway:
 		project := e.EntityDetails["project"].(map[string]interface{})
 		environment := e.EntityDetails["environment"].(map[string]interface{})
@@ -100,7 +176,14 @@ func (e *Event) GetEntityTitle() string {
 func (e *Event) GetEntityType() string {
 	switch e.Integration.Type {
 	case integrations.Github:
-		return "repository"
+		switch e.EntityDetails["scope"] {
+		case "environment":
+			return "environment"
+		case "organisation":
+			return "organisation"
+		default:
+			return "repository"
+		}
 	case integrations.Railway:
 		if e.EntityDetails["service"] != nil {
 			return "service"
@@ -110,17 +193,70 @@ func (e *Event) GetEntityType() string {
 		return "project"
 	case integrations.Gitlab:
 		return "project/group"
-	case integrations.ASM, integrations.GSM:
+	case integrations.ASM, integrations.GSM, integrations.Vault:
+		if e.EntityDetails["mode"] == "keys" {
+			return "secrets"
+		}
 		return "secret"
 	case integrations.Netlify:
 		return "site"
-	case integrations.Nhost, integrations.Heroku:
+	case integrations.Kubernetes:
+		return "secret"
+	case integrations.SSM:
+		return "parameters"
+	case integrations.Webhook:
+		return "endpoint"
+	case integrations.Bitbucket:
+		if e.EntityDetails["environment_uuid"] != nil {
+			return "environment"
+		}
+		return "repository"
+	case integrations.Fly:
+		return "app"
+	case integrations.Cloudflare:
+		if e.EntityDetails["type"] == "pages" {
+			return "project"
+		}
+		return "worker"
+	case integrations.Terraform:
+		if e.EntityDetails["type"] == "varset" {
+			return "variable set"
+		}
+		return "workspace"
+	case integrations.Render:
+		if e.EntityDetails["type"] == "env_group" {
+			return "environment group"
+		}
+		return "service"
+	case integrations.Heroku:
+		if e.EntityDetails["pipeline"] != nil {
+			return "pipeline stage"
+		}
+		return "app"
+	case integrations.Nhost:
 		return "app"
 	default:
 		return ""
 	}
 }
 
+// Returns the Github organisation of the event, defaulting to the owner of the repository.
+func (e *Event) getGithubOrg() string {
+	if e.EntityDetails["org"] != nil {
+		return e.EntityDetails["org"].(string)
+	}
+	owner, _, _ := strings.Cut(fmt.Sprint(e.EntityDetails["full_name"]), "/")
+	return owner
+}
+
+// Returns the Github application consuming the secrets, defaulting to "actions".
+func (e *Event) getGithubApplication() string {
+	if e.EntityDetails["application"] != nil {
+		return e.EntityDetails["application"].(string)
+	}
+	return "actions"
+}
+
 type ActionsGetOptions struct {
 	EnvID string `json:"env_id,omitempty"`
 }
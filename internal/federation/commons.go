@@ -0,0 +1,72 @@
+// Package federation exchanges the OIDC ID tokens CI providers issue to their jobs
+// for short-lived environment tokens, so that pipelines don't need long-lived tokens in their secrets.
+//
+// Every exchange must match a trust policy of the environment. A policy trusts one issuer and audience,
+// and restricts the repository, branch and deployment environment of the job, or any other claim of
+// the token for generic issuers. Conditions are glob patterns, like "acme/*" or "release-*",
+// where "*" doesn't match a "/". The owner of a repository is never a pattern, and policies can
+// pin the IDs of the owner and of the repository, which survive renames and aren't reused.
+//
+// Exchanges name the environment, or the policy, they want a token for, so that the policies
+// of other organisations trusting the same issuer are never considered.
+//
+// The server keeps its own sealed copy of the organisation's key for every policy,
+// since nobody is around to decrypt theirs when a pipeline runs. It's re-sealed when the key is rotated.
+// Unlike the rest of envsecrets, where only members and token holders can decrypt secrets, this lets
+// the server decrypt every secret of the organisation on its own, not just those of the policy's environment,
+// since all of them are encrypted with the same key. Creating a policy requires consenting to that.
+//
+// Issued tokens are bound to the subject of the exchanged ID token, and every request made with them
+// must carry a fresh ID token of the same job.
+//
+// Issuers are discovered like any OIDC provider, so a mock issuer on localhost,
+// serving a discovery document and a key set, is enough to test exchanges locally.
+// Issuers on the loopback interface are only accepted in development, with $DEV set,
+// so that the server can't be made to fetch from services listening on itself.
+package federation
+
+import (
+	"errors"
+	"regexp"
+	"time"
+)
+
+type Provider string
+
+const (
+	GitHubProvider  Provider = "github"
+	GitLabProvider  Provider = "gitlab"
+	GenericProvider Provider = "generic"
+)
+
+const (
+	GITHUB_ISSUER = "https://token.actions.githubusercontent.com"
+	GITLAB_ISSUER = "https://gitlab.com"
+
+	//	Audience the CLI requests its ID tokens for, unless the policy says otherwise.
+	DEFAULT_AUDIENCE = "envsecrets"
+
+	DEFAULT_TOKEN_EXPIRY = 15 * time.Minute
+	MAX_TOKEN_EXPIRY     = time.Hour
+
+	//	Prefix of the names of the environment tokens issued by exchanges.
+	TOKEN_NAME_PREFIX = "oidc:"
+)
+
+var namePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{1,62}$`)
+
+var (
+	ErrInvalidName       = errors.New("name must be 2 to 63 lowercase letters, digits or hyphens")
+	ErrInvalidProvider   = errors.New("provider must be one of github, gitlab or generic")
+	ErrInvalidIssuer     = errors.New("issuer must be an https URL")
+	ErrLoopbackIssuer    = errors.New("issuer must not be on the loopback interface")
+	ErrInvalidExpiry     = errors.New("token expiry must be between 1 minute and 1 hour")
+	ErrInvalidPattern    = errors.New("invalid pattern")
+	ErrOwnerPattern      = errors.New("the owner of the repository must be spelled out, like acme/*")
+	ErrNoConditions      = errors.New("policies of github and gitlab must restrict the repository, and generic ones at least one claim")
+	ErrNoMatchingPolicy  = errors.New("no trust policy matches the ID token")
+	ErrAmbiguousPolicies = errors.New("more than one trust policy matches the ID token; pass the ID of the one to use")
+	ErrNotFound          = errors.New("trust policy not found")
+	ErrNoScope           = errors.New("either the environment or the trust policy to exchange the ID token with is required")
+	ErrNoConsent         = errors.New("trust policies let the server decrypt every secret of the organisation, which must be explicitly allowed")
+)
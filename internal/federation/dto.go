@@ -0,0 +1,170 @@
+package federation
+
+import (
+	"path"
+	"strings"
+	"time"
+
+	"github.com/envsecrets/envsecrets/internal/oidc"
+)
+
+// CI identity allowed to read the secrets of an environment,
+// by exchanging its ID token for a short-lived environment token.
+type TrustPolicy struct {
+	ID          string            `json:"id,omitempty"`
+	CreatedAt   time.Time         `json:"created_at,omitempty"`
+	UpdatedAt   time.Time         `json:"updated_at,omitempty"`
+	OrgID       string            `json:"org_id,omitempty"`
+	EnvID       string            `json:"env_id,omitempty"`
+	Name        string            `json:"name,omitempty"`
+	Provider    Provider          `json:"provider,omitempty"`
+	Issuer      string            `json:"issuer,omitempty"`
+	Audience    string            `json:"audience,omitempty"`
+	Repository  string            `json:"repository,omitempty"`
+	Branch      string            `json:"branch,omitempty"`
+	Environment string            `json:"environment,omitempty"`
+	Claims      map[string]string `json:"claims,omitempty"`
+	CreatedBy   string            `json:"created_by,omitempty"`
+
+	//	Exact IDs of the repository's owner and of the repository, on GitHub or GitLab.
+	RepositoryOwnerID string `json:"repository_owner_id,omitempty"`
+	RepositoryID      string `json:"repository_id,omitempty"`
+
+	//	Lifetime of the issued environment tokens, in seconds.
+	TokenExpiry int `json:"token_expiry,omitempty"`
+}
+
+// Reports whether the validated claims of an ID token satisfy every condition of the policy.
+// Conditions on claims the token doesn't carry never match.
+func (p *TrustPolicy) Matches(claims oidc.Claims) bool {
+
+	attributes := Attributes(p.Provider, claims)
+
+	conditions := map[string]string{
+		"repository":  p.Repository,
+		"branch":      p.Branch,
+		"environment": p.Environment,
+	}
+
+	for key, pattern := range conditions {
+		if pattern != "" && !match(pattern, attributes[key]) {
+			return false
+		}
+	}
+
+	for key, id := range map[string]string{
+		"repository_owner_id": p.RepositoryOwnerID,
+		"repository_id":       p.RepositoryID,
+	} {
+		if id != "" && id != attributes[key] {
+			return false
+		}
+	}
+
+	for key, pattern := range p.Claims {
+		if !match(pattern, claims.String(key)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Maps the claims of a CI provider's ID token to the repository, branch and deployment environment of the job.
+// Generic issuers have no such mapping, and their policies match on the raw claims instead.
+func Attributes(provider Provider, claims oidc.Claims) map[string]string {
+
+	result := make(map[string]string)
+
+	switch provider {
+	case GitHubProvider:
+
+		result["repository"] = claims.String("repository")
+		result["repository_owner_id"] = claims.String("repository_owner_id")
+		result["repository_id"] = claims.String("repository_id")
+		result["environment"] = claims.String("environment")
+
+		//	Tags and pull requests have refs of their own, and never match a branch.
+		if ref := claims.String("ref"); strings.HasPrefix(ref, "refs/heads/") {
+			result["branch"] = strings.TrimPrefix(ref, "refs/heads/")
+		}
+
+	case GitLabProvider:
+
+		result["repository"] = claims.String("project_path")
+		result["repository_owner_id"] = claims.String("namespace_id")
+		result["repository_id"] = claims.String("project_id")
+		result["environment"] = claims.String("environment")
+
+		if claims.String("ref_type") == "branch" {
+			result["branch"] = claims.String("ref")
+		}
+	}
+
+	return result
+}
+
+func match(pattern, value string) bool {
+	if value == "" {
+		return false
+	}
+	matched, err := path.Match(pattern, value)
+	return err == nil && matched
+}
+
+type CreateOptions struct {
+	OrgID       string            `json:"-"`
+	EnvID       string            `json:"env_id"`
+	Name        string            `json:"name"`
+	Provider    Provider          `json:"provider"`
+	Issuer      string            `json:"issuer,omitempty"`
+	Audience    string            `json:"audience,omitempty"`
+	Repository  string            `json:"repository,omitempty"`
+	Branch      string            `json:"branch,omitempty"`
+	Environment string            `json:"environment,omitempty"`
+	Claims      map[string]string `json:"claims,omitempty"`
+	CreatedBy   string            `json:"-"`
+
+	RepositoryOwnerID string `json:"repository_owner_id,omitempty"`
+	RepositoryID      string `json:"repository_id,omitempty"`
+
+	//	Lifetime of the issued environment tokens. Defaults to 15 minutes.
+	TokenExpiry time.Duration `json:"-"`
+
+	//	Decrypted key of the organisation, to seal a copy for the server.
+	OrgKey []byte `json:"-"`
+
+	//	Consent to the server keeping that copy, with which it can decrypt
+	//	every secret of the organisation without anyone present.
+	AllowServerDecryption bool `json:"allow_server_decryption"`
+}
+
+type ListOptions struct {
+	OrgID string
+	EnvID string
+}
+
+type ExchangeOptions struct {
+
+	//	Raw ID token issued by the CI provider.
+	IDToken string `json:"id_token"`
+
+	//	Environment to exchange the token for. Either this or the policy is required.
+	EnvID string `json:"env_id,omitempty"`
+
+	//	Policy to exchange the token with, when more than one of the environment's could match it.
+	PolicyID string `json:"policy_id,omitempty"`
+}
+
+type ExchangeResponse struct {
+
+	//	Hex encoded environment token, exactly like the ones passed to `envs export --token`.
+	Token     string    `json:"token"`
+	EnvID     string    `json:"env_id"`
+	ExpiresAt time.Time `json:"expires_at"`
+
+	Policy *TrustPolicy `json:"-"`
+
+	//	Validated claims of the exchanged ID token, for the audit logs.
+	Claims oidc.Claims `json:"-"`
+}
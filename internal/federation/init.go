@@ -0,0 +1,5 @@
+package federation
+
+func init() {
+	SetService(&DefaultService{})
+}
@@ -0,0 +1,490 @@
+package federation
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"net"
+	"net/url"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/envsecrets/envsecrets/internal/clients"
+	"github.com/envsecrets/envsecrets/internal/context"
+	"github.com/envsecrets/envsecrets/internal/keys"
+	"github.com/envsecrets/envsecrets/internal/oidc"
+	"github.com/envsecrets/envsecrets/internal/tokens"
+	"github.com/machinebox/graphql"
+)
+
+// All the methods expect a client with admin privileges,
+// since exchanges are made by pipelines which aren't signed in.
+type Service interface {
+	Create(context.ServiceContext, *clients.GQLClient, *CreateOptions) (*TrustPolicy, error)
+	Get(context.ServiceContext, *clients.GQLClient, string) (*TrustPolicy, error)
+	List(context.ServiceContext, *clients.GQLClient, *ListOptions) ([]TrustPolicy, error)
+	Delete(context.ServiceContext, *clients.GQLClient, *TrustPolicy) (int, error)
+	Exchange(context.ServiceContext, *clients.GQLClient, *ExchangeOptions) (*ExchangeResponse, error)
+}
+
+type DefaultService struct{}
+
+// The server's copy of the organisation's key is deliberately left out.
+const fields = `
+	id
+	created_at
+	updated_at
+	org_id
+	env_id
+	name
+	provider
+	issuer
+	audience
+	repository
+	branch
+	environment
+	claims
+	repository_owner_id
+	repository_id
+	token_expiry
+	created_by
+`
+
+// Validates the policy, fills in the defaults of its provider,
+// and saves it along with a copy of the organisation's key sealed by the server,
+// which the caller must have consented to.
+func (*DefaultService) Create(ctx context.ServiceContext, client *clients.GQLClient, options *CreateOptions) (*TrustPolicy, error) {
+
+	if !options.AllowServerDecryption {
+		return nil, ErrNoConsent
+	}
+
+	if !namePattern.MatchString(options.Name) {
+		return nil, ErrInvalidName
+	}
+
+	issuer := options.Issuer
+	switch options.Provider {
+	case GitHubProvider:
+		if issuer == "" {
+			issuer = GITHUB_ISSUER
+		}
+	case GitLabProvider:
+		if issuer == "" {
+			issuer = GITLAB_ISSUER
+		}
+	case GenericProvider:
+	default:
+		return nil, ErrInvalidProvider
+	}
+
+	issuer, err := validateIssuer(issuer)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateConditions(options); err != nil {
+		return nil, err
+	}
+
+	expiry := options.TokenExpiry
+	if expiry == 0 {
+		expiry = DEFAULT_TOKEN_EXPIRY
+	}
+
+	if expiry < time.Minute || expiry > MAX_TOKEN_EXPIRY {
+		return nil, ErrInvalidExpiry
+	}
+
+	audience := options.Audience
+	if audience == "" {
+		audience = DEFAULT_AUDIENCE
+	}
+
+	sealed, err := keys.SealSymmetricallyByServer(options.OrgKey)
+	if err != nil {
+		return nil, err
+	}
+
+	req := graphql.NewRequest(`
+	mutation MyMutation($object: trust_policies_insert_input!) {
+		insert_trust_policies_one(object: $object) {` + fields + `}
+	  }
+	`)
+
+	object := map[string]interface{}{
+		"org_id":       options.OrgID,
+		"env_id":       options.EnvID,
+		"name":         options.Name,
+		"provider":     options.Provider,
+		"issuer":       issuer,
+		"audience":     audience,
+		"token_expiry": int(expiry.Seconds()),
+		"key":          base64.StdEncoding.EncodeToString(sealed),
+	}
+
+	for key, value := range map[string]string{
+		"repository":          options.Repository,
+		"branch":              options.Branch,
+		"environment":         options.Environment,
+		"repository_owner_id": options.RepositoryOwnerID,
+		"repository_id":       options.RepositoryID,
+		"created_by":          options.CreatedBy,
+	} {
+		if value != "" {
+			object[key] = value
+		}
+	}
+
+	if len(options.Claims) > 0 {
+		object["claims"] = options.Claims
+	}
+
+	req.Var("object", object)
+
+	var response struct {
+		TrustPolicy TrustPolicy `json:"insert_trust_policies_one"`
+	}
+
+	if err := client.Do(ctx, req, &response); err != nil {
+		return nil, err
+	}
+
+	return &response.TrustPolicy, nil
+}
+
+func (*DefaultService) Get(ctx context.ServiceContext, client *clients.GQLClient, id string) (*TrustPolicy, error) {
+
+	req := graphql.NewRequest(`
+	query MyQuery($id: uuid!) {
+		trust_policies_by_pk(id: $id) {` + fields + `}
+	  }
+	`)
+
+	req.Var("id", id)
+
+	var response struct {
+		TrustPolicy *TrustPolicy `json:"trust_policies_by_pk"`
+	}
+
+	if err := client.Do(ctx, req, &response); err != nil {
+		return nil, err
+	}
+
+	if response.TrustPolicy == nil {
+		return nil, ErrNotFound
+	}
+
+	return response.TrustPolicy, nil
+}
+
+func (*DefaultService) List(ctx context.ServiceContext, client *clients.GQLClient, options *ListOptions) ([]TrustPolicy, error) {
+
+	where := map[string]interface{}{
+		"org_id": map[string]interface{}{
+			"_eq": options.OrgID,
+		},
+	}
+
+	if options.EnvID != "" {
+		where["env_id"] = map[string]interface{}{
+			"_eq": options.EnvID,
+		}
+	}
+
+	return list(ctx, client, where)
+}
+
+// Deletes the policy, and revokes the environment tokens issued through it.
+// Returns the number of revoked tokens.
+func (*DefaultService) Delete(ctx context.ServiceContext, client *clients.GQLClient, policy *TrustPolicy) (int, error) {
+
+	req := graphql.NewRequest(`
+	mutation MyMutation($id: uuid!, $env_id: uuid!, $token_name: String!) {
+		delete_trust_policies_by_pk(id: $id) {
+		  id
+		}
+		delete_tokens(where: {env_id: {_eq: $env_id}, name: {_eq: $token_name}}) {
+		  affected_rows
+		}
+	  }
+	`)
+
+	req.Var("id", policy.ID)
+	req.Var("env_id", policy.EnvID)
+	req.Var("token_name", TOKEN_NAME_PREFIX+policy.Name)
+
+	var response struct {
+		Tokens struct {
+			AffectedRows int `json:"affected_rows"`
+		} `json:"delete_tokens"`
+	}
+
+	if err := client.Do(ctx, req, &response); err != nil {
+		return 0, err
+	}
+
+	return response.Tokens.AffectedRows, nil
+}
+
+// --- Flow ---
+//
+//  1. Find the policies of the requested environment, or the requested policy, trusting the issuer of the ID token.
+//  2. Validate the token against the issuer's key set, and the audience of each policy.
+//  3. Pick the only policy whose conditions the token's claims satisfy.
+//  4. Issue a short-lived environment token with the server's copy of the organisation's key,
+//     bound to the subject of the ID token.
+func (*DefaultService) Exchange(ctx context.ServiceContext, client *clients.GQLClient, options *ExchangeOptions) (*ExchangeResponse, error) {
+
+	//	Never consider the policies of every organisation trusting the issuer,
+	//	or anyone could make the exchanges of others ambiguous.
+	if options.EnvID == "" && options.PolicyID == "" {
+		return nil, ErrNoScope
+	}
+
+	issuer, err := oidc.UnverifiedIssuer(options.IDToken)
+	if err != nil {
+		return nil, err
+	}
+
+	where := map[string]interface{}{
+		"issuer": map[string]interface{}{
+			"_eq": issuer,
+		},
+	}
+
+	if options.EnvID != "" {
+		where["env_id"] = map[string]interface{}{
+			"_eq": options.EnvID,
+		}
+	}
+
+	if options.PolicyID != "" {
+		where["id"] = map[string]interface{}{
+			"_eq": options.PolicyID,
+		}
+	}
+
+	candidates, err := list(ctx, client, where)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(candidates) == 0 {
+		return nil, ErrNoMatchingPolicy
+	}
+
+	provider, err := oidc.Discover(ctx, issuer)
+	if err != nil {
+		return nil, err
+	}
+
+	var matched []TrustPolicy
+	var claims oidc.Claims
+	var verified bool
+	var verifyErr error
+	for _, item := range candidates {
+
+		result, err := provider.Verify(ctx, options.IDToken, &oidc.VerifyOptions{
+			Audience: item.Audience,
+		})
+		if err != nil {
+			verifyErr = err
+			continue
+		}
+
+		verified = true
+		if item.Matches(result) {
+			matched = append(matched, item)
+			claims = result
+		}
+	}
+
+	//	Surface why the token is invalid, rather than hiding it behind a missing policy.
+	if !verified {
+		return nil, verifyErr
+	}
+
+	switch len(matched) {
+	case 0:
+		return nil, ErrNoMatchingPolicy
+	case 1:
+	default:
+		return nil, ErrAmbiguousPolicies
+	}
+
+	policy := matched[0]
+
+	orgKey, err := getKey(ctx, client, policy.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	//	Bind the token to the job's identity, so that it's useless to anyone it leaks to
+	//	without a fresh ID token of the same job.
+	expiry := time.Duration(policy.TokenExpiry) * time.Second
+	token, err := tokens.GetService().Create(ctx, client, &tokens.CreateOptions{
+		OrgKey: orgKey,
+		EnvID:  policy.EnvID,
+		Expiry: expiry,
+		Name:   TOKEN_NAME_PREFIX + policy.Name,
+		Restrictions: &tokens.Restrictions{
+			CIIssuer:   issuer,
+			CIAudience: policy.Audience,
+			CISubject:  claims.Subject(),
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &ExchangeResponse{
+		Token:     hex.EncodeToString(token),
+		EnvID:     policy.EnvID,
+		ExpiresAt: time.Now().Add(expiry).UTC(),
+		Policy:    &policy,
+		Claims:    claims,
+	}, nil
+}
+
+//	---	Helpers ---
+
+// Only https issuers are trusted, since the server fetches their discovery documents and key sets.
+// Issuers on the loopback interface are refused, except for a mock issuer in development.
+func validateIssuer(issuer string) (string, error) {
+
+	issuer = strings.TrimSuffix(strings.TrimSpace(issuer), "/")
+
+	parsed, err := url.Parse(issuer)
+	if err != nil || parsed.Host == "" {
+		return "", ErrInvalidIssuer
+	}
+
+	loopback := isLoopback(parsed.Hostname())
+	if loopback {
+		isDevEnvironment, _ := strconv.ParseBool(os.Getenv("DEV"))
+		if !isDevEnvironment {
+			return "", ErrLoopbackIssuer
+		}
+	}
+
+	switch parsed.Scheme {
+	case "https":
+	case "http":
+		if !loopback {
+			return "", ErrInvalidIssuer
+		}
+	default:
+		return "", ErrInvalidIssuer
+	}
+
+	return issuer, nil
+}
+
+func isLoopback(host string) bool {
+
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return true
+	}
+
+	ip := net.ParseIP(host)
+	return ip != nil && (ip.IsLoopback() || ip.IsUnspecified())
+}
+
+// A policy must always restrict who can use it,
+// otherwise every job of every repository on the issuer could read the environment.
+func validateConditions(options *CreateOptions) error {
+
+	patterns := []string{options.Repository, options.Branch, options.Environment}
+	for key, pattern := range options.Claims {
+		if key == "" || pattern == "" {
+			return ErrInvalidPattern
+		}
+		patterns = append(patterns, pattern)
+	}
+
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return ErrInvalidPattern
+		}
+	}
+
+	switch options.Provider {
+	case GenericProvider:
+		if len(options.Claims) == 0 {
+			return ErrNoConditions
+		}
+	default:
+		if options.Repository == "" {
+			return ErrNoConditions
+		}
+
+		//	A pattern in the owner, like "*/*" or "acme*/*",
+		//	would trust the repositories of anyone who can pick a matching name.
+		owner, _, found := strings.Cut(options.Repository, "/")
+		if !found || owner == "" || strings.ContainsAny(owner, `*?[\`) {
+			return ErrOwnerPattern
+		}
+	}
+
+	return nil
+}
+
+func list(ctx context.ServiceContext, client *clients.GQLClient, where map[string]interface{}) ([]TrustPolicy, error) {
+
+	req := graphql.NewRequest(`
+	query MyQuery($where: trust_policies_bool_exp!) {
+		trust_policies(where: $where, order_by: {name: asc}) {` + fields + `}
+	  }
+	`)
+
+	req.Var("where", where)
+
+	var response struct {
+		TrustPolicies []TrustPolicy `json:"trust_policies"`
+	}
+
+	if err := client.Do(ctx, req, &response); err != nil {
+		return nil, err
+	}
+
+	return response.TrustPolicies, nil
+}
+
+// Opens the server's copy of the organisation's key saved with the policy.
+func getKey(ctx context.ServiceContext, client *clients.GQLClient, id string) ([]byte, error) {
+
+	req := graphql.NewRequest(`
+	query MyQuery($id: uuid!) {
+		trust_policies_by_pk(id: $id) {
+		  key
+		}
+	  }
+	`)
+
+	req.Var("id", id)
+
+	var response struct {
+		TrustPolicy *struct {
+			Key string `json:"key"`
+		} `json:"trust_policies_by_pk"`
+	}
+
+	if err := client.Do(ctx, req, &response); err != nil {
+		return nil, err
+	}
+
+	if response.TrustPolicy == nil {
+		return nil, ErrNotFound
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(response.TrustPolicy.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	return keys.OpenSymmetricallyByServer(sealed)
+}
@@ -12,20 +12,29 @@ func (t *Type) IsValid() bool {
 }
 
 const (
-	Github   Type = "github"
-	Gitlab   Type = "gitlab"
-	Vercel   Type = "vercel"
-	ASM      Type = "asm"
-	GSM      Type = "gsm"
-	CircleCI Type = "circleci"
-	Supabase Type = "supabase"
-	Netlify  Type = "netlify"
-	Railway  Type = "railway"
-	Hasura   Type = "hasura"
-	Nhost    Type = "nhost"
-	Heroku   Type = "heroku"
+	Github     Type = "github"
+	Gitlab     Type = "gitlab"
+	Vercel     Type = "vercel"
+	ASM        Type = "asm"
+	GSM        Type = "gsm"
+	CircleCI   Type = "circleci"
+	Supabase   Type = "supabase"
+	Netlify    Type = "netlify"
+	Railway    Type = "railway"
+	Hasura     Type = "hasura"
+	Nhost      Type = "nhost"
+	Heroku     Type = "heroku"
+	Vault      Type = "vault"
+	Kubernetes Type = "kubernetes"
+	SSM        Type = "ssm"
+	Webhook    Type = "webhook"
+	Bitbucket  Type = "bitbucket"
+	Fly        Type = "fly"
+	Render     Type = "render"
+	Cloudflare Type = "cloudflare"
+	Terraform  Type = "terraform"
 )
 
 var (
-	AllowedIntegrations = []Type{Github, Gitlab, Vercel, ASM, CircleCI, GSM, Supabase, Netlify, Railway, Hasura, Nhost, Heroku}
+	AllowedIntegrations = []Type{Github, Gitlab, Vercel, ASM, CircleCI, GSM, Supabase, Netlify, Railway, Hasura, Nhost, Heroku, Vault, Kubernetes, SSM, Webhook, Bitbucket, Fly, Render, Cloudflare, Terraform}
 )
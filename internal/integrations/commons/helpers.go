@@ -3,10 +3,15 @@ package commons
 import (
 	"encoding/base64"
 	"encoding/json"
+	"fmt"
 	"os"
+	"sort"
+	"strings"
 
 	"github.com/envsecrets/envsecrets/internal/context"
 	"github.com/envsecrets/envsecrets/internal/keys"
+	"github.com/envsecrets/envsecrets/internal/secrets/pkg/keypayload"
+	"github.com/envsecrets/envsecrets/internal/secrets/pkg/payload"
 )
 
 func EncryptCredentials(ctx context.ServiceContext, org_id string, payload map[string]interface{}) ([]byte, error) {
@@ -54,3 +59,59 @@ func DecryptCredentials(ctx context.ServiceContext, org_id string, payload []byt
 	//	Decrypt the value using org-key.
 	return keys.OpenAsymmetricallyAnonymous(payload, publicKey, privateKey)
 }
+
+// Parses a JSON secret string into a key-payload map.
+// It understands the format we write while syncing to secret managers,
+// as well as flat JSON objects of key=value pairs written by other tools.
+func ParseSecretString(data []byte) (*keypayload.KPMap, error) {
+
+	result := keypayload.KPMap{}
+	if err := json.Unmarshal(data, &result); err == nil {
+		return &result, nil
+	}
+
+	var pairs map[string]interface{}
+	if err := json.Unmarshal(data, &pairs); err != nil {
+		return nil, err
+	}
+
+	for key, value := range pairs {
+		result.Set(key, &payload.Payload{
+			Value: fmt.Sprint(value),
+		})
+	}
+
+	return &result, nil
+}
+
+// Name of the variable in which we record the keys synced by us,
+// on platforms where our variables can't otherwise be told apart from the rest.
+const ManagedKeysVariable = "ENVSECRETS_MANAGED_KEYS"
+
+// Returns the value of the managed keys variable for the data being synced.
+func FormatManagedKeys(data *keypayload.KPMap) string {
+	var keys []string
+	for key := range *data {
+		if key != ManagedKeysVariable {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, ",")
+}
+
+// Returns the keys recorded in the managed keys variable during the last sync,
+// which are no longer part of the data.
+func RemovedKeys(recorded string, data *keypayload.KPMap) []string {
+	var result []string
+	for _, key := range strings.Split(recorded, ",") {
+		key = strings.TrimSpace(key)
+		if key == "" || key == ManagedKeysVariable {
+			continue
+		}
+		if data.Get(key) == nil {
+			result = append(result, key)
+		}
+	}
+	return result
+}
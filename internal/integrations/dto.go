@@ -42,6 +42,24 @@ func (i *Integration) GetTitle() string {
 		return "Hasura"
 	case Nhost:
 		return "Nhost"
+	case Vault:
+		return "HashiCorp Vault"
+	case Kubernetes:
+		return "Kubernetes"
+	case SSM:
+		return "AWS Systems Manager Parameter Store"
+	case Webhook:
+		return "Webhook"
+	case Bitbucket:
+		return "Bitbucket Pipelines"
+	case Fly:
+		return "Fly.io"
+	case Render:
+		return "Render"
+	case Cloudflare:
+		return "Cloudflare"
+	case Terraform:
+		return "Terraform Cloud"
 	default:
 		return ""
 	}
@@ -72,6 +90,24 @@ func (i *Integration) GetSubtitle() string {
 		return "Your Hasura project where we sync this environment's secrets."
 	case Nhost:
 		return "Your Nhost app where we sync this environment's secrets."
+	case Vault:
+		return "Your Vault KV path where we sync this environment's secrets."
+	case Kubernetes:
+		return "Your Kubernetes secret where we sync this environment's secrets."
+	case SSM:
+		return "Your Parameter Store hierarchy where we sync this environment's secrets."
+	case Webhook:
+		return "Your endpoint where we send this environment's secrets."
+	case Bitbucket:
+		return "Your Bitbucket repository or deployment environment where we sync this environment's secrets."
+	case Fly:
+		return "Your Fly.io app where we sync this environment's secrets."
+	case Render:
+		return "Your Render service or environment group where we sync this environment's secrets."
+	case Cloudflare:
+		return "Your Cloudflare Worker or Pages project where we sync this environment's secrets."
+	case Terraform:
+		return "Your Terraform Cloud workspace or variable set where we sync this environment's secrets."
 	default:
 		return ""
 	}
@@ -102,6 +138,24 @@ func (i *Integration) GetDescription() string {
 		return "Make your secrets natively available in your Hasura project's environment variables."
 	case Nhost:
 		return "Make your secrets natively available in your Nhost app's environment variables."
+	case Vault:
+		return "Make your secrets natively available to the services reading from your Vault."
+	case Kubernetes:
+		return "Make your secrets natively available to the pods of your Kubernetes cluster."
+	case SSM:
+		return "Make your secrets natively available in your AWS Lambda functions."
+	case Webhook:
+		return "Send your secrets to any system with signed, and optionally encrypted, webhooks."
+	case Bitbucket:
+		return "Make your secrets natively available in your repository's pipelines."
+	case Fly:
+		return "Make your secrets natively available in your Fly.io app's machines."
+	case Render:
+		return "Make your secrets natively available in your Render service's environment variables."
+	case Cloudflare:
+		return "Make your secrets natively available in your Workers and Pages functions."
+	case Terraform:
+		return "Make your secrets available to your Terraform runs as environment or Terraform variables."
 	default:
 		return ""
 	}
@@ -144,7 +198,16 @@ type SetupOptions struct {
 
 type SyncOptions struct {
 	EventID       string                 `json:"event_id"`
+	EnvID         string                 `json:"env_id,omitempty"`
 	IntegrationID string                 `json:"integration_id"`
 	EntityDetails map[string]interface{} `json:"entity_details"`
 	Data          *keypayload.KPMap      `json:"data"`
+
+	//	Version of the secrets being synced, if known.
+	Version *int `json:"version,omitempty"`
+}
+
+type ImportOptions struct {
+	IntegrationID string                 `json:"integration_id"`
+	EntityDetails map[string]interface{} `json:"entity_details"`
 }
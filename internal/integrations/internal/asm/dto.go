@@ -1,6 +1,10 @@
 package asm
 
-import "github.com/envsecrets/envsecrets/internal/secrets/pkg/keypayload"
+import (
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager/types"
+	"github.com/envsecrets/envsecrets/internal/secrets/pkg/keypayload"
+)
 
 type SetupOptions struct {
 	Region  string
@@ -19,3 +23,49 @@ type SyncOptions struct {
 	EntityDetails map[string]interface{} `json:"entity_details"`
 	Data          *keypayload.KPMap      `json:"data"`
 }
+
+type ImportOptions struct {
+	OrgID         string                 `json:"org_id"`
+	Credentials   map[string]interface{} `json:"credentials"`
+	EntityDetails map[string]interface{} `json:"entity_details"`
+}
+
+type Mode string
+
+const (
+
+	//	Stores the entire environment as a single JSON secret.
+	JSONMode Mode = "json"
+
+	//	Stores every key as a separate secret under a path prefix.
+	KeysMode Mode = "keys"
+)
+
+// Per-event options, read from the event's entity details.
+type SecretOptions struct {
+	Name      string            `json:"name,omitempty"`
+	SecretARN string            `json:"secret_arn,omitempty"`
+	Mode      Mode              `json:"mode,omitempty"`
+	Prefix    string            `json:"prefix,omitempty"`
+	KMSKeyID  string            `json:"kms_key_id,omitempty"`
+	Tags      map[string]string `json:"tags,omitempty"`
+}
+
+type putSecretOptions struct {
+	Name     string
+	ARN      string
+	Value    string
+	KMSKeyID string
+	Tags     map[string]string
+}
+
+func (o *putSecretOptions) tags() []types.Tag {
+	var result []types.Tag
+	for key, value := range o.Tags {
+		result = append(result, types.Tag{
+			Key:   aws.String(key),
+			Value: aws.String(value),
+		})
+	}
+	return result
+}
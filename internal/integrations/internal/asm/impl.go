@@ -1,34 +1,26 @@
 package asm
 
 import (
+	"errors"
+	"fmt"
+
 	"github.com/envsecrets/envsecrets/internal/context"
+	"github.com/envsecrets/envsecrets/internal/integrations/commons"
+	"github.com/envsecrets/envsecrets/internal/secrets/pkg/keypayload"
+	"github.com/envsecrets/envsecrets/utils"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/config"
-	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
 	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
-	"github.com/aws/aws-sdk-go-v2/service/sts"
 )
 
 func ListEntities(ctx context.ServiceContext, options *ListOptions) (interface{}, error) {
 
-	cfg, err := config.LoadDefaultConfig(ctx,
-		config.WithRegion(options.Credentials["region"].(string)),
-	)
+	client, err := newClient(ctx, options.Credentials, options.OrgID)
 	if err != nil {
 		return nil, err
 	}
 
-	stsClient := sts.NewFromConfig(cfg)
-	provider := stscreds.NewAssumeRoleProvider(stsClient, options.Credentials["role_arn"].(string), func(aro *stscreds.AssumeRoleOptions) {
-		aro.RoleARN = options.Credentials["role_arn"].(string)
-		aro.ExternalID = aws.String(options.OrgID)
-	})
-	cfgCopy := cfg.Copy()
-	cfgCopy.Credentials = aws.NewCredentialsCache(provider)
-	client := secretsmanager.NewFromConfig(cfg)
-
-	resp, err := client.ListSecrets(ctx, nil)
+	resp, err := client.ListSecrets(ctx, &secretsmanager.ListSecretsInput{})
 	if err != nil {
 		return nil, err
 	}
@@ -38,44 +30,72 @@ func ListEntities(ctx context.ServiceContext, options *ListOptions) (interface{}
 
 func Sync(ctx context.ServiceContext, options *SyncOptions) (*secretsmanager.CreateSecretOutput, error) {
 
-	cfg, err := config.LoadDefaultConfig(ctx,
-		config.WithRegion(options.Credentials["region"].(string)),
-	)
+	client, err := newClient(ctx, options.Credentials, options.OrgID)
 	if err != nil {
 		return nil, err
 	}
 
-	stsClient := sts.NewFromConfig(cfg)
-	provider := stscreds.NewAssumeRoleProvider(stsClient, options.Credentials["role_arn"].(string), func(aro *stscreds.AssumeRoleOptions) {
-		aro.RoleARN = options.Credentials["role_arn"].(string)
-		aro.ExternalID = aws.String(options.OrgID)
-	})
-	cfgCopy := cfg.Copy()
-	cfgCopy.Credentials = aws.NewCredentialsCache(provider)
-	client := secretsmanager.NewFromConfig(cfg)
-
-	//	Marshal the secrets
-	payload, err := options.Data.Marshal()
-	if err != nil {
+	var secretOptions SecretOptions
+	if err := utils.MapToStruct(options.EntityDetails, &secretOptions); err != nil {
 		return nil, err
 	}
 
-	resp, err := client.CreateSecret(ctx, &secretsmanager.CreateSecretInput{
-		Name:         aws.String(options.EntityDetails["name"].(string)),
-		SecretString: aws.String(string(payload)),
-	})
-	if err != nil {
+	switch secretOptions.Mode {
+	case KeysMode:
 
-		//	TODO: Use better error management over here.
-		//	If the secret already exists, it returns HTTP Status Code 400.
-		//	Use that response code for error handling.
-		_, err = client.PutSecretValue(ctx, &secretsmanager.PutSecretValueInput{
-			SecretId:     aws.String(options.EntityDetails["secret_arn"].(string)),
-			SecretString: aws.String(string(payload)),
-		})
+		//	Write one secret per key under the path prefix.
+		for key, payload := range *options.Data {
+			if _, err := putSecret(ctx, client, &putSecretOptions{
+				Name:     secretOptions.Prefix + key,
+				Value:    fmt.Sprint(payload.Value),
+				KMSKeyID: secretOptions.KMSKeyID,
+				Tags:     secretOptions.Tags,
+			}); err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+
+	case JSONMode, "":
+
+		//	Marshal the secrets
+		payload, err := options.Data.Marshal()
 		if err != nil {
 			return nil, err
 		}
+
+		return putSecret(ctx, client, &putSecretOptions{
+			Name:     secretOptions.Name,
+			ARN:      secretOptions.SecretARN,
+			Value:    string(payload),
+			KMSKeyID: secretOptions.KMSKeyID,
+			Tags:     secretOptions.Tags,
+		})
+
+	default:
+		return nil, fmt.Errorf("invalid secrets manager mode: %s", secretOptions.Mode)
 	}
-	return resp, nil
+}
+
+// Fetches the current value of the secret and parses its key=value pairs.
+func Import(ctx context.ServiceContext, options *ImportOptions) (*keypayload.KPMap, error) {
+
+	client, err := newClient(ctx, options.Credentials, options.OrgID)
+	if err != nil {
+		return nil, err
+	}
+
+	name, ok := options.EntityDetails["name"].(string)
+	if !ok || name == "" {
+		return nil, errors.New("name of the secret to import is missing from entity details")
+	}
+
+	resp, err := client.GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{
+		SecretId: aws.String(name),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return commons.ParseSecretString([]byte(aws.ToString(resp.SecretString)))
 }
@@ -0,0 +1,123 @@
+package asm
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/envsecrets/envsecrets/internal/context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager/types"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+)
+
+// Initializes a new Secrets Manager client
+// with the credentials of the role assumed on behalf of the organisation.
+func newClient(ctx context.ServiceContext, credentials map[string]interface{}, orgID string) (*secretsmanager.Client, error) {
+
+	cfg, err := NewConfig(ctx, credentials, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	return secretsmanager.NewFromConfig(cfg), nil
+}
+
+// Loads the AWS configuration with the credentials of the role assumed on behalf of the organisation.
+// The organisation's ID is used as the external ID of the role.
+func NewConfig(ctx context.ServiceContext, credentials map[string]interface{}, orgID string) (aws.Config, error) {
+
+	opts := []func(*config.LoadOptions) error{
+		config.WithRegion(credentials["region"].(string)),
+	}
+
+	//	Point every AWS service to a local stand-in, if one has been configured.
+	if endpoint := os.Getenv("AWS_ENDPOINT_URL"); endpoint != "" {
+		opts = append(opts, config.WithEndpointResolverWithOptions(aws.EndpointResolverWithOptionsFunc(func(service, region string, options ...interface{}) (aws.Endpoint, error) {
+			return aws.Endpoint{
+				URL:               endpoint,
+				SigningRegion:     region,
+				HostnameImmutable: true,
+			}, nil
+		})))
+	}
+
+	cfg, err := config.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return aws.Config{}, err
+	}
+
+	stsClient := sts.NewFromConfig(cfg)
+	provider := stscreds.NewAssumeRoleProvider(stsClient, credentials["role_arn"].(string), func(aro *stscreds.AssumeRoleOptions) {
+		aro.RoleARN = credentials["role_arn"].(string)
+		aro.ExternalID = aws.String(orgID)
+	})
+	cfgCopy := cfg.Copy()
+	cfgCopy.Credentials = aws.NewCredentialsCache(provider)
+
+	return cfgCopy, nil
+}
+
+// Creates the secret, or updates its value if it already exists.
+// Returns the output of creation only if a new secret was created.
+func putSecret(ctx context.ServiceContext, client *secretsmanager.Client, options *putSecretOptions) (*secretsmanager.CreateSecretOutput, error) {
+
+	input := secretsmanager.CreateSecretInput{
+		Name:         aws.String(options.Name),
+		SecretString: aws.String(options.Value),
+		Tags:         options.tags(),
+	}
+
+	if options.KMSKeyID != "" {
+		input.KmsKeyId = aws.String(options.KMSKeyID)
+	}
+
+	resp, err := client.CreateSecret(ctx, &input)
+	if err == nil {
+		return resp, nil
+	}
+
+	var exists *types.ResourceExistsException
+	if !errors.As(err, &exists) {
+		return nil, fmt.Errorf("failed to create secret %s: %w", options.Name, err)
+	}
+
+	//	The secret already exists,
+	//	so update its value along with the encryption key.
+	secretID := options.Name
+	if options.ARN != "" {
+		secretID = options.ARN
+	}
+
+	update := secretsmanager.UpdateSecretInput{
+		SecretId:     aws.String(secretID),
+		SecretString: aws.String(options.Value),
+	}
+
+	if options.KMSKeyID != "" {
+		update.KmsKeyId = aws.String(options.KMSKeyID)
+	}
+
+	if _, err := client.UpdateSecret(ctx, &update); err != nil {
+		var notFound *types.ResourceNotFoundException
+		if errors.As(err, &notFound) {
+			return nil, fmt.Errorf("secret %s is scheduled for deletion or no longer exists: %w", options.Name, err)
+		}
+		return nil, fmt.Errorf("failed to update secret %s: %w", options.Name, err)
+	}
+
+	if len(input.Tags) > 0 {
+		if _, err := client.TagResource(ctx, &secretsmanager.TagResourceInput{
+			SecretId: aws.String(secretID),
+			Tags:     input.Tags,
+		}); err != nil {
+			return nil, fmt.Errorf("failed to tag secret %s: %w", options.Name, err)
+		}
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,48 @@
+package bitbucket
+
+import (
+	"encoding/json"
+
+	"github.com/envsecrets/envsecrets/internal/secrets/pkg/keypayload"
+)
+
+type ListOptions struct {
+	Credentials map[string]interface{}
+	FullName    string
+}
+
+type SyncOptions struct {
+	Credentials   map[string]interface{} `json:"credentials"`
+	EntityDetails map[string]interface{} `json:"entity_details"`
+	Data          *keypayload.KPMap      `json:"data"`
+}
+
+type Repository struct {
+	UUID     string `json:"uuid"`
+	Name     string `json:"name"`
+	FullName string `json:"full_name"`
+}
+
+type Environment struct {
+	UUID string `json:"uuid"`
+	Name string `json:"name"`
+}
+
+type Variable struct {
+	UUID    string `json:"uuid,omitempty"`
+	Key     string `json:"key"`
+	Value   string `json:"value,omitempty"`
+	Secured bool   `json:"secured"`
+}
+
+// Paginated response of the Bitbucket API.
+type Page struct {
+	Values json.RawMessage `json:"values"`
+	Next   string          `json:"next"`
+}
+
+type ErrorResponse struct {
+	Error struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
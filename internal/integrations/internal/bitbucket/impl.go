@@ -0,0 +1,62 @@
+package bitbucket
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/envsecrets/envsecrets/internal/context"
+)
+
+// Lists the repositories the credentials are a member of.
+func ListEntities(ctx context.ServiceContext, options *ListOptions) (interface{}, error) {
+	return listRepositories(ctx, newClient(options.Credentials))
+}
+
+// Lists the deployment environments of a repository.
+func ListSubEntities(ctx context.ServiceContext, options *ListOptions) (interface{}, error) {
+
+	if options.FullName == "" {
+		return nil, errors.New("repository is required")
+	}
+
+	return listEnvironments(ctx, newClient(options.Credentials), options.FullName)
+}
+
+// Syncs the secrets as repository variables,
+// or as variables of the deployment environment, if one is specified in entity details.
+func Sync(ctx context.ServiceContext, options *SyncOptions) error {
+
+	fullName, _ := options.EntityDetails["full_name"].(string)
+	if fullName == "" {
+		return errors.New("repository is required")
+	}
+
+	environment, _ := options.EntityDetails["environment_uuid"].(string)
+
+	client := newClient(options.Credentials)
+	URL := variablesURL(fullName, environment)
+
+	//	Fetch the existing variables, to update them in place.
+	existing, err := listVariables(ctx, client, URL)
+	if err != nil {
+		return err
+	}
+
+	uuids := make(map[string]string)
+	for _, item := range existing {
+		uuids[item.Key] = item.UUID
+	}
+
+	for key, payload := range *options.Data {
+		if err := putVariable(ctx, client, URL, &Variable{
+			UUID:    uuids[key],
+			Key:     key,
+			Value:   fmt.Sprint(payload.Value),
+			Secured: !payload.IsExposable(),
+		}); err != nil {
+			return fmt.Errorf("failed to sync %s: %w", key, err)
+		}
+	}
+
+	return nil
+}
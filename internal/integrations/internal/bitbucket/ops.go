@@ -0,0 +1,144 @@
+package bitbucket
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/envsecrets/envsecrets/internal/clients"
+	"github.com/envsecrets/envsecrets/internal/context"
+)
+
+const API = "https://api.bitbucket.org/2.0"
+
+// Initializes a new HTTP client,
+// authenticated with either an access token or an app password.
+func newClient(credentials map[string]interface{}) *clients.HTTPClient {
+
+	authorization := "Bearer " + credentials["token"].(string)
+	if username, ok := credentials["username"].(string); ok && username != "" {
+		authorization = "Basic " + base64.StdEncoding.EncodeToString([]byte(username+":"+credentials["token"].(string)))
+	}
+
+	return clients.NewHTTPClient(&clients.HTTPConfig{
+		Type:          clients.HTTPClientType,
+		Authorization: authorization,
+		ResponseHandler: func(response *http.Response) error {
+			if response.StatusCode >= http.StatusMultipleChoices {
+				var body ErrorResponse
+				json.NewDecoder(response.Body).Decode(&body)
+				return fmt.Errorf("bitbucket responded with status %d: %s", response.StatusCode, body.Error.Message)
+			}
+			return nil
+		},
+	})
+}
+
+// Walks through every page of the endpoint,
+// passing the values of each page to the collector.
+func list(ctx context.ServiceContext, client *clients.HTTPClient, URL string, collect func(json.RawMessage) error) error {
+
+	for URL != "" {
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, URL, nil)
+		if err != nil {
+			return err
+		}
+
+		var response Page
+		if err := client.Run(ctx, req, &response); err != nil {
+			return err
+		}
+
+		if err := collect(response.Values); err != nil {
+			return err
+		}
+
+		URL = response.Next
+	}
+
+	return nil
+}
+
+func listRepositories(ctx context.ServiceContext, client *clients.HTTPClient) ([]Repository, error) {
+
+	var result []Repository
+	err := list(ctx, client, API+"/repositories?role=member&pagelen=100", func(values json.RawMessage) error {
+		var page []Repository
+		if err := json.Unmarshal(values, &page); err != nil {
+			return err
+		}
+		result = append(result, page...)
+		return nil
+	})
+
+	return result, err
+}
+
+func listEnvironments(ctx context.ServiceContext, client *clients.HTTPClient, fullName string) ([]Environment, error) {
+
+	var result []Environment
+	err := list(ctx, client, fmt.Sprintf("%s/repositories/%s/environments?pagelen=100", API, fullName), func(values json.RawMessage) error {
+		var page []Environment
+		if err := json.Unmarshal(values, &page); err != nil {
+			return err
+		}
+		result = append(result, page...)
+		return nil
+	})
+
+	return result, err
+}
+
+func listVariables(ctx context.ServiceContext, client *clients.HTTPClient, URL string) ([]Variable, error) {
+
+	var result []Variable
+	err := list(ctx, client, URL+"?pagelen=100", func(values json.RawMessage) error {
+		var page []Variable
+		if err := json.Unmarshal(values, &page); err != nil {
+			return err
+		}
+		result = append(result, page...)
+		return nil
+	})
+
+	return result, err
+}
+
+// Returns the URL of the variables endpoint,
+// either of the repository or of one of its deployment environments.
+func variablesURL(fullName, environment string) string {
+	if environment == "" {
+		return fmt.Sprintf("%s/repositories/%s/pipelines_config/variables", API, fullName)
+	}
+	return fmt.Sprintf("%s/repositories/%s/deployments_config/environments/%s/variables", API, fullName, url.PathEscape(environment))
+}
+
+// Creates the variable, or updates it if it already exists.
+func putVariable(ctx context.ServiceContext, client *clients.HTTPClient, URL string, variable *Variable) error {
+
+	method := http.MethodPost
+	if variable.UUID != "" {
+		method = http.MethodPut
+		URL = strings.TrimSuffix(URL, "/") + "/" + url.PathEscape(variable.UUID)
+	} else {
+		URL = strings.TrimSuffix(URL, "/") + "/"
+	}
+
+	body, err := json.Marshal(variable)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, URL, bytes.NewBuffer(body))
+	if err != nil {
+		return err
+	}
+
+	var response Variable
+	return client.Run(ctx, req, &response)
+}
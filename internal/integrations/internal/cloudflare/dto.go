@@ -0,0 +1,81 @@
+package cloudflare
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/envsecrets/envsecrets/internal/secrets/pkg/keypayload"
+)
+
+type EntityType string
+
+const (
+	WorkerType EntityType = "worker"
+	PagesType  EntityType = "pages"
+)
+
+type ListOptions struct {
+	Credentials map[string]interface{}
+}
+
+type SyncOptions struct {
+	Credentials   map[string]interface{} `json:"credentials"`
+	EntityDetails map[string]interface{} `json:"entity_details"`
+	Data          *keypayload.KPMap      `json:"data"`
+}
+
+type Entity struct {
+	Type EntityType `json:"type"`
+	Name string     `json:"name"`
+}
+
+// Envelope of every Cloudflare API response.
+type Response struct {
+	Success bool            `json:"success"`
+	Errors  []Error         `json:"errors"`
+	Result  json.RawMessage `json:"result"`
+}
+
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (r *Response) Error() string {
+	var messages []string
+	for _, item := range r.Errors {
+		messages = append(messages, item.Message)
+	}
+	return strings.Join(messages, ", ")
+}
+
+type Script struct {
+	ID string `json:"id"`
+}
+
+type Project struct {
+	Name string `json:"name"`
+}
+
+// Binding of a Worker script.
+// Only the name and type are interpreted, the rest of the binding is sent back as is.
+type Binding map[string]interface{}
+
+func (b Binding) Name() string {
+	name, _ := b["name"].(string)
+	return name
+}
+
+func (b Binding) Type() string {
+	typ, _ := b["type"].(string)
+	return typ
+}
+
+type ScriptSettings struct {
+	Bindings []Binding `json:"bindings"`
+}
+
+type EnvVar struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
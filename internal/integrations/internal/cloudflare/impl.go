@@ -0,0 +1,150 @@
+package cloudflare
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/envsecrets/envsecrets/internal/context"
+)
+
+// Lists the Workers scripts and Pages projects of the account.
+func ListEntities(ctx context.ServiceContext, options *ListOptions) (interface{}, error) {
+
+	client, err := newClient(options.Credentials)
+	if err != nil {
+		return nil, err
+	}
+
+	scripts, err := client.listScripts(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	projects, err := client.listProjects(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []Entity
+	for _, item := range scripts {
+		result = append(result, Entity{
+			Type: WorkerType,
+			Name: item.ID,
+		})
+	}
+	for _, item := range projects {
+		result = append(result, Entity{
+			Type: PagesType,
+			Name: item.Name,
+		})
+	}
+
+	return result, nil
+}
+
+func Sync(ctx context.ServiceContext, options *SyncOptions) error {
+
+	name, _ := options.EntityDetails["name"].(string)
+	if name == "" {
+		return errors.New("script or project name is required")
+	}
+
+	client, err := newClient(options.Credentials)
+	if err != nil {
+		return err
+	}
+
+	switch EntityType(fmt.Sprint(options.EntityDetails["type"])) {
+	case WorkerType:
+		return syncWorker(ctx, client, name, options)
+	case PagesType:
+
+		environment, _ := options.EntityDetails["environment"].(string)
+		switch environment {
+		case "":
+			environment = "production"
+		case "production", "preview":
+		default:
+			return fmt.Errorf("invalid pages environment: %s", environment)
+		}
+
+		vars := make(map[string]EnvVar)
+		for key, payload := range *options.Data {
+			typ := "secret_text"
+			if payload.IsExposable() {
+				typ = "plain_text"
+			}
+			vars[key] = EnvVar{
+				Type:  typ,
+				Value: fmt.Sprint(payload.Value),
+			}
+		}
+
+		return client.patchPagesEnvVars(ctx, name, environment, vars)
+	default:
+		return errors.New("invalid entity type")
+	}
+}
+
+// Exposable values are bound to the script as plain text variables,
+// and the rest are uploaded as secrets.
+func syncWorker(ctx context.ServiceContext, client *client, script string, options *SyncOptions) error {
+
+	//	--- Flow ---
+	//	1. Fetch the existing bindings of the script.
+	//	2. Replace the plain text variables of our keys, and drop the ones of keys which are now secrets,
+	//	   since a binding name can only be used once. For the same reason,
+	//	   delete the secrets of keys which are now exposable.
+	//	3. Upload the secrets.
+
+	settings, err := client.getWorkerSettings(ctx, script)
+	if err != nil {
+		return err
+	}
+
+	var bindings []Binding
+	for _, binding := range settings.Bindings {
+
+		//	Secrets are kept by the API itself.
+		if binding.Type() == "secret_text" || binding.Type() == "secret_key" {
+			if payload := options.Data.Get(binding.Name()); payload != nil && payload.IsExposable() {
+				if err := client.deleteWorkerSecret(ctx, script, binding.Name()); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		if binding.Type() == "plain_text" && options.Data.Get(binding.Name()) != nil {
+			continue
+		}
+
+		bindings = append(bindings, binding)
+	}
+
+	for key, payload := range *options.Data {
+		if payload.IsExposable() {
+			bindings = append(bindings, Binding{
+				"type": "plain_text",
+				"name": key,
+				"text": fmt.Sprint(payload.Value),
+			})
+		}
+	}
+
+	if err := client.patchWorkerBindings(ctx, script, bindings); err != nil {
+		return err
+	}
+
+	for key, payload := range *options.Data {
+		if payload.IsExposable() {
+			continue
+		}
+
+		if err := client.putWorkerSecret(ctx, script, key, fmt.Sprint(payload.Value)); err != nil {
+			return fmt.Errorf("failed to sync %s: %w", key, err)
+		}
+	}
+
+	return nil
+}
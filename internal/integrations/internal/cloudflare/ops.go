@@ -0,0 +1,154 @@
+package cloudflare
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+
+	"github.com/envsecrets/envsecrets/internal/clients"
+	"github.com/envsecrets/envsecrets/internal/context"
+)
+
+const API = "https://api.cloudflare.com/client/v4"
+
+type client struct {
+	token     string
+	accountID string
+}
+
+func newClient(credentials map[string]interface{}) (*client, error) {
+
+	token, _ := credentials["token"].(string)
+	accountID, _ := credentials["account_id"].(string)
+	if token == "" || accountID == "" {
+		return nil, errors.New("cloudflare token and account ID are required")
+	}
+
+	return &client{
+		token:     token,
+		accountID: accountID,
+	}, nil
+}
+
+// Calls the endpoint of the account, and decodes the result.
+func (c *client) do(ctx context.ServiceContext, method, path string, body io.Reader, contentType string, result interface{}) error {
+
+	var headers []clients.CustomHeader
+	if contentType != "" {
+		headers = append(headers, clients.CustomHeader{
+			Key:   "Content-Type",
+			Value: contentType,
+		})
+	}
+
+	httpClient := clients.NewHTTPClient(&clients.HTTPConfig{
+		Type:          clients.HTTPClientType,
+		Authorization: "Bearer " + c.token,
+		CustomHeaders: headers,
+	})
+
+	req, err := http.NewRequestWithContext(ctx, method, fmt.Sprintf("%s/accounts/%s%s", API, url.PathEscape(c.accountID), path), body)
+	if err != nil {
+		return err
+	}
+
+	var response Response
+	if err := httpClient.Run(ctx, req, &response); err != nil {
+		return err
+	}
+
+	if !response.Success {
+		return fmt.Errorf("cloudflare request failed: %s", response.Error())
+	}
+
+	if result == nil || len(response.Result) == 0 {
+		return nil
+	}
+
+	return json.Unmarshal(response.Result, result)
+}
+
+func (c *client) doJSON(ctx context.ServiceContext, method, path string, body, result interface{}) error {
+
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewBuffer(payload)
+	}
+
+	return c.do(ctx, method, path, reader, "", result)
+}
+
+func (c *client) listScripts(ctx context.ServiceContext) ([]Script, error) {
+	var result []Script
+	return result, c.doJSON(ctx, http.MethodGet, "/workers/scripts", nil, &result)
+}
+
+func (c *client) listProjects(ctx context.ServiceContext) ([]Project, error) {
+	var result []Project
+	return result, c.doJSON(ctx, http.MethodGet, "/pages/projects", nil, &result)
+}
+
+// Creates or replaces a secret of the Worker script.
+func (c *client) putWorkerSecret(ctx context.ServiceContext, script, name, value string) error {
+	return c.doJSON(ctx, http.MethodPut, fmt.Sprintf("/workers/scripts/%s/secrets", url.PathEscape(script)), map[string]string{
+		"name": name,
+		"text": value,
+		"type": "secret_text",
+	}, nil)
+}
+
+// Deletes a secret of the Worker script.
+func (c *client) deleteWorkerSecret(ctx context.ServiceContext, script, name string) error {
+	return c.doJSON(ctx, http.MethodDelete, fmt.Sprintf("/workers/scripts/%s/secrets/%s", url.PathEscape(script), url.PathEscape(name)), nil, nil)
+}
+
+func (c *client) getWorkerSettings(ctx context.ServiceContext, script string) (*ScriptSettings, error) {
+	var result ScriptSettings
+	return &result, c.doJSON(ctx, http.MethodGet, fmt.Sprintf("/workers/scripts/%s/settings", url.PathEscape(script)), nil, &result)
+}
+
+// Replaces the bindings of the Worker script.
+// Secrets can't be read back, so they are kept from the previous version of the script.
+func (c *client) patchWorkerBindings(ctx context.ServiceContext, script string, bindings []Binding) error {
+
+	settings, err := json.Marshal(map[string]interface{}{
+		"bindings":      bindings,
+		"keep_bindings": []string{"secret_text", "secret_key"},
+	})
+	if err != nil {
+		return err
+	}
+
+	//	The settings endpoint only accepts multipart forms.
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	if err := writer.WriteField("settings", string(settings)); err != nil {
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+
+	return c.do(ctx, http.MethodPatch, fmt.Sprintf("/workers/scripts/%s/settings", url.PathEscape(script)), &body, writer.FormDataContentType(), nil)
+}
+
+// Sets the environment variables of a deployment environment of the Pages project.
+// Variables not included are left untouched.
+func (c *client) patchPagesEnvVars(ctx context.ServiceContext, project, environment string, vars map[string]EnvVar) error {
+	return c.doJSON(ctx, http.MethodPatch, fmt.Sprintf("/pages/projects/%s", url.PathEscape(project)), map[string]interface{}{
+		"deployment_configs": map[string]interface{}{
+			environment: map[string]interface{}{
+				"env_vars": vars,
+			},
+		},
+	}, nil)
+}
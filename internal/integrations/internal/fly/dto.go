@@ -0,0 +1,38 @@
+package fly
+
+import (
+	"encoding/json"
+
+	"github.com/envsecrets/envsecrets/internal/secrets/pkg/keypayload"
+)
+
+type ListOptions struct {
+	Credentials map[string]interface{}
+}
+
+type SyncOptions struct {
+	Credentials   map[string]interface{} `json:"credentials"`
+	EntityDetails map[string]interface{} `json:"entity_details"`
+	Data          *keypayload.KPMap      `json:"data"`
+}
+
+type App struct {
+	ID           string `json:"id"`
+	Name         string `json:"name"`
+	Organization struct {
+		Slug string `json:"slug"`
+	} `json:"organization"`
+}
+
+type SecretInput struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// Machine of the app, along with its configuration.
+// The configuration is kept raw, to be sent back as is when the machine is updated.
+type Machine struct {
+	ID     string          `json:"id"`
+	State  string          `json:"state"`
+	Config json.RawMessage `json:"config"`
+}
@@ -0,0 +1,167 @@
+package fly
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/envsecrets/envsecrets/internal/clients"
+	"github.com/envsecrets/envsecrets/internal/context"
+)
+
+const (
+	API         = "https://api.fly.io/graphql"
+	MachinesAPI = "https://api.machines.dev/v1"
+)
+
+func ListEntities(ctx context.ServiceContext, options *ListOptions) (interface{}, error) {
+
+	//	Initialize a new GraphQL client.
+	client := clients.NewGQLClient2(&clients.GQL2Config{
+		BaseURL: API,
+		Authorization: &clients.Authorization{
+			Token:     options.Credentials["token"].(string),
+			TokenType: clients.Bearer,
+		},
+	})
+
+	data, err := client.ExecRaw(ctx, `query MyQuery {
+		apps {
+			nodes {
+				id
+				name
+				organization {
+					slug
+				}
+			}
+		}
+	}`, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Apps struct {
+			Nodes []App `json:"nodes"`
+		} `json:"apps"`
+	}
+	if err := json.Unmarshal(data, &response); err != nil {
+		return nil, err
+	}
+
+	return response.Apps.Nodes, nil
+}
+
+// Sets the secrets of the app.
+//
+//	Secrets of machine apps only take effect once their machines are updated,
+//	which is skipped if the "stage" option is set in entity details.
+func Sync(ctx context.ServiceContext, options *SyncOptions) error {
+
+	app, _ := options.EntityDetails["name"].(string)
+	if app == "" {
+		return errors.New("app is required")
+	}
+
+	//	Initialize a new GraphQL client.
+	client := clients.NewGQLClient2(&clients.GQL2Config{
+		BaseURL: API,
+		Authorization: &clients.Authorization{
+			Token:     options.Credentials["token"].(string),
+			TokenType: clients.Bearer,
+		},
+	})
+
+	var secrets []SecretInput
+	for key, payload := range *options.Data {
+		secrets = append(secrets, SecretInput{
+			Key:   key,
+			Value: fmt.Sprint(payload.Value),
+		})
+	}
+
+	if len(secrets) == 0 {
+		return nil
+	}
+
+	if _, err := client.ExecRaw(ctx, `mutation MyMutation($input: SetSecretsInput!) {
+		setSecrets(input: $input) {
+			app {
+				name
+			}
+		}
+	}`, map[string]interface{}{
+		"input": map[string]interface{}{
+			"appId":   app,
+			"secrets": secrets,
+		},
+	}); err != nil {
+		return err
+	}
+
+	if stage, _ := options.EntityDetails["stage"].(bool); stage {
+		return nil
+	}
+
+	return deploy(ctx, options.Credentials["token"].(string), app)
+}
+
+// Updates every machine of the app with its current configuration,
+// so that they are restarted with the new secrets.
+func deploy(ctx context.ServiceContext, token, app string) error {
+
+	client := clients.NewHTTPClient(&clients.HTTPConfig{
+		Type:          clients.HTTPClientType,
+		Authorization: "Bearer " + token,
+		ResponseHandler: func(response *http.Response) error {
+			if response.StatusCode >= http.StatusMultipleChoices {
+				var body struct {
+					Error string `json:"error"`
+				}
+				json.NewDecoder(response.Body).Decode(&body)
+				return fmt.Errorf("fly.io responded with status %d: %s", response.StatusCode, body.Error)
+			}
+			return nil
+		},
+	})
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/apps/%s/machines", MachinesAPI, url.PathEscape(app)), nil)
+	if err != nil {
+		return err
+	}
+
+	var machines []Machine
+	if err := client.Run(ctx, req, &machines); err != nil {
+		return err
+	}
+
+	for _, machine := range machines {
+
+		//	Machines which have been destroyed can't be updated.
+		if machine.State == "destroyed" || machine.State == "destroying" {
+			continue
+		}
+
+		body, err := json.Marshal(map[string]interface{}{
+			"config": machine.Config,
+		})
+		if err != nil {
+			return err
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("%s/apps/%s/machines/%s", MachinesAPI, url.PathEscape(app), url.PathEscape(machine.ID)), bytes.NewBuffer(body))
+		if err != nil {
+			return err
+		}
+
+		var response Machine
+		if err := client.Run(ctx, req, &response); err != nil {
+			return fmt.Errorf("failed to update machine %s: %w", machine.ID, err)
+		}
+	}
+
+	return nil
+}
@@ -1,9 +1,38 @@
 package github
 
 import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
 	"github.com/envsecrets/envsecrets/internal/secrets/pkg/keypayload"
 )
 
+// Level at which the secrets are saved in Github.
+type Scope string
+
+const (
+	RepositoryScope   Scope = "repository"
+	EnvironmentScope  Scope = "environment"
+	OrganisationScope Scope = "organisation"
+)
+
+// Github feature that consumes the secrets.
+type Application string
+
+const (
+	Actions    Application = "actions"
+	Dependabot Application = "dependabot"
+	Codespaces Application = "codespaces"
+)
+
+// Largest pages Github serves for the listings of secrets and variables.
+const (
+	SECRETS_PAGE_SIZE   = 100
+	VARIABLES_PAGE_SIZE = 30
+)
+
 type SetupOptions struct {
 	InstallationID string
 	SetupAction    string
@@ -17,6 +46,26 @@ type SyncOptions struct {
 	Data           *keypayload.KPMap      `json:"data"`
 }
 
+type ImportOptions struct {
+	InstallationID string                 `json:"installation_id"`
+	EntityDetails  map[string]interface{} `json:"entity_details"`
+}
+
+type ListSecretsResponse struct {
+	TotalCount int `json:"total_count"`
+	Secrets    []struct {
+		Name string `json:"name"`
+	} `json:"secrets"`
+}
+
+type ListVariablesResponse struct {
+	TotalCount int `json:"total_count"`
+	Variables  []struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	} `json:"variables"`
+}
+
 type InstallationAccessTokenResponse struct {
 	Token                string `json:"token"`
 	ExpiresAt            string `json:"expires_at"`
@@ -30,7 +79,7 @@ type InstallationAccessTokenResponse struct {
 	} `json:"permissions"`
 }
 
-type RepositoryActionsSecretsPublicKeyResponse struct {
+type SecretsPublicKeyResponse struct {
 	Key   string `json:"key"`
 	KeyID string `json:"key_id"`
 }
@@ -54,3 +103,157 @@ type Repository struct {
 type ListOptions struct {
 	InstallationID string
 }
+
+// Destination of the secrets in Github, as configured in an event's entity details.
+type Target struct {
+
+	//	Full name of the repository. For example, "envsecrets/envsecrets".
+	Slug string
+
+	//	ID of the repository.
+	//	Used to grant the repository access to organisation secrets with "selected" visibility.
+	RepositoryID interface{}
+
+	Scope       Scope
+	Application Application
+
+	//	Name of the deployment environment in the repository.
+	Environment string
+
+	//	Login of the organisation owning the secrets.
+	Org string
+
+	//	Visibility of organisation secrets: "all", "private" or "selected".
+	Visibility string
+}
+
+// Parses the target from an event's entity details.
+//
+//	Defaults to the repository's Actions secrets,
+//	which is how events were configured before scopes were introduced.
+func NewTarget(details map[string]interface{}) (*Target, error) {
+
+	target := Target{
+		Scope:        RepositoryScope,
+		Application:  Actions,
+		RepositoryID: details["id"],
+		Visibility:   "selected",
+	}
+
+	if details["full_name"] != nil {
+		target.Slug = details["full_name"].(string)
+	}
+
+	if details["scope"] != nil {
+		target.Scope = Scope(details["scope"].(string))
+	}
+
+	if details["application"] != nil {
+		target.Application = Application(details["application"].(string))
+	}
+
+	if details["environment"] != nil {
+		target.Environment = details["environment"].(string)
+	}
+
+	if details["visibility"] != nil {
+		target.Visibility = details["visibility"].(string)
+	}
+
+	//	Default to the owner of the repository.
+	if details["org"] != nil {
+		target.Org = details["org"].(string)
+	} else if owner, _, found := strings.Cut(target.Slug, "/"); found {
+		target.Org = owner
+	}
+
+	switch target.Application {
+	case Actions, Dependabot, Codespaces:
+	default:
+		return nil, fmt.Errorf("invalid github application: %s", target.Application)
+	}
+
+	switch target.Scope {
+	case RepositoryScope:
+		if target.Slug == "" {
+			return nil, errors.New("repository is required")
+		}
+	case EnvironmentScope:
+		if target.Slug == "" || target.Environment == "" {
+			return nil, errors.New("both repository and environment are required")
+		}
+		if target.Application != Actions {
+			return nil, errors.New("environment secrets are only available to github actions")
+		}
+	case OrganisationScope:
+		if target.Org == "" {
+			return nil, errors.New("organisation is required")
+		}
+		if target.Visibility == "selected" && target.RepositoryID == nil {
+			return nil, errors.New("repository ID is required for secrets with selected visibility")
+		}
+	default:
+		return nil, fmt.Errorf("invalid github scope: %s", target.Scope)
+	}
+
+	return &target, nil
+}
+
+// Returns the base URL of the secrets endpoints for this target.
+func (t *Target) SecretsURL() string {
+	switch t.Scope {
+	case EnvironmentScope:
+		return fmt.Sprintf("https://api.github.com/repos/%s/environments/%s/secrets", t.Slug, t.Environment)
+	case OrganisationScope:
+		return fmt.Sprintf("https://api.github.com/orgs/%s/%s/secrets", t.Org, t.Application)
+	default:
+		return fmt.Sprintf("https://api.github.com/repos/%s/%s/secrets", t.Slug, t.Application)
+	}
+}
+
+// Returns the base URL of the variables endpoints for this target.
+func (t *Target) VariablesURL() string {
+	switch t.Scope {
+	case EnvironmentScope:
+		return fmt.Sprintf("https://api.github.com/repos/%s/environments/%s/variables", t.Slug, t.Environment)
+	case OrganisationScope:
+		return fmt.Sprintf("https://api.github.com/orgs/%s/actions/variables", t.Org)
+	default:
+		return fmt.Sprintf("https://api.github.com/repos/%s/actions/variables", t.Slug)
+	}
+}
+
+// Only Github Actions support plaintext variables.
+// Exposable values are synced as secrets for Dependabot and Codespaces.
+func (t *Target) SupportsVariables() bool {
+	return t.Application == Actions
+}
+
+// Adds the visibility fields required by organisation level secrets and variables.
+func (t *Target) withVisibility(body map[string]interface{}) map[string]interface{} {
+	if t.Scope != OrganisationScope {
+		return body
+	}
+
+	//	Repositories are granted access separately, through grantRepository,
+	//	since "selected_repository_ids" would replace the ones already selected.
+	body["visibility"] = t.Visibility
+
+	return body
+}
+
+// Organisation secrets with "selected" visibility must list the repository among those allowed to use them.
+func (t *Target) NeedsRepositoryAccess() bool {
+	return t.Scope == OrganisationScope && t.Visibility == "selected"
+}
+
+// Returns the repository ID as Github expects it in URLs.
+// IDs parsed from JSON entity details are floats, which fmt would print in exponent notation.
+func (t *Target) RepositoryIDString() string {
+	switch id := t.RepositoryID.(type) {
+	case float64:
+		return strconv.FormatFloat(id, 'f', -1, 64)
+	default:
+		return fmt.Sprint(id)
+	}
+}
@@ -12,6 +12,8 @@ import (
 
 	"github.com/envsecrets/envsecrets/internal/clients"
 	"github.com/envsecrets/envsecrets/internal/context"
+	"github.com/envsecrets/envsecrets/internal/secrets/pkg/keypayload"
+	"github.com/envsecrets/envsecrets/internal/secrets/pkg/payload"
 )
 
 func ListEntities(ctx context.ServiceContext, options *ListOptions) (interface{}, error) {
@@ -55,9 +57,10 @@ func ListRepositories(ctx context.ServiceContext, client *clients.HTTPClient) (*
 }
 
 // -- Flow --
-// 1. Get repository's action secrets public key.
-// 2. Encrypt the secret data.
-// 3. Post the secrets to Github actions endpoint.
+// 1. Parse the target repository, environment or organisation from entity details.
+// 2. Get the target's secrets public key, once per sync.
+// 3. Encrypt the secret data.
+// 4. Post the secrets and variables to the target's endpoints.
 func Sync(ctx context.ServiceContext, options *SyncOptions) error {
 
 	//	Get installation's access token
@@ -72,121 +75,281 @@ func Sync(ctx context.ServiceContext, options *SyncOptions) error {
 		Authorization: "Bearer " + auth.Token,
 	})
 
-	//	Extract the slug from entity details
-	slug := options.EntityDetails["full_name"].(string)
+	target, err := NewTarget(options.EntityDetails)
+	if err != nil {
+		return err
+	}
 
+	var publicKey *SecretsPublicKeyResponse
 	for key, payload := range *options.Data {
 
-		//	If the payload is of type `ciphertext`,
-		//	we have to encrypt its value and push it to Github action's secrets.
-		if !payload.IsExposable() {
-
-			//	Get the public key.
-			publicKey, err := getRepositoryActionsSecretsPublicKey(ctx, client, slug)
-			if err != nil {
+		//	If the payload is exposable, and the target supports it,
+		//	save it as a normal variable.
+		if payload.IsExposable() && target.SupportsVariables() {
+			if err := pushVariable(ctx, client, target, key, payload.GetValue()); err != nil {
 				return err
 			}
+			continue
+		}
 
-			//	Encrypt the secret value.
-			encryptedValue, err := encryptSecret(publicKey.Key, []byte(payload.GetValue()))
+		//	Get the public key.
+		if publicKey == nil {
+			publicKey, err = getSecretsPublicKey(ctx, client, target)
 			if err != nil {
 				return err
 			}
+		}
 
-			//	Add response handler to HTTP client.
-			client.ResponseHandler = func(response *http.Response) error {
+		//	Encrypt the secret value.
+		encryptedValue, err := encryptSecret(publicKey.Key, []byte(payload.GetValue()))
+		if err != nil {
+			return err
+		}
 
-				//	Github Responses:
-				//	201 -> New secret created
-				//	204 -> Existing secret updated
-				if response.StatusCode != http.StatusCreated && response.StatusCode != http.StatusNoContent {
-					return errors.New("failed to push secret to github repo")
-				}
-				return nil
-			}
+		//	Post the secret to Github.
+		if err := pushSecret(ctx, client, target, key, publicKey.KeyID, encryptedValue); err != nil {
+			return err
+		}
+	}
 
-			//	Post the secret to Github actions.
-			if err := pushRepositorySecret(ctx, client, slug, key, publicKey.KeyID, encryptedValue); err != nil {
-				return err
-			}
+	return nil
+}
+
+// -- Flow --
+// 1. List the names of the target's secrets.
+// 2. List the target's action variables along with their values.
+//
+// Github never exposes the values of secrets,
+// so they are imported with empty values and only serve as a checklist of key names.
+func Import(ctx context.ServiceContext, options *ImportOptions) (*keypayload.KPMap, error) {
+
+	//	Get installation's access token
+	auth, err := GetInstallationAccessToken(ctx, options.InstallationID)
+	if err != nil {
+		return nil, err
+	}
+
+	//	Initialize a new HTTP client for Github.
+	client := clients.NewHTTPClient(&clients.HTTPConfig{
+		Type:          clients.GithubClientType,
+		Authorization: "Bearer " + auth.Token,
+	})
 
-		} else {
+	target, err := NewTarget(options.EntityDetails)
+	if err != nil {
+		return nil, err
+	}
 
-			//	Add response handler to HTTP client.
-			client.ResponseHandler = func(response *http.Response) error {
+	result := keypayload.KPMap{}
 
-				//	Github Responses:
-				//	201 (Created) -> New variable created
-				//	409 (Conflict) -> Variable exists
-				if response.StatusCode == http.StatusConflict {
+	secrets, err := listSecrets(ctx, client, target)
+	if err != nil {
+		return nil, err
+	}
 
-					//	Delete the variable and recreate it.
-					if err := deleteRepositoryVariable(ctx, client, slug, key); err != nil {
-						return err
-					}
+	for _, secret := range secrets.Secrets {
+		result.Set(secret.Name, &payload.Payload{})
+	}
 
-					return pushRepositoryVariable(ctx, client, slug, key, payload.Value)
-				}
+	if !target.SupportsVariables() {
+		return &result, nil
+	}
 
-				return nil
-			}
+	variables, err := listVariables(ctx, client, target)
+	if err != nil {
+		return nil, err
+	}
 
-			//	If the payload type is `plaintext`,
-			//	save it as a normal variable in Github actions.
-			if err := pushRepositoryVariable(ctx, client, slug, key, payload.Value); err != nil {
-				return err
-			}
+	for _, variable := range variables.Variables {
+		result.Set(variable.Name, &payload.Payload{
+			Value:     variable.Value,
+			Exposable: true,
+		})
+	}
+
+	return &result, nil
+}
+
+// Github pages its listings, so keep fetching until every secret has been read.
+func listSecrets(ctx context.ServiceContext, client *clients.HTTPClient, target *Target) (*ListSecretsResponse, error) {
+
+	var result ListSecretsResponse
+	for page := 1; ; page++ {
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s?per_page=%d&page=%d", target.SecretsURL(), SECRETS_PAGE_SIZE, page), nil)
+		if err != nil {
+			return nil, err
+		}
+
+		var response ListSecretsResponse
+		if err := client.Run(ctx, req, &response); err != nil {
+			return nil, err
+		}
+
+		result.TotalCount = response.TotalCount
+		result.Secrets = append(result.Secrets, response.Secrets...)
+
+		if len(response.Secrets) < SECRETS_PAGE_SIZE || len(result.Secrets) >= response.TotalCount {
+			return &result, nil
 		}
 	}
+}
 
-	return nil
+// Github pages its listings, so keep fetching until every variable has been read.
+func listVariables(ctx context.ServiceContext, client *clients.HTTPClient, target *Target) (*ListVariablesResponse, error) {
+
+	var result ListVariablesResponse
+	for page := 1; ; page++ {
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s?per_page=%d&page=%d", target.VariablesURL(), VARIABLES_PAGE_SIZE, page), nil)
+		if err != nil {
+			return nil, err
+		}
+
+		var response ListVariablesResponse
+		if err := client.Run(ctx, req, &response); err != nil {
+			return nil, err
+		}
+
+		result.TotalCount = response.TotalCount
+		result.Variables = append(result.Variables, response.Variables...)
+
+		if len(response.Variables) < VARIABLES_PAGE_SIZE || len(result.Variables) >= response.TotalCount {
+			return &result, nil
+		}
+	}
 }
 
-func pushRepositorySecret(ctx context.ServiceContext, client *clients.HTTPClient, slug, secretName, keyID, value string) error {
+func pushSecret(ctx context.ServiceContext, client *clients.HTTPClient, target *Target, secretName, keyID, value string) error {
 
-	body, err := json.Marshal(map[string]interface{}{
+	body, err := json.Marshal(target.withVisibility(map[string]interface{}{
 		"encrypted_value": value,
 		"key_id":          keyID,
-	})
-
+	}))
 	if err != nil {
 		return err
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPut, fmt.Sprintf("https://api.github.com/repos/%s/actions/secrets/%s", slug, secretName), bytes.NewBuffer(body))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, fmt.Sprintf("%s/%s", target.SecretsURL(), secretName), bytes.NewBuffer(body))
 	if err != nil {
 		return err
 	}
 
-	return client.Run(ctx, req, nil)
+	//	Github Responses:
+	//	201 -> New secret created
+	//	204 -> Existing secret updated
+	client.ResponseHandler = func(response *http.Response) error {
+		if response.StatusCode != http.StatusCreated && response.StatusCode != http.StatusNoContent {
+			return fmt.Errorf("failed to push secret %s to github: %s", secretName, response.Status)
+		}
+		return nil
+	}
+	defer func() { client.ResponseHandler = nil }()
+
+	if err := client.Run(ctx, req, nil); err != nil {
+		return err
+	}
+
+	if !target.NeedsRepositoryAccess() {
+		return nil
+	}
+
+	return grantRepository(ctx, client, target, fmt.Sprintf("%s/%s", target.SecretsURL(), secretName))
 }
 
-func pushRepositoryVariable(ctx context.ServiceContext, client *clients.HTTPClient, slug, name, value string) error {
+// -- Flow --
+// 1. Create the variable.
+// 2. If it already exists, update its value instead.
+// 3. Grant the target's repository access to it, if its visibility is "selected".
+func pushVariable(ctx context.ServiceContext, client *clients.HTTPClient, target *Target, name, value string) error {
 
-	body, err := json.Marshal(map[string]interface{}{
+	body, err := json.Marshal(target.withVisibility(map[string]interface{}{
 		"name":  name,
 		"value": value,
-	})
+	}))
+	if err != nil {
+		return err
+	}
 
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target.VariablesURL(), bytes.NewBuffer(body))
 	if err != nil {
 		return err
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("https://api.github.com/repos/%s/actions/variables", slug), bytes.NewBuffer(body))
+	//	Github Responses:
+	//	201 (Created) -> New variable created
+	//	409 (Conflict) -> Variable exists
+	var exists bool
+	client.ResponseHandler = func(response *http.Response) error {
+		switch response.StatusCode {
+		case http.StatusCreated:
+			return nil
+		case http.StatusConflict:
+			exists = true
+			return nil
+		default:
+			return fmt.Errorf("failed to push variable %s to github: %s", name, response.Status)
+		}
+	}
+	defer func() { client.ResponseHandler = nil }()
+
+	if err := client.Run(ctx, req, nil); err != nil {
+		return err
+	}
+
+	if exists {
+		if err := updateVariable(ctx, client, target, name, body); err != nil {
+			return err
+		}
+	}
+
+	if !target.NeedsRepositoryAccess() {
+		return nil
+	}
+
+	return grantRepository(ctx, client, target, fmt.Sprintf("%s/%s", target.VariablesURL(), name))
+}
+
+func updateVariable(ctx context.ServiceContext, client *clients.HTTPClient, target *Target, name string, body []byte) error {
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPatch, fmt.Sprintf("%s/%s", target.VariablesURL(), name), bytes.NewBuffer(body))
 	if err != nil {
 		return err
 	}
 
+	//	Github Responses:
+	//	204 -> Existing variable updated
+	client.ResponseHandler = func(response *http.Response) error {
+		if response.StatusCode != http.StatusNoContent {
+			return fmt.Errorf("failed to update variable %s in github: %s", name, response.Status)
+		}
+		return nil
+	}
+	defer func() { client.ResponseHandler = nil }()
+
 	return client.Run(ctx, req, nil)
 }
 
-func deleteRepositoryVariable(ctx context.ServiceContext, client *clients.HTTPClient, slug, name string) error {
+// Adds the target's repository to those selected for an organisation secret or variable,
+// leaving the repositories already selected untouched.
+func grantRepository(ctx context.ServiceContext, client *clients.HTTPClient, target *Target, url string) error {
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, fmt.Sprintf("https://api.github.com/repos/%s/actions/variables/%s", slug, name), nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, fmt.Sprintf("%s/repositories/%s", url, target.RepositoryIDString()), nil)
 	if err != nil {
 		return err
 	}
 
+	//	Github Responses:
+	//	204 -> Repository added, or already selected
+	//	409 -> Visibility isn't "selected"
+	client.ResponseHandler = func(response *http.Response) error {
+		if response.StatusCode != http.StatusNoContent {
+			return fmt.Errorf("failed to grant repository %s access in github: %s", target.RepositoryIDString(), response.Status)
+		}
+		return nil
+	}
+	defer func() { client.ResponseHandler = nil }()
+
 	return client.Run(ctx, req, nil)
 }
 
@@ -227,19 +390,22 @@ func GetInstallationAccessToken(ctx context.ServiceContext, installationID strin
 	return &response, nil
 }
 
-// Fetches the public key for action secrets for supplied repository slug.
-func getRepositoryActionsSecretsPublicKey(ctx context.ServiceContext, client *clients.HTTPClient, slug string) (*RepositoryActionsSecretsPublicKeyResponse, error) {
+// Fetches the public key used to encrypt the secrets of the supplied target.
+func getSecretsPublicKey(ctx context.ServiceContext, client *clients.HTTPClient, target *Target) (*SecretsPublicKeyResponse, error) {
 
-	//	Get user's access token from Github API.
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("https://api.github.com/repos/%s/actions/secrets/public-key", slug), nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target.SecretsURL()+"/public-key", nil)
 	if err != nil {
 		return nil, err
 	}
 
-	var response RepositoryActionsSecretsPublicKeyResponse
+	var response SecretsPublicKeyResponse
 	if err := client.Run(ctx, req, &response); err != nil {
 		return nil, err
 	}
 
+	if response.Key == "" {
+		return nil, errors.New("failed to fetch the secrets public key from github")
+	}
+
 	return &response, nil
 }
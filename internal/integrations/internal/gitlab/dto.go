@@ -2,6 +2,7 @@ package gitlab
 
 import (
 	"encoding/json"
+	"time"
 
 	"github.com/envsecrets/envsecrets/internal/secrets/pkg/keypayload"
 )
@@ -30,16 +31,28 @@ type PrepareCredentialsOptions struct {
 }
 
 type TokenRefreshOptions struct {
-	RefreshToken  string
+	Credentials   map[string]interface{}
 	OrgID         string
 	IntegrationID string
 }
 
 type TokenResponse struct {
-	TokenType    string `json:"token_type"`
-	AccessToken  string `json:"access_token"`
-	ExpiresIn    int    `json:"expires_in"`
-	RefreshToken string `json:"refresh_token"`
+	TokenType        string `json:"token_type"`
+	AccessToken      string `json:"access_token"`
+	ExpiresIn        int    `json:"expires_in"`
+	RefreshToken     string `json:"refresh_token"`
+	Error            string `json:"error,omitempty"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
+
+// Converts the tokens to credentials to be saved in the database.
+func (t *TokenResponse) toCredentials() map[string]interface{} {
+	return map[string]interface{}{
+		"token_type":    t.TokenType,
+		"refresh_token": t.RefreshToken,
+		"access_token":  t.AccessToken,
+		"expires_at":    time.Now().Add(time.Duration(t.ExpiresIn) * time.Second).Unix(),
+	}
 }
 
 type ListOptions struct {
@@ -57,6 +70,13 @@ type SyncOptions struct {
 	OrgID         string                 `json:"org_id"`
 }
 
+type ImportOptions struct {
+	Credentials   map[string]interface{} `json:"credentials"`
+	EntityDetails map[string]interface{} `json:"entity_details"`
+	IntegrationID string                 `json:"integration_id"`
+	OrgID         string                 `json:"org_id"`
+}
+
 type ListProjectsResponse []Project
 
 type Project struct {
@@ -81,6 +101,24 @@ type CreateVariableOptions struct {
 	Variable Variable
 }
 
+// Per-event options for the variables, read from the event's entity details.
+type VariableOptions struct {
+	EnvironmentScope string `json:"environment_scope,omitempty"`
+
+	//	Masks the values in job logs.
+	//	If not specified, non-exposable values are masked whenever Gitlab allows it.
+	Masked *bool `json:"masked,omitempty"`
+
+	//	Exposes the variables only to protected branches and tags.
+	Protected bool `json:"protected,omitempty"`
+
+	//	Disables the expansion of variable references in the values.
+	Raw bool `json:"raw,omitempty"`
+
+	//	Either "env_var" or "file".
+	VariableType string `json:"variable_type,omitempty"`
+}
+
 type CreateVariableResponse struct {
 	Message map[string]interface{} `json:"message"`
 	Key     string                 `json:"key" form:"key"`
@@ -89,8 +127,10 @@ type CreateVariableResponse struct {
 type Variable struct {
 	Key              string `json:"key" form:"key"`
 	Value            string `json:"value" form:"value"`
-	Protected        bool   `json:"protected,omitempty" form:"protected,omitempty"`
-	Masked           bool   `json:"masked,omitempty" form:"masked,omitempty"`
+	Protected        bool   `json:"protected" form:"protected"`
+	Masked           bool   `json:"masked" form:"masked"`
+	Raw              bool   `json:"raw" form:"raw"`
+	VariableType     string `json:"variable_type,omitempty" form:"variable_type,omitempty"`
 	EnvironmentScope string `json:"environment_scope,omitempty" form:"environment_scope,omitempty"`
 }
 
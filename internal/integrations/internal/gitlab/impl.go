@@ -2,12 +2,17 @@ package gitlab
 
 import (
 	"fmt"
+	"io"
+	"net/http"
 	"os"
 
 	"errors"
 
 	"github.com/envsecrets/envsecrets/internal/clients"
 	"github.com/envsecrets/envsecrets/internal/context"
+	"github.com/envsecrets/envsecrets/internal/secrets/pkg/keypayload"
+	"github.com/envsecrets/envsecrets/internal/secrets/pkg/payload"
+	"github.com/envsecrets/envsecrets/utils"
 )
 
 // Prepares credentials to be saved in the database.
@@ -22,17 +27,14 @@ func PrepareCredentials(ctx context.ServiceContext, options *PrepareCredentialsO
 		return nil, err
 	}
 
-	return map[string]interface{}{
-		"token_type":    response.TokenType,
-		"refresh_token": response.RefreshToken,
-	}, nil
+	return response.toCredentials(), nil
 }
 
 func ListEntities(ctx context.ServiceContext, options *ListOptions) (interface{}, error) {
 
 	//	Refresh access token
 	access, err := RefreshToken(ctx, &TokenRefreshOptions{
-		RefreshToken:  options.Credentials["refresh_token"].(string),
+		Credentials:   options.Credentials,
 		OrgID:         options.OrgID,
 		IntegrationID: options.IntegrationID,
 	})
@@ -60,7 +62,7 @@ func Sync(ctx context.ServiceContext, options *SyncOptions) error {
 
 	//	Refresh access token
 	access, err := RefreshToken(ctx, &TokenRefreshOptions{
-		RefreshToken:  options.Credentials["refresh_token"].(string),
+		Credentials:   options.Credentials,
 		OrgID:         options.OrgID,
 		IntegrationID: options.IntegrationID,
 	})
@@ -72,32 +74,154 @@ func Sync(ctx context.ServiceContext, options *SyncOptions) error {
 	client := clients.NewHTTPClient(&clients.HTTPConfig{
 		Type:          clients.HTTPClientType,
 		Authorization: fmt.Sprintf("%s %s", access.TokenType, access.AccessToken),
+		ResponseHandler: func(response *http.Response) error {
+			if response.StatusCode >= http.StatusMultipleChoices {
+				body, _ := io.ReadAll(response.Body)
+				return fmt.Errorf("gitlab responded with status %d: %s", response.StatusCode, body)
+			}
+			return nil
+		},
 	})
 
+	//	Load the per-event variable options.
+	var variableOptions VariableOptions
+	if err := utils.MapToStruct(options.EntityDetails, &variableOptions); err != nil {
+		return err
+	}
+
+	if variableOptions.EnvironmentScope == "" {
+		variableOptions.EnvironmentScope = "*"
+	}
+
+	if variableOptions.VariableType != "" && variableOptions.VariableType != "env_var" && variableOptions.VariableType != "file" {
+		return fmt.Errorf("invalid gitlab variable type: %s", variableOptions.VariableType)
+	}
+
+	entityType := EntityType(fmt.Sprint(options.EntityDetails["type"]))
+	id := getEntityID(options.EntityDetails)
+
+	//	Fetch the existing variables,
+	//	to decide whether each key needs to be created or updated.
+	var existing []Variable
+	switch entityType {
+	case ProjectType:
+		existing, err = ListProjectVariables(ctx, client, id)
+	case GroupType:
+		existing, err = ListGroupVariables(ctx, client, id)
+	default:
+		return errors.New("invalid entity type")
+	}
+	if err != nil {
+		return err
+	}
+
+	exists := make(map[string]bool)
+	for _, variable := range existing {
+		if variable.EnvironmentScope == variableOptions.EnvironmentScope {
+			exists[variable.Key] = true
+		}
+	}
+
 	for key, payload := range *options.Data {
 
-		switch EntityType(options.EntityDetails["type"].(string)) {
+		value := fmt.Sprint(payload.Value)
+
+		variable := Variable{
+			Key:              key,
+			Value:            value,
+			Protected:        variableOptions.Protected,
+			Raw:              variableOptions.Raw,
+			VariableType:     variableOptions.VariableType,
+			EnvironmentScope: variableOptions.EnvironmentScope,
+		}
+
+		//	Unless explicitly specified,
+		//	mask the non-exposable values which Gitlab is able to mask.
+		if variableOptions.Masked != nil {
+			variable.Masked = *variableOptions.Masked
+		} else {
+			variable.Masked = !payload.IsExposable() && isMaskable(value)
+		}
+
+		createOptions := &CreateVariableOptions{
+			ID:       id,
+			Variable: variable,
+		}
+
+		switch entityType {
 		case ProjectType:
-			if _, err := CreateProjectVariable(ctx, client, &CreateVariableOptions{
-				ID: int64(options.EntityDetails["id"].(float64)),
-				Variable: Variable{
-					Key:   key,
-					Value: fmt.Sprint(payload.Value),
-				},
-			}); err != nil {
-				return err
+			if exists[key] {
+				_, err = UpdateProjectVariable(ctx, client, createOptions)
+			} else {
+				_, err = CreateProjectVariable(ctx, client, createOptions)
 			}
 		case GroupType:
-			if _, err := CreateGroupVariable(ctx, client, &CreateVariableOptions{
-				ID: int64(options.EntityDetails["id"].(float64)),
-				Variable: Variable{
-					Key:   key,
-					Value: fmt.Sprint(payload.Value),
-				},
-			}); err != nil {
-				return err
+			if exists[key] {
+				_, err = UpdateGroupVariable(ctx, client, createOptions)
+			} else {
+				_, err = CreateGroupVariable(ctx, client, createOptions)
 			}
 		}
+		if err != nil {
+			return fmt.Errorf("failed to sync %s: %w", key, err)
+		}
 	}
 	return nil
 }
+
+// Fetches the variables of the Gitlab project or group.
+// Only the variables available to all environments, or to the environment scope
+// specified in entity details, are imported.
+func Import(ctx context.ServiceContext, options *ImportOptions) (*keypayload.KPMap, error) {
+
+	//	Refresh access token
+	access, err := RefreshToken(ctx, &TokenRefreshOptions{
+		Credentials:   options.Credentials,
+		OrgID:         options.OrgID,
+		IntegrationID: options.IntegrationID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	//	Initialize a new HTTP client.
+	client := clients.NewHTTPClient(&clients.HTTPConfig{
+		Type:          clients.HTTPClientType,
+		Authorization: fmt.Sprintf("%s %s", access.TokenType, access.AccessToken),
+	})
+
+	var variables []Variable
+	switch EntityType(fmt.Sprint(options.EntityDetails["type"])) {
+	case ProjectType:
+		variables, err = ListProjectVariables(ctx, client, getEntityID(options.EntityDetails))
+	case GroupType:
+		variables, err = ListGroupVariables(ctx, client, getEntityID(options.EntityDetails))
+	default:
+		return nil, errors.New("invalid entity type")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	scope := "*"
+	if options.EntityDetails["environment_scope"] != nil {
+		scope = options.EntityDetails["environment_scope"].(string)
+	}
+
+	//	Variables available to all environments are loaded first,
+	//	so that the ones scoped to the requested environment can override them.
+	result := keypayload.KPMap{}
+	for _, match := range []string{"*", scope} {
+		for _, variable := range variables {
+			if variable.EnvironmentScope != match {
+				continue
+			}
+
+			result.Set(variable.Key, &payload.Payload{
+				Value: variable.Value,
+			})
+		}
+	}
+
+	return &result, nil
+}
@@ -7,7 +7,10 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/envsecrets/envsecrets/internal/clients"
 	"github.com/envsecrets/envsecrets/internal/context"
@@ -110,7 +113,10 @@ func CreateGroupVariable(ctx context.ServiceContext, client *clients.HTTPClient,
 // Updates an existing variable.
 func UpdateProjectVariable(ctx context.ServiceContext, client *clients.HTTPClient, options *CreateVariableOptions) (*Variable, error) {
 
-	URL := fmt.Sprintf("https://gitlab.com/api/v4/projects/%v/variables/%s", options.ID, options.Variable.Key)
+	URL := fmt.Sprintf("https://gitlab.com/api/v4/projects/%s/variables/%s", url.PathEscape(fmt.Sprint(options.ID)), url.PathEscape(options.Variable.Key))
+	if options.Variable.EnvironmentScope != "" {
+		URL += "?filter[environment_scope]=" + url.QueryEscape(options.Variable.EnvironmentScope)
+	}
 
 	body, err := options.Variable.Marshal()
 	if err != nil {
@@ -133,7 +139,10 @@ func UpdateProjectVariable(ctx context.ServiceContext, client *clients.HTTPClien
 // Updates an existing variable.
 func UpdateGroupVariable(ctx context.ServiceContext, client *clients.HTTPClient, options *CreateVariableOptions) (*Variable, error) {
 
-	URL := fmt.Sprintf("https://gitlab.com/api/v4/groups/%v/variables/%s", options.ID, options.Variable.Key)
+	URL := fmt.Sprintf("https://gitlab.com/api/v4/groups/%s/variables/%s", url.PathEscape(fmt.Sprint(options.ID)), url.PathEscape(options.Variable.Key))
+	if options.Variable.EnvironmentScope != "" {
+		URL += "?filter[environment_scope]=" + url.QueryEscape(options.Variable.EnvironmentScope)
+	}
 
 	body, err := options.Variable.Marshal()
 	if err != nil {
@@ -153,6 +162,42 @@ func UpdateGroupVariable(ctx context.ServiceContext, client *clients.HTTPClient,
 	return &response, nil
 }
 
+// Fetches all the variables of a project.
+func ListProjectVariables(ctx context.ServiceContext, client *clients.HTTPClient, id interface{}) ([]Variable, error) {
+	return listVariables(ctx, client, fmt.Sprintf("https://gitlab.com/api/v4/projects/%s/variables", url.PathEscape(fmt.Sprint(id))))
+}
+
+// Fetches all the variables of a group.
+func ListGroupVariables(ctx context.ServiceContext, client *clients.HTTPClient, id interface{}) ([]Variable, error) {
+	return listVariables(ctx, client, fmt.Sprintf("https://gitlab.com/api/v4/groups/%s/variables", url.PathEscape(fmt.Sprint(id))))
+}
+
+// Walks through every page of the variables endpoint.
+func listVariables(ctx context.ServiceContext, client *clients.HTTPClient, URL string) ([]Variable, error) {
+
+	var result []Variable
+	for page := 1; ; page++ {
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s?per_page=100&page=%d", URL, page), nil)
+		if err != nil {
+			return nil, err
+		}
+
+		var response []Variable
+		if err := client.Run(ctx, req, &response); err != nil {
+			return nil, err
+		}
+
+		result = append(result, response...)
+
+		if len(response) < 100 {
+			break
+		}
+	}
+
+	return result, nil
+}
+
 func GetAccessToken(ctx context.ServiceContext, options *TokenRequestOptions) (*TokenResponse, error) {
 
 	//	Initialize a new HTTP client.
@@ -200,14 +245,32 @@ func GetAccessToken(ctx context.ServiceContext, options *TokenRequestOptions) (*
 		return nil, err
 	}
 
+	if response.Error != "" {
+		return nil, fmt.Errorf("failed to get gitlab access token: %s", response.ErrorDescription)
+	}
+
 	return &response, nil
 }
 
+// Returns a valid access token, and only refreshes it once the saved one has expired.
+//
+//	Gitlab rotates the refresh token every time it is used,
+//	so the new pair of tokens is always saved back to the integration.
 func RefreshToken(ctx context.ServiceContext, options *TokenRefreshOptions) (*TokenResponse, error) {
 
+	//	Reuse the saved access token if it is valid for at least another minute.
+	if accessToken, ok := options.Credentials["access_token"].(string); ok && accessToken != "" {
+		if getExpiry(options.Credentials).After(time.Now().Add(time.Minute)) {
+			return &TokenResponse{
+				TokenType:   fmt.Sprint(options.Credentials["token_type"]),
+				AccessToken: accessToken,
+			}, nil
+		}
+	}
+
 	//	Generate a fresh pair of tokens
 	tokens, err := GetAccessToken(ctx, &TokenRequestOptions{
-		RefreshToken: options.RefreshToken,
+		RefreshToken: options.Credentials["refresh_token"].(string),
 	})
 	if err != nil {
 		return nil, err
@@ -216,11 +279,10 @@ func RefreshToken(ctx context.ServiceContext, options *TokenRefreshOptions) (*To
 	//	Save updated credentials in Hasura.
 	if tokens.RefreshToken != "" {
 
+		updated := tokens.toCredentials()
+
 		//	Encrypt the credentials
-		credentials, err := commons.EncryptCredentials(ctx, options.OrgID, map[string]interface{}{
-			"token_type":    tokens.TokenType,
-			"refresh_token": tokens.RefreshToken,
-		})
+		credentials, err := commons.EncryptCredentials(ctx, options.OrgID, updated)
 		if err != nil {
 			return nil, err
 		}
@@ -240,7 +302,54 @@ func RefreshToken(ctx context.ServiceContext, options *TokenRefreshOptions) (*To
 		if err != nil {
 			return nil, err
 		}
+
+		//	Keep the caller's copy of credentials fresh as well,
+		//	since the old refresh token is no longer valid.
+		for key, value := range updated {
+			options.Credentials[key] = value
+		}
 	}
 
 	return tokens, nil
 }
+
+// Returns the expiry of the saved access token.
+func getExpiry(credentials map[string]interface{}) time.Time {
+	switch value := credentials["expires_at"].(type) {
+	case float64:
+		return time.Unix(int64(value), 0)
+	case int64:
+		return time.Unix(value, 0)
+	default:
+		return time.Time{}
+	}
+}
+
+// Gitlab only masks values which are at least 8 characters long,
+// fit in a single line, and only contain characters from the Base64 alphabet
+// along with a few additional ones.
+//
+//	Ref: https://docs.gitlab.com/ee/ci/variables/#mask-a-cicd-variable
+func isMaskable(value string) bool {
+	if len(value) < 8 {
+		return false
+	}
+	for _, char := range value {
+		switch {
+		case char >= 'a' && char <= 'z', char >= 'A' && char <= 'Z', char >= '0' && char <= '9':
+		case strings.ContainsRune("+/=@:.~-_", char):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// Returns the ID of the project or group from the entity details.
+// Numeric IDs are decoded from JSON as floats, which must not be formatted in exponent notation.
+func getEntityID(details map[string]interface{}) interface{} {
+	if id, ok := details["id"].(float64); ok {
+		return int64(id)
+	}
+	return details["id"]
+}
@@ -18,3 +18,56 @@ type SyncOptions struct {
 	EntityDetails map[string]interface{} `json:"entity_details"`
 	Data          *keypayload.KPMap      `json:"data"`
 }
+
+type ImportOptions struct {
+	OrgID         string                 `json:"org_id"`
+	Credentials   map[string]interface{} `json:"credentials"`
+	EntityDetails map[string]interface{} `json:"entity_details"`
+}
+
+type Mode string
+
+const (
+
+	//	Stores the entire environment as a single secret.
+	JSONMode Mode = "json"
+
+	//	Stores every key as a separate secret, named with a prefix.
+	KeysMode Mode = "keys"
+)
+
+// Per-event options, read from the event's entity details.
+type SecretOptions struct {
+	Name   string            `json:"name,omitempty"`
+	Mode   Mode              `json:"mode,omitempty"`
+	Prefix string            `json:"prefix,omitempty"`
+	Labels map[string]string `json:"labels,omitempty"`
+
+	//	Replication and encryption can only be chosen while creating the secret.
+	Replication Replication `json:"replication,omitempty"`
+
+	//	Disables the previous versions after the new one is written,
+	//	so that they are no longer billed as active versions.
+	DisablePrevious bool `json:"disable_previous,omitempty"`
+}
+
+type Replication struct {
+
+	//	Uses automatic replication, unless locations are specified.
+	Locations []string `json:"locations,omitempty"`
+
+	//	Customer-managed key for automatic replication.
+	KMSKeyName string `json:"kms_key_name,omitempty"`
+
+	//	Customer-managed keys for user-managed replication, by location.
+	KMSKeyNames map[string]string `json:"kms_key_names,omitempty"`
+}
+
+type putSecretOptions struct {
+	Parent          string
+	SecretID        string
+	Payload         []byte
+	Labels          map[string]string
+	Replication     Replication
+	DisablePrevious bool
+}
@@ -3,10 +3,12 @@ package gsm
 import (
 	"encoding/json"
 	"fmt"
-	"strings"
 
 	secretmanager "cloud.google.com/go/secretmanager/apiv1"
 	"github.com/envsecrets/envsecrets/internal/context"
+	"github.com/envsecrets/envsecrets/internal/integrations/commons"
+	"github.com/envsecrets/envsecrets/internal/secrets/pkg/keypayload"
+	"github.com/envsecrets/envsecrets/utils"
 	"google.golang.org/api/option"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -64,30 +66,75 @@ func Sync(ctx context.ServiceContext, options *SyncOptions) error {
 	}
 	defer client.Close()
 
-	PARENT := fmt.Sprintf("projects/%v", options.Credentials["project_id"])
-
-	//	Prepare the payload
-	payload, err := options.Data.Marshal()
-	if err != nil {
+	var secretOptions SecretOptions
+	if err := utils.MapToStruct(options.EntityDetails, &secretOptions); err != nil {
 		return err
 	}
 
-	//	Create a new version
-	_, err = AddSecretVersion(ctx, client, fmt.Sprintf("%s/secrets/%s", PARENT, options.EntityDetails["name"].(string)), payload)
-	if err != nil {
-
-		//	Create the secret if it doesn't exist
-		if strings.Contains(err.Error(), "NotFound") {
+	PARENT := fmt.Sprintf("projects/%v", options.Credentials["project_id"])
 
-			if _, err = CreateSecret(ctx, client, PARENT, options.EntityDetails["name"].(string)); err != nil {
-				return err
+	switch secretOptions.Mode {
+	case KeysMode:
+
+		//	Write one secret per key, named with the prefix.
+		for key, payload := range *options.Data {
+			if err := putSecret(ctx, client, &putSecretOptions{
+				Parent:          PARENT,
+				SecretID:        secretOptions.Prefix + key,
+				Payload:         []byte(fmt.Sprint(payload.Value)),
+				Labels:          secretOptions.Labels,
+				Replication:     secretOptions.Replication,
+				DisablePrevious: secretOptions.DisablePrevious,
+			}); err != nil {
+				return fmt.Errorf("failed to sync %s: %w", key, err)
 			}
+		}
+		return nil
+
+	case JSONMode, "":
 
-			return Sync(ctx, options)
+		//	Prepare the payload
+		payload, err := options.Data.Marshal()
+		if err != nil {
+			return err
 		}
 
-		return err
+		return putSecret(ctx, client, &putSecretOptions{
+			Parent:          PARENT,
+			SecretID:        secretOptions.Name,
+			Payload:         payload,
+			Labels:          secretOptions.Labels,
+			Replication:     secretOptions.Replication,
+			DisablePrevious: secretOptions.DisablePrevious,
+		})
+
+	default:
+		return fmt.Errorf("invalid secret manager mode: %s", secretOptions.Mode)
+	}
+}
+
+// Fetches the latest version of the secret and parses its key=value pairs.
+func Import(ctx context.ServiceContext, options *ImportOptions) (*keypayload.KPMap, error) {
+
+	//	Marshal the credentials
+	creds, err := json.Marshal(options.Credentials)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create the client.
+	client, err := secretmanager.NewClient(ctx, option.WithCredentialsJSON(creds))
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	PARENT := fmt.Sprintf("projects/%v", options.Credentials["project_id"])
+
+	data, err := AccessSecretVersion(ctx, client, fmt.Sprintf("%s/secrets/%s/versions/latest", PARENT, options.EntityDetails["name"].(string)))
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
+	return commons.ParseSecretString(data)
 }
@@ -6,31 +6,53 @@ import (
 	secretmanager "cloud.google.com/go/secretmanager/apiv1"
 	secretmanagerpb "cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
 	"github.com/envsecrets/envsecrets/internal/context"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
 )
 
-func CreateSecret(ctx context.ServiceContext, client *secretmanager.Client, parent string, secretID string) (string, error) {
+func CreateSecret(ctx context.ServiceContext, client *secretmanager.Client, options *putSecretOptions) (string, error) {
 
 	// Build the request.
 	req := &secretmanagerpb.CreateSecretRequest{
-		Parent:   parent,
-		SecretId: secretID,
+		Parent:   options.Parent,
+		SecretId: options.SecretID,
 		Secret: &secretmanagerpb.Secret{
-			Replication: &secretmanagerpb.Replication{
-				Replication: &secretmanagerpb.Replication_Automatic_{
-					Automatic: &secretmanagerpb.Replication_Automatic{},
-				},
-			},
+			Labels:      options.Labels,
+			Replication: options.Replication.toProto(),
 		},
 	}
 
 	// Call the API.
 	result, err := client.CreateSecret(ctx, req)
 	if err != nil {
-		return "", fmt.Errorf("failed to create secret: %v", err)
+		return "", fmt.Errorf("failed to create secret: %w", err)
 	}
 	return result.Name, nil
 }
 
+// Overwrites the labels of an existing secret.
+func UpdateSecretLabels(ctx context.ServiceContext, client *secretmanager.Client, name string, labels map[string]string) error {
+
+	// Build the request.
+	req := &secretmanagerpb.UpdateSecretRequest{
+		Secret: &secretmanagerpb.Secret{
+			Name:   name,
+			Labels: labels,
+		},
+		UpdateMask: &fieldmaskpb.FieldMask{
+			Paths: []string{"labels"},
+		},
+	}
+
+	// Call the API.
+	if _, err := client.UpdateSecret(ctx, req); err != nil {
+		return fmt.Errorf("failed to update secret labels: %w", err)
+	}
+	return nil
+}
+
 // AddSecretVersion adds a new secret version to the given secret path with the
 // provided payload.
 func AddSecretVersion(ctx context.ServiceContext, client *secretmanager.Client, path string, payload []byte) (string, error) {
@@ -46,8 +68,120 @@ func AddSecretVersion(ctx context.ServiceContext, client *secretmanager.Client,
 	// Call the API.
 	result, err := client.AddSecretVersion(ctx, req)
 	if err != nil {
-		return "", fmt.Errorf("failed to add secret version: %v", err)
+		return "", fmt.Errorf("failed to add secret version: %w", err)
 	}
 
 	return result.Name, nil
 }
+
+// DisableSecretVersions disables every enabled version of the secret, except the one to keep.
+func DisableSecretVersions(ctx context.ServiceContext, client *secretmanager.Client, path string, keep string) error {
+
+	it := client.ListSecretVersions(ctx, &secretmanagerpb.ListSecretVersionsRequest{
+		Parent: path,
+		Filter: "state:ENABLED",
+	})
+
+	for {
+		version, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to list secret versions: %w", err)
+		}
+
+		if version.Name == keep || version.State != secretmanagerpb.SecretVersion_ENABLED {
+			continue
+		}
+
+		if _, err := client.DisableSecretVersion(ctx, &secretmanagerpb.DisableSecretVersionRequest{
+			Name: version.Name,
+		}); err != nil {
+			return fmt.Errorf("failed to disable secret version: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Adds a new version to the secret, creating the secret first if it doesn't exist yet.
+func putSecret(ctx context.ServiceContext, client *secretmanager.Client, options *putSecretOptions) error {
+
+	path := fmt.Sprintf("%s/secrets/%s", options.Parent, options.SecretID)
+
+	version, err := AddSecretVersion(ctx, client, path, options.Payload)
+	if status.Code(err) == codes.NotFound {
+		if _, err := CreateSecret(ctx, client, options); err != nil {
+			return err
+		}
+		version, err = AddSecretVersion(ctx, client, path, options.Payload)
+	} else if err == nil && len(options.Labels) > 0 {
+		err = UpdateSecretLabels(ctx, client, path, options.Labels)
+	}
+	if err != nil {
+		return err
+	}
+
+	if options.DisablePrevious {
+		return DisableSecretVersions(ctx, client, path, version)
+	}
+
+	return nil
+}
+
+func (r *Replication) toProto() *secretmanagerpb.Replication {
+
+	if len(r.Locations) == 0 {
+		automatic := &secretmanagerpb.Replication_Automatic{}
+		if r.KMSKeyName != "" {
+			automatic.CustomerManagedEncryption = &secretmanagerpb.CustomerManagedEncryption{
+				KmsKeyName: r.KMSKeyName,
+			}
+		}
+
+		return &secretmanagerpb.Replication{
+			Replication: &secretmanagerpb.Replication_Automatic_{
+				Automatic: automatic,
+			},
+		}
+	}
+
+	var replicas []*secretmanagerpb.Replication_UserManaged_Replica
+	for _, location := range r.Locations {
+		replica := &secretmanagerpb.Replication_UserManaged_Replica{
+			Location: location,
+		}
+		if key, ok := r.KMSKeyNames[location]; ok {
+			replica.CustomerManagedEncryption = &secretmanagerpb.CustomerManagedEncryption{
+				KmsKeyName: key,
+			}
+		}
+		replicas = append(replicas, replica)
+	}
+
+	return &secretmanagerpb.Replication{
+		Replication: &secretmanagerpb.Replication_UserManaged_{
+			UserManaged: &secretmanagerpb.Replication_UserManaged{
+				Replicas: replicas,
+			},
+		},
+	}
+}
+
+// AccessSecretVersion fetches the payload of the given secret version path.
+func AccessSecretVersion(ctx context.ServiceContext, client *secretmanager.Client, path string) ([]byte, error) {
+
+	// Build the request.
+	req := &secretmanagerpb.AccessSecretVersionRequest{
+		Name: path,
+	}
+
+	// Call the API.
+	result, err := client.AccessSecretVersion(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to access secret version: %v", err)
+	}
+
+	return result.Payload.Data, nil
+}
@@ -1,9 +1,13 @@
 package heroku
 
 import (
+	"time"
+
 	"github.com/envsecrets/envsecrets/internal/secrets/pkg/keypayload"
 )
 
+const API = "https://api.heroku.com"
+
 type SetupOptions struct {
 	Token string
 	OrgID string
@@ -21,7 +25,7 @@ type PrepareCredentialsOptions struct {
 }
 
 type TokenRefreshOptions struct {
-	RefreshToken  string
+	Credentials   map[string]interface{}
 	OrgID         string
 	IntegrationID string
 }
@@ -33,6 +37,15 @@ type TokenResponse struct {
 	RefreshToken string `json:"refresh_token"`
 }
 
+func (t *TokenResponse) toCredentials() map[string]interface{} {
+	return map[string]interface{}{
+		"token_type":    t.TokenType,
+		"refresh_token": t.RefreshToken,
+		"access_token":  t.AccessToken,
+		"expires_at":    time.Now().Add(time.Duration(t.ExpiresIn) * time.Second).Unix(),
+	}
+}
+
 type ListOptions struct {
 	Credentials   map[string]interface{}
 	OrgID         string
@@ -47,6 +60,13 @@ type SyncOptions struct {
 	OrgID         string                 `json:"org_id"`
 }
 
+type ImportOptions struct {
+	Credentials   map[string]interface{} `json:"credentials"`
+	EntityDetails map[string]interface{} `json:"entity_details"`
+	IntegrationID string                 `json:"integration_id"`
+	OrgID         string                 `json:"org_id"`
+}
+
 type ListProjectsResponse []Project
 
 type Project struct {
@@ -55,6 +75,27 @@ type Project struct {
 	WebURL string `json:"web_url,omitempty"`
 }
 
+type Pipeline struct {
+	ID   string `json:"id"`
+	Name string `json:"name,omitempty"`
+}
+
+type PipelineCoupling struct {
+	Stage string  `json:"stage"`
+	App   Project `json:"app"`
+}
+
+// Per-event options, read from the event's entity details.
+type Target struct {
+
+	//	ID of the app, when syncing to a single app.
+	ID string `json:"id,omitempty"`
+
+	//	Pipeline and its stage, when syncing to every app coupled to the stage.
+	Pipeline *Pipeline `json:"pipeline,omitempty"`
+	Stage    string    `json:"stage,omitempty"`
+}
+
 type CreateVariableOptions struct {
 	ID       interface{}
 	Variable Variable
@@ -1,14 +1,15 @@
 package heroku
 
 import (
-	"bytes"
-	"encoding/json"
 	"fmt"
 	"net/http"
 	"os"
 
-	"github.com/envsecrets/envsecrets/internal/clients"
 	"github.com/envsecrets/envsecrets/internal/context"
+	"github.com/envsecrets/envsecrets/internal/integrations/commons"
+	"github.com/envsecrets/envsecrets/internal/secrets/pkg/keypayload"
+	"github.com/envsecrets/envsecrets/internal/secrets/pkg/payload"
+	"github.com/envsecrets/envsecrets/utils"
 )
 
 // Prepares credentials to be saved in the database.
@@ -23,17 +24,14 @@ func PrepareCredentials(ctx context.ServiceContext, options *PrepareCredentialsO
 		return nil, err
 	}
 
-	return map[string]interface{}{
-		"token_type":    response.TokenType,
-		"refresh_token": response.RefreshToken,
-	}, nil
+	return response.toCredentials(), nil
 }
 
 func ListEntities(ctx context.ServiceContext, options *ListOptions) (interface{}, error) {
 
 	//	Refresh access token
 	access, err := RefreshToken(ctx, &TokenRefreshOptions{
-		RefreshToken:  options.Credentials["refresh_token"].(string),
+		Credentials:   options.Credentials,
 		OrgID:         options.OrgID,
 		IntegrationID: options.IntegrationID,
 	})
@@ -42,18 +40,9 @@ func ListEntities(ctx context.ServiceContext, options *ListOptions) (interface{}
 	}
 
 	//	Initialize a new HTTP client.
-	client := clients.NewHTTPClient(&clients.HTTPConfig{
-		Type:          clients.HTTPClientType,
-		Authorization: fmt.Sprintf("%s %s", access.TokenType, access.AccessToken),
-		CustomHeaders: []clients.CustomHeader{
-			{
-				Key:   "Accept",
-				Value: "application/vnd.heroku+json; version=3",
-			},
-		},
-	})
+	client := newClient(access)
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://api.heroku.com/apps", nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, API+"/apps", nil)
 	if err != nil {
 		return nil, err
 	}
@@ -66,11 +55,27 @@ func ListEntities(ctx context.ServiceContext, options *ListOptions) (interface{}
 	return &response, nil
 }
 
+// Lists the pipelines, whose stages can be synced instead of individual apps.
+func ListSubEntities(ctx context.ServiceContext, options *ListOptions) (interface{}, error) {
+
+	//	Refresh access token
+	access, err := RefreshToken(ctx, &TokenRefreshOptions{
+		Credentials:   options.Credentials,
+		OrgID:         options.OrgID,
+		IntegrationID: options.IntegrationID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return listPipelines(ctx, newClient(access))
+}
+
 func Sync(ctx context.ServiceContext, options *SyncOptions) error {
 
 	//	Refresh access token
 	access, err := RefreshToken(ctx, &TokenRefreshOptions{
-		RefreshToken:  options.Credentials["refresh_token"].(string),
+		Credentials:   options.Credentials,
 		OrgID:         options.OrgID,
 		IntegrationID: options.IntegrationID,
 	})
@@ -79,31 +84,55 @@ func Sync(ctx context.ServiceContext, options *SyncOptions) error {
 	}
 
 	//	Initialize a new HTTP client.
-	client := clients.NewHTTPClient(&clients.HTTPConfig{
-		Authorization: fmt.Sprintf("%s %s", access.TokenType, access.AccessToken),
-		CustomHeaders: []clients.CustomHeader{
-			{
-				Key:   "Accept",
-				Value: "application/vnd.heroku+json; version=3",
-			},
-		},
-	})
+	client := newClient(access)
 
-	//	Prepare the secrets.
-	body, err := json.Marshal(options.Data.ToKVMap())
-	if err != nil {
+	var target Target
+	if err := utils.MapToStruct(options.EntityDetails, &target); err != nil {
 		return err
 	}
 
-	url := fmt.Sprintf("https://api.heroku.com/apps/%v/config-vars", options.EntityDetails["id"])
-	req, err := http.NewRequestWithContext(ctx, http.MethodPatch, url, bytes.NewBuffer(body))
+	paths, err := target.paths(ctx, client)
 	if err != nil {
 		return err
 	}
 
-	if err := client.Run(ctx, req, nil); err != nil {
-		return err
+	for _, path := range paths {
+		if err := putConfigVars(ctx, client, path, options.Data); err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
+
+// Fetches the config vars of the Heroku app.
+func Import(ctx context.ServiceContext, options *ImportOptions) (*keypayload.KPMap, error) {
+
+	//	Refresh access token
+	access, err := RefreshToken(ctx, &TokenRefreshOptions{
+		Credentials:   options.Credentials,
+		OrgID:         options.OrgID,
+		IntegrationID: options.IntegrationID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := getConfigVars(ctx, newClient(access), fmt.Sprintf("/apps/%v/config-vars", options.EntityDetails["id"]))
+	if err != nil {
+		return nil, err
+	}
+
+	result := keypayload.KPMap{}
+	for key, value := range response {
+		if key == commons.ManagedKeysVariable {
+			continue
+		}
+
+		result.Set(key, &payload.Payload{
+			Value: value,
+		})
+	}
+
+	return &result, nil
+}
@@ -1,17 +1,22 @@
 package heroku
 
 import (
+	"bytes"
 	"encoding/base64"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/url"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/envsecrets/envsecrets/internal/clients"
 	"github.com/envsecrets/envsecrets/internal/context"
 	"github.com/envsecrets/envsecrets/internal/integrations/commons"
 	"github.com/envsecrets/envsecrets/internal/integrations/graphql"
+	"github.com/envsecrets/envsecrets/internal/secrets/pkg/keypayload"
 )
 
 func GetAccessToken(ctx context.ServiceContext, options *TokenRequestOptions) (*TokenResponse, error) {
@@ -57,42 +62,199 @@ func GetAccessToken(ctx context.ServiceContext, options *TokenRequestOptions) (*
 
 func RefreshToken(ctx context.ServiceContext, options *TokenRefreshOptions) (*TokenResponse, error) {
 
+	//	Reuse the saved access token if it is valid for at least another minute.
+	if accessToken, ok := options.Credentials["access_token"].(string); ok && accessToken != "" {
+		if getExpiry(options.Credentials).After(time.Now().Add(time.Minute)) {
+			return &TokenResponse{
+				TokenType:   fmt.Sprint(options.Credentials["token_type"]),
+				AccessToken: accessToken,
+			}, nil
+		}
+	}
+
 	//	Generate a fresh pair of tokens
 	tokens, err := GetAccessToken(ctx, &TokenRequestOptions{
-		RefreshToken: options.RefreshToken,
+		RefreshToken: options.Credentials["refresh_token"].(string),
 	})
 	if err != nil {
 		return nil, err
 	}
 
+	//	Heroku may not rotate the refresh token,
+	//	in which case the existing one remains valid.
+	if tokens.RefreshToken == "" {
+		tokens.RefreshToken = options.Credentials["refresh_token"].(string)
+	}
+
+	updated := tokens.toCredentials()
+
+	//	Encrypt the credentials
+	credentials, err := commons.EncryptCredentials(ctx, options.OrgID, updated)
+	if err != nil {
+		return nil, err
+	}
+
+	//	Initialize Hasura client with admin privileges
+	client := clients.NewGQLClient(&clients.GQLConfig{
+		Type: clients.HasuraClientType,
+		Headers: []clients.Header{
+			clients.XHasuraAdminSecretHeader,
+		},
+	})
+
 	//	Save updated credentials in Hasura.
-	if tokens.RefreshToken != "" {
-
-		//	Encrypt the credentials
-		credentials, err := commons.EncryptCredentials(ctx, options.OrgID, map[string]interface{}{
-			"token_type":    tokens.TokenType,
-			"refresh_token": tokens.RefreshToken,
-		})
-		if err != nil {
-			return nil, err
-		}
+	err = graphql.UpdateCredentials(ctx, client, &graphql.UpdateCredentialsOptions{
+		ID:          options.IntegrationID,
+		Credentials: base64.StdEncoding.EncodeToString(credentials),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	//	Keep the caller's copy of credentials fresh as well.
+	for key, value := range updated {
+		options.Credentials[key] = value
+	}
 
-		//	Initialize Hasura client with admin privileges
-		client := clients.NewGQLClient(&clients.GQLConfig{
-			Type: clients.HasuraClientType,
-			Headers: []clients.Header{
-				clients.XHasuraAdminSecretHeader,
+	return tokens, nil
+}
+
+func getExpiry(credentials map[string]interface{}) time.Time {
+	switch value := credentials["expires_at"].(type) {
+	case float64:
+		return time.Unix(int64(value), 0)
+	case int64:
+		return time.Unix(value, 0)
+	default:
+		return time.Time{}
+	}
+}
+
+// Initializes a new HTTP client for the Platform API.
+func newClient(access *TokenResponse) *clients.HTTPClient {
+	return clients.NewHTTPClient(&clients.HTTPConfig{
+		Type:          clients.HTTPClientType,
+		Authorization: fmt.Sprintf("%s %s", access.TokenType, access.AccessToken),
+		CustomHeaders: []clients.CustomHeader{
+			{
+				Key:   "Accept",
+				Value: "application/vnd.heroku+json; version=3",
 			},
-		})
-
-		err = graphql.UpdateCredentials(ctx, client, &graphql.UpdateCredentialsOptions{
-			ID:          options.IntegrationID,
-			Credentials: base64.StdEncoding.EncodeToString(credentials),
-		})
-		if err != nil {
-			return nil, err
+		},
+		ResponseHandler: func(response *http.Response) error {
+			if response.StatusCode >= http.StatusMultipleChoices {
+				var body struct {
+					Message string `json:"message"`
+				}
+				json.NewDecoder(response.Body).Decode(&body)
+				return fmt.Errorf("heroku responded with status %d: %s", response.StatusCode, body.Message)
+			}
+			return nil
+		},
+	})
+}
+
+func listPipelines(ctx context.ServiceContext, client *clients.HTTPClient) ([]Pipeline, error) {
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, API+"/pipelines", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response []Pipeline
+	if err := client.Run(ctx, req, &response); err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// Returns the config vars endpoints of the target.
+//
+// Review and test stages have their own config vars, shared by the apps created for them.
+// For every other stage, the config vars of each app coupled to the stage are synced.
+func (t *Target) paths(ctx context.ServiceContext, client *clients.HTTPClient) ([]string, error) {
+
+	if t.Pipeline == nil {
+		if t.ID == "" {
+			return nil, errors.New("app is required")
 		}
+		return []string{fmt.Sprintf("/apps/%s/config-vars", url.PathEscape(t.ID))}, nil
 	}
 
-	return tokens, nil
+	switch t.Stage {
+	case "review", "test":
+		return []string{fmt.Sprintf("/pipelines/%s/stage/%s/config-vars", url.PathEscape(t.Pipeline.ID), t.Stage)}, nil
+	case "development", "staging", "production":
+	default:
+		return nil, fmt.Errorf("invalid pipeline stage: %s", t.Stage)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/pipelines/%s/pipeline-couplings", API, url.PathEscape(t.Pipeline.ID)), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var couplings []PipelineCoupling
+	if err := client.Run(ctx, req, &couplings); err != nil {
+		return nil, err
+	}
+
+	var result []string
+	for _, coupling := range couplings {
+		if coupling.Stage == t.Stage {
+			result = append(result, fmt.Sprintf("/apps/%s/config-vars", url.PathEscape(coupling.App.ID)))
+		}
+	}
+
+	return result, nil
+}
+
+func getConfigVars(ctx context.ServiceContext, client *clients.HTTPClient, path string) (map[string]string, error) {
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, API+path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response map[string]string
+	if err := client.Run(ctx, req, &response); err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// Sets the config vars, and unsets the ones we synced earlier which have since been removed.
+func putConfigVars(ctx context.ServiceContext, client *clients.HTTPClient, path string, data *keypayload.KPMap) error {
+
+	existing, err := getConfigVars(ctx, client, path)
+	if err != nil {
+		return err
+	}
+
+	//	Heroku removes the config vars which are set to null.
+	body := make(map[string]interface{})
+	for _, key := range commons.RemovedKeys(existing[commons.ManagedKeysVariable], data) {
+		if _, ok := existing[key]; ok {
+			body[key] = nil
+		}
+	}
+
+	for key, value := range *data.ToKVMap() {
+		body[key] = value
+	}
+	body[commons.ManagedKeysVariable] = commons.FormatManagedKeys(data)
+
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPatch, API+path, bytes.NewBuffer(payload))
+	if err != nil {
+		return err
+	}
+
+	return client.Run(ctx, req, nil)
 }
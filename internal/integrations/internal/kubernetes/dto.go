@@ -0,0 +1,131 @@
+package kubernetes
+
+import (
+	"github.com/envsecrets/envsecrets/internal/secrets/pkg/keypayload"
+)
+
+type AuthMethod string
+
+const (
+	KubeconfigAuth AuthMethod = "kubeconfig"
+	TokenAuth      AuthMethod = "token"
+)
+
+const (
+	ManagedByLabel      = "app.kubernetes.io/managed-by"
+	ManagedByValue      = "envsecrets"
+	VersionAnnotation   = "envsecrets.com/version"
+	ChecksumAnnotation  = "envsecrets.com/checksum"
+	RestartAnnotation   = "envsecrets.com/restart-on-change"
+	RestartedAnnotation = "kubectl.kubernetes.io/restartedAt"
+)
+
+type Credentials struct {
+	AuthMethod AuthMethod `json:"auth_method"`
+
+	//	For kubeconfig authentication.
+	Kubeconfig string `json:"kubeconfig,omitempty"`
+
+	//	For service account token authentication.
+	APIURL   string `json:"api_url,omitempty"`
+	Token    string `json:"token,omitempty"`
+	CAData   string `json:"ca_data,omitempty"`
+	Insecure bool   `json:"insecure,omitempty"`
+}
+
+type ListOptions struct {
+	Credentials map[string]interface{}
+	Namespace   string
+}
+
+type SyncOptions struct {
+	Credentials   map[string]interface{} `json:"credentials"`
+	EntityDetails map[string]interface{} `json:"entity_details"`
+	Data          *keypayload.KPMap      `json:"data"`
+	Version       *int                   `json:"version,omitempty"`
+}
+
+// Per-event options, read from the event's entity details.
+type Target struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+
+	//	Restarts the deployments annotated with the name of the secret,
+	//	every time the secret changes.
+	Restart bool `json:"restart,omitempty"`
+}
+
+type Entity struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Managed   bool   `json:"managed"`
+}
+
+type ObjectMeta struct {
+	Name            string            `json:"name"`
+	Namespace       string            `json:"namespace,omitempty"`
+	ResourceVersion string            `json:"resourceVersion,omitempty"`
+	Labels          map[string]string `json:"labels,omitempty"`
+	Annotations     map[string]string `json:"annotations,omitempty"`
+}
+
+type Secret struct {
+	APIVersion string            `json:"apiVersion"`
+	Kind       string            `json:"kind"`
+	Metadata   ObjectMeta        `json:"metadata"`
+	Type       string            `json:"type,omitempty"`
+	StringData map[string]string `json:"stringData,omitempty"`
+}
+
+type SecretList struct {
+	Items []struct {
+		Metadata ObjectMeta `json:"metadata"`
+	} `json:"items"`
+}
+
+type NamespaceList struct {
+	Items []struct {
+		Metadata ObjectMeta `json:"metadata"`
+	} `json:"items"`
+}
+
+type DeploymentList struct {
+	Items []struct {
+		Metadata ObjectMeta `json:"metadata"`
+	} `json:"items"`
+}
+
+type Status struct {
+	Kind    string `json:"kind"`
+	Message string `json:"message"`
+	Reason  string `json:"reason"`
+	Code    int    `json:"code"`
+}
+
+// Subset of the kubeconfig file needed to connect with the current context.
+type kubeconfig struct {
+	CurrentContext string `yaml:"current-context"`
+	Clusters       []struct {
+		Name    string `yaml:"name"`
+		Cluster struct {
+			Server                   string `yaml:"server"`
+			CertificateAuthorityData string `yaml:"certificate-authority-data"`
+			InsecureSkipTLSVerify    bool   `yaml:"insecure-skip-tls-verify"`
+		} `yaml:"cluster"`
+	} `yaml:"clusters"`
+	Contexts []struct {
+		Name    string `yaml:"name"`
+		Context struct {
+			Cluster string `yaml:"cluster"`
+			User    string `yaml:"user"`
+		} `yaml:"context"`
+	} `yaml:"contexts"`
+	Users []struct {
+		Name string `yaml:"name"`
+		User struct {
+			Token                 string `yaml:"token"`
+			ClientCertificateData string `yaml:"client-certificate-data"`
+			ClientKeyData         string `yaml:"client-key-data"`
+		} `yaml:"user"`
+	} `yaml:"users"`
+}
@@ -0,0 +1,112 @@
+package kubernetes
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/envsecrets/envsecrets/internal/context"
+	"github.com/envsecrets/envsecrets/utils"
+)
+
+// Lists the opaque secrets of the cluster.
+func ListEntities(ctx context.ServiceContext, options *ListOptions) (interface{}, error) {
+
+	cluster, err := newCluster(options.Credentials)
+	if err != nil {
+		return nil, err
+	}
+
+	return cluster.listSecrets(ctx, options.Namespace)
+}
+
+// Lists the namespaces of the cluster, to create new secrets in.
+func ListSubEntities(ctx context.ServiceContext, options *ListOptions) (interface{}, error) {
+
+	cluster, err := newCluster(options.Credentials)
+	if err != nil {
+		return nil, err
+	}
+
+	return cluster.listNamespaces(ctx)
+}
+
+func Sync(ctx context.ServiceContext, options *SyncOptions) error {
+
+	var target Target
+	if err := utils.MapToStruct(options.EntityDetails, &target); err != nil {
+		return err
+	}
+
+	if target.Namespace == "" || target.Name == "" {
+		return errors.New("namespace and name of the secret are required")
+	}
+
+	cluster, err := newCluster(options.Credentials)
+	if err != nil {
+		return err
+	}
+
+	data := make(map[string]string)
+	for key, payload := range *options.Data {
+		data[key] = fmt.Sprint(payload.Value)
+	}
+
+	//	--- Flow ---
+	//	1. Fetch the existing secret, to preserve its metadata.
+	//	2. Replace its data, and record the version and checksum of our secrets.
+	//	3. Restart the annotated deployments, if the data has changed.
+
+	existing, err := cluster.getSecret(ctx, target.Namespace, target.Name)
+	if err != nil && !errors.Is(err, errNotFound) {
+		return err
+	}
+
+	secret := Secret{
+		APIVersion: "v1",
+		Kind:       "Secret",
+		Type:       "Opaque",
+		Metadata: ObjectMeta{
+			Name:        target.Name,
+			Namespace:   target.Namespace,
+			Labels:      map[string]string{},
+			Annotations: map[string]string{},
+		},
+		StringData: data,
+	}
+
+	var previous string
+	if existing != nil {
+		if existing.Type != "" && existing.Type != "Opaque" {
+			return fmt.Errorf("secret %s/%s is of type %s, and not Opaque", target.Namespace, target.Name, existing.Type)
+		}
+
+		secret.Metadata.ResourceVersion = existing.Metadata.ResourceVersion
+		for key, value := range existing.Metadata.Labels {
+			secret.Metadata.Labels[key] = value
+		}
+		for key, value := range existing.Metadata.Annotations {
+			secret.Metadata.Annotations[key] = value
+		}
+
+		previous = existing.Metadata.Annotations[ChecksumAnnotation]
+	}
+
+	secret.Metadata.Labels[ManagedByLabel] = ManagedByValue
+	secret.Metadata.Annotations[ChecksumAnnotation] = checksum(data)
+	if options.Version != nil {
+		secret.Metadata.Annotations[VersionAnnotation] = fmt.Sprint(*options.Version)
+	}
+
+	//	The whole object is replaced instead of patched,
+	//	so that keys removed from the environment don't linger in the secret.
+	if err := cluster.putSecret(ctx, &secret, existing != nil); err != nil {
+		return err
+	}
+
+	if target.Restart && previous != secret.Metadata.Annotations[ChecksumAnnotation] {
+		return cluster.restartDeployments(ctx, target.Namespace, target.Name, time.Now().Format(time.RFC3339))
+	}
+
+	return nil
+}
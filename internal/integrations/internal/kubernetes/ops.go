@@ -0,0 +1,321 @@
+package kubernetes
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/envsecrets/envsecrets/internal/clients"
+	"github.com/envsecrets/envsecrets/internal/context"
+	"github.com/envsecrets/envsecrets/utils"
+	"gopkg.in/yaml.v2"
+)
+
+var errNotFound = errors.New("not found")
+
+// Statuses returned by the API server carry the reason of failure.
+func handleResponse(response *http.Response) error {
+	if response.StatusCode < http.StatusMultipleChoices {
+		return nil
+	}
+
+	if response.StatusCode == http.StatusNotFound {
+		return errNotFound
+	}
+
+	var status Status
+	json.NewDecoder(response.Body).Decode(&status)
+	return fmt.Errorf("kubernetes responded with status %d: %s", response.StatusCode, status.Message)
+}
+
+// Connection details of the cluster.
+type cluster struct {
+	server string
+	client *clients.HTTPClient
+}
+
+// Initializes a new HTTP client for the API server of the cluster.
+func newCluster(credentials map[string]interface{}) (*cluster, error) {
+
+	var creds Credentials
+	if err := utils.MapToStruct(credentials, &creds); err != nil {
+		return nil, err
+	}
+
+	var server, token, caData string
+	var insecure bool
+	var certificates []tls.Certificate
+
+	switch creds.AuthMethod {
+	case KubeconfigAuth:
+
+		var config kubeconfig
+		if err := yaml.Unmarshal([]byte(creds.Kubeconfig), &config); err != nil {
+			return nil, fmt.Errorf("failed to parse kubeconfig: %w", err)
+		}
+
+		var clusterName, userName string
+		for _, item := range config.Contexts {
+			if item.Name == config.CurrentContext {
+				clusterName = item.Context.Cluster
+				userName = item.Context.User
+			}
+		}
+
+		for _, item := range config.Clusters {
+			if item.Name == clusterName {
+				server = item.Cluster.Server
+				caData = item.Cluster.CertificateAuthorityData
+				insecure = item.Cluster.InsecureSkipTLSVerify
+			}
+		}
+
+		for _, item := range config.Users {
+			if item.Name != userName {
+				continue
+			}
+
+			token = item.User.Token
+
+			if item.User.ClientCertificateData != "" {
+				cert, err := base64.StdEncoding.DecodeString(item.User.ClientCertificateData)
+				if err != nil {
+					return nil, err
+				}
+
+				key, err := base64.StdEncoding.DecodeString(item.User.ClientKeyData)
+				if err != nil {
+					return nil, err
+				}
+
+				pair, err := tls.X509KeyPair(cert, key)
+				if err != nil {
+					return nil, err
+				}
+
+				certificates = append(certificates, pair)
+			}
+		}
+
+		if token == "" && len(certificates) == 0 {
+			return nil, errors.New("kubeconfig must contain a token or client certificate for the current context")
+		}
+
+	case TokenAuth:
+		server = creds.APIURL
+		token = creds.Token
+		caData = creds.CAData
+		insecure = creds.Insecure
+
+	default:
+		return nil, fmt.Errorf("unsupported kubernetes auth method: %s", creds.AuthMethod)
+	}
+
+	if server == "" {
+		return nil, errors.New("kubernetes API server URL is required")
+	}
+
+	tlsConfig := tls.Config{
+		Certificates:       certificates,
+		InsecureSkipVerify: insecure,
+	}
+
+	if caData != "" {
+		ca, err := base64.StdEncoding.DecodeString(caData)
+		if err != nil {
+			return nil, err
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, errors.New("invalid certificate authority data")
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	config := clients.HTTPConfig{
+		Type:            clients.HTTPClientType,
+		ResponseHandler: handleResponse,
+	}
+
+	if token != "" {
+		config.Authorization = "Bearer " + token
+	}
+
+	client := clients.NewHTTPClient(&config)
+	client.Client.Transport = &http.Transport{
+		TLSClientConfig: &tlsConfig,
+	}
+
+	return &cluster{
+		server: strings.TrimSuffix(server, "/"),
+		client: client,
+	}, nil
+}
+
+func (c *cluster) do(ctx context.ServiceContext, method, path string, body, response interface{}) error {
+
+	var payload []byte
+	if body != nil {
+		var err error
+		payload, err = json.Marshal(body)
+		if err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.server+path, bytes.NewBuffer(payload))
+	if err != nil {
+		return err
+	}
+
+	return c.client.Run(ctx, req, response)
+}
+
+// Fetches the names of all namespaces.
+func (c *cluster) listNamespaces(ctx context.ServiceContext) ([]string, error) {
+
+	var response NamespaceList
+	if err := c.do(ctx, http.MethodGet, "/api/v1/namespaces", nil, &response); err != nil {
+		return nil, err
+	}
+
+	var result []string
+	for _, item := range response.Items {
+		result = append(result, item.Metadata.Name)
+	}
+
+	return result, nil
+}
+
+// Fetches the opaque secrets, either of a namespace or of the entire cluster.
+func (c *cluster) listSecrets(ctx context.ServiceContext, namespace string) ([]Entity, error) {
+
+	path := "/api/v1/secrets"
+	if namespace != "" {
+		path = fmt.Sprintf("/api/v1/namespaces/%s/secrets", url.PathEscape(namespace))
+	}
+
+	var response SecretList
+	if err := c.do(ctx, http.MethodGet, path+"?fieldSelector="+url.QueryEscape("type=Opaque"), nil, &response); err != nil {
+		return nil, err
+	}
+
+	var result []Entity
+	for _, item := range response.Items {
+		result = append(result, Entity{
+			Namespace: item.Metadata.Namespace,
+			Name:      item.Metadata.Name,
+			Managed:   item.Metadata.Labels[ManagedByLabel] == ManagedByValue,
+		})
+	}
+
+	return result, nil
+}
+
+// Fetches the metadata of a secret.
+func (c *cluster) getSecret(ctx context.ServiceContext, namespace, name string) (*Secret, error) {
+
+	var response Secret
+	if err := c.do(ctx, http.MethodGet, fmt.Sprintf("/api/v1/namespaces/%s/secrets/%s", url.PathEscape(namespace), url.PathEscape(name)), nil, &response); err != nil {
+		return nil, err
+	}
+
+	return &response, nil
+}
+
+// Creates the secret, or replaces it if it already exists.
+func (c *cluster) putSecret(ctx context.ServiceContext, secret *Secret, exists bool) error {
+
+	var response Secret
+	if !exists {
+		return c.do(ctx, http.MethodPost, fmt.Sprintf("/api/v1/namespaces/%s/secrets", url.PathEscape(secret.Metadata.Namespace)), secret, &response)
+	}
+
+	return c.do(ctx, http.MethodPut, fmt.Sprintf("/api/v1/namespaces/%s/secrets/%s", url.PathEscape(secret.Metadata.Namespace), url.PathEscape(secret.Metadata.Name)), secret, &response)
+}
+
+// Restarts the deployments of the namespace which are annotated with the name of the secret.
+func (c *cluster) restartDeployments(ctx context.ServiceContext, namespace, secret, timestamp string) error {
+
+	var response DeploymentList
+	if err := c.do(ctx, http.MethodGet, fmt.Sprintf("/apis/apps/v1/namespaces/%s/deployments", url.PathEscape(namespace)), nil, &response); err != nil {
+		return err
+	}
+
+	//	Deployments are patched the same way "kubectl rollout restart" does,
+	//	which requires a merge patch instead of plain JSON.
+	patcher := *c.client
+	patcher.CustomHeaders = append(patcher.CustomHeaders, clients.CustomHeader{
+		Key:   "Content-Type",
+		Value: "application/merge-patch+json",
+	})
+
+	for _, item := range response.Items {
+
+		var matches bool
+		for _, name := range strings.Split(item.Metadata.Annotations[RestartAnnotation], ",") {
+			if strings.TrimSpace(name) == secret {
+				matches = true
+			}
+		}
+
+		if !matches {
+			continue
+		}
+
+		body, err := json.Marshal(map[string]interface{}{
+			"spec": map[string]interface{}{
+				"template": map[string]interface{}{
+					"metadata": map[string]interface{}{
+						"annotations": map[string]string{
+							RestartedAnnotation: timestamp,
+						},
+					},
+				},
+			},
+		})
+		if err != nil {
+			return err
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPatch, fmt.Sprintf("%s/apis/apps/v1/namespaces/%s/deployments/%s", c.server, url.PathEscape(namespace), url.PathEscape(item.Metadata.Name)), bytes.NewBuffer(body))
+		if err != nil {
+			return err
+		}
+
+		var deployment map[string]interface{}
+		if err := patcher.Run(ctx, req, &deployment); err != nil {
+			return fmt.Errorf("failed to restart deployment %s: %w", item.Metadata.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// Returns a checksum of the key-value pairs, independent of their order.
+func checksum(data map[string]string) string {
+
+	var keys []string
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	hash := sha256.New()
+	for _, key := range keys {
+		fmt.Fprintf(hash, "%s=%s\n", key, data[key])
+	}
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
@@ -2,8 +2,11 @@ package netlify
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 
 	"github.com/envsecrets/envsecrets/internal/secrets/pkg/keypayload"
+	"github.com/envsecrets/envsecrets/utils"
 )
 
 type SetupOptions struct {
@@ -26,6 +29,7 @@ type User struct {
 type Site struct {
 	ID          string `json:"id"`
 	Name        string `json:"name"`
+	AccountID   string `json:"account_id"`
 	AccountSlug string `json:"account_slug"`
 }
 
@@ -38,3 +42,78 @@ type SyncOptions struct {
 	EntityDetails map[string]interface{} `json:"entity_details"`
 	Data          *keypayload.KPMap      `json:"data"`
 }
+
+type ImportOptions struct {
+	Credentials   map[string]interface{} `json:"credentials"`
+	EntityDetails map[string]interface{} `json:"entity_details"`
+}
+
+type EnvVar struct {
+	Key      string     `json:"key"`
+	Scopes   []string   `json:"scopes,omitempty"`
+	Values   []EnvValue `json:"values"`
+	IsSecret bool       `json:"is_secret,omitempty"`
+}
+
+type EnvValue struct {
+	Value            string `json:"value"`
+	Context          string `json:"context"`
+	ContextParameter string `json:"context_parameter,omitempty"`
+}
+
+// Returns true if the value belongs to the given deploy context.
+func (v *EnvValue) matches(target *Target) bool {
+	return v.Context == target.Context && v.ContextParameter == target.Branch
+}
+
+// Per-event options, read from the event's entity details.
+type Target struct {
+
+	//	Netlify deploy context to sync the secrets to.
+	//	Any of "all", "production", "deploy-preview", "branch-deploy", "dev" and "branch".
+	Context string `json:"context,omitempty"`
+
+	//	Name of the branch, if the deploy context is "branch".
+	Branch string `json:"branch,omitempty"`
+
+	//	Netlify scopes the variables are available to.
+	Scopes []string `json:"scopes,omitempty"`
+}
+
+// Loads the deploy context and scopes from entity details.
+func NewTarget(details map[string]interface{}, defaultContext string) (*Target, error) {
+
+	var result Target
+	if err := utils.MapToStruct(details, &result); err != nil {
+		return nil, err
+	}
+
+	if result.Context == "" {
+		result.Context = defaultContext
+	}
+
+	switch result.Context {
+	case "all", "production", "deploy-preview", "branch-deploy", "dev":
+		result.Branch = ""
+	case "branch":
+		if result.Branch == "" {
+			return nil, errors.New("branch is required for the branch deploy context")
+		}
+	default:
+		return nil, fmt.Errorf("invalid netlify deploy context: %s", result.Context)
+	}
+
+	if len(result.Scopes) == 0 {
+		result.Scopes = []string{"builds", "functions", "runtime", "post-processing"}
+	}
+
+	for _, scope := range result.Scopes {
+		switch scope {
+		case "builds", "functions", "runtime", "post-processing":
+		default:
+			return nil, fmt.Errorf("invalid netlify scope: %s", scope)
+		}
+	}
+
+	return &result, nil
+}
@@ -1,13 +1,14 @@
 package netlify
 
 import (
-	"bytes"
 	"encoding/json"
 	"fmt"
 	"net/http"
 
 	"github.com/envsecrets/envsecrets/internal/clients"
 	"github.com/envsecrets/envsecrets/internal/context"
+	"github.com/envsecrets/envsecrets/internal/secrets/pkg/keypayload"
+	"github.com/envsecrets/envsecrets/internal/secrets/pkg/payload"
 )
 
 func ListEntities(ctx context.ServiceContext, options *ListOptions) (interface{}, error) {
@@ -37,55 +38,134 @@ func Sync(ctx context.ServiceContext, options *SyncOptions) error {
 	client := clients.NewHTTPClient(&clients.HTTPConfig{
 		Type:          clients.HTTPClientType,
 		Authorization: "Bearer " + options.Credentials["token"].(string),
+		ResponseHandler: func(response *http.Response) error {
+			if response.StatusCode >= http.StatusMultipleChoices {
+				var body struct {
+					Message string `json:"message"`
+				}
+				json.NewDecoder(response.Body).Decode(&body)
+				return fmt.Errorf("failed to sync the secrets to netlify site: %s", body.Message)
+			}
+			return nil
+		},
 	})
 
-	//	Fetch the account ID from netlify
-	user, err := fetchAccounts(ctx, client)
+	target, err := NewTarget(options.EntityDetails, "all")
 	if err != nil {
 		return err
 	}
 
-	var result []map[string]interface{}
-	for key, payload := range *options.Data {
-		result = append(result, map[string]interface{}{
-			"key":    key,
-			"scopes": []string{"builds", "functions", "runtime", "post-processing"},
-			"values": []map[string]interface{}{
-				{
-					"value":   payload.Value,
-					"context": "all",
-				},
-			},
-		})
+	siteID := fmt.Sprint(options.EntityDetails["id"])
+
+	//	Fetch the ID of the account the site belongs to.
+	accountID, err := getAccountID(ctx, client, options.EntityDetails)
+	if err != nil {
+		return err
 	}
 
-	body, err := json.Marshal(result)
+	//	Fetch the variables already present on the site,
+	//	so that existing keys are updated instead of created.
+	existing, err := listEnv(ctx, client, accountID, siteID)
 	if err != nil {
 		return err
 	}
 
-	/* 	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("https://api.netlify.com/api/v1/accounts/%s/env?site_id=%s", user.ID, options.EntityDetails["id"].(string)), bytes.NewBuffer(body))
-	   	if err != nil {
-	   		return errors.New(er, errMessage, errors.ErrorTypeBadRequest, errors.ErrorSourceGo)
-	   	}
-	*/
+	vars := make(map[string]EnvVar)
+	for _, item := range existing {
+		vars[item.Key] = item
+	}
+
+	var result []EnvVar
+	for key, payload := range *options.Data {
 
-	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("https://api.netlify.com/api/v1/accounts/%s/env", user.ID), bytes.NewBuffer(body))
+		value := EnvValue{
+			Value:            fmt.Sprint(payload.Value),
+			Context:          target.Context,
+			ContextParameter: target.Branch,
+		}
+
+		current, ok := vars[key]
+		if !ok {
+			result = append(result, EnvVar{
+				Key:    key,
+				Scopes: target.Scopes,
+				Values: []EnvValue{value},
+			})
+			continue
+		}
+
+		//	Values of secret variables can't be read back,
+		//	so only the value of our deploy context is replaced.
+		if current.IsSecret || equalScopes(current.Scopes, target.Scopes) {
+			if err := setEnvValue(ctx, client, accountID, siteID, key, &value); err != nil {
+				return err
+			}
+			continue
+		}
+
+		//	Replace the value of our deploy context,
+		//	while preserving the values of other contexts.
+		values := []EnvValue{value}
+		for _, item := range current.Values {
+			if !item.matches(target) {
+				values = append(values, item)
+			}
+		}
+
+		if err := updateEnv(ctx, client, accountID, siteID, &EnvVar{
+			Key:    key,
+			Scopes: target.Scopes,
+			Values: values,
+		}); err != nil {
+			return err
+		}
+	}
+
+	if len(result) == 0 {
+		return nil
+	}
+
+	return createEnv(ctx, client, accountID, siteID, result)
+}
+
+// Fetches the environment variables of the Netlify site.
+// Values are picked for the deploy context specified in entity details, defaulting to "production".
+func Import(ctx context.ServiceContext, options *ImportOptions) (*keypayload.KPMap, error) {
+
+	//	Initialize a new HTTP client.
+	client := clients.NewHTTPClient(&clients.HTTPConfig{
+		Type:          clients.HTTPClientType,
+		Authorization: "Bearer " + options.Credentials["token"].(string),
+	})
+
+	target, err := NewTarget(options.EntityDetails, "production")
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	var response struct {
-		Message string `json:"message"`
-		Code    int    `json:"code"`
+	//	Fetch the ID of the account the site belongs to.
+	accountID, err := getAccountID(ctx, client, options.EntityDetails)
+	if err != nil {
+		return nil, err
 	}
-	if err := client.Run(ctx, req, &response); err != nil {
-		return err
+
+	response, err := listEnv(ctx, client, accountID, fmt.Sprint(options.EntityDetails["id"]))
+	if err != nil {
+		return nil, err
 	}
 
-	if response.Code != http.StatusCreated {
-		return fmt.Errorf("failed to sync the secrets to netlify site: %s", response.Message)
+	result := keypayload.KPMap{}
+	for _, variable := range response {
+		for _, item := range variable.Values {
+
+			//	Values set for the exact deploy context take precedence over the ones set for all contexts.
+			if item.matches(target) || (item.Context == "all" && result.Get(variable.Key) == nil) {
+				result.Set(variable.Key, &payload.Payload{
+					Value: item.Value,
+				})
+			}
+		}
 	}
 
-	return nil
+	return &result, nil
 }
@@ -1,23 +1,118 @@
 package netlify
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
 	"net/http"
+	"net/url"
 
 	"github.com/envsecrets/envsecrets/internal/clients"
 	"github.com/envsecrets/envsecrets/internal/context"
 )
 
-func fetchAccounts(ctx context.ServiceContext, client *clients.HTTPClient) (*User, error) {
+// Returns the ID of the account the site belongs to.
+// Falls back to fetching the site, for events created before the account ID was saved in entity details.
+func getAccountID(ctx context.ServiceContext, client *clients.HTTPClient, details map[string]interface{}) (string, error) {
 
-	req, err := http.NewRequest(http.MethodGet, "https://api.netlify.com/api/v1/user", nil)
+	if accountID, ok := details["account_id"].(string); ok && accountID != "" {
+		return accountID, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("https://api.netlify.com/api/v1/sites/%s", url.PathEscape(fmt.Sprint(details["id"]))), nil)
+	if err != nil {
+		return "", err
+	}
+
+	var site Site
+	if err := client.Run(ctx, req, &site); err != nil {
+		return "", err
+	}
+
+	return site.AccountID, nil
+}
+
+// Fetches the environment variables of a site.
+func listEnv(ctx context.ServiceContext, client *clients.HTTPClient, accountID, siteID string) ([]EnvVar, error) {
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("https://api.netlify.com/api/v1/accounts/%s/env?site_id=%s", accountID, url.QueryEscape(siteID)), nil)
 	if err != nil {
 		return nil, err
 	}
 
-	var user User
-	if err := client.Run(ctx, req, &user); err != nil {
+	var response []EnvVar
+	if err := client.Run(ctx, req, &response); err != nil {
 		return nil, err
 	}
 
-	return &user, nil
+	return response, nil
+}
+
+// Creates new environment variables on a site.
+func createEnv(ctx context.ServiceContext, client *clients.HTTPClient, accountID, siteID string, vars []EnvVar) error {
+
+	body, err := json.Marshal(vars)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("https://api.netlify.com/api/v1/accounts/%s/env?site_id=%s", accountID, url.QueryEscape(siteID)), bytes.NewBuffer(body))
+	if err != nil {
+		return err
+	}
+
+	var response []EnvVar
+	return client.Run(ctx, req, &response)
+}
+
+// Replaces the scopes and values of an existing environment variable.
+func updateEnv(ctx context.ServiceContext, client *clients.HTTPClient, accountID, siteID string, env *EnvVar) error {
+
+	body, err := json.Marshal(env)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, fmt.Sprintf("https://api.netlify.com/api/v1/accounts/%s/env/%s?site_id=%s", accountID, url.PathEscape(env.Key), url.QueryEscape(siteID)), bytes.NewBuffer(body))
+	if err != nil {
+		return err
+	}
+
+	var response EnvVar
+	return client.Run(ctx, req, &response)
+}
+
+// Sets the value of an existing environment variable for a single deploy context.
+func setEnvValue(ctx context.ServiceContext, client *clients.HTTPClient, accountID, siteID, key string, value *EnvValue) error {
+
+	body, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPatch, fmt.Sprintf("https://api.netlify.com/api/v1/accounts/%s/env/%s?site_id=%s", accountID, url.PathEscape(key), url.QueryEscape(siteID)), bytes.NewBuffer(body))
+	if err != nil {
+		return err
+	}
+
+	var response EnvVar
+	return client.Run(ctx, req, &response)
+}
+
+func equalScopes(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	scopes := make(map[string]bool)
+	for _, scope := range a {
+		scopes[scope] = true
+	}
+
+	for _, scope := range b {
+		if !scopes[scope] {
+			return false
+		}
+	}
+	return true
 }
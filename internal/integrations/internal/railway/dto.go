@@ -19,3 +19,9 @@ type SyncOptions struct {
 	EntityDetails map[string]interface{} `json:"entity_details"`
 	Data          *keypayload.KPMap      `json:"data"`
 }
+
+type ImportOptions struct {
+	OrgID         string                 `json:"org_id"`
+	Credentials   map[string]interface{} `json:"credentials"`
+	EntityDetails map[string]interface{} `json:"entity_details"`
+}
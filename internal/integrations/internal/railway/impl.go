@@ -1,11 +1,14 @@
 package railway
 
 import (
+	"encoding/json"
 	"fmt"
 
 	"github.com/envsecrets/envsecrets/internal/clients"
 	"github.com/envsecrets/envsecrets/internal/context"
-	"github.com/hasura/go-graphql-client"
+	"github.com/envsecrets/envsecrets/internal/integrations/commons"
+	"github.com/envsecrets/envsecrets/internal/secrets/pkg/keypayload"
+	"github.com/envsecrets/envsecrets/internal/secrets/pkg/payload"
 )
 
 const API = "https://backboard.railway.app/graphql/v2"
@@ -104,51 +107,141 @@ func Sync(ctx context.ServiceContext, options *SyncOptions) error {
 		},
 	})
 
-	//type uuid string
-	type VariableUpsertInput struct {
-		ProjectID     graphql.String `json:"projectId"`
-		EnvironmentID graphql.String `json:"environmentId"`
-		ServiceID     graphql.String `json:"serviceId,omitempty"`
-		Name          graphql.String `json:"name"`
-		Value         graphql.String `json:"value"`
+	scope := getScope(options.EntityDetails)
+
+	//	--- Flow ---
+	//	1. Fetch the existing variables, to find the keys we synced earlier which have since been removed.
+	//	2. Upsert all the variables in a single batch, along with the record of our keys.
+	//	3. Delete the removed keys.
+
+	existing, err := getVariables(ctx, client, scope)
+	if err != nil {
+		return err
 	}
 
-	project := options.EntityDetails["project"].(map[string]interface{})
-	environment := options.EntityDetails["environment"].(map[string]interface{})
-	var service map[string]interface{}
-	if options.EntityDetails["service"] != nil {
-		service = options.EntityDetails["service"].(map[string]interface{})
+	variables := map[string]string{
+		commons.ManagedKeysVariable: commons.FormatManagedKeys(options.Data),
+	}
+	for key, value := range *options.Data.ToKVMap() {
+		variables[key] = value
 	}
 
-	for key, payload := range *options.Data {
+	input := map[string]interface{}{
+		"variables": variables,
+	}
+	for key, value := range scope {
+		input[key] = value
+	}
 
-		var mutation struct {
-			VariableUpsert bool `graphql:"variableUpsert(input: $input)"`
-		}
+	data, err := client.ExecRaw(ctx, `mutation MyMutation($input: VariableCollectionUpsertInput!) {
+		variableCollectionUpsert(input: $input)
+	}`, map[string]interface{}{
+		"input": input,
+	})
+	if err != nil {
+		return err
+	}
 
-		var inputs = VariableUpsertInput{
-			ProjectID:     graphql.String(project["id"].(string)),
-			EnvironmentID: graphql.String(environment["id"].(string)),
-			Name:          graphql.String(key),
-			Value:         graphql.String(payload.Value),
+	var response struct {
+		VariableCollectionUpsert bool `json:"variableCollectionUpsert"`
+	}
+	if err := json.Unmarshal(data, &response); err != nil {
+		return err
+	}
+
+	if !response.VariableCollectionUpsert {
+		return fmt.Errorf("failed to upsert variables")
+	}
+
+	for _, key := range commons.RemovedKeys(existing[commons.ManagedKeysVariable], options.Data) {
+		if _, ok := existing[key]; !ok {
+			continue
 		}
 
-		if service != nil {
-			inputs.ServiceID = graphql.String(service["id"].(string))
+		input := map[string]interface{}{
+			"name": key,
+		}
+		for key, value := range scope {
+			input[key] = value
 		}
 
-		err := client.Mutate(ctx, &mutation, map[string]interface{}{
-			"input": inputs,
-		}, graphql.OperationName("MyMutation"))
-		if err != nil {
-			return err
+		if _, err := client.ExecRaw(ctx, `mutation MyMutation($input: VariableDeleteInput!) {
+			variableDelete(input: $input)
+		}`, map[string]interface{}{
+			"input": input,
+		}); err != nil {
+			return fmt.Errorf("failed to delete %s: %w", key, err)
 		}
+	}
+
+	return nil
+}
+
+// Returns the project, environment and optionally the service, to which the variables belong.
+func getScope(details map[string]interface{}) map[string]interface{} {
+
+	project := details["project"].(map[string]interface{})
+	environment := details["environment"].(map[string]interface{})
+
+	result := map[string]interface{}{
+		"projectId":     project["id"],
+		"environmentId": environment["id"],
+		"serviceId":     nil,
+	}
+
+	if details["service"] != nil {
+		result["serviceId"] = details["service"].(map[string]interface{})["id"]
+	}
+
+	return result
+}
+
+func getVariables(ctx context.ServiceContext, client *clients.GQLClient2, scope map[string]interface{}) (map[string]string, error) {
+
+	data, err := client.ExecRaw(ctx, `query MyQuery($projectId: String!, $environmentId: String!, $serviceId: String) {
+		variables(projectId: $projectId, environmentId: $environmentId, serviceId: $serviceId)
+	}`, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Variables map[string]string `json:"variables"`
+	}
+	if err := json.Unmarshal(data, &response); err != nil {
+		return nil, err
+	}
 
-		if !mutation.VariableUpsert {
-			return fmt.Errorf("failed to upsert variable")
+	return response.Variables, nil
+}
+
+// Fetches the variables of the Railway environment, or of the service in it if one is specified.
+func Import(ctx context.ServiceContext, options *ImportOptions) (*keypayload.KPMap, error) {
+
+	//	Initialize a new GraphQL client.
+	client := clients.NewGQLClient2(&clients.GQL2Config{
+		BaseURL: API,
+		Authorization: &clients.Authorization{
+			Token:     options.Credentials["token"].(string),
+			TokenType: clients.Bearer,
+		},
+	})
+
+	variables, err := getVariables(ctx, client, getScope(options.EntityDetails))
+	if err != nil {
+		return nil, err
+	}
+
+	result := keypayload.KPMap{}
+	for key, value := range variables {
+		if key == commons.ManagedKeysVariable {
+			continue
 		}
 
+		result.Set(key, &payload.Payload{
+			Value: value,
+		})
 	}
 
-	return nil
+	return &result, nil
 }
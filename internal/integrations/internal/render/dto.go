@@ -0,0 +1,43 @@
+package render
+
+import (
+	"github.com/envsecrets/envsecrets/internal/secrets/pkg/keypayload"
+)
+
+type EntityType string
+
+const (
+	ServiceType  EntityType = "service"
+	EnvGroupType EntityType = "env_group"
+)
+
+type ListOptions struct {
+	Credentials map[string]interface{}
+}
+
+type SyncOptions struct {
+	Credentials   map[string]interface{} `json:"credentials"`
+	EntityDetails map[string]interface{} `json:"entity_details"`
+	Data          *keypayload.KPMap      `json:"data"`
+}
+
+type Service struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+type EnvGroup struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type ListServicesResponse []struct {
+	Service Service `json:"service"`
+	Cursor  string  `json:"cursor"`
+}
+
+type ListEnvGroupsResponse []struct {
+	EnvGroup EnvGroup `json:"envGroup"`
+	Cursor   string   `json:"cursor"`
+}
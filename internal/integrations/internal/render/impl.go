@@ -0,0 +1,138 @@
+package render
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/envsecrets/envsecrets/internal/clients"
+	"github.com/envsecrets/envsecrets/internal/context"
+)
+
+const API = "https://api.render.com/v1"
+
+func newClient(credentials map[string]interface{}) *clients.HTTPClient {
+	return clients.NewHTTPClient(&clients.HTTPConfig{
+		Type:          clients.HTTPClientType,
+		Authorization: "Bearer " + credentials["token"].(string),
+		ResponseHandler: func(response *http.Response) error {
+			if response.StatusCode >= http.StatusMultipleChoices {
+				var body struct {
+					Message string `json:"message"`
+				}
+				json.NewDecoder(response.Body).Decode(&body)
+				return fmt.Errorf("render responded with status %d: %s", response.StatusCode, body.Message)
+			}
+			return nil
+		},
+	})
+}
+
+// Lists the services.
+func ListEntities(ctx context.ServiceContext, options *ListOptions) (interface{}, error) {
+
+	client := newClient(options.Credentials)
+
+	var result []Service
+	for cursor := ""; ; {
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/services?limit=100&cursor=%s", API, url.QueryEscape(cursor)), nil)
+		if err != nil {
+			return nil, err
+		}
+
+		var response ListServicesResponse
+		if err := client.Run(ctx, req, &response); err != nil {
+			return nil, err
+		}
+
+		for _, item := range response {
+			result = append(result, item.Service)
+		}
+
+		if len(response) < 100 {
+			break
+		}
+		cursor = response[len(response)-1].Cursor
+	}
+
+	return result, nil
+}
+
+// Lists the environment groups.
+func ListSubEntities(ctx context.ServiceContext, options *ListOptions) (interface{}, error) {
+
+	client := newClient(options.Credentials)
+
+	var result []EnvGroup
+	for cursor := ""; ; {
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/env-groups?limit=100&cursor=%s", API, url.QueryEscape(cursor)), nil)
+		if err != nil {
+			return nil, err
+		}
+
+		var response ListEnvGroupsResponse
+		if err := client.Run(ctx, req, &response); err != nil {
+			return nil, err
+		}
+
+		for _, item := range response {
+			result = append(result, item.EnvGroup)
+		}
+
+		if len(response) < 100 {
+			break
+		}
+		cursor = response[len(response)-1].Cursor
+	}
+
+	return result, nil
+}
+
+// Sets the secrets as environment variables of the service, or of the environment group.
+// Every key is set on its own, so that variables not managed by envsecrets are left untouched.
+func Sync(ctx context.ServiceContext, options *SyncOptions) error {
+
+	id, _ := options.EntityDetails["id"].(string)
+	if id == "" {
+		return errors.New("service or environment group is required")
+	}
+
+	var base string
+	switch EntityType(fmt.Sprint(options.EntityDetails["type"])) {
+	case ServiceType:
+		base = fmt.Sprintf("%s/services/%s/env-vars", API, url.PathEscape(id))
+	case EnvGroupType:
+		base = fmt.Sprintf("%s/env-groups/%s/env-vars", API, url.PathEscape(id))
+	default:
+		return errors.New("invalid entity type")
+	}
+
+	client := newClient(options.Credentials)
+
+	for key, payload := range *options.Data {
+
+		body, err := json.Marshal(map[string]interface{}{
+			"value": fmt.Sprint(payload.Value),
+		})
+		if err != nil {
+			return err
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPut, base+"/"+url.PathEscape(key), bytes.NewBuffer(body))
+		if err != nil {
+			return err
+		}
+
+		var response map[string]interface{}
+		if err := client.Run(ctx, req, &response); err != nil {
+			return fmt.Errorf("failed to sync %s: %w", key, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,43 @@
+package ssm
+
+import (
+	"strings"
+
+	"github.com/envsecrets/envsecrets/internal/secrets/pkg/keypayload"
+)
+
+// Tag marking the parameters created by envsecrets,
+// so that only those are pruned once removed from the environment.
+const ManagedTag = "envsecrets:managed"
+
+type ListOptions struct {
+	Credentials map[string]interface{}
+	OrgID       string `json:"org_id"`
+	Path        string `json:"path"`
+}
+
+type SyncOptions struct {
+	OrgID         string                 `json:"org_id"`
+	Credentials   map[string]interface{} `json:"credentials"`
+	EntityDetails map[string]interface{} `json:"entity_details"`
+	Data          *keypayload.KPMap      `json:"data"`
+}
+
+// Per-event options, read from the event's entity details.
+type Target struct {
+
+	//	Hierarchy the parameters are created under, e.g. "/myapp/prod".
+	Path     string            `json:"path"`
+	KMSKeyID string            `json:"kms_key_id,omitempty"`
+	Tags     map[string]string `json:"tags,omitempty"`
+}
+
+// Returns the name of the parameter for the key.
+func (t *Target) name(key string) string {
+	return t.prefix() + key
+}
+
+// Returns the path of the hierarchy, with a trailing slash.
+func (t *Target) prefix() string {
+	return "/" + strings.Trim(t.Path, "/") + "/"
+}
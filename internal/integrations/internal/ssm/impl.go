@@ -0,0 +1,103 @@
+package ssm
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/envsecrets/envsecrets/internal/context"
+	"github.com/envsecrets/envsecrets/utils"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+// Lists the parameters directly under a path, defaulting to the root of the hierarchy.
+func ListEntities(ctx context.ServiceContext, options *ListOptions) (interface{}, error) {
+
+	client, err := newClient(ctx, options.Credentials, options.OrgID)
+	if err != nil {
+		return nil, err
+	}
+
+	path := "/" + strings.Trim(options.Path, "/")
+	return listParameters(ctx, client, path, false)
+}
+
+func Sync(ctx context.ServiceContext, options *SyncOptions) error {
+
+	var target Target
+	if err := utils.MapToStruct(options.EntityDetails, &target); err != nil {
+		return err
+	}
+
+	if strings.Trim(target.Path, "/") == "" {
+		return errors.New("parameter path is required")
+	}
+
+	client, err := newClient(ctx, options.Credentials, options.OrgID)
+	if err != nil {
+		return err
+	}
+
+	tags := []types.Tag{
+		{
+			Key:   aws.String(ManagedTag),
+			Value: aws.String("true"),
+		},
+	}
+
+	for key, value := range target.Tags {
+		tags = append(tags, types.Tag{
+			Key:   aws.String(key),
+			Value: aws.String(value),
+		})
+	}
+
+	//	--- Flow ---
+	//	1. Write every key as a SecureString parameter under the path.
+	//	   SSM rejects empty values, so keys without one are skipped.
+	//	2. Delete the parameters created by envsecrets under the path,
+	//	   whose keys have since been removed from the environment, or emptied,
+	//	   so that they don't keep serving their previous values.
+
+	empty := make(map[string]bool)
+	for key, payload := range *options.Data {
+
+		value := fmt.Sprint(payload.Value)
+		if value == "" {
+			empty[target.name(key)] = true
+			continue
+		}
+
+		input := ssm.PutParameterInput{
+			Name:  aws.String(target.name(key)),
+			Value: aws.String(value),
+			Type:  types.ParameterTypeSecureString,
+			Tags:  tags,
+		}
+
+		if target.KMSKeyID != "" {
+			input.KeyId = aws.String(target.KMSKeyID)
+		}
+
+		if err := putParameter(ctx, client, &input); err != nil {
+			return fmt.Errorf("failed to put parameter %s: %w", target.name(key), err)
+		}
+	}
+
+	existing, err := listParameters(ctx, client, strings.TrimSuffix(target.prefix(), "/"), true)
+	if err != nil {
+		return err
+	}
+
+	var stale []string
+	for _, name := range existing {
+		if empty[name] || options.Data.Get(strings.TrimPrefix(name, target.prefix())) == nil {
+			stale = append(stale, name)
+		}
+	}
+
+	return deleteParameters(ctx, client, stale)
+}
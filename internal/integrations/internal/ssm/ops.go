@@ -0,0 +1,107 @@
+package ssm
+
+import (
+	"errors"
+
+	"github.com/envsecrets/envsecrets/internal/context"
+	"github.com/envsecrets/envsecrets/internal/integrations/internal/asm"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+func newClient(ctx context.ServiceContext, credentials map[string]interface{}, orgID string) (*ssm.Client, error) {
+
+	cfg, err := asm.NewConfig(ctx, credentials, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	return ssm.NewFromConfig(cfg), nil
+}
+
+// Creates the parameter, or overwrites its value if it already exists.
+//
+//	Tags can't be passed along with an overwrite,
+//	so they are added separately for existing parameters.
+func putParameter(ctx context.ServiceContext, client *ssm.Client, input *ssm.PutParameterInput) error {
+
+	_, err := client.PutParameter(ctx, input)
+
+	var exists *types.ParameterAlreadyExists
+	if err == nil || !errors.As(err, &exists) {
+		return err
+	}
+
+	tags := input.Tags
+	overwrite := *input
+	overwrite.Overwrite = aws.Bool(true)
+	overwrite.Tags = nil
+
+	if _, err := client.PutParameter(ctx, &overwrite); err != nil {
+		return err
+	}
+
+	_, err = client.AddTagsToResource(ctx, &ssm.AddTagsToResourceInput{
+		ResourceType: types.ResourceTypeForTaggingParameter,
+		ResourceId:   input.Name,
+		Tags:         tags,
+	})
+	return err
+}
+
+// Fetches the names of the parameters directly under the path.
+// Only the parameters created by envsecrets are returned, if managed is true.
+func listParameters(ctx context.ServiceContext, client *ssm.Client, path string, managed bool) ([]string, error) {
+
+	input := ssm.DescribeParametersInput{
+		ParameterFilters: []types.ParameterStringFilter{
+			{
+				Key:    aws.String("Path"),
+				Option: aws.String("OneLevel"),
+				Values: []string{path},
+			},
+		},
+		MaxResults: aws.Int32(50),
+	}
+
+	if managed {
+		input.ParameterFilters = append(input.ParameterFilters, types.ParameterStringFilter{
+			Key:    aws.String("tag:" + ManagedTag),
+			Values: []string{"true"},
+		})
+	}
+
+	var result []string
+	paginator := ssm.NewDescribeParametersPaginator(client, &input)
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, item := range output.Parameters {
+			result = append(result, aws.ToString(item.Name))
+		}
+	}
+
+	return result, nil
+}
+
+// Deletes the parameters, in batches of the maximum size allowed by AWS.
+func deleteParameters(ctx context.ServiceContext, client *ssm.Client, names []string) error {
+	for start := 0; start < len(names); start += 10 {
+		end := start + 10
+		if end > len(names) {
+			end = len(names)
+		}
+
+		if _, err := client.DeleteParameters(ctx, &ssm.DeleteParametersInput{
+			Names: names[start:end],
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
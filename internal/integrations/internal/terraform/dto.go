@@ -0,0 +1,75 @@
+package terraform
+
+import (
+	"github.com/envsecrets/envsecrets/internal/secrets/pkg/keypayload"
+)
+
+// Description marking the variables created by envsecrets,
+// so that only those are deleted once removed from the environment.
+const ManagedDescription = "Managed by envsecrets"
+
+type EntityType string
+
+const (
+	WorkspaceType   EntityType = "workspace"
+	VariableSetType EntityType = "varset"
+)
+
+type ListOptions struct {
+	Credentials  map[string]interface{}
+	Organization string
+	Type         EntityType
+}
+
+type SyncOptions struct {
+	Credentials   map[string]interface{} `json:"credentials"`
+	EntityDetails map[string]interface{} `json:"entity_details"`
+	Data          *keypayload.KPMap      `json:"data"`
+}
+
+// Per-event options, read from the event's entity details.
+type Target struct {
+	Type EntityType `json:"type"`
+	ID   string     `json:"id"`
+
+	//	Either "env" or "terraform".
+	Category string `json:"category,omitempty"`
+}
+
+type Entity struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// Resource of the JSON:API documents returned by Terraform Cloud.
+type Resource struct {
+	ID         string                 `json:"id,omitempty"`
+	Type       string                 `json:"type"`
+	Attributes map[string]interface{} `json:"attributes"`
+}
+
+type Document struct {
+	Data   Resource `json:"data"`
+	Errors []struct {
+		Title  string `json:"title"`
+		Detail string `json:"detail"`
+	} `json:"errors,omitempty"`
+}
+
+type ListDocument struct {
+	Data []Resource `json:"data"`
+	Meta struct {
+		Pagination struct {
+			NextPage int `json:"next-page"`
+		} `json:"pagination"`
+	} `json:"meta"`
+}
+
+type Variable struct {
+	ID          string `json:"-"`
+	Key         string `json:"key"`
+	Value       string `json:"value"`
+	Category    string `json:"category"`
+	Sensitive   bool   `json:"sensitive"`
+	Description string `json:"description"`
+}
@@ -0,0 +1,125 @@
+package terraform
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+
+	"github.com/envsecrets/envsecrets/internal/context"
+	"github.com/envsecrets/envsecrets/utils"
+)
+
+// Lists the organisations.
+func ListEntities(ctx context.ServiceContext, options *ListOptions) (interface{}, error) {
+
+	resources, err := newClient(options.Credentials).list(ctx, "/organizations")
+	if err != nil {
+		return nil, err
+	}
+
+	return toEntities(resources), nil
+}
+
+// Lists the workspaces, or the variable sets, of an organisation.
+func ListSubEntities(ctx context.ServiceContext, options *ListOptions) (interface{}, error) {
+
+	if options.Organization == "" {
+		return nil, errors.New("organization is required")
+	}
+
+	path := fmt.Sprintf("/organizations/%s/workspaces", url.PathEscape(options.Organization))
+	if options.Type == VariableSetType {
+		path = fmt.Sprintf("/organizations/%s/varsets", url.PathEscape(options.Organization))
+	}
+
+	resources, err := newClient(options.Credentials).list(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	return toEntities(resources), nil
+}
+
+func toEntities(resources []Resource) []Entity {
+	var result []Entity
+	for _, item := range resources {
+		name, _ := item.Attributes["name"].(string)
+		result = append(result, Entity{
+			ID:   item.ID,
+			Name: name,
+		})
+	}
+	return result
+}
+
+func Sync(ctx context.ServiceContext, options *SyncOptions) error {
+
+	var target Target
+	if err := utils.MapToStruct(options.EntityDetails, &target); err != nil {
+		return err
+	}
+
+	if target.ID == "" {
+		return errors.New("workspace or variable set is required")
+	}
+
+	switch target.Type {
+	case WorkspaceType, VariableSetType:
+	default:
+		return errors.New("invalid entity type")
+	}
+
+	switch target.Category {
+	case "":
+		target.Category = "env"
+	case "env", "terraform":
+	default:
+		return fmt.Errorf("invalid terraform variable category: %s", target.Category)
+	}
+
+	client := newClient(options.Credentials)
+
+	//	--- Flow ---
+	//	1. Fetch the existing variables of our category.
+	//	2. Update the existing ones in place, and create the rest.
+	//	3. Delete the variables created by envsecrets, whose keys have since been removed from the environment.
+
+	existing, err := client.listVariables(ctx, &target)
+	if err != nil {
+		return err
+	}
+
+	ids := make(map[string]string)
+	for _, variable := range existing {
+		if variable.Category == target.Category {
+			ids[variable.Key] = variable.ID
+		}
+	}
+
+	for key, payload := range *options.Data {
+		if err := client.putVariable(ctx, &target, &Variable{
+			ID:          ids[key],
+			Key:         key,
+			Value:       fmt.Sprint(payload.Value),
+			Category:    target.Category,
+			Sensitive:   !payload.IsExposable(),
+			Description: ManagedDescription,
+		}); err != nil {
+			return fmt.Errorf("failed to sync %s: %w", key, err)
+		}
+	}
+
+	for _, variable := range existing {
+		if variable.Category != target.Category || variable.Description != ManagedDescription {
+			continue
+		}
+
+		if options.Data.Get(variable.Key) == nil {
+			if err := client.deleteVariable(ctx, &target, variable.ID); err != nil {
+				return fmt.Errorf("failed to delete %s: %w", variable.Key, err)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,160 @@
+package terraform
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/envsecrets/envsecrets/internal/clients"
+	"github.com/envsecrets/envsecrets/internal/context"
+)
+
+const API = "https://app.terraform.io/api/v2"
+
+type client struct {
+	http    *clients.HTTPClient
+	address string
+}
+
+// Initializes a new client for Terraform Cloud,
+// or for a Terraform Enterprise instance if its address is part of the credentials.
+func newClient(credentials map[string]interface{}) *client {
+
+	address := API
+	if value, ok := credentials["address"].(string); ok && value != "" {
+		address = strings.TrimSuffix(value, "/") + "/api/v2"
+	}
+
+	return &client{
+		address: address,
+		http: clients.NewHTTPClient(&clients.HTTPConfig{
+			Type:          clients.HTTPClientType,
+			Authorization: "Bearer " + credentials["token"].(string),
+			CustomHeaders: []clients.CustomHeader{
+				{
+					Key:   "Content-Type",
+					Value: "application/vnd.api+json",
+				},
+			},
+			ResponseHandler: func(response *http.Response) error {
+				if response.StatusCode < http.StatusMultipleChoices {
+					return nil
+				}
+
+				var document Document
+				json.NewDecoder(response.Body).Decode(&document)
+
+				var messages []string
+				for _, item := range document.Errors {
+					messages = append(messages, strings.TrimSpace(item.Title+" "+item.Detail))
+				}
+				return fmt.Errorf("terraform responded with status %d: %s", response.StatusCode, strings.Join(messages, ", "))
+			},
+		}),
+	}
+}
+
+func (c *client) do(ctx context.ServiceContext, method, path string, body, response interface{}) error {
+
+	var payload []byte
+	if body != nil {
+		var err error
+		payload, err = json.Marshal(body)
+		if err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.address+path, bytes.NewBuffer(payload))
+	if err != nil {
+		return err
+	}
+
+	return c.http.Run(ctx, req, response)
+}
+
+// Walks through every page of the endpoint.
+func (c *client) list(ctx context.ServiceContext, path string) ([]Resource, error) {
+
+	separator := "?"
+	if strings.Contains(path, "?") {
+		separator = "&"
+	}
+
+	var result []Resource
+	for page := 1; page > 0; {
+
+		var response ListDocument
+		if err := c.do(ctx, http.MethodGet, fmt.Sprintf("%s%spage[number]=%d&page[size]=100", path, separator, page), nil, &response); err != nil {
+			return nil, err
+		}
+
+		result = append(result, response.Data...)
+		page = response.Meta.Pagination.NextPage
+	}
+
+	return result, nil
+}
+
+// Returns the path of the variables of the workspace or variable set.
+func (t *Target) path() string {
+	if t.Type == VariableSetType {
+		return fmt.Sprintf("/varsets/%s/relationships/vars", url.PathEscape(t.ID))
+	}
+	return fmt.Sprintf("/workspaces/%s/vars", url.PathEscape(t.ID))
+}
+
+func (c *client) listVariables(ctx context.ServiceContext, target *Target) ([]Variable, error) {
+
+	resources, err := c.list(ctx, target.path())
+	if err != nil {
+		return nil, err
+	}
+
+	var result []Variable
+	for _, item := range resources {
+		variable := Variable{
+			ID: item.ID,
+		}
+		variable.Key, _ = item.Attributes["key"].(string)
+		variable.Category, _ = item.Attributes["category"].(string)
+		variable.Sensitive, _ = item.Attributes["sensitive"].(bool)
+		variable.Description, _ = item.Attributes["description"].(string)
+		result = append(result, variable)
+	}
+
+	return result, nil
+}
+
+// Creates the variable, or updates it if it already exists.
+func (c *client) putVariable(ctx context.ServiceContext, target *Target, variable *Variable) error {
+
+	payload, err := json.Marshal(variable)
+	if err != nil {
+		return err
+	}
+
+	var attributes map[string]interface{}
+	if err := json.Unmarshal(payload, &attributes); err != nil {
+		return err
+	}
+
+	resource := Resource{
+		ID:         variable.ID,
+		Type:       "vars",
+		Attributes: attributes,
+	}
+
+	var response Document
+	if variable.ID == "" {
+		return c.do(ctx, http.MethodPost, target.path(), &Document{Data: resource}, &response)
+	}
+	return c.do(ctx, http.MethodPatch, target.path()+"/"+url.PathEscape(variable.ID), &Document{Data: resource}, &response)
+}
+
+func (c *client) deleteVariable(ctx context.ServiceContext, target *Target, id string) error {
+	return c.do(ctx, http.MethodDelete, target.path()+"/"+url.PathEscape(id), nil, nil)
+}
@@ -0,0 +1,124 @@
+package vault
+
+import (
+	"strings"
+
+	"github.com/envsecrets/envsecrets/internal/secrets/pkg/keypayload"
+)
+
+type AuthMethod string
+
+const (
+	TokenAuth   AuthMethod = "token"
+	AppRoleAuth AuthMethod = "approle"
+)
+
+type Mode string
+
+const (
+
+	//	Stores the entire environment as a single secret at the path.
+	SecretMode Mode = "secret"
+
+	//	Stores every key as a separate secret under the path.
+	KeysMode Mode = "keys"
+)
+
+type Credentials struct {
+	Address    string     `json:"address"`
+	Namespace  string     `json:"namespace,omitempty"`
+	AuthMethod AuthMethod `json:"auth_method"`
+
+	//	For token authentication.
+	Token string `json:"token,omitempty"`
+
+	//	For AppRole authentication.
+	RoleID       string `json:"role_id,omitempty"`
+	SecretID     string `json:"secret_id,omitempty"`
+	AppRoleMount string `json:"approle_mount,omitempty"`
+}
+
+type ListOptions struct {
+	Credentials map[string]interface{}
+}
+
+type ListSubOptions struct {
+	Credentials map[string]interface{}
+	Mount       string
+	Path        string
+}
+
+type SyncOptions struct {
+	Credentials   map[string]interface{} `json:"credentials"`
+	EntityDetails map[string]interface{} `json:"entity_details"`
+	Data          *keypayload.KPMap      `json:"data"`
+}
+
+// Per-event options, read from the event's entity details.
+type Target struct {
+	Mount string `json:"mount"`
+	Path  string `json:"path"`
+	Mode  Mode   `json:"mode,omitempty"`
+
+	//	Versions written by the last sync, by path.
+	//	Saved by the server, and used as the check-and-set value of the next write.
+	Versions map[string]int `json:"versions,omitempty"`
+}
+
+type SyncResponse struct {
+	Versions map[string]int `json:"versions"`
+}
+
+// Returns the path of the secret, relative to the mount.
+func (t *Target) path(key string) string {
+	path := strings.Trim(t.Path, "/")
+	if key == "" {
+		return path
+	}
+	if path == "" {
+		return key
+	}
+	return path + "/" + key
+}
+
+type Mount struct {
+	Address     string `json:"address"`
+	Path        string `json:"path"`
+	Description string `json:"description"`
+}
+
+type MountsResponse struct {
+	Data map[string]struct {
+		Type        string            `json:"type"`
+		Description string            `json:"description"`
+		Options     map[string]string `json:"options"`
+	} `json:"data"`
+}
+
+type ListResponse struct {
+	Data struct {
+		Keys []string `json:"keys"`
+	} `json:"data"`
+}
+
+type MetadataResponse struct {
+	Data struct {
+		CurrentVersion int `json:"current_version"`
+	} `json:"data"`
+}
+
+type WriteResponse struct {
+	Data struct {
+		Version int `json:"version"`
+	} `json:"data"`
+}
+
+type LoginResponse struct {
+	Auth struct {
+		ClientToken string `json:"client_token"`
+	} `json:"auth"`
+}
+
+type ErrorResponse struct {
+	Errors []string `json:"errors"`
+}
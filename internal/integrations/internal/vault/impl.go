@@ -0,0 +1,121 @@
+package vault
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/envsecrets/envsecrets/internal/context"
+	"github.com/envsecrets/envsecrets/utils"
+)
+
+// Lists the KV version 2 secret engines.
+func ListEntities(ctx context.ServiceContext, options *ListOptions) (interface{}, error) {
+
+	client, credentials, err := newClient(ctx, options.Credentials)
+	if err != nil {
+		return nil, err
+	}
+
+	return listMounts(ctx, client, credentials.Address)
+}
+
+// Lists the paths under a path of a KV mount.
+func ListSubEntities(ctx context.ServiceContext, options *ListSubOptions) (interface{}, error) {
+
+	if options.Mount == "" {
+		return nil, errors.New("mount is required")
+	}
+
+	client, credentials, err := newClient(ctx, options.Credentials)
+	if err != nil {
+		return nil, err
+	}
+
+	return listKeys(ctx, client, credentials.Address, strings.Trim(options.Mount, "/"), options.Path)
+}
+
+// Writes the secrets, and returns the versions written, to be saved in the event's entity details.
+// The versions are returned even when a write fails, since the writes before it have already gone through.
+func Sync(ctx context.ServiceContext, options *SyncOptions) (*SyncResponse, error) {
+
+	var target Target
+	if err := utils.MapToStruct(options.EntityDetails, &target); err != nil {
+		return nil, err
+	}
+
+	target.Mount = strings.Trim(target.Mount, "/")
+	if target.Mount == "" {
+		return nil, errors.New("vault mount is required")
+	}
+
+	client, credentials, err := newClient(ctx, options.Credentials)
+	if err != nil {
+		return nil, err
+	}
+
+	//	--- Flow ---
+	//	1. Take the version of every secret written by the last sync, from the event's entity details.
+	//	   Secrets never synced before are adopted at their current version.
+	//	2. Write the new version with check-and-set against that version,
+	//	   so that edits made in Vault since the last sync are not silently overwritten.
+	//	3. Return the versions written, for the next sync to check against.
+
+	response := SyncResponse{
+		Versions: make(map[string]int),
+	}
+	for path, version := range target.Versions {
+		response.Versions[path] = version
+	}
+
+	write := func(path string, data map[string]interface{}) error {
+
+		cas, ok := target.Versions[path]
+		if !ok {
+			cas, err = getVersion(ctx, client, credentials.Address, target.Mount, path)
+			if err != nil {
+				return err
+			}
+		}
+
+		version, err := writeSecret(ctx, client, credentials.Address, target.Mount, path, data, cas)
+		if err != nil {
+			return err
+		}
+
+		response.Versions[path] = version
+		return nil
+	}
+
+	switch target.Mode {
+	case SecretMode, "":
+
+		if target.path("") == "" {
+			return nil, errors.New("vault path is required")
+		}
+
+		data := make(map[string]interface{})
+		for key, payload := range *options.Data {
+			data[key] = payload.Value
+		}
+
+		if err := write(target.path(""), data); err != nil {
+			return &response, err
+		}
+
+	case KeysMode:
+
+		for key, payload := range *options.Data {
+			if err := write(target.path(key), map[string]interface{}{
+				"value": payload.Value,
+			}); err != nil {
+				return &response, err
+			}
+		}
+
+	default:
+		return nil, fmt.Errorf("invalid vault mode: %s", target.Mode)
+	}
+
+	return &response, nil
+}
@@ -0,0 +1,218 @@
+package vault
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/envsecrets/envsecrets/internal/clients"
+	"github.com/envsecrets/envsecrets/internal/context"
+	"github.com/envsecrets/envsecrets/utils"
+)
+
+// Errors returned by Vault are accumulated in the response body.
+func handleResponse(response *http.Response) error {
+	if response.StatusCode < http.StatusMultipleChoices {
+		return nil
+	}
+
+	var body ErrorResponse
+	json.NewDecoder(response.Body).Decode(&body)
+	return fmt.Errorf("vault responded with status %d: %s", response.StatusCode, strings.Join(body.Errors, ", "))
+}
+
+// Authenticates with the Vault server and initializes a new HTTP client with the resulting token.
+func newClient(ctx context.ServiceContext, credentials map[string]interface{}) (*clients.HTTPClient, *Credentials, error) {
+
+	var creds Credentials
+	if err := utils.MapToStruct(credentials, &creds); err != nil {
+		return nil, nil, err
+	}
+
+	creds.Address = strings.TrimSuffix(creds.Address, "/")
+	if creds.Address == "" {
+		return nil, nil, errors.New("vault address is required")
+	}
+
+	var headers []clients.CustomHeader
+	if creds.Namespace != "" {
+		headers = append(headers, clients.CustomHeader{
+			Key:   string(clients.VaultNamespaceHeader),
+			Value: creds.Namespace,
+		})
+	}
+
+	token := creds.Token
+
+	switch creds.AuthMethod {
+	case TokenAuth, "":
+	case AppRoleAuth:
+
+		mount := creds.AppRoleMount
+		if mount == "" {
+			mount = "approle"
+		}
+
+		body, err := json.Marshal(map[string]string{
+			"role_id":   creds.RoleID,
+			"secret_id": creds.SecretID,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("%s/v1/auth/%s/login", creds.Address, strings.Trim(mount, "/")), bytes.NewBuffer(body))
+		if err != nil {
+			return nil, nil, err
+		}
+
+		login := clients.NewHTTPClient(&clients.HTTPConfig{
+			Type:            clients.HTTPClientType,
+			CustomHeaders:   headers,
+			ResponseHandler: handleResponse,
+		})
+
+		var response LoginResponse
+		if err := login.Run(ctx, req, &response); err != nil {
+			return nil, nil, err
+		}
+
+		token = response.Auth.ClientToken
+
+	default:
+		return nil, nil, fmt.Errorf("unsupported vault auth method: %s", creds.AuthMethod)
+	}
+
+	if token == "" {
+		return nil, nil, errors.New("failed to authenticate with vault")
+	}
+
+	headers = append(headers, clients.CustomHeader{
+		Key:   string(clients.VaultTokenHeader),
+		Value: token,
+	})
+
+	return clients.NewHTTPClient(&clients.HTTPConfig{
+		Type:            clients.HTTPClientType,
+		CustomHeaders:   headers,
+		ResponseHandler: handleResponse,
+	}), &creds, nil
+}
+
+// Fetches the KV version 2 secret engines.
+func listMounts(ctx context.ServiceContext, client *clients.HTTPClient, address string) ([]Mount, error) {
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, address+"/v1/sys/mounts", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response MountsResponse
+	if err := client.Run(ctx, req, &response); err != nil {
+		return nil, err
+	}
+
+	var result []Mount
+	for path, mount := range response.Data {
+		if mount.Type != "kv" || mount.Options["version"] != "2" {
+			continue
+		}
+
+		result = append(result, Mount{
+			Address:     address,
+			Path:        strings.TrimSuffix(path, "/"),
+			Description: mount.Description,
+		})
+	}
+
+	return result, nil
+}
+
+// Fetches the keys directly under a path.
+// Keys ending in "/" are sub-paths.
+func listKeys(ctx context.ServiceContext, client *clients.HTTPClient, address, mount, path string) ([]string, error) {
+
+	req, err := http.NewRequestWithContext(ctx, "LIST", fmt.Sprintf("%s/v1/%s/metadata/%s", address, url.PathEscape(mount), escapePath(path)), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	//	Vault responds with 404 for paths without any secrets.
+	client.ResponseHandler = func(response *http.Response) error {
+		if response.StatusCode == http.StatusNotFound {
+			return nil
+		}
+		return handleResponse(response)
+	}
+	defer func() { client.ResponseHandler = handleResponse }()
+
+	var response ListResponse
+	if err := client.Run(ctx, req, &response); err != nil {
+		return nil, err
+	}
+
+	return response.Data.Keys, nil
+}
+
+// Returns the current version of the secret, or 0 if it doesn't exist yet.
+func getVersion(ctx context.ServiceContext, client *clients.HTTPClient, address, mount, path string) (int, error) {
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/v1/%s/metadata/%s", address, url.PathEscape(mount), escapePath(path)), nil)
+	if err != nil {
+		return 0, err
+	}
+
+	client.ResponseHandler = func(response *http.Response) error {
+		if response.StatusCode == http.StatusNotFound {
+			return nil
+		}
+		return handleResponse(response)
+	}
+	defer func() { client.ResponseHandler = handleResponse }()
+
+	var response MetadataResponse
+	if err := client.Run(ctx, req, &response); err != nil {
+		return 0, err
+	}
+
+	return response.Data.CurrentVersion, nil
+}
+
+// Writes a new version of the secret, and returns it.
+// The write is rejected by Vault if the secret has been modified since the given version.
+func writeSecret(ctx context.ServiceContext, client *clients.HTTPClient, address, mount, path string, data map[string]interface{}, cas int) (int, error) {
+
+	body, err := json.Marshal(map[string]interface{}{
+		"options": map[string]interface{}{
+			"cas": cas,
+		},
+		"data": data,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("%s/v1/%s/data/%s", address, url.PathEscape(mount), escapePath(path)), bytes.NewBuffer(body))
+	if err != nil {
+		return 0, err
+	}
+
+	var response WriteResponse
+	if err := client.Run(ctx, req, &response); err != nil {
+		return 0, fmt.Errorf("failed to write %s/%s: %w", mount, path, err)
+	}
+
+	return response.Data.Version, nil
+}
+
+func escapePath(path string) string {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for index, segment := range segments {
+		segments[index] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
@@ -1,6 +1,11 @@
 package vercel
 
-import "github.com/envsecrets/envsecrets/internal/secrets/pkg/keypayload"
+import (
+	"errors"
+	"fmt"
+
+	"github.com/envsecrets/envsecrets/internal/secrets/pkg/keypayload"
+)
 
 type SetupOptions struct {
 	ConfigurationID string
@@ -61,6 +66,67 @@ type SyncOptions struct {
 	Data          *keypayload.KPMap      `json:"data"`
 }
 
+// Per-event options, read from the event's entity details.
+type SyncTarget struct {
+
+	//	Vercel environments to sync the secrets to.
+	//	Any of "production", "preview" and "development".
+	Targets []string `json:"targets,omitempty"`
+
+	//	Restricts the secrets to preview deployments of this branch.
+	GitBranch string `json:"git_branch,omitempty"`
+}
+
+// Loads the sync target from entity details.
+func NewSyncTarget(details map[string]interface{}) (*SyncTarget, error) {
+
+	result := SyncTarget{
+		Targets: []string{"production", "preview"},
+	}
+
+	if values, ok := details["targets"].([]interface{}); ok && len(values) > 0 {
+		result.Targets = nil
+		for _, value := range values {
+			target := fmt.Sprint(value)
+			switch target {
+			case "production", "preview", "development":
+			default:
+				return nil, fmt.Errorf("invalid vercel target: %s", target)
+			}
+			result.Targets = append(result.Targets, target)
+		}
+	}
+
+	if branch, ok := details["git_branch"].(string); ok && branch != "" {
+		if len(result.Targets) != 1 || result.Targets[0] != "preview" {
+			return nil, errors.New("git branch can only be set for the preview target")
+		}
+		result.GitBranch = branch
+	}
+
+	return &result, nil
+}
+
+// Returns true if any of the given environments is one of our targets.
+func (t *SyncTarget) Overlaps(targets []string) bool {
+	for _, item := range targets {
+		if t.Has(item) {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns true if the target list contains the given environment.
+func (t *SyncTarget) Has(target string) bool {
+	for _, item := range t.Targets {
+		if item == target {
+			return true
+		}
+	}
+	return false
+}
+
 type ListProjectsResponse struct {
 	Projects []Project `json:"projects"`
 }
@@ -87,6 +153,22 @@ type VercelResponse struct {
 	Error map[string]interface{} `json:"error,omitempty"`
 }
 
+type ListEnvResponse struct {
+	Error map[string]interface{} `json:"error,omitempty"`
+	Envs  []Env                  `json:"envs"`
+}
+
+type Env struct {
+	ID        string   `json:"id"`
+	Key       string   `json:"key"`
+	Type      string   `json:"type"`
+	Target    []string `json:"target"`
+	GitBranch string   `json:"gitBranch,omitempty"`
+
+	//	Only returned in plain text for plain variables, like the record of our managed keys.
+	Value string `json:"value,omitempty"`
+}
+
 type VercelSecret struct {
 	Error       map[string]interface{} `json:"error,omitempty"`
 	ID          string                 `json:"uid"`
@@ -11,6 +11,7 @@ import (
 
 	"github.com/envsecrets/envsecrets/internal/clients"
 	"github.com/envsecrets/envsecrets/internal/context"
+	"github.com/envsecrets/envsecrets/internal/integrations/commons"
 )
 
 func PrepareCredentials(ctx context.ServiceContext, options *PrepareCredentialsOptions) (map[string]interface{}, error) {
@@ -95,43 +96,133 @@ func Sync(ctx context.ServiceContext, options *SyncOptions) error {
 	//	Initialize TEAM ID
 	teamID := options.Credentials.TeamID
 
+	//	Load the targets of this event.
+	target, err := NewSyncTarget(options.EntityDetails)
+	if err != nil {
+		return err
+	}
+
+	projectID := options.EntityDetails["id"].(string)
+
+	//	--- Flow ---
+	//	1. Fetch the variables already present in the project,
+	//	   and the keys recorded on our targets during the last sync.
+	//	2. Take our targets off every variable of ours which overlaps them, but can't be upserted as is.
+	//	   That is, variables which have been removed from the environment,
+	//	   or the ones which are also shared with other targets.
+	//	   Variables envsecrets never synced are left untouched.
+	//	3. Upsert the secrets on our targets, along with the record of our keys.
+
+	existing, err := ListEnv(ctx, client, projectID, teamID)
+	if err != nil {
+		return err
+	}
+
+	managed := make(map[string]bool)
+	for _, env := range existing {
+		if env.Key != commons.ManagedKeysVariable || env.GitBranch != target.GitBranch || !target.Overlaps(env.Target) {
+			continue
+		}
+		for _, key := range strings.Split(env.Value, ",") {
+			managed[strings.TrimSpace(key)] = true
+		}
+	}
+
+	for _, env := range existing {
+
+		if env.GitBranch != target.GitBranch {
+			continue
+		}
+
+		upserted := env.Key == commons.ManagedKeysVariable || options.Data.Get(env.Key) != nil
+		if !upserted && !managed[env.Key] {
+			continue
+		}
+
+		var remaining []string
+		for _, item := range env.Target {
+			if !target.Has(item) {
+				remaining = append(remaining, item)
+			}
+		}
+
+		//	Variables which don't overlap our targets don't belong to this event.
+		if len(remaining) == len(env.Target) {
+			continue
+		}
+
+		if upserted && len(remaining) == 0 && len(env.Target) == len(target.Targets) {
+			continue
+		}
+
+		if len(remaining) == 0 {
+			err = DeleteEnv(ctx, client, projectID, env.ID, teamID)
+		} else {
+			err = EditEnvTarget(ctx, client, projectID, env.ID, remaining, teamID)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
 	//	Prepare array of all values
 	var array []map[string]interface{}
 	for key, value := range *options.Data {
 
-		//	Prepare the secret type
-		var typ string
-		v := value.Value
+		if key == commons.ManagedKeysVariable {
+			continue
+		}
 
+		//	Prepare the secret type.
+		//	Vercel doesn't allow sensitive variables in the development target,
+		//	in which case they are only encrypted.
+		var typ string
 		if value.IsExposable() {
 			typ = "plain"
-
-			/* 			//	Create the secret separately in vercel first.
-			   			secret, err := CreateSecret(ctx, client, key, value.Value, &teamID)
-			   			if err != nil {
-			   				return err
-			   			}
-			*/
-
-		} else {
+		} else if target.Has("development") {
 			typ = "encrypted"
+		} else {
+			typ = "sensitive"
 		}
 
-		array = append(array, map[string]interface{}{
+		item := map[string]interface{}{
 			"key":    key,
-			"value":  v,
+			"value":  value.Value,
 			"type":   typ,
-			"target": []string{"production", "preview"},
-		})
+			"target": target.Targets,
+		}
+
+		if target.GitBranch != "" {
+			item["gitBranch"] = target.GitBranch
+		}
+
+		array = append(array, item)
 	}
 
+	if len(array) == 0 {
+		return nil
+	}
+
+	managedKeys := map[string]interface{}{
+		"key":    commons.ManagedKeysVariable,
+		"value":  commons.FormatManagedKeys(options.Data),
+		"type":   "plain",
+		"target": target.Targets,
+	}
+
+	if target.GitBranch != "" {
+		managedKeys["gitBranch"] = target.GitBranch
+	}
+
+	array = append(array, managedKeys)
+
 	//	Prepare the request body
 	body, err := json.Marshal(array)
 	if err != nil {
 		return err
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("https://api.vercel.com/v10/projects/%s/env", options.EntityDetails["id"].(string)), bytes.NewBuffer(body))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("https://api.vercel.com/v10/projects/%s/env", projectID), bytes.NewBuffer(body))
 	if err != nil {
 		return err
 	}
@@ -156,7 +247,95 @@ func Sync(ctx context.ServiceContext, options *SyncOptions) error {
 	}
 
 	if response.Error != nil {
-		return fmt.Errorf(response.Error["message"].(string))
+		return fmt.Errorf("%v", response.Error["message"])
+	}
+
+	return nil
+}
+
+// Fetches the environment variables of a project.
+// Docs: https://vercel.com/docs/rest-api/endpoints#retrieve-the-environment-variables-of-a-project-by-id-or-name
+func ListEnv(ctx context.ServiceContext, client *clients.HTTPClient, projectID, teamID string) ([]Env, error) {
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("https://api.vercel.com/v9/projects/%s/env", projectID), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if teamID != "" {
+		params := req.URL.Query()
+		params.Set("teamId", teamID)
+		req.URL.RawQuery = params.Encode()
+	}
+
+	var response ListEnvResponse
+	if err := client.Run(ctx, req, &response); err != nil {
+		return nil, err
+	}
+
+	if response.Error != nil {
+		return nil, fmt.Errorf("%v", response.Error["message"])
+	}
+
+	return response.Envs, nil
+}
+
+// Updates the targets of an environment variable.
+// Docs: https://vercel.com/docs/rest-api/endpoints#edit-an-environment-variable
+func EditEnvTarget(ctx context.ServiceContext, client *clients.HTTPClient, projectID, envID string, targets []string, teamID string) error {
+
+	body, err := json.Marshal(map[string]interface{}{
+		"target": targets,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPatch, fmt.Sprintf("https://api.vercel.com/v9/projects/%s/env/%s", projectID, envID), bytes.NewBuffer(body))
+	if err != nil {
+		return err
+	}
+
+	if teamID != "" {
+		params := req.URL.Query()
+		params.Set("teamId", teamID)
+		req.URL.RawQuery = params.Encode()
+	}
+
+	var response VercelResponse
+	if err := client.Run(ctx, req, &response); err != nil {
+		return err
+	}
+
+	if response.Error != nil {
+		return fmt.Errorf("%v", response.Error["message"])
+	}
+
+	return nil
+}
+
+// Deletes an environment variable.
+// Docs: https://vercel.com/docs/rest-api/endpoints#remove-an-environment-variable
+func DeleteEnv(ctx context.ServiceContext, client *clients.HTTPClient, projectID, envID, teamID string) error {
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, fmt.Sprintf("https://api.vercel.com/v9/projects/%s/env/%s", projectID, envID), nil)
+	if err != nil {
+		return err
+	}
+
+	if teamID != "" {
+		params := req.URL.Query()
+		params.Set("teamId", teamID)
+		req.URL.RawQuery = params.Encode()
+	}
+
+	var response VercelResponse
+	if err := client.Run(ctx, req, &response); err != nil {
+		return err
+	}
+
+	if response.Error != nil {
+		return fmt.Errorf("%v", response.Error["message"])
 	}
 
 	return nil
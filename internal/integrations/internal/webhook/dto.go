@@ -0,0 +1,43 @@
+package webhook
+
+import (
+	"github.com/envsecrets/envsecrets/internal/clients"
+	"github.com/envsecrets/envsecrets/internal/secrets/pkg/keypayload"
+)
+
+type Credentials struct {
+	URL    string `json:"url"`
+	Secret string `json:"secret"`
+
+	//	Base64 encoded public key of the recipient.
+	//	If set, the secrets are sealed to it before they are sent.
+	PublicKey string `json:"public_key,omitempty"`
+}
+
+type ListOptions struct {
+	Credentials map[string]interface{}
+}
+
+type SyncOptions struct {
+	Credentials   map[string]interface{} `json:"credentials"`
+	EntityDetails map[string]interface{} `json:"entity_details"`
+	Data          *keypayload.KPMap      `json:"data"`
+	Version       *int                   `json:"version,omitempty"`
+	EnvID         string                 `json:"env_id,omitempty"`
+
+	//	To fetch the metadata of the environment.
+	Client *clients.GQLClient `json:"-"`
+}
+
+type Entity struct {
+	Host string `json:"host"`
+}
+
+type environment struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Project struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"project"`
+}
@@ -0,0 +1,169 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/envsecrets/envsecrets/internal/clients"
+	"github.com/envsecrets/envsecrets/internal/context"
+	"github.com/envsecrets/envsecrets/utils"
+	hook "github.com/envsecrets/envsecrets/webhook"
+	"github.com/machinebox/graphql"
+)
+
+// Webhooks don't have entities of their own,
+// so the host of the URL is returned to be displayed as one.
+func ListEntities(ctx context.ServiceContext, options *ListOptions) (interface{}, error) {
+
+	var credentials Credentials
+	if err := utils.MapToStruct(options.Credentials, &credentials); err != nil {
+		return nil, err
+	}
+
+	parsed, err := url.Parse(credentials.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	return []Entity{{Host: parsed.Host}}, nil
+}
+
+func Sync(ctx context.ServiceContext, options *SyncOptions) error {
+
+	var credentials Credentials
+	if err := utils.MapToStruct(options.Credentials, &credentials); err != nil {
+		return err
+	}
+
+	if credentials.URL == "" || credentials.Secret == "" {
+		return errors.New("webhook url and secret are required")
+	}
+
+	//	Integrations set up before URLs were validated may still point elsewhere.
+	if err := ValidateURL(credentials.URL); err != nil {
+		return err
+	}
+
+	payload := hook.Payload{
+		Event:     hook.SyncEvent,
+		Timestamp: time.Now().Unix(),
+		Version:   options.Version,
+	}
+
+	//	Load the metadata of the environment and its project.
+	if options.EnvID != "" && options.Client != nil {
+		environment, err := getEnvironment(ctx, options.Client, options.EnvID)
+		if err != nil {
+			return err
+		}
+
+		payload.Environment = &hook.Metadata{
+			ID:   environment.ID,
+			Name: environment.Name,
+		}
+		payload.Project = &hook.Metadata{
+			ID:   environment.Project.ID,
+			Name: environment.Project.Name,
+		}
+	}
+
+	data := make(map[string]string)
+	for key, value := range *options.Data {
+		data[key] = fmt.Sprint(value.Value)
+	}
+
+	if credentials.PublicKey != "" {
+		publicKey, err := hook.DecodeKey(credentials.PublicKey)
+		if err != nil {
+			return fmt.Errorf("invalid recipient public key: %w", err)
+		}
+
+		if err := payload.Encrypt(data, publicKey); err != nil {
+			return err
+		}
+	} else {
+		payload.Data = data
+	}
+
+	body, err := json.Marshal(&payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, credentials.URL, bytes.NewBuffer(body))
+	if err != nil {
+		return err
+	}
+
+	client := clients.NewHTTPClient(&clients.HTTPConfig{
+		Type: clients.HTTPClientType,
+		CustomHeaders: []clients.CustomHeader{
+			{
+				Key:   hook.SignatureHeader,
+				Value: hook.Sign(credentials.Secret, payload.Timestamp, body),
+			},
+			{
+				Key:   hook.TimestampHeader,
+				Value: strconv.FormatInt(payload.Timestamp, 10),
+			},
+			{
+				Key:   hook.EventHeader,
+				Value: hook.SyncEvent,
+			},
+		},
+		ResponseHandler: func(response *http.Response) error {
+			if response.StatusCode >= http.StatusMultipleChoices {
+				return fmt.Errorf("webhook responded with status %d", response.StatusCode)
+			}
+			return nil
+		},
+	})
+
+	return client.Run(ctx, req, nil)
+}
+
+// Validates the URL secrets are delivered to.
+// Receivers on the loopback interface are only accepted in development.
+func ValidateURL(value string) error {
+	isDevEnvironment, _ := strconv.ParseBool(os.Getenv("DEV"))
+	return hook.ValidateURL(value, isDevEnvironment)
+}
+
+// Fetches the environment along with its project.
+func getEnvironment(ctx context.ServiceContext, client *clients.GQLClient, id string) (*environment, error) {
+
+	req := graphql.NewRequest(`
+	query MyQuery($id: uuid!) {
+		environments_by_pk(id: $id) {
+			id
+			name
+			project {
+				id
+				name
+			}
+		}
+	}
+	`)
+
+	req.Var("id", id)
+
+	var response struct {
+		Environment *environment `json:"environments_by_pk"`
+	}
+	if err := client.Do(ctx, req, &response); err != nil {
+		return nil, err
+	}
+
+	if response.Environment == nil {
+		return nil, errors.New("environment not found")
+	}
+
+	return response.Environment, nil
+}
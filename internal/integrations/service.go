@@ -12,17 +12,27 @@ import (
 	"github.com/envsecrets/envsecrets/internal/integrations/commons"
 	"github.com/envsecrets/envsecrets/internal/integrations/graphql"
 	"github.com/envsecrets/envsecrets/internal/integrations/internal/asm"
+	"github.com/envsecrets/envsecrets/internal/integrations/internal/bitbucket"
 	"github.com/envsecrets/envsecrets/internal/integrations/internal/circleci"
+	"github.com/envsecrets/envsecrets/internal/integrations/internal/cloudflare"
+	"github.com/envsecrets/envsecrets/internal/integrations/internal/fly"
 	"github.com/envsecrets/envsecrets/internal/integrations/internal/github"
 	"github.com/envsecrets/envsecrets/internal/integrations/internal/gitlab"
 	"github.com/envsecrets/envsecrets/internal/integrations/internal/gsm"
 	"github.com/envsecrets/envsecrets/internal/integrations/internal/hasura"
 	"github.com/envsecrets/envsecrets/internal/integrations/internal/heroku"
+	"github.com/envsecrets/envsecrets/internal/integrations/internal/kubernetes"
 	"github.com/envsecrets/envsecrets/internal/integrations/internal/netlify"
 	"github.com/envsecrets/envsecrets/internal/integrations/internal/nhost"
 	"github.com/envsecrets/envsecrets/internal/integrations/internal/railway"
+	"github.com/envsecrets/envsecrets/internal/integrations/internal/render"
+	"github.com/envsecrets/envsecrets/internal/integrations/internal/ssm"
 	"github.com/envsecrets/envsecrets/internal/integrations/internal/supabase"
+	"github.com/envsecrets/envsecrets/internal/integrations/internal/terraform"
+	"github.com/envsecrets/envsecrets/internal/integrations/internal/vault"
 	"github.com/envsecrets/envsecrets/internal/integrations/internal/vercel"
+	"github.com/envsecrets/envsecrets/internal/integrations/internal/webhook"
+	"github.com/envsecrets/envsecrets/internal/secrets/pkg/keypayload"
 	"github.com/envsecrets/envsecrets/utils"
 )
 
@@ -33,6 +43,7 @@ type Service interface {
 	ListSubEntities(context.ServiceContext, *clients.GQLClient, Type, string, url.Values) (interface{}, error)
 	Setup(context.ServiceContext, *clients.GQLClient, Type, *SetupOptions) (*Integration, error)
 	Sync(context.ServiceContext, *clients.GQLClient, *SyncOptions) error
+	Import(context.ServiceContext, *clients.GQLClient, Type, *ImportOptions) (*keypayload.KPMap, error)
 }
 
 type DefaultService struct{}
@@ -151,6 +162,13 @@ func (*DefaultService) ListEntities(ctx context.ServiceContext, client *clients.
 			Credentials: credentials,
 			OrgID:       integration.OrgID,
 		})
+	case SSM:
+		path, _ := options["path"].(string)
+		return ssm.ListEntities(ctx, &ssm.ListOptions{
+			Credentials: credentials,
+			OrgID:       integration.OrgID,
+			Path:        path,
+		})
 	case GSM:
 		return gsm.ListEntities(ctx, &gsm.ListOptions{
 			Credentials: credentials,
@@ -175,6 +193,40 @@ func (*DefaultService) ListEntities(ctx context.ServiceContext, client *clients.
 		return nhost.ListEntities(ctx, &nhost.ListOptions{
 			Credentials: credentials,
 		})
+	case Vault:
+		return vault.ListEntities(ctx, &vault.ListOptions{
+			Credentials: credentials,
+		})
+	case Webhook:
+		return webhook.ListEntities(ctx, &webhook.ListOptions{
+			Credentials: credentials,
+		})
+	case Bitbucket:
+		return bitbucket.ListEntities(ctx, &bitbucket.ListOptions{
+			Credentials: credentials,
+		})
+	case Fly:
+		return fly.ListEntities(ctx, &fly.ListOptions{
+			Credentials: credentials,
+		})
+	case Render:
+		return render.ListEntities(ctx, &render.ListOptions{
+			Credentials: credentials,
+		})
+	case Cloudflare:
+		return cloudflare.ListEntities(ctx, &cloudflare.ListOptions{
+			Credentials: credentials,
+		})
+	case Terraform:
+		return terraform.ListEntities(ctx, &terraform.ListOptions{
+			Credentials: credentials,
+		})
+	case Kubernetes:
+		namespace, _ := options["namespace"].(string)
+		return kubernetes.ListEntities(ctx, &kubernetes.ListOptions{
+			Credentials: credentials,
+			Namespace:   namespace,
+		})
 	default:
 		return nil, errors.New("invalid integration type")
 	}
@@ -213,6 +265,37 @@ func (*DefaultService) ListSubEntities(ctx context.ServiceContext, client *clien
 			OrgID:       integration.OrgID,
 			OrgSlug:     params.Get("org-slug"),
 		})
+	case Vault:
+		return vault.ListSubEntities(ctx, &vault.ListSubOptions{
+			Credentials: credentials,
+			Mount:       params.Get("mount"),
+			Path:        params.Get("path"),
+		})
+	case Kubernetes:
+		return kubernetes.ListSubEntities(ctx, &kubernetes.ListOptions{
+			Credentials: credentials,
+		})
+	case Bitbucket:
+		return bitbucket.ListSubEntities(ctx, &bitbucket.ListOptions{
+			Credentials: credentials,
+			FullName:    params.Get("full_name"),
+		})
+	case Render:
+		return render.ListSubEntities(ctx, &render.ListOptions{
+			Credentials: credentials,
+		})
+	case Heroku:
+		return heroku.ListSubEntities(ctx, &heroku.ListOptions{
+			Credentials:   credentials,
+			OrgID:         integration.OrgID,
+			IntegrationID: integration.ID,
+		})
+	case Terraform:
+		return terraform.ListSubEntities(ctx, &terraform.ListOptions{
+			Credentials:  credentials,
+			Organization: params.Get("organization"),
+			Type:         terraform.EntityType(params.Get("type")),
+		})
 	default:
 		return nil, errors.New("invalid integration type")
 	}
@@ -271,7 +354,7 @@ func (*DefaultService) Setup(ctx context.ServiceContext, client *clients.GQLClie
 
 		data.Credentials = credentials
 
-	case ASM:
+	case ASM, SSM:
 
 		data.Credentials = map[string]interface{}{
 			"role_arn": fmt.Sprint(options.Options["role_arn"]),
@@ -311,12 +394,108 @@ func (*DefaultService) Setup(ctx context.ServiceContext, client *clients.GQLClie
 			"token": fmt.Sprint(options.Options["token"]),
 		}
 
-	case Supabase:
+	case Supabase, Fly, Render:
 
 		data.Credentials = map[string]interface{}{
 			"token": fmt.Sprint(options.Options["token"]),
 		}
 
+	case Cloudflare:
+
+		data.Credentials = map[string]interface{}{
+			"token":      fmt.Sprint(options.Options["token"]),
+			"account_id": fmt.Sprint(options.Options["account_id"]),
+		}
+
+	case Terraform:
+
+		data.Credentials = map[string]interface{}{
+			"token": fmt.Sprint(options.Options["token"]),
+		}
+
+		//	Only required for self-hosted Terraform Enterprise instances.
+		if options.Options["address"] != nil && options.Options["address"] != "" {
+			data.Credentials["address"] = fmt.Sprint(options.Options["address"])
+		}
+
+	case Bitbucket:
+
+		data.Credentials = map[string]interface{}{
+			"token": fmt.Sprint(options.Options["token"]),
+		}
+
+		//	App passwords are used along with the username.
+		if options.Options["username"] != nil && options.Options["username"] != "" {
+			data.Credentials["username"] = fmt.Sprint(options.Options["username"])
+		}
+
+	case Vault:
+
+		credentials := map[string]interface{}{
+			"address":     fmt.Sprint(options.Options["address"]),
+			"auth_method": fmt.Sprint(options.Options["auth_method"]),
+		}
+
+		if options.Options["namespace"] != nil {
+			credentials["namespace"] = fmt.Sprint(options.Options["namespace"])
+		}
+
+		switch vault.AuthMethod(fmt.Sprint(options.Options["auth_method"])) {
+		case vault.TokenAuth:
+			credentials["token"] = fmt.Sprint(options.Options["token"])
+		case vault.AppRoleAuth:
+			credentials["role_id"] = fmt.Sprint(options.Options["role_id"])
+			credentials["secret_id"] = fmt.Sprint(options.Options["secret_id"])
+			if options.Options["approle_mount"] != nil {
+				credentials["approle_mount"] = fmt.Sprint(options.Options["approle_mount"])
+			}
+		default:
+			return nil, errors.New("unsupported vault auth method")
+		}
+
+		data.Credentials = credentials
+
+	case Webhook:
+
+		if err := webhook.ValidateURL(fmt.Sprint(options.Options["url"])); err != nil {
+			return nil, err
+		}
+
+		if options.Options["secret"] == nil || options.Options["secret"] == "" {
+			return nil, errors.New("webhook secret is required")
+		}
+
+		data.Credentials = map[string]interface{}{
+			"url":    fmt.Sprint(options.Options["url"]),
+			"secret": fmt.Sprint(options.Options["secret"]),
+		}
+
+		if options.Options["public_key"] != nil && options.Options["public_key"] != "" {
+			data.Credentials["public_key"] = fmt.Sprint(options.Options["public_key"])
+		}
+
+	case Kubernetes:
+
+		switch kubernetes.AuthMethod(fmt.Sprint(options.Options["auth_method"])) {
+		case kubernetes.KubeconfigAuth:
+			data.Credentials = map[string]interface{}{
+				"auth_method": kubernetes.KubeconfigAuth,
+				"kubeconfig":  fmt.Sprint(options.Options["kubeconfig"]),
+			}
+		case kubernetes.TokenAuth:
+			data.Credentials = map[string]interface{}{
+				"auth_method": kubernetes.TokenAuth,
+				"api_url":     fmt.Sprint(options.Options["api_url"]),
+				"token":       fmt.Sprint(options.Options["token"]),
+				"insecure":    options.Options["insecure"] == true,
+			}
+			if options.Options["ca_data"] != nil {
+				data.Credentials["ca_data"] = fmt.Sprint(options.Options["ca_data"])
+			}
+		default:
+			return nil, errors.New("unsupported kubernetes auth method")
+		}
+
 	default:
 		return nil, errors.New("unsupported integration type")
 	}
@@ -454,6 +633,83 @@ func (d *DefaultService) Sync(ctx context.ServiceContext, client *clients.GQLCli
 			Data:          options.Data,
 			EntityDetails: options.EntityDetails,
 		})
+	case Vault:
+		resp, err := vault.Sync(ctx, &vault.SyncOptions{
+			Credentials:   credentials,
+			Data:          options.Data,
+			EntityDetails: options.EntityDetails,
+		})
+
+		//	Save the versions written in event's entity_details,
+		//	even if a later write failed, for the next sync to check against.
+		if resp != nil && options.EventID != "" {
+			options.EntityDetails["versions"] = resp.Versions
+			if updateErr := graphql.UpdateDetails(ctx, clients.NewGQLClient(&clients.GQLConfig{
+				Type: clients.HasuraClientType,
+				Headers: []clients.Header{
+					clients.XHasuraAdminSecretHeader,
+				},
+			}), &graphql.UpdateDetailsOptions{
+				ID:            options.EventID,
+				EntityDetails: options.EntityDetails,
+			}); updateErr != nil && err == nil {
+				err = updateErr
+			}
+		}
+		return err
+	case Bitbucket:
+		return bitbucket.Sync(ctx, &bitbucket.SyncOptions{
+			Credentials:   credentials,
+			Data:          options.Data,
+			EntityDetails: options.EntityDetails,
+		})
+	case Fly:
+		return fly.Sync(ctx, &fly.SyncOptions{
+			Credentials:   credentials,
+			Data:          options.Data,
+			EntityDetails: options.EntityDetails,
+		})
+	case Render:
+		return render.Sync(ctx, &render.SyncOptions{
+			Credentials:   credentials,
+			Data:          options.Data,
+			EntityDetails: options.EntityDetails,
+		})
+	case Cloudflare:
+		return cloudflare.Sync(ctx, &cloudflare.SyncOptions{
+			Credentials:   credentials,
+			Data:          options.Data,
+			EntityDetails: options.EntityDetails,
+		})
+	case Terraform:
+		return terraform.Sync(ctx, &terraform.SyncOptions{
+			Credentials:   credentials,
+			Data:          options.Data,
+			EntityDetails: options.EntityDetails,
+		})
+	case Webhook:
+		return webhook.Sync(ctx, &webhook.SyncOptions{
+			Credentials:   credentials,
+			Data:          options.Data,
+			EntityDetails: options.EntityDetails,
+			Version:       options.Version,
+			EnvID:         options.EnvID,
+			Client:        client,
+		})
+	case SSM:
+		return ssm.Sync(ctx, &ssm.SyncOptions{
+			OrgID:         integration.OrgID,
+			Credentials:   credentials,
+			Data:          options.Data,
+			EntityDetails: options.EntityDetails,
+		})
+	case Kubernetes:
+		return kubernetes.Sync(ctx, &kubernetes.SyncOptions{
+			Credentials:   credentials,
+			Data:          options.Data,
+			EntityDetails: options.EntityDetails,
+			Version:       options.Version,
+		})
 	case ASM:
 		resp, err := asm.Sync(ctx, &asm.SyncOptions{
 			OrgID:         integration.OrgID,
@@ -486,3 +742,83 @@ func (d *DefaultService) Sync(ctx context.ServiceContext, client *clients.GQLCli
 		return errors.New("invalid integration type")
 	}
 }
+
+// Reads the existing secrets from the connected platform.
+// The returned values are plaintext, and must be encrypted before they leave the server.
+func (d *DefaultService) Import(ctx context.ServiceContext, client *clients.GQLClient, integrationType Type, options *ImportOptions) (*keypayload.KPMap, error) {
+
+	//	Get the integration to import the secrets from.
+	integration, err := d.Get(ctx, client, options.IntegrationID)
+	if err != nil {
+		return nil, err
+	}
+
+	if integration.Type != integrationType {
+		return nil, fmt.Errorf("integration is not of type %s", integrationType)
+	}
+
+	//	Decrypt the credentials.
+	var credentials map[string]interface{}
+	if integration.Credentials != "" {
+		payload, err := base64.StdEncoding.DecodeString(integration.Credentials)
+		if err != nil {
+			return nil, err
+		}
+
+		decryptedCredentials, err := commons.DecryptCredentials(ctx, integration.OrgID, payload)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal(decryptedCredentials, &credentials); err != nil {
+			return nil, err
+		}
+	}
+
+	switch integration.Type {
+	case Github:
+		return github.Import(ctx, &github.ImportOptions{
+			InstallationID: integration.InstallationID,
+			EntityDetails:  options.EntityDetails,
+		})
+	case Gitlab:
+		return gitlab.Import(ctx, &gitlab.ImportOptions{
+			Credentials:   credentials,
+			EntityDetails: options.EntityDetails,
+			IntegrationID: integration.ID,
+			OrgID:         integration.OrgID,
+		})
+	case Heroku:
+		return heroku.Import(ctx, &heroku.ImportOptions{
+			Credentials:   credentials,
+			EntityDetails: options.EntityDetails,
+			IntegrationID: integration.ID,
+			OrgID:         integration.OrgID,
+		})
+	case Netlify:
+		return netlify.Import(ctx, &netlify.ImportOptions{
+			Credentials:   credentials,
+			EntityDetails: options.EntityDetails,
+		})
+	case Railway:
+		return railway.Import(ctx, &railway.ImportOptions{
+			OrgID:         integration.OrgID,
+			Credentials:   credentials,
+			EntityDetails: options.EntityDetails,
+		})
+	case ASM:
+		return asm.Import(ctx, &asm.ImportOptions{
+			OrgID:         integration.OrgID,
+			Credentials:   credentials,
+			EntityDetails: options.EntityDetails,
+		})
+	case GSM:
+		return gsm.Import(ctx, &gsm.ImportOptions{
+			OrgID:         integration.OrgID,
+			Credentials:   credentials,
+			EntityDetails: options.EntityDetails,
+		})
+	default:
+		return nil, errors.New("importing is not supported for this integration type")
+	}
+}
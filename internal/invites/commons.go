@@ -0,0 +1,12 @@
+package invites
+
+import "errors"
+
+var (
+	//	Returned when an invite is accepted before an admin
+	//	has sealed the organisation's key for the invitee.
+	ErrKeyGrantPending = errors.New("invite accepted, waiting for an admin to grant you the organisation's key")
+
+	ErrKeyAlreadyGranted = errors.New("organisation key has already been granted for this invite")
+	ErrInviteeKeyMissing = errors.New("invitee has not generated their key pair yet")
+)
@@ -44,7 +44,8 @@ type UpdateOptions struct {
 }
 
 type SetUpdateOptions struct {
-	Accepted bool `json:"accepted,omitempty"`
+	Accepted bool   `json:"accepted,omitempty"`
+	Key      string `json:"key,omitempty"`
 }
 
 type SendOptions struct {
@@ -64,3 +65,24 @@ type InsertOptions struct {
 	Email  string `json:"email,omitempty"`
 	RoleID string `json:"role_id,omitempty"`
 }
+
+// Invite whose invitee did not have an envsecrets account when it was sent,
+// but has since generated their key pair, and is now waiting for
+// an admin to seal the organisation's key for them.
+type PendingGrant struct {
+	Invite
+
+	//	Base64 encoded public key of the invitee.
+	PublicKey string `json:"public_key,omitempty"`
+}
+
+type GrantOptions struct {
+
+	//	Decrypted organisation key.
+	//	It will be sealed with the invitee's public key by the server.
+	Key []byte
+
+	//	Base64 encoded copy of the organisation key,
+	//	already sealed with the invitee's public key by the admin's client.
+	SealedKey string
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/envsecrets/envsecrets/internal/clients"
 	"github.com/envsecrets/envsecrets/internal/context"
 	"github.com/envsecrets/envsecrets/internal/keys"
+	keyCommons "github.com/envsecrets/envsecrets/internal/keys/commons"
 	"github.com/envsecrets/envsecrets/internal/memberships"
 	"github.com/envsecrets/envsecrets/internal/organisations"
 	"github.com/envsecrets/envsecrets/internal/users"
@@ -19,6 +20,8 @@ type Service interface {
 	Send(context.ServiceContext, *clients.GQLClient, *SendOptions) error
 	Accept(context.ServiceContext, *clients.GQLClient, string) error
 	Update(context.ServiceContext, *clients.GQLClient, string, *UpdateOptions) error
+	ListPendingGrants(context.ServiceContext, *clients.GQLClient, string) ([]PendingGrant, error)
+	Grant(context.ServiceContext, *clients.GQLClient, string, *GrantOptions) error
 }
 
 type DefaultService struct{}
@@ -57,17 +60,24 @@ func (*DefaultService) Send(ctx context.ServiceContext, client *clients.GQLClien
 	client.Headers = append(client.Headers, clients.XHasuraAdminSecretHeader)
 
 	//	Fetch the invitee user's public key.
+	//	If the invitee doesn't have an account yet,
+	//	the invite is saved without a key copy and
+	//	an admin will have to grant it once the invitee has generated their key pair.
+	var inviteeKeyCopy string
 	public_key, err := keys.GetPublicKeyByUserEmail(ctx, client, options.InviteeEmail)
-	if err != nil {
+	if err != nil && !errors.Is(err, keyCommons.ErrKeyNotFound) {
 		return err
-	}
+	} else if err == nil {
+
+		//	Encrypt the passed decrypted key with the invitee's public key.
+		var key [32]byte
+		copy(key[:], public_key)
+		sealed, err := keys.SealAsymmetricallyAnonymous(options.Key, key)
+		if err != nil {
+			return err
+		}
 
-	//	Encrypt the passed decrypted key with the invitee's public key.
-	var key [32]byte
-	copy(key[:], public_key)
-	inviteeKeyCopy, err := keys.SealAsymmetricallyAnonymous(options.Key, key)
-	if err != nil {
-		return err
+		inviteeKeyCopy = base64.StdEncoding.EncodeToString(sealed)
 	}
 
 	//	Insert the invite.
@@ -81,7 +91,7 @@ func (*DefaultService) Send(ctx context.ServiceContext, client *clients.GQLClien
 
 	req.Var("objects", []InsertOptions{
 		{
-			Key:    base64.StdEncoding.EncodeToString(inviteeKeyCopy),
+			Key:    inviteeKeyCopy,
 			OrgID:  options.OrgID,
 			RoleID: options.RoleID,
 			Email:  options.InviteeEmail,
@@ -112,6 +122,10 @@ func (*DefaultService) Send(ctx context.ServiceContext, client *clients.GQLClien
 // 2. Copy the encrypted key copy from the invite row.
 // 3. Insert new membership in the organisation for the invitee, their key copy and the assigned role from invite row.
 // 4. Mark the invite accepted.
+//
+// If the organisation's key has not yet been granted to the invitee,
+// the invite is only marked accepted and ErrKeyGrantPending is returned.
+// The membership is then completed when an admin grants the key.
 func (d *DefaultService) Accept(ctx context.ServiceContext, client *clients.GQLClient, id string) error {
 
 	//	Get the invite
@@ -125,6 +139,139 @@ func (d *DefaultService) Accept(ctx context.ServiceContext, client *clients.GQLC
 		return fmt.Errorf("invite already accepted")
 	}
 
+	if invite.Key != "" {
+		if err := d.createMembership(ctx, client, invite); err != nil {
+			return err
+		}
+	}
+
+	//	Mark the invite accepted.
+	if err := d.Update(ctx, client, id, &UpdateOptions{
+		Set: SetUpdateOptions{
+			Accepted: true,
+		},
+	}); err != nil {
+		return err
+	}
+
+	if invite.Key == "" {
+		return ErrKeyGrantPending
+	}
+
+	return nil
+}
+
+// Lists the invites of an organisation which are waiting for an admin
+// to seal the organisation's key for invitees who have now generated their key pair.
+// Invitees who still haven't signed up are left out.
+func (*DefaultService) ListPendingGrants(ctx context.ServiceContext, client *clients.GQLClient, org_id string) ([]PendingGrant, error) {
+
+	req := graphql.NewRequest(`
+	query MyQuery($org_id: uuid!) {
+		invites(where: {org_id: {_eq: $org_id}, key: {_is_null: true}}, order_by: {created_at: asc}) {
+			id
+			created_at
+			org_id
+			role_id
+			email
+			accepted
+			user_id
+		}
+	  }	  
+	`)
+
+	req.Var("org_id", org_id)
+
+	var response struct {
+		Invites []Invite `json:"invites"`
+	}
+
+	if err := client.Do(ctx, req, &response); err != nil {
+		return nil, err
+	}
+
+	var result []PendingGrant
+	for _, invite := range response.Invites {
+
+		public_key, err := keys.GetPublicKeyByUserEmail(ctx, client, invite.Email)
+		if err != nil {
+			if errors.Is(err, keyCommons.ErrKeyNotFound) {
+				continue
+			}
+			return nil, err
+		}
+
+		result = append(result, PendingGrant{
+			Invite:    invite,
+			PublicKey: base64.StdEncoding.EncodeToString(public_key),
+		})
+	}
+
+	return result, nil
+}
+
+// ---	Flow ---
+// 1. Fetch the invite row from database using it's ID.
+// 2. Seal the organisation's key with the invitee's public key, unless the admin's client already has.
+// 3. Save the key copy in the invite row.
+// 4. If the invitee has already accepted the invite, complete their membership.
+func (d *DefaultService) Grant(ctx context.ServiceContext, client *clients.GQLClient, id string, options *GrantOptions) error {
+
+	//	Get the invite
+	invite, err := d.Get(ctx, client, id)
+	if err != nil {
+		return err
+	}
+
+	if invite.Key != "" {
+		return ErrKeyAlreadyGranted
+	}
+
+	inviteeKeyCopy := options.SealedKey
+	if inviteeKeyCopy == "" {
+
+		//	Fetch the invitee user's public key.
+		public_key, err := keys.GetPublicKeyByUserEmail(ctx, client, invite.Email)
+		if err != nil {
+			if errors.Is(err, keyCommons.ErrKeyNotFound) {
+				return ErrInviteeKeyMissing
+			}
+			return err
+		}
+
+		//	Encrypt the passed decrypted key with the invitee's public key.
+		var key [32]byte
+		copy(key[:], public_key)
+		sealed, err := keys.SealAsymmetricallyAnonymous(options.Key, key)
+		if err != nil {
+			return err
+		}
+
+		inviteeKeyCopy = base64.StdEncoding.EncodeToString(sealed)
+	}
+
+	if err := d.Update(ctx, client, id, &UpdateOptions{
+		Set: SetUpdateOptions{
+			Key: inviteeKeyCopy,
+		},
+	}); err != nil {
+		return err
+	}
+
+	//	If the invitee is still to accept the invite,
+	//	the membership will be created when they do.
+	if !invite.Accepted {
+		return nil
+	}
+
+	invite.Key = inviteeKeyCopy
+	return d.createMembership(ctx, client, invite)
+}
+
+// Inserts the membership of the invitee in the organisation,
+// and consumes one invite from the organisation's invite limit.
+func (*DefaultService) createMembership(ctx context.ServiceContext, client *clients.GQLClient, invite *Invite) error {
+
 	//	Get the organisation's invite limit.
 	inviteLimit, err := organisations.GetService().GetInviteLimit(ctx, client, invite.OrgID)
 	if err != nil {
@@ -159,24 +306,11 @@ func (d *DefaultService) Accept(ctx context.ServiceContext, client *clients.GQLC
 		return err
 	}
 
-	//	Mark the invite accepted.
-	if err := d.Update(ctx, client, id, &UpdateOptions{
-		Set: SetUpdateOptions{
-			Accepted: true,
-		},
-	}); err != nil {
-		return err
-	}
-
 	//	Update the invite limit of the organisation.
-	if err := organisations.GetService().UpdateInviteLimit(ctx, client, &organisations.UpdateInviteLimitOptions{
+	return organisations.GetService().UpdateInviteLimit(ctx, client, &organisations.UpdateInviteLimitOptions{
 		ID:               invite.OrgID,
 		IncrementLimitBy: -1,
-	}); err != nil {
-		return err
-	}
-
-	return nil
+	})
 }
 
 func (*DefaultService) Update(ctx context.ServiceContext, client *clients.GQLClient, id string, options *UpdateOptions) error {
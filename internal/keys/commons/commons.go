@@ -9,4 +9,5 @@ const (
 
 var (
 	ErrNoServerKey = errors.New("SERVER_SYMMETRIC_KEY is not set")
+	ErrKeyNotFound = errors.New("failed to fetch the key")
 )
@@ -257,7 +257,7 @@ func GetPublicKeyByUserEmail(ctx context.ServiceContext, client *clients.GQLClie
 	}
 
 	if len(resp) == 0 {
-		return nil, errors.New("failed to fetch the key")
+		return nil, commons.ErrKeyNotFound
 	}
 
 	result, err := base64.StdEncoding.DecodeString(resp[0].PublicKey)
@@ -86,7 +86,7 @@ func (*DefaultMailService) Invite(ctx context.ServiceContext, options *commons.I
 			},
 			Actions: []hermes.Action{
 				{
-					Instructions: "Accepting this invite requires you to have an envsecrets account. If you do not already have one, please create one from app.envsecrets.com.",
+					Instructions: "Accepting this invite requires you to have an envsecrets account. If you do not already have one, please create one from app.envsecrets.com. Once you have signed up, an admin of the organisation will grant you access to its secrets.",
 					Button: hermes.Button{
 						Color: "#222", // Optional action button color
 						Text:  "Accept Invite",
@@ -3,6 +3,9 @@ package memberships
 import (
 	"encoding/json"
 	"time"
+
+	"github.com/envsecrets/envsecrets/internal/roles"
+	"github.com/envsecrets/envsecrets/internal/users"
 )
 
 type Membership struct {
@@ -15,6 +18,9 @@ type Membership struct {
 	RoleID string `json:"role_id,omitempty"`
 
 	Key string `json:"key,omitempty"`
+
+	User *users.User `json:"user,omitempty"`
+	Role *roles.Role `json:"role,omitempty"`
 }
 
 func (w *Membership) Marshal() ([]byte, error) {
@@ -45,3 +51,9 @@ type GetKeyOptions struct {
 	UserID string `json:"user_id,omitempty"`
 	OrgID  string `json:"org_id,omitempty"`
 }
+
+type UpdateRoleOptions struct {
+	UserID string `json:"user_id,omitempty"`
+	OrgID  string `json:"org_id,omitempty"`
+	RoleID string `json:"role_id,omitempty"`
+}
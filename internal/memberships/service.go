@@ -141,3 +141,72 @@ func GetKey(ctx context.ServiceContext, client *clients.GQLClient, options *GetK
 
 	return result, nil
 }
+
+// List all the memberships of an organisation.
+func List(ctx context.ServiceContext, client *clients.GQLClient, org_id string) ([]Membership, error) {
+
+	req := graphql.NewRequest(`
+	query MyQuery($org_id: uuid!) {
+		org_has_user(where: {org_id: {_eq: $org_id}}, order_by: {created_at: asc}) {
+		  id
+		  created_at
+		  user_id
+		  org_id
+		  role_id
+		  user {
+			id
+			email
+			displayName
+		  }
+		  role {
+			id
+			name
+		  }
+		}
+	  }
+	`)
+
+	req.Var("org_id", org_id)
+
+	var response struct {
+		Memberships []Membership `json:"org_has_user"`
+	}
+
+	if err := client.Do(ctx, req, &response); err != nil {
+		return nil, err
+	}
+
+	return response.Memberships, nil
+}
+
+// Change the role of a member in the organisation.
+func UpdateRole(ctx context.ServiceContext, client *clients.GQLClient, options *UpdateRoleOptions) error {
+
+	req := graphql.NewRequest(`
+	mutation MyMutation($user_id: uuid!, $org_id: uuid!, $role_id: uuid!) {
+		update_org_has_user(where: {org_id: {_eq: $org_id}, user_id: {_eq: $user_id}}, _set: {role_id: $role_id}) {
+		  affected_rows
+		}
+	  }
+	`)
+
+	req.Var("user_id", options.UserID)
+	req.Var("org_id", options.OrgID)
+	req.Var("role_id", options.RoleID)
+
+	var response struct {
+		Result struct {
+			AffectedRows int `json:"affected_rows"`
+		} `json:"update_org_has_user"`
+	}
+
+	if err := client.Do(ctx, req, &response); err != nil {
+		return err
+	}
+
+	if response.Result.AffectedRows == 0 {
+		return errors.New("membership not found")
+	}
+
+	return nil
+}
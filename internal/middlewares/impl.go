@@ -2,12 +2,20 @@ package middlewares
 
 import (
 	"encoding/hex"
+	"errors"
 	"log"
+	"net/http"
 	"os"
 
 	"github.com/envsecrets/envsecrets/cli/auth"
+	"github.com/envsecrets/envsecrets/internal/audits"
 	"github.com/envsecrets/envsecrets/internal/clients"
 	"github.com/envsecrets/envsecrets/internal/context"
+	"github.com/envsecrets/envsecrets/internal/environments"
+	"github.com/envsecrets/envsecrets/internal/organisations"
+	"github.com/envsecrets/envsecrets/internal/permissions"
+	"github.com/envsecrets/envsecrets/internal/roles"
+	"github.com/envsecrets/envsecrets/internal/sso"
 	"github.com/envsecrets/envsecrets/internal/tokens"
 	"github.com/envsecrets/envsecrets/utils"
 	"github.com/golang-jwt/jwt/v4"
@@ -49,6 +57,8 @@ func JWTAuth(skipper middleware.Skipper) echo.MiddlewareFunc {
 
 }
 
+// TokenHeader authenticates requests with environment tokens,
+// and enforces the restrictions of the token and of its environment.
 func TokenHeader() echo.MiddlewareFunc {
 	return middleware.KeyAuthWithConfig(middleware.KeyAuthConfig{
 		Skipper: func(c echo.Context) bool {
@@ -88,9 +98,148 @@ func TokenHeader() echo.MiddlewareFunc {
 				return false, echo.ErrUnauthorized
 			}
 
+			//	Fetch the environment for its allowed networks.
+			environment, err := environments.GetService().Get(ctx, client, token.EnvID)
+			if err != nil {
+				return false, err
+			}
+
+			//	Validate the request against the token's restrictions, and count it towards its usage limit.
+			if err := tokens.GetService().Use(ctx, client, token, &tokens.UseOptions{
+				IP:              c.RealIP(),
+				UserAgent:       c.Request().UserAgent(),
+				IDToken:         c.Request().Header.Get(string(clients.IDTokenHeader)),
+				EnvAllowedCIDRs: environment.AllowedCIDRs,
+			}); err != nil {
+				return false, err
+			}
+
 			c.Set("token", token)
 
+			return true, nil
+		},
+		ErrorHandler: func(err error, c echo.Context) error {
+
+			var violation *tokens.RestrictionError
+			if errors.As(err, &violation) {
+				alert(c, violation)
+				return c.JSON(http.StatusForbidden, &clients.APIResponse{
+					Message: violation.Error(),
+					Error:   string(clients.ErrorTypeTokenRestricted),
+				})
+			}
+
+			//	Same responses as the middleware's defaults.
+			var missing *middleware.ErrKeyAuthMissing
+			if errors.As(err, &missing) {
+				return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+			}
+
+			return &echo.HTTPError{
+				Code:     http.StatusUnauthorized,
+				Message:  "Unauthorized",
+				Internal: err,
+			}
+		},
+	})
+}
+
+// SCIMToken authenticates identity providers with the bearer SCIM token
+// issued for their organisation, and saves the organisation's connection in the context.
+func SCIMToken() echo.MiddlewareFunc {
+	return middleware.KeyAuthWithConfig(middleware.KeyAuthConfig{
+		KeyLookup:  "header:" + echo.HeaderAuthorization,
+		AuthScheme: "Bearer",
+		Validator: func(key string, c echo.Context) (bool, error) {
+
+			//	Initialize a new default context
+			ctx := context.NewContext(&context.Config{Type: context.APIContext, EchoContext: c})
+
+			//	Initialize Hasura client with admin privileges
+			client := clients.NewGQLClient(&clients.GQLConfig{
+				Type: clients.HasuraClientType,
+				Headers: []clients.Header{
+					clients.XHasuraAdminSecretHeader,
+				},
+			})
+
+			connection, err := sso.GetService().GetConnectionBySCIMToken(ctx, client, key)
+			if err != nil {
+				return false, nil
+			}
+
+			c.Set("sso_connection", connection)
+
 			return true, nil
 		},
 	})
 }
+
+// Authorize validates the user's permission to perform the action on the resource,
+// in the scope of the environment, project or organisation in the route's path parameters.
+func Authorize(resource roles.Resource, action roles.Action) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if err := permissions.AuthorizeRequest(c, permissions.Scope{
+				OrgID:     c.Param("org_id"),
+				ProjectID: c.Param("project_id"),
+				EnvID:     c.Param("env_id"),
+			}, resource, action); err != nil {
+				return c.JSON(http.StatusForbidden, &clients.APIResponse{
+					Message: "You are not allowed to perform this action",
+					Error:   err.Error(),
+				})
+			}
+			return next(c)
+		}
+	}
+}
+
+//	---	Helpers ---
+
+// Logs a violation of a token's restrictions for alerting,
+// and records it in the audit logs of the token's organisation.
+func alert(c echo.Context, violation *tokens.RestrictionError) {
+
+	c.Logger().Warnf("event=token.restriction_violated violation=%s token_id=%s env_id=%s ip=%s user_agent=%q reason=%q",
+		violation.Violation,
+		violation.TokenID,
+		violation.EnvID,
+		c.RealIP(),
+		c.Request().UserAgent(),
+		violation.Reason,
+	)
+
+	//	Initialize a new default context
+	ctx := context.NewContext(&context.Config{Type: context.APIContext, EchoContext: c})
+
+	//	Initialize Hasura client with admin privileges
+	client := clients.NewGQLClient(&clients.GQLConfig{
+		Type: clients.HasuraClientType,
+		Headers: []clients.Header{
+			clients.XHasuraAdminSecretHeader,
+		},
+	})
+
+	organisation, err := organisations.GetService().GetByEnvironment(ctx, client, violation.EnvID)
+	if err != nil {
+		c.Logger().Error(err)
+		return
+	}
+
+	if _, err := audits.GetService().Create(ctx, client, &audits.CreateOptions{
+		OrgID:      organisation.ID,
+		Action:     audits.TokenRestrictionViolatedAction,
+		EntityType: audits.TokenEntity,
+		EntityID:   violation.TokenID,
+		Metadata: map[string]interface{}{
+			"env_id":     violation.EnvID,
+			"violation":  violation.Violation,
+			"reason":     violation.Reason,
+			"ip":         c.RealIP(),
+			"user_agent": c.Request().UserAgent(),
+		},
+	}); err != nil {
+		c.Logger().Error(err)
+	}
+}
@@ -0,0 +1,22 @@
+package oidc
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	DISCOVERY_PATH = "/.well-known/openid-configuration"
+
+	//	Clock skew tolerated when validating the timestamps of ID tokens.
+	LEEWAY = time.Minute
+)
+
+var (
+	ErrIssuerMismatch   = errors.New("issuer of the discovery document does not match the configured issuer")
+	ErrKeyNotFound      = errors.New("no key in the issuer's key set matches the token")
+	ErrUnsupportedKey   = errors.New("unsupported key type in the issuer's key set")
+	ErrInvalidNonce     = errors.New("token nonce does not match")
+	ErrMissingIDToken   = errors.New("token response does not contain an id_token")
+	ErrEmailNotVerified = errors.New("email address is not verified by the identity provider")
+)
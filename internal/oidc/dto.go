@@ -0,0 +1,111 @@
+package oidc
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// Subset of an issuer's discovery document.
+type Provider struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// JSON Web Key, as published in the issuer's key set.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg,omitempty"`
+	Use string `json:"use,omitempty"`
+
+	//	RSA keys
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+
+	//	EC keys
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+type KeySet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// Validated claims of an ID token.
+type Claims map[string]interface{}
+
+func (c Claims) String(key string) string {
+	value, _ := c[key].(string)
+	return value
+}
+
+func (c Claims) Bool(key string) bool {
+	switch value := c[key].(type) {
+	case bool:
+		return value
+	case string:
+		return strings.EqualFold(value, "true")
+	default:
+		return false
+	}
+}
+
+// Returns the claim as a list of strings,
+// whether the issuer sends it as an array or a single value.
+func (c Claims) Strings(key string) []string {
+	switch value := c[key].(type) {
+	case string:
+		return []string{value}
+	case []interface{}:
+		var result []string
+		for _, item := range value {
+			if s, ok := item.(string); ok {
+				result = append(result, s)
+			}
+		}
+		return result
+	default:
+		return nil
+	}
+}
+
+func (c Claims) Subject() string {
+	return c.String("sub")
+}
+
+func (c Claims) Email() string {
+	return strings.ToLower(c.String("email"))
+}
+
+func (c Claims) Marshal() ([]byte, error) {
+	return json.Marshal(c)
+}
+
+type VerifyOptions struct {
+
+	//	Expected "aud" claim of the token, usually the client ID.
+	Audience string
+
+	//	Expected "nonce" claim, for tokens issued in an authorization code flow.
+	Nonce string
+}
+
+type ExchangeOptions struct {
+	ClientID     string
+	ClientSecret string
+	Code         string
+	CodeVerifier string
+	RedirectURI  string
+}
+
+type AuthCodeURLOptions struct {
+	ClientID      string
+	RedirectURI   string
+	State         string
+	Nonce         string
+	CodeChallenge string
+	Scopes        []string
+}
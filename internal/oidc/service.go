@@ -0,0 +1,352 @@
+package oidc
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/envsecrets/envsecrets/internal/context"
+	"github.com/golang-jwt/jwt/v4"
+	"golang.org/x/oauth2"
+)
+
+var httpClient = &http.Client{Timeout: 10 * time.Second}
+
+// Key sets are cached for a few minutes, and refetched early
+// whenever a token is signed by a key we haven't seen yet, i.e. after a key rotation.
+// Refetches are limited to one per interval, so that tokens with made up key IDs
+// can't make us fetch the issuer's key set on every request.
+var cache = struct {
+	sync.Mutex
+	sets map[string]cachedKeySet
+}{sets: make(map[string]cachedKeySet)}
+
+type cachedKeySet struct {
+	set       *KeySet
+	fetchedAt time.Time
+
+	//	When the key set was last refetched, successfully or not.
+	refreshedAt time.Time
+}
+
+// Discovery documents rarely change, so they're cached for longer than key sets,
+// sparing requests verifying ID tokens a fetch each.
+var providers = struct {
+	sync.Mutex
+	items map[string]cachedProvider
+}{items: make(map[string]cachedProvider)}
+
+type cachedProvider struct {
+	provider  Provider
+	fetchedAt time.Time
+}
+
+const (
+	keySetTTL             = 5 * time.Minute
+	keySetRefreshInterval = time.Minute
+	providerTTL           = time.Hour
+)
+
+// Fetches the discovery document of the issuer, unless it's cached.
+func Discover(ctx context.ServiceContext, issuer string) (*Provider, error) {
+
+	issuer = strings.TrimSuffix(issuer, "/")
+
+	providers.Lock()
+	cached, ok := providers.items[issuer]
+	providers.Unlock()
+
+	if ok && time.Since(cached.fetchedAt) < providerTTL {
+		provider := cached.provider
+		return &provider, nil
+	}
+
+	var provider Provider
+	if err := get(ctx, issuer+DISCOVERY_PATH, &provider); err != nil {
+		return nil, err
+	}
+
+	if strings.TrimSuffix(provider.Issuer, "/") != issuer {
+		return nil, ErrIssuerMismatch
+	}
+
+	providers.Lock()
+	providers.items[issuer] = cachedProvider{provider: provider, fetchedAt: time.Now()}
+	providers.Unlock()
+
+	return &provider, nil
+}
+
+// Builds the URL of the issuer's authorization endpoint for the authorization code flow with PKCE.
+func (p *Provider) AuthCodeURL(options *AuthCodeURLOptions) string {
+
+	scopes := options.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{"openid", "email", "profile"}
+	}
+
+	config := oauth2.Config{
+		ClientID:    options.ClientID,
+		RedirectURL: options.RedirectURI,
+		Scopes:      scopes,
+		Endpoint: oauth2.Endpoint{
+			AuthURL: p.AuthorizationEndpoint,
+		},
+	}
+
+	return config.AuthCodeURL(options.State,
+		oauth2.SetAuthURLParam("nonce", options.Nonce),
+		oauth2.SetAuthURLParam("code_challenge", options.CodeChallenge),
+		oauth2.SetAuthURLParam("code_challenge_method", "S256"),
+	)
+}
+
+// Exchanges an authorization code for the raw ID token issued with it.
+func (p *Provider) Exchange(ctx context.ServiceContext, options *ExchangeOptions) (string, error) {
+
+	config := oauth2.Config{
+		ClientID:     options.ClientID,
+		ClientSecret: options.ClientSecret,
+		RedirectURL:  options.RedirectURI,
+		Endpoint: oauth2.Endpoint{
+			TokenURL: p.TokenEndpoint,
+		},
+	}
+
+	token, err := config.Exchange(ctx, options.Code,
+		oauth2.SetAuthURLParam("code_verifier", options.CodeVerifier),
+	)
+	if err != nil {
+		return "", err
+	}
+
+	idToken, ok := token.Extra("id_token").(string)
+	if !ok || idToken == "" {
+		return "", ErrMissingIDToken
+	}
+
+	return idToken, nil
+}
+
+// --- Flow ---
+//
+//  1. Validate the token's signature against the issuer's published key set.
+//  2. Validate the issuer, audience and timestamps, with a small leeway for clock skew.
+//  3. Validate the nonce, if one is expected.
+func (p *Provider) Verify(ctx context.ServiceContext, raw string, options *VerifyOptions) (Claims, error) {
+
+	parser := jwt.NewParser(
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512"}),
+		jwt.WithoutClaimsValidation(),
+	)
+
+	claims := jwt.MapClaims{}
+	if _, err := parser.ParseWithClaims(raw, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return p.publicKey(ctx, kid)
+	}); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+
+	if !claims.VerifyIssuer(p.Issuer, true) {
+		return nil, jwt.ErrTokenInvalidIssuer
+	}
+
+	if !claims.VerifyAudience(options.Audience, true) {
+		return nil, jwt.ErrTokenInvalidAudience
+	}
+
+	if !claims.VerifyExpiresAt(now.Add(-LEEWAY).Unix(), true) {
+		return nil, jwt.ErrTokenExpired
+	}
+
+	if !claims.VerifyNotBefore(now.Add(LEEWAY).Unix(), false) {
+		return nil, jwt.ErrTokenNotValidYet
+	}
+
+	if !claims.VerifyIssuedAt(now.Add(LEEWAY).Unix(), false) {
+		return nil, jwt.ErrTokenUsedBeforeIssued
+	}
+
+	result := Claims(claims)
+
+	if options.Nonce != "" && result.String("nonce") != options.Nonce {
+		return nil, ErrInvalidNonce
+	}
+
+	return result, nil
+}
+
+// Returns the issuer's public key with the given ID,
+// refetching the key set if it isn't in the cached one, unless it was refetched recently.
+func (p *Provider) publicKey(ctx context.ServiceContext, kid string) (interface{}, error) {
+
+	cache.Lock()
+	cached, ok := cache.sets[p.JWKSURI]
+	if ok {
+		key, err := cached.set.find(kid)
+		if err == nil && time.Since(cached.fetchedAt) < keySetTTL {
+			cache.Unlock()
+			return key, nil
+		}
+
+		if time.Since(cached.refreshedAt) < keySetRefreshInterval {
+			cache.Unlock()
+			return key, err
+		}
+
+		//	Claim the refetch, so that concurrent requests use the cached key set meanwhile.
+		cached.refreshedAt = time.Now()
+		cache.sets[p.JWKSURI] = cached
+	}
+	cache.Unlock()
+
+	var set KeySet
+	if err := get(ctx, p.JWKSURI, &set); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+
+	cache.Lock()
+	cache.sets[p.JWKSURI] = cachedKeySet{set: &set, fetchedAt: now, refreshedAt: now}
+	cache.Unlock()
+
+	return set.find(kid)
+}
+
+func (s *KeySet) find(kid string) (interface{}, error) {
+	for _, key := range s.Keys {
+
+		//	Issuers with a single signing key may leave out the key ID.
+		if key.Kid != kid && kid != "" {
+			continue
+		}
+
+		if key.Use != "" && key.Use != "sig" {
+			continue
+		}
+
+		return key.PublicKey()
+	}
+
+	return nil, ErrKeyNotFound
+}
+
+// Converts the JSON Web Key to an RSA or ECDSA public key.
+func (k *JWK) PublicKey() (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, err
+		}
+
+		return &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}, nil
+
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, ErrUnsupportedKey
+		}
+
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return nil, err
+		}
+
+		return &ecdsa.PublicKey{
+			Curve: curve,
+			X:     new(big.Int).SetBytes(x),
+			Y:     new(big.Int).SetBytes(y),
+		}, nil
+
+	default:
+		return nil, ErrUnsupportedKey
+	}
+}
+
+// Reads the issuer of an ID token without validating it,
+// to find out which issuer to validate the token against.
+// Never trust anything else from an unverified token.
+func UnverifiedIssuer(raw string) (string, error) {
+
+	claims := jwt.MapClaims{}
+	if _, _, err := jwt.NewParser().ParseUnverified(raw, claims); err != nil {
+		return "", err
+	}
+
+	issuer := Claims(claims).String("iss")
+	if issuer == "" {
+		return "", jwt.ErrTokenInvalidIssuer
+	}
+
+	return strings.TrimSuffix(issuer, "/"), nil
+}
+
+// Generates a random, URL safe value for the state, nonce and PKCE code verifier.
+func GenerateRandomValue() (string, error) {
+	value := make([]byte, 32)
+	if _, err := rand.Read(value); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(value), nil
+}
+
+// Derives the S256 PKCE code challenge from the code verifier.
+func CodeChallenge(verifier string) string {
+	hash := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(hash[:])
+}
+
+func get(ctx context.ServiceContext, url string, result interface{}) error {
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to fetch %s: %s", url, resp.Status)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(result)
+}
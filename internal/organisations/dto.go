@@ -11,6 +11,10 @@ type Organisation struct {
 	Name        string    `json:"name,omitempty"`
 	UserID      string    `json:"user_id,omitempty"`
 	InviteLimit *int      `json:"invite_limit,omitempty"`
+
+	//	Policy requiring the organisation's key to be rotated
+	//	every time a member is removed from the organisation.
+	RotateKeyOnMemberRemoval bool `json:"rotate_key_on_member_removal,omitempty"`
 }
 
 type CreateOptions struct {
@@ -31,3 +35,28 @@ type UpdateServerKeyCopyOptions struct {
 	OrgID string
 	Key   string
 }
+
+type RemoveMemberOptions struct {
+	ID     string
+	UserID string
+}
+
+type RemoveMemberResponse struct {
+	RevokedTokens int `json:"revoked_tokens"`
+}
+
+type RotateKeyOptions struct {
+	ID string
+
+	//	Current decrypted organisation key.
+	Key []byte
+}
+
+type RotateKeyResponse struct {
+	Secrets        int `json:"secrets"`
+	ChangeRequests int `json:"change_requests"`
+	Members        int `json:"members"`
+	Invites        int `json:"invites"`
+	TrustPolicies  int `json:"trust_policies"`
+	RevokedTokens  int `json:"revoked_tokens"`
+}
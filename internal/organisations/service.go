@@ -2,6 +2,7 @@ package organisations
 
 import (
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
 
 	"github.com/envsecrets/envsecrets/internal/clients"
@@ -10,6 +11,8 @@ import (
 	keyCommons "github.com/envsecrets/envsecrets/internal/keys/commons"
 	"github.com/envsecrets/envsecrets/internal/memberships"
 	"github.com/envsecrets/envsecrets/internal/roles"
+	"github.com/envsecrets/envsecrets/internal/secrets/pkg/keypayload"
+	"github.com/envsecrets/envsecrets/internal/secrets/pkg/payload"
 	"github.com/envsecrets/envsecrets/utils"
 	"github.com/machinebox/graphql"
 )
@@ -21,7 +24,10 @@ type Service interface {
 	GetInviteLimit(context.ServiceContext, *clients.GQLClient, string) (*int, error)
 	Create(context.ServiceContext, *clients.GQLClient, *CreateOptions) (*Organisation, error)
 	List(context.ServiceContext, *clients.GQLClient) (*[]Organisation, error)
+	Update(context.ServiceContext, *clients.GQLClient, string, *UpdateOptions) (*Organisation, error)
 	UpdateInviteLimit(context.ServiceContext, *clients.GQLClient, *UpdateInviteLimitOptions) error
+	RemoveMember(context.ServiceContext, *clients.GQLClient, *RemoveMemberOptions) (*RemoveMemberResponse, error)
+	RotateKey(context.ServiceContext, *clients.GQLClient, *RotateKeyOptions) (*RotateKeyResponse, error)
 }
 
 type DefaultService struct{}
@@ -33,6 +39,8 @@ func (*DefaultService) Get(ctx context.ServiceContext, client *clients.GQLClient
 		organisations_by_pk(id: $id) {
 			id
 			name
+			user_id
+			rotate_key_on_member_removal
 		}
 	  }	  
 	`)
@@ -252,6 +260,36 @@ func (*DefaultService) Create(ctx context.ServiceContext, client *clients.GQLCli
 	return organisation, nil
 }
 
+// Update an organisation by ID
+func (*DefaultService) Update(ctx context.ServiceContext, client *clients.GQLClient, id string, options *UpdateOptions) (*Organisation, error) {
+
+	req := graphql.NewRequest(`
+	mutation MyMutation($id: uuid!, $name: String!) {
+		update_organisations_by_pk(pk_columns: {id: $id}, _set: {name: $name}) {
+			id
+			name
+		}
+	  }
+	`)
+
+	req.Var("id", id)
+	req.Var("name", options.Name)
+
+	var response struct {
+		Organisation *Organisation `json:"update_organisations_by_pk"`
+	}
+
+	if err := client.Do(ctx, req, &response); err != nil {
+		return nil, err
+	}
+
+	if response.Organisation == nil {
+		return nil, fmt.Errorf("organisation not found")
+	}
+
+	return response.Organisation, nil
+}
+
 func (*DefaultService) UpdateInviteLimit(ctx context.ServiceContext, client *clients.GQLClient, options *UpdateInviteLimitOptions) error {
 
 	req := graphql.NewRequest(`
@@ -282,10 +320,437 @@ func (*DefaultService) UpdateInviteLimit(ctx context.ServiceContext, client *cli
 	return nil
 }
 
+// ---	Flow ---
+// 1. Delete the member's membership, and with it, their copy of the organisation's key.
+// 2. Delete the overrides of the member's role in the organisation's projects and environments.
+// 3. Revoke the environment tokens created by the member.
+//
+// All the deletions are written in a single mutation, so that a failure
+// never leaves the member removed with their tokens still valid.
+// The organisation's key itself is not rotated here,
+// since that requires an admin to decrypt their own copy of it.
+func (d *DefaultService) RemoveMember(ctx context.ServiceContext, client *clients.GQLClient, options *RemoveMemberOptions) (*RemoveMemberResponse, error) {
+
+	organisation, err := d.Get(ctx, client, options.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if organisation == nil {
+		return nil, fmt.Errorf("organisation not found")
+	}
+
+	if organisation.UserID == options.UserID {
+		return nil, fmt.Errorf("the owner cannot be removed from the organisation")
+	}
+
+	req := graphql.NewRequest(`
+	mutation MyMutation($org_id: uuid!, $user_id: uuid!) {
+		delete_org_has_user(where: {org_id: {_eq: $org_id}, user_id: {_eq: $user_id}}) {
+		  affected_rows
+		}
+		delete_env_level_permissions(where: {environment: {project: {org_id: {_eq: $org_id}}}, user_id: {_eq: $user_id}}) {
+		  affected_rows
+		}
+		delete_project_level_permissions(where: {project: {org_id: {_eq: $org_id}}, user_id: {_eq: $user_id}}) {
+		  affected_rows
+		}
+		delete_tokens(where: {environment: {project: {org_id: {_eq: $org_id}}}, user_id: {_eq: $user_id}}) {
+		  affected_rows
+		}
+	  }
+	`)
+
+	req.Var("org_id", options.ID)
+	req.Var("user_id", options.UserID)
+
+	var response struct {
+		Memberships struct {
+			AffectedRows int `json:"affected_rows"`
+		} `json:"delete_org_has_user"`
+		Tokens struct {
+			AffectedRows int `json:"affected_rows"`
+		} `json:"delete_tokens"`
+	}
+
+	if err := client.Do(ctx, req, &response); err != nil {
+		return nil, err
+	}
+
+	//	Hasura runs the mutation in a single transaction,
+	//	so nothing else was deleted if the membership didn't exist.
+	if response.Memberships.AffectedRows == 0 {
+		return nil, fmt.Errorf("membership not found")
+	}
+
+	return &RemoveMemberResponse{
+		RevokedTokens: response.Tokens.AffectedRows,
+	}, nil
+}
+
+// ---	Flow ---
+// 1. Generate a new symmetric key for the organisation.
+// 2. Re-encrypt every version of every secret in the organisation with the new key,
+// and the proposed values of the pending change requests of its protected environments.
+// 3. Seal the new key with the public keys of all the members, and of the invitees who already have a copy.
+// 4. Re-seal the server's copies of the new key, kept for the trust policies of CI providers.
+// 5. Revoke all the environment tokens, since they carry a copy of the old key.
+//
+// All the changes are written in a single mutation, so that
+// the organisation is never left with secrets encrypted by different keys.
+func (*DefaultService) RotateKey(ctx context.ServiceContext, client *clients.GQLClient, options *RotateKeyOptions) (*RotateKeyResponse, error) {
+
+	var oldKey, newKey [32]byte
+	copy(oldKey[:], options.Key)
+
+	//	Generate a new symmetric key for the organisation.
+	keyBytes, err := utils.GenerateRandomBytes(keyCommons.KEY_BYTES)
+	if err != nil {
+		return nil, err
+	}
+	copy(newKey[:], keyBytes)
+
+	rows, err := listSecrets(ctx, client, options.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var secretUpdates []map[string]interface{}
+	for _, row := range rows {
+
+		data := keypayload.KPMap{}
+		if err := json.Unmarshal(row.Data, &data); err != nil {
+
+			//	Legacy rows store a single payload instead of a map.
+			var item payload.Payload
+			if err := json.Unmarshal(row.Data, &item); err != nil {
+				return nil, err
+			}
+
+			item.MarkEncoded()
+			if err := item.Decrypt(oldKey); err != nil {
+				return nil, fmt.Errorf("failed to decrypt the secrets with the current key: %w", err)
+			}
+
+			if err := item.Encrypt(newKey); err != nil {
+				return nil, err
+			}
+
+			secretUpdates = append(secretUpdates, updateByID(row.ID, "data", &item))
+			continue
+		}
+
+		data.MarkAllEncoded()
+		if err := data.Decrypt(oldKey); err != nil {
+			return nil, fmt.Errorf("failed to decrypt the secrets with the current key: %w", err)
+		}
+
+		if err := data.Encrypt(newKey); err != nil {
+			return nil, err
+		}
+
+		secretUpdates = append(secretUpdates, updateByID(row.ID, "data", data))
+	}
+
+	//	Re-encrypt the proposed values of pending change requests,
+	//	so that they can still be applied once approved.
+	changeRequests, err := listPendingChangeRequests(ctx, client, options.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var changeRequestUpdates []map[string]interface{}
+	for _, row := range changeRequests {
+
+		if len(row.Data) == 0 {
+			continue
+		}
+
+		row.Data.MarkAllEncoded()
+		if err := row.Data.Decrypt(oldKey); err != nil {
+			return nil, fmt.Errorf("failed to decrypt the pending change requests with the current key: %w", err)
+		}
+
+		if err := row.Data.Encrypt(newKey); err != nil {
+			return nil, err
+		}
+
+		changeRequestUpdates = append(changeRequestUpdates, updateByID(row.ID, "data", row.Data))
+	}
+
+	//	Seal the new key for every member of the organisation.
+	members, err := memberships.List(ctx, client, options.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var memberUpdates []map[string]interface{}
+	for _, member := range members {
+
+		publicKey, err := keys.GetPublicKeyByUserID(ctx, client, member.UserID)
+		if err != nil {
+			return nil, err
+		}
+
+		sealed, err := sealWithPublicKey(keyBytes, publicKey)
+		if err != nil {
+			return nil, err
+		}
+
+		memberUpdates = append(memberUpdates, updateByID(member.ID, "key", sealed))
+	}
+
+	//	Seal the new key for the invitees who have not yet accepted their invites.
+	invites, err := listSealedInvites(ctx, client, options.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var inviteUpdates []map[string]interface{}
+	for _, invite := range invites {
+
+		publicKey, err := keys.GetPublicKeyByUserEmail(ctx, client, invite.Email)
+		if err != nil {
+			return nil, err
+		}
+
+		sealed, err := sealWithPublicKey(keyBytes, publicKey)
+		if err != nil {
+			return nil, err
+		}
+
+		inviteUpdates = append(inviteUpdates, updateByID(invite.ID, "key", sealed))
+	}
+
+	//	Seal the new key by the server for every trust policy.
+	policies, err := listTrustPolicies(ctx, client, options.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var policyUpdates []map[string]interface{}
+	if len(policies) > 0 {
+
+		sealed, err := keys.SealSymmetricallyByServer(keyBytes)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, id := range policies {
+			policyUpdates = append(policyUpdates, updateByID(id, "key", base64.StdEncoding.EncodeToString(sealed)))
+		}
+	}
+
+	req := graphql.NewRequest(`
+	mutation MyMutation($org_id: uuid!, $secrets: [secrets_updates!]!, $change_requests: [change_requests_updates!]!, $members: [org_has_user_updates!]!, $invites: [invites_updates!]!, $policies: [trust_policies_updates!]!) {
+		update_secrets_many(updates: $secrets) {
+		  affected_rows
+		}
+		update_change_requests_many(updates: $change_requests) {
+		  affected_rows
+		}
+		update_org_has_user_many(updates: $members) {
+		  affected_rows
+		}
+		update_invites_many(updates: $invites) {
+		  affected_rows
+		}
+		update_trust_policies_many(updates: $policies) {
+		  affected_rows
+		}
+		delete_tokens(where: {environment: {project: {org_id: {_eq: $org_id}}}}) {
+		  affected_rows
+		}
+	  }
+	`)
+
+	req.Var("org_id", options.ID)
+	req.Var("secrets", emptyIfNil(secretUpdates))
+	req.Var("change_requests", emptyIfNil(changeRequestUpdates))
+	req.Var("members", emptyIfNil(memberUpdates))
+	req.Var("invites", emptyIfNil(inviteUpdates))
+	req.Var("policies", emptyIfNil(policyUpdates))
+
+	var response struct {
+		Tokens struct {
+			AffectedRows int `json:"affected_rows"`
+		} `json:"delete_tokens"`
+	}
+
+	if err := client.Do(ctx, req, &response); err != nil {
+		return nil, err
+	}
+
+	return &RotateKeyResponse{
+		Secrets:        len(secretUpdates),
+		ChangeRequests: len(changeRequestUpdates),
+		Members:        len(memberUpdates),
+		Invites:        len(inviteUpdates),
+		TrustPolicies:  len(policyUpdates),
+		RevokedTokens:  response.Tokens.AffectedRows,
+	}, nil
+}
+
+// Seals the organisation's key with a user's public key,
+// and base64 encodes it to be saved in the database.
+func sealWithPublicKey(orgKey, publicKeyBytes []byte) (string, error) {
+
+	var publicKey [32]byte
+	copy(publicKey[:], publicKeyBytes)
+	result, err := keys.SealAsymmetricallyAnonymous(orgKey, publicKey)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(result), nil
+}
+
+// Prepares an entry for the "updates" argument of Hasura's batch update mutations.
+func updateByID(id, column string, value interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"where": map[string]interface{}{
+			"id": map[string]interface{}{
+				"_eq": id,
+			},
+		},
+		"_set": map[string]interface{}{
+			column: value,
+		},
+	}
+}
+
+func emptyIfNil(updates []map[string]interface{}) []map[string]interface{} {
+	if updates == nil {
+		return []map[string]interface{}{}
+	}
+	return updates
+}
+
 //
 //	--- GraphQL ---
 //
 
+type secretRow struct {
+	ID   string          `json:"id"`
+	Data json.RawMessage `json:"data"`
+}
+
+// Lists every version of every secret in the organisation.
+func listSecrets(ctx context.ServiceContext, client *clients.GQLClient, org_id string) ([]secretRow, error) {
+
+	req := graphql.NewRequest(`
+	query MyQuery($org_id: uuid!) {
+		secrets(where: {environment: {project: {org_id: {_eq: $org_id}}}}) {
+		  id
+		  data
+		}
+	  }
+	`)
+
+	req.Var("org_id", org_id)
+
+	var response struct {
+		Secrets []secretRow `json:"secrets"`
+	}
+
+	if err := client.Do(ctx, req, &response); err != nil {
+		return nil, err
+	}
+
+	return response.Secrets, nil
+}
+
+type changeRequestRow struct {
+	ID   string           `json:"id"`
+	Data keypayload.KPMap `json:"data"`
+}
+
+// Lists the change requests of the organisation's environments which are yet to be reviewed.
+func listPendingChangeRequests(ctx context.ServiceContext, client *clients.GQLClient, org_id string) ([]changeRequestRow, error) {
+
+	req := graphql.NewRequest(`
+	query MyQuery($org_id: uuid!) {
+		change_requests(where: {environment: {project: {org_id: {_eq: $org_id}}}, status: {_eq: "pending"}}) {
+		  id
+		  data
+		}
+	  }
+	`)
+
+	req.Var("org_id", org_id)
+
+	var response struct {
+		ChangeRequests []changeRequestRow `json:"change_requests"`
+	}
+
+	if err := client.Do(ctx, req, &response); err != nil {
+		return nil, err
+	}
+
+	return response.ChangeRequests, nil
+}
+
+type inviteRow struct {
+	ID    string `json:"id"`
+	Email string `json:"email"`
+}
+
+// Lists the invites which carry a copy of the organisation's key, but are yet to be accepted.
+func listSealedInvites(ctx context.ServiceContext, client *clients.GQLClient, org_id string) ([]inviteRow, error) {
+
+	req := graphql.NewRequest(`
+	query MyQuery($org_id: uuid!) {
+		invites(where: {org_id: {_eq: $org_id}, accepted: {_eq: false}, key: {_is_null: false}}) {
+		  id
+		  email
+		}
+	  }
+	`)
+
+	req.Var("org_id", org_id)
+
+	var response struct {
+		Invites []inviteRow `json:"invites"`
+	}
+
+	if err := client.Do(ctx, req, &response); err != nil {
+		return nil, err
+	}
+
+	return response.Invites, nil
+}
+
+// Lists the IDs of the trust policies of the organisation.
+func listTrustPolicies(ctx context.ServiceContext, client *clients.GQLClient, org_id string) ([]string, error) {
+
+	req := graphql.NewRequest(`
+	query MyQuery($org_id: uuid!) {
+		trust_policies(where: {org_id: {_eq: $org_id}}) {
+		  id
+		}
+	  }
+	`)
+
+	req.Var("org_id", org_id)
+
+	var response struct {
+		TrustPolicies []struct {
+			ID string `json:"id"`
+		} `json:"trust_policies"`
+	}
+
+	if err := client.Do(ctx, req, &response); err != nil {
+		return nil, err
+	}
+
+	var result []string
+	for _, item := range response.TrustPolicies {
+		result = append(result, item.ID)
+	}
+
+	return result, nil
+}
+
 // Create a new organisation
 func create(ctx context.ServiceContext, client *clients.GQLClient, name string) (*Organisation, error) {
 
@@ -0,0 +1,43 @@
+package permissions
+
+import (
+	"github.com/envsecrets/envsecrets/cli/auth"
+	"github.com/envsecrets/envsecrets/internal/clients"
+	"github.com/envsecrets/envsecrets/internal/context"
+	"github.com/envsecrets/envsecrets/internal/roles"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/labstack/echo/v4"
+)
+
+// Authorizes the user who made the request, identified by the JWT parsed by our middleware.
+func AuthorizeRequest(c echo.Context, scope Scope, resource roles.Resource, action roles.Action) error {
+
+	token, ok := c.Get("user").(*jwt.Token)
+	if !ok {
+		return echo.ErrUnauthorized
+	}
+
+	claims, ok := token.Claims.(*auth.Claims)
+	if !ok {
+		return echo.ErrUnauthorized
+	}
+
+	//	Initialize a new default context
+	ctx := context.NewContext(&context.Config{Type: context.APIContext, EchoContext: c})
+
+	//	Initialize Hasura client with admin privileges,
+	//	since the user may not be able to read the permissions of their own role.
+	client := clients.NewGQLClient(&clients.GQLConfig{
+		Type: clients.HasuraClientType,
+		Headers: []clients.Header{
+			clients.XHasuraAdminSecretHeader,
+		},
+	})
+
+	return GetService().Authorize(ctx, client, &AuthorizeOptions{
+		Scope:    scope,
+		UserID:   claims.Hasura.UserID,
+		Resource: resource,
+		Action:   action,
+	})
+}
@@ -0,0 +1,53 @@
+package permissions
+
+import (
+	"errors"
+
+	"github.com/envsecrets/envsecrets/internal/roles"
+)
+
+var ErrForbidden = errors.New("you do not have the permission to perform this action")
+
+// Scope of the permission being checked.
+// Only the narrowest known ID is required, the broader ones are resolved from it.
+type Scope struct {
+	OrgID     string `json:"org_id,omitempty"`
+	ProjectID string `json:"project_id,omitempty"`
+	EnvID     string `json:"env_id,omitempty"`
+}
+
+type AuthorizeOptions struct {
+	Scope
+	UserID   string
+	Resource roles.Resource
+	Action   roles.Action
+}
+
+type GetOptions struct {
+	Scope
+	UserID string
+}
+
+type SetOverridesOptions struct {
+	Scope
+	UserID    string          `json:"user_id"`
+	Overrides roles.Overrides `json:"permissions"`
+}
+
+type DeleteOverridesOptions struct {
+	ProjectID string `json:"project_id,omitempty"`
+	EnvID     string `json:"env_id,omitempty"`
+	UserID    string `json:"user_id"`
+}
+
+// Effective permissions of a user in a scope.
+type Permissions struct {
+	roles.Permissions
+
+	//	Owners of the organisation are allowed everything.
+	Owner bool `json:"owner,omitempty"`
+}
+
+func (p *Permissions) Allows(resource roles.Resource, action roles.Action) bool {
+	return p.Owner || p.Permissions.Allows(resource, action)
+}
@@ -0,0 +1,5 @@
+package permissions
+
+func init() {
+	SetService(&DefaultService{})
+}
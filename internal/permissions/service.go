@@ -0,0 +1,305 @@
+package permissions
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/envsecrets/envsecrets/internal/access"
+	"github.com/envsecrets/envsecrets/internal/clients"
+	"github.com/envsecrets/envsecrets/internal/context"
+	"github.com/envsecrets/envsecrets/internal/roles"
+	"github.com/machinebox/graphql"
+)
+
+type Service interface {
+	Get(context.ServiceContext, *clients.GQLClient, *GetOptions) (*Permissions, error)
+	Authorize(context.ServiceContext, *clients.GQLClient, *AuthorizeOptions) error
+	SetOverrides(context.ServiceContext, *clients.GQLClient, *SetOverridesOptions) error
+	DeleteOverrides(context.ServiceContext, *clients.GQLClient, *DeleteOverridesOptions) error
+}
+
+type DefaultService struct{}
+
+// Resolves the effective permissions of a user in the scope.
+// The client must have admin privileges, since the user may not be able to read the overrides.
+//
+// --- Flow ---
+//
+//  1. Resolve the project and organisation of the scope.
+//  2. Owners of the organisation are allowed everything.
+//  3. Load the permissions of the user's role in the organisation.
+//  4. Apply the project level overrides, followed by the environment level ones.
+//  5. Allow reading the environment's secrets if the user has an active access grant.
+func (*DefaultService) Get(ctx context.ServiceContext, client *clients.GQLClient, options *GetOptions) (*Permissions, error) {
+
+	scope := options.Scope
+	if err := resolve(ctx, client, &scope); err != nil {
+		return nil, err
+	}
+
+	req := graphql.NewRequest(`
+	query MyQuery($org_id: uuid!, $user_id: uuid!) {
+		organisations_by_pk(id: $org_id) {
+		  user_id
+		}
+		org_has_user(where: {org_id: {_eq: $org_id}, user_id: {_eq: $user_id}}) {
+		  role {
+			permissions
+		  }
+		}
+	  }
+	`)
+
+	req.Var("org_id", scope.OrgID)
+	req.Var("user_id", options.UserID)
+
+	var response struct {
+		Organisation *struct {
+			UserID string `json:"user_id"`
+		} `json:"organisations_by_pk"`
+		Memberships []struct {
+			Role *struct {
+				Permissions roles.Permissions `json:"permissions"`
+			} `json:"role"`
+		} `json:"org_has_user"`
+	}
+	if err := client.Do(ctx, req, &response); err != nil {
+		return nil, err
+	}
+
+	if response.Organisation == nil {
+		return nil, errors.New("organisation not found")
+	}
+
+	var result Permissions
+	if response.Organisation.UserID == options.UserID {
+		result.Owner = true
+		return &result, nil
+	}
+
+	if len(response.Memberships) == 0 || response.Memberships[0].Role == nil {
+		return nil, ErrForbidden
+	}
+
+	result.Permissions = response.Memberships[0].Role.Permissions
+
+	if scope.ProjectID != "" {
+		overrides, err := getOverrides(ctx, client, "project_level_permissions", "project_id", scope.ProjectID, options.UserID)
+		if err != nil {
+			return nil, err
+		}
+		result.Override(overrides)
+	}
+
+	if scope.EnvID != "" {
+		overrides, err := getOverrides(ctx, client, "env_level_permissions", "env_id", scope.EnvID, options.UserID)
+		if err != nil {
+			return nil, err
+		}
+		result.Override(overrides)
+
+		//	Approved access grants allow reading the secrets until they expire,
+		//	even if the member's role or overrides don't.
+		active, err := access.GetService().IsActive(ctx, client, scope.EnvID, options.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if active {
+			read := true
+			result.Override(&roles.Overrides{SecretsRead: &read})
+		}
+	}
+
+	return &result, nil
+}
+
+// Returns ErrForbidden if the user is not allowed to perform the action on the resource in the scope.
+func (d *DefaultService) Authorize(ctx context.ServiceContext, client *clients.GQLClient, options *AuthorizeOptions) error {
+
+	permissions, err := d.Get(ctx, client, &GetOptions{
+		Scope:  options.Scope,
+		UserID: options.UserID,
+	})
+	if err != nil {
+		return err
+	}
+
+	if !permissions.Allows(options.Resource, options.Action) {
+		return ErrForbidden
+	}
+
+	return nil
+}
+
+// Saves the overrides of a member's role for a project or an environment.
+func (*DefaultService) SetOverrides(ctx context.ServiceContext, client *clients.GQLClient, options *SetOverridesOptions) error {
+
+	var req *graphql.Request
+	switch {
+	case options.EnvID != "":
+		req = graphql.NewRequest(`
+		mutation MyMutation($id: uuid!, $user_id: uuid!, $permissions: jsonb!) {
+			insert_env_level_permissions_one(object: {env_id: $id, user_id: $user_id, permissions: $permissions}, on_conflict: {constraint: env_level_permissions_env_id_user_id_key, update_columns: [permissions]}) {
+			  id
+			}
+		  }
+		`)
+		req.Var("id", options.EnvID)
+	case options.ProjectID != "":
+		req = graphql.NewRequest(`
+		mutation MyMutation($id: uuid!, $user_id: uuid!, $permissions: jsonb!) {
+			insert_project_level_permissions_one(object: {project_id: $id, user_id: $user_id, permissions: $permissions}, on_conflict: {constraint: project_level_permissions_project_id_user_id_key, update_columns: [permissions]}) {
+			  id
+			}
+		  }
+		`)
+		req.Var("id", options.ProjectID)
+	default:
+		return errors.New("either project or environment is required")
+	}
+
+	req.Var("user_id", options.UserID)
+	req.Var("permissions", options.Overrides)
+
+	var response map[string]interface{}
+	return client.Do(ctx, req, &response)
+}
+
+// Removes the overrides of a member's role for a project or an environment.
+func (*DefaultService) DeleteOverrides(ctx context.ServiceContext, client *clients.GQLClient, options *DeleteOverridesOptions) error {
+
+	var req *graphql.Request
+	switch {
+	case options.EnvID != "":
+		req = graphql.NewRequest(`
+		mutation MyMutation($id: uuid!, $user_id: uuid!) {
+			delete_env_level_permissions(where: {env_id: {_eq: $id}, user_id: {_eq: $user_id}}) {
+			  affected_rows
+			}
+		  }
+		`)
+		req.Var("id", options.EnvID)
+	case options.ProjectID != "":
+		req = graphql.NewRequest(`
+		mutation MyMutation($id: uuid!, $user_id: uuid!) {
+			delete_project_level_permissions(where: {project_id: {_eq: $id}, user_id: {_eq: $user_id}}) {
+			  affected_rows
+			}
+		  }
+		`)
+		req.Var("id", options.ProjectID)
+	default:
+		return errors.New("either project or environment is required")
+	}
+
+	req.Var("user_id", options.UserID)
+
+	var response map[string]interface{}
+	return client.Do(ctx, req, &response)
+}
+
+//
+//	--- GraphQL ---
+//
+
+// Fills the project and organisation IDs of the scope.
+func resolve(ctx context.ServiceContext, client *clients.GQLClient, scope *Scope) error {
+
+	if scope.EnvID != "" {
+
+		req := graphql.NewRequest(`
+		query MyQuery($id: uuid!) {
+			environments_by_pk(id: $id) {
+			  project {
+				id
+				org_id
+			  }
+			}
+		  }
+		`)
+
+		req.Var("id", scope.EnvID)
+
+		var response struct {
+			Environment *struct {
+				Project struct {
+					ID    string `json:"id"`
+					OrgID string `json:"org_id"`
+				} `json:"project"`
+			} `json:"environments_by_pk"`
+		}
+		if err := client.Do(ctx, req, &response); err != nil {
+			return err
+		}
+
+		if response.Environment == nil {
+			return errors.New("environment not found")
+		}
+
+		scope.ProjectID = response.Environment.Project.ID
+		scope.OrgID = response.Environment.Project.OrgID
+		return nil
+	}
+
+	if scope.ProjectID != "" {
+
+		req := graphql.NewRequest(`
+		query MyQuery($id: uuid!) {
+			projects_by_pk(id: $id) {
+			  org_id
+			}
+		  }
+		`)
+
+		req.Var("id", scope.ProjectID)
+
+		var response struct {
+			Project *struct {
+				OrgID string `json:"org_id"`
+			} `json:"projects_by_pk"`
+		}
+		if err := client.Do(ctx, req, &response); err != nil {
+			return err
+		}
+
+		if response.Project == nil {
+			return errors.New("project not found")
+		}
+
+		scope.OrgID = response.Project.OrgID
+		return nil
+	}
+
+	if scope.OrgID == "" {
+		return errors.New("scope is required")
+	}
+
+	return nil
+}
+
+func getOverrides(ctx context.ServiceContext, client *clients.GQLClient, table, column, id, userID string) (*roles.Overrides, error) {
+
+	req := graphql.NewRequest(fmt.Sprintf(`
+	query MyQuery($id: uuid!, $user_id: uuid!) {
+		%s(where: {%s: {_eq: $id}, user_id: {_eq: $user_id}}, limit: 1) {
+		  permissions
+		}
+	  }
+	`, table, column))
+
+	req.Var("id", id)
+	req.Var("user_id", userID)
+
+	var response map[string][]struct {
+		Permissions roles.Overrides `json:"permissions"`
+	}
+	if err := client.Do(ctx, req, &response); err != nil {
+		return nil, err
+	}
+
+	if len(response[table]) == 0 {
+		return nil, nil
+	}
+
+	return &response[table][0].Permissions, nil
+}
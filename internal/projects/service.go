@@ -1,6 +1,7 @@
 package projects
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/envsecrets/envsecrets/internal/clients"
@@ -160,5 +161,30 @@ func (*DefaultService) Update(ctx context.ServiceContext, client *clients.GQLCli
 
 // Delete a project by ID
 func (*DefaultService) Delete(ctx context.ServiceContext, client *clients.GQLClient, id string) error {
+
+	req := graphql.NewRequest(`
+	mutation MyMutation($id: uuid!) {
+		delete_projects_by_pk(id: $id) {
+		  id
+		}
+	  }
+	`)
+
+	req.Var("id", id)
+
+	var response struct {
+		Result *struct {
+			ID string `json:"id"`
+		} `json:"delete_projects_by_pk"`
+	}
+
+	if err := client.Do(ctx, req, &response); err != nil {
+		return err
+	}
+
+	if response.Result == nil {
+		return errors.New("project not found")
+	}
+
 	return nil
 }
@@ -17,6 +17,12 @@ type Role struct {
 	Permissions string `json:"permissions,omitempty"`
 }
 
+type RoleUpdateOptions struct {
+	ID          string      `json:"id"`
+	Name        string      `json:"name,omitempty"`
+	Permissions Permissions `json:"permissions"`
+}
+
 type RoleInsertOptions struct {
 	OrgID       string      `json:"org_id,omitempty"`
 	Name        string      `json:"name"`
@@ -36,6 +42,97 @@ type Permissions struct {
 
 	//	Add/Delete Integrations.
 	Integrations CRUD `json:"integrations,omitempty"`
+
+	//	Secret values of the environments.
+	//	Roles created before this was introduced derive it from their project and environment permissions.
+	Secrets *Secrets `json:"secrets,omitempty"`
+}
+
+type Secrets struct {
+	Read  bool `json:"read,omitempty"`
+	Write bool `json:"write,omitempty"`
+	Sync  bool `json:"sync,omitempty"`
+
+	//	Review change requests of protected environments.
+	Approve bool `json:"approve,omitempty"`
+}
+
+// Checks whether the permissions allow the action on the resource.
+func (p *Permissions) Allows(resource Resource, action Action) bool {
+	switch resource {
+	case PermissionsResource:
+		return p.Permissions.allows(action)
+	case ProjectsResource:
+		return p.Projects.allows(action)
+	case EnvironmentsResource:
+
+		//	Environments are readable by everyone who can read their project.
+		if action == ReadAction && p.Projects.Read {
+			return true
+		}
+		return p.Environments.allows(action)
+	case IntegrationsResource:
+		return p.Integrations.allows(action)
+	case SecretsResource:
+		return p.secrets().allows(action)
+	default:
+		return false
+	}
+}
+
+// Returns the secret permissions,
+// deriving them the same way our Hasura row filters do for roles which don't specify them.
+func (p *Permissions) secrets() *Secrets {
+	if p.Secrets != nil {
+		return p.Secrets
+	}
+	return &Secrets{
+		Read:    p.Projects.Read,
+		Write:   p.Environments.Create || p.Environments.Update,
+		Sync:    p.Environments.Update,
+		Approve: p.Environments.Update,
+	}
+}
+
+// Applies the project or environment level overrides on top of the permissions.
+func (p *Permissions) Override(overrides *Overrides) {
+	if overrides == nil {
+		return
+	}
+
+	secrets := *p.secrets()
+	if overrides.SecretsRead != nil {
+		secrets.Read = *overrides.SecretsRead
+	}
+	if overrides.SecretsWrite != nil {
+		secrets.Write = *overrides.SecretsWrite
+	}
+	if overrides.SecretsSync != nil {
+		secrets.Sync = *overrides.SecretsSync
+	}
+	if overrides.SecretsApprove != nil {
+		secrets.Approve = *overrides.SecretsApprove
+	}
+	p.Secrets = &secrets
+
+	if overrides.EnvironmentsCreate != nil {
+		p.Environments.Create = *overrides.EnvironmentsCreate
+	}
+}
+
+func (s *Secrets) allows(action Action) bool {
+	switch action {
+	case ReadAction:
+		return s.Read
+	case WriteAction, CreateAction, UpdateAction, DeleteAction:
+		return s.Write
+	case SyncAction:
+		return s.Sync
+	case ApproveAction:
+		return s.Approve
+	default:
+		return false
+	}
 }
 
 type CRUD struct {
@@ -44,3 +141,60 @@ type CRUD struct {
 	Update bool `json:"update,omitempty"`
 	Delete bool `json:"delete,omitempty"`
 }
+
+func (c *CRUD) allows(action Action) bool {
+	switch action {
+	case CreateAction:
+		return c.Create
+	case ReadAction:
+		return c.Read
+	case UpdateAction:
+		return c.Update
+	case DeleteAction:
+		return c.Delete
+	default:
+		return false
+	}
+}
+
+// Overrides of a member's role for a single project or environment,
+// saved in the project_level_permissions and env_level_permissions tables.
+// Unset fields fall back to the member's role.
+type Overrides struct {
+	SecretsRead  *bool `json:"secrets_read,omitempty"`
+	SecretsWrite *bool `json:"secrets_write,omitempty"`
+	SecretsSync  *bool `json:"secrets_sync,omitempty"`
+
+	//	Only applicable to protected environments.
+	SecretsApprove *bool `json:"secrets_approve,omitempty"`
+
+	//	Only applicable to projects.
+	EnvironmentsCreate *bool `json:"environments_create,omitempty"`
+}
+
+type Resource string
+
+const (
+	PermissionsResource  Resource = "permissions"
+	ProjectsResource     Resource = "projects"
+	EnvironmentsResource Resource = "environments"
+	IntegrationsResource Resource = "integrations"
+	SecretsResource      Resource = "secrets"
+)
+
+type Action string
+
+const (
+	CreateAction Action = "create"
+	ReadAction   Action = "read"
+	UpdateAction Action = "update"
+	DeleteAction Action = "delete"
+
+	//	Only applicable to secrets.
+	WriteAction   Action = "write"
+	SyncAction    Action = "sync"
+	ApproveAction Action = "approve"
+)
+
+// Roles created along with every organisation.
+var DefaultRoles = []string{"viewer", "editor", "admin"}
@@ -2,6 +2,7 @@ package roles
 
 import (
 	"encoding/json"
+	"errors"
 
 	"github.com/envsecrets/envsecrets/internal/clients"
 	"github.com/envsecrets/envsecrets/internal/context"
@@ -76,3 +77,116 @@ func GetRolesByOrgID(ctx context.ServiceContext, client *clients.GQLClient, org_
 
 	return &resp, nil
 }
+
+// Fetches a role by its ID.
+func Get(ctx context.ServiceContext, client *clients.GQLClient, id string) (*Role, error) {
+
+	req := graphql.NewRequest(`
+	query MyQuery($id: uuid!) {
+		roles_by_pk(id: $id) {
+		  id
+		  name
+		  org_id
+		  permissions
+		}
+	  }
+	`)
+
+	req.Var("id", id)
+
+	var response struct {
+		Role *struct {
+			Role
+			Permissions Permissions `json:"permissions"`
+		} `json:"roles_by_pk"`
+	}
+	if err := client.Do(ctx, req, &response); err != nil {
+		return nil, err
+	}
+
+	if response.Role == nil {
+		return nil, errors.New("role not found")
+	}
+
+	permissions, err := json.Marshal(response.Role.Permissions)
+	if err != nil {
+		return nil, err
+	}
+
+	result := response.Role.Role
+	result.Permissions = string(permissions)
+	return &result, nil
+}
+
+// Updates the name and permissions of a role.
+func Update(ctx context.ServiceContext, client *clients.GQLClient, options *RoleUpdateOptions) error {
+
+	req := graphql.NewRequest(`
+	mutation MyMutation($id: uuid!, $set: roles_set_input!) {
+		update_roles_by_pk(pk_columns: {id: $id}, _set: $set) {
+		  id
+		}
+	  }
+	`)
+
+	set := map[string]interface{}{
+		"permissions": options.Permissions,
+	}
+	if options.Name != "" {
+		set["name"] = options.Name
+	}
+
+	req.Var("id", options.ID)
+	req.Var("set", set)
+
+	var response struct {
+		Role *Role `json:"update_roles_by_pk"`
+	}
+	if err := client.Do(ctx, req, &response); err != nil {
+		return err
+	}
+
+	if response.Role == nil {
+		return errors.New("failed to update the role")
+	}
+
+	return nil
+}
+
+// Deletes a role.
+// Roles which are still assigned to members, or to pending invites, can not be deleted.
+func Delete(ctx context.ServiceContext, client *clients.GQLClient, id string) error {
+
+	req := graphql.NewRequest(`
+	mutation MyMutation($id: uuid!) {
+		delete_roles_by_pk(id: $id) {
+		  id
+		}
+	  }
+	`)
+
+	req.Var("id", id)
+
+	var response struct {
+		Role *Role `json:"delete_roles_by_pk"`
+	}
+	if err := client.Do(ctx, req, &response); err != nil {
+		return err
+	}
+
+	if response.Role == nil {
+		return errors.New("failed to delete the role")
+	}
+
+	return nil
+}
+
+// Checks whether the name belongs to one of the roles created along with every organisation.
+func IsDefault(name string) bool {
+	for _, item := range DefaultRoles {
+		if item == name {
+			return true
+		}
+	}
+	return false
+}
@@ -1,6 +1,12 @@
 package commons
 
+import "errors"
+
 const (
 	ENV_ID        = "env_id"
 	TEMP_KEY_NAME = "key"
 )
+
+// Returned when secrets of a protected environment are written directly,
+// instead of through a change request.
+var ErrProtectedEnvironment = errors.New("environment is protected, its secrets can only be changed through approved change requests")
@@ -5,6 +5,9 @@ import (
 
 	"github.com/envsecrets/envsecrets/internal/clients"
 	"github.com/envsecrets/envsecrets/internal/context"
+	"github.com/envsecrets/envsecrets/internal/environments"
+	"github.com/envsecrets/envsecrets/internal/permissions"
+	"github.com/envsecrets/envsecrets/internal/roles"
 	"github.com/envsecrets/envsecrets/internal/secrets/commons"
 	"github.com/envsecrets/envsecrets/internal/tokens"
 	"github.com/labstack/echo/v4"
@@ -21,6 +24,14 @@ func SetHandler(c echo.Context) error {
 		})
 	}
 
+	//	Validate the user's permission on the secrets of this environment.
+	if err := permissions.AuthorizeRequest(c, permissions.Scope{EnvID: payload.EnvID}, roles.SecretsResource, roles.WriteAction); err != nil {
+		return c.JSON(http.StatusForbidden, &clients.APIResponse{
+			Message: "You are not allowed to write the secrets of this environment",
+			Error:   err.Error(),
+		})
+	}
+
 	//	Initialize a new default context
 	ctx := context.NewContext(&context.Config{Type: context.APIContext, EchoContext: c})
 
@@ -30,6 +41,14 @@ func SetHandler(c echo.Context) error {
 		Authorization: c.Request().Header.Get(echo.HeaderAuthorization),
 	})
 
+	//	Protected environments only accept changes through approved change requests.
+	if err := validateUnprotected(ctx, client, payload.EnvID); err != nil {
+		return c.JSON(http.StatusConflict, &clients.APIResponse{
+			Message: "Failed to write the secrets",
+			Error:   err.Error(),
+		})
+	}
+
 	//	Call the service function.
 	secret, err := Set(ctx, client, &commons.SetOptions{
 		EnvID: payload.EnvID,
@@ -59,6 +78,14 @@ func DeleteHandler(c echo.Context) error {
 		})
 	}
 
+	//	Validate the user's permission on the secrets of this environment.
+	if err := permissions.AuthorizeRequest(c, permissions.Scope{EnvID: payload.EnvID}, roles.SecretsResource, roles.WriteAction); err != nil {
+		return c.JSON(http.StatusForbidden, &clients.APIResponse{
+			Message: "You are not allowed to write the secrets of this environment",
+			Error:   err.Error(),
+		})
+	}
+
 	//	Initialize a new default context
 	ctx := context.NewContext(&context.Config{Type: context.APIContext, EchoContext: c})
 
@@ -68,6 +95,14 @@ func DeleteHandler(c echo.Context) error {
 		Authorization: c.Request().Header.Get(echo.HeaderAuthorization),
 	})
 
+	//	Protected environments only accept changes through approved change requests.
+	if err := validateUnprotected(ctx, client, payload.EnvID); err != nil {
+		return c.JSON(http.StatusConflict, &clients.APIResponse{
+			Message: "Failed to write the secrets",
+			Error:   err.Error(),
+		})
+	}
+
 	//	Call the service function.
 	if _, err := Delete(ctx, client, &commons.DeleteSecretOptions{
 		EnvID:   payload.EnvID,
@@ -110,6 +145,15 @@ func GetHandler(c echo.Context) error {
 	//	it is safe to use the admin token.
 	if c.Request().Header.Get(echo.HeaderAuthorization) != "" {
 		client.Authorization = c.Request().Header.Get(echo.HeaderAuthorization)
+
+		//	Validate the user's permission on the secrets of this environment.
+		//	Environment tokens are already scoped to their own environment.
+		if err := permissions.AuthorizeRequest(c, permissions.Scope{EnvID: payload.EnvID}, roles.SecretsResource, roles.ReadAction); err != nil {
+			return c.JSON(http.StatusForbidden, &clients.APIResponse{
+				Message: "You are not allowed to read the secrets of this environment",
+				Error:   err.Error(),
+			})
+		}
 	} else if c.Request().Header.Get(string(clients.TokenHeader)) != "" {
 		client.Headers = append(client.Headers, clients.XHasuraAdminSecretHeader)
 	} else {
@@ -147,3 +191,18 @@ func GetHandler(c echo.Context) error {
 		},
 	})
 }
+
+// Returns an error if the environment is protected.
+func validateUnprotected(ctx context.ServiceContext, client *clients.GQLClient, envID string) error {
+
+	environment, err := environments.GetService().Get(ctx, client, envID)
+	if err != nil {
+		return err
+	}
+
+	if environment.Protected {
+		return commons.ErrProtectedEnvironment
+	}
+
+	return nil
+}
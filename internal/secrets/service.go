@@ -126,8 +126,10 @@ func Sync(ctx context.ServiceContext, client *clients.GQLClient, options *common
 		if err := integrationService.Sync(ctx, client, &integrations.SyncOptions{
 			IntegrationID: event.Integration.ID,
 			EventID:       event.ID,
+			EnvID:         options.EnvID,
 			EntityDetails: event.EntityDetails,
 			Data:          options.Data,
+			Version:       options.Version,
 		}); err != nil {
 			return err
 		}
@@ -0,0 +1,32 @@
+package serviceaccounts
+
+import (
+	"errors"
+	"regexp"
+	"time"
+)
+
+const (
+
+	//	Service accounts are members of their organisation backed by a user account,
+	//	which can never sign in with a password or receive emails.
+	EMAIL_DOMAIN = "service-accounts.invalid"
+
+	//	Prefix of the credentials, to tell them apart from environment tokens.
+	CREDENTIAL_PREFIX = "sa_"
+
+	//	Length in bytes of the random passphrase which protects the private key of a service account.
+	PASSPHRASE_BYTES = 32
+
+	DEFAULT_EXPIRY = 90 * 24 * time.Hour
+	MAX_EXPIRY     = 365 * 24 * time.Hour
+)
+
+var namePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{1,62}$`)
+
+var (
+	ErrInvalidName       = errors.New("name must be 2 to 63 lowercase letters, digits or hyphens")
+	ErrInvalidExpiry     = errors.New("expiry must be between 1 hour and 365 days")
+	ErrInvalidCredential = errors.New("invalid service account credential")
+	ErrNotFound          = errors.New("service account not found")
+)
@@ -0,0 +1,92 @@
+package serviceaccounts
+
+import (
+	"strings"
+	"time"
+
+	"github.com/envsecrets/envsecrets/internal/users"
+)
+
+// Non-human member of an organisation, like a CI pipeline or a Terraform workspace.
+// It has its own key pair and copy of the organisation's key, and is assigned a role like any member.
+// Unlike a member's personal tokens, it isn't tied to the employee who created it.
+type ServiceAccount struct {
+	ID          string     `json:"id,omitempty"`
+	CreatedAt   time.Time  `json:"created_at,omitempty"`
+	UpdatedAt   time.Time  `json:"updated_at,omitempty"`
+	OrgID       string     `json:"org_id,omitempty"`
+	UserID      string     `json:"user_id,omitempty"`
+	Name        string     `json:"name,omitempty"`
+	Description string     `json:"description,omitempty"`
+	CreatedBy   string     `json:"created_by,omitempty"`
+	LastUsedAt  *time.Time `json:"last_used_at,omitempty"`
+
+	User *users.User `json:"user,omitempty"`
+}
+
+type CreateOptions struct {
+	OrgID       string
+	Name        string
+	Description string
+	RoleID      string
+	CreatedBy   string
+
+	//	Decrypted key of the organisation, to seal a copy for the service account.
+	OrgKey []byte
+
+	//	Lifetime of the credentials.
+	Expiry time.Duration
+}
+
+type CreateResponse struct {
+	ServiceAccount *ServiceAccount `json:"service_account"`
+
+	//	Only ever returned once. Rotate the credentials if it's lost.
+	Credential string `json:"credential"`
+}
+
+type RotateOptions struct {
+	ID     string
+	OrgKey []byte
+	Expiry time.Duration
+}
+
+type RotateResponse struct {
+	Credential      string `json:"credential"`
+	RevokedSessions int    `json:"revoked_sessions"`
+}
+
+type DeleteResponse struct {
+	RevokedTokens int `json:"revoked_tokens"`
+}
+
+// Credential of a service account.
+// The personal access token signs it in, and the passphrase unlocks its private key.
+// The passphrase is never sent to the server.
+type Credential struct {
+	PAT        string
+	Passphrase string
+}
+
+func (c *Credential) String() string {
+	return CREDENTIAL_PREFIX + c.PAT + "." + c.Passphrase
+}
+
+// Parses a credential from its string form.
+func ParseCredential(value string) (*Credential, error) {
+
+	value = strings.TrimSpace(value)
+	if !strings.HasPrefix(value, CREDENTIAL_PREFIX) {
+		return nil, ErrInvalidCredential
+	}
+
+	pat, passphrase, found := strings.Cut(strings.TrimPrefix(value, CREDENTIAL_PREFIX), ".")
+	if !found || pat == "" || passphrase == "" {
+		return nil, ErrInvalidCredential
+	}
+
+	return &Credential{
+		PAT:        pat,
+		Passphrase: passphrase,
+	}, nil
+}
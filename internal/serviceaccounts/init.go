@@ -0,0 +1,5 @@
+package serviceaccounts
+
+func init() {
+	SetService(&DefaultService{})
+}
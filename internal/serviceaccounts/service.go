@@ -0,0 +1,510 @@
+package serviceaccounts
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"time"
+
+	"github.com/envsecrets/envsecrets/internal/auth"
+	"github.com/envsecrets/envsecrets/internal/clients"
+	"github.com/envsecrets/envsecrets/internal/context"
+	"github.com/envsecrets/envsecrets/internal/keys"
+	keyCommons "github.com/envsecrets/envsecrets/internal/keys/commons"
+	"github.com/envsecrets/envsecrets/internal/memberships"
+	"github.com/envsecrets/envsecrets/internal/organisations"
+	"github.com/envsecrets/envsecrets/internal/users"
+	"github.com/envsecrets/envsecrets/utils"
+	"github.com/machinebox/graphql"
+)
+
+// All the methods expect a client with admin privileges,
+// since service accounts and their user accounts are only ever written by the server.
+type Service interface {
+	Create(context.ServiceContext, *clients.GQLClient, *CreateOptions) (*CreateResponse, error)
+	Get(context.ServiceContext, *clients.GQLClient, string) (*ServiceAccount, error)
+	GetByUserID(context.ServiceContext, *clients.GQLClient, string) (*ServiceAccount, error)
+	List(context.ServiceContext, *clients.GQLClient, string) ([]ServiceAccount, error)
+	Rotate(context.ServiceContext, *clients.GQLClient, *RotateOptions) (*RotateResponse, error)
+	Delete(context.ServiceContext, *clients.GQLClient, string) (*DeleteResponse, error)
+	MarkUsed(context.ServiceContext, *clients.GQLClient, string) error
+}
+
+type DefaultService struct{}
+
+const fields = `
+	id
+	created_at
+	updated_at
+	org_id
+	user_id
+	name
+	description
+	created_by
+	last_used_at
+	user {
+	  id
+	  email
+	  displayName
+	}
+`
+
+// --- Flow ---
+//
+//  1. Create the user account backing the service account.
+//  2. Generate its key pair, protected by a random passphrase.
+//  3. Add it as a member of the organisation, with its own copy of the organisation's key.
+//  4. Issue its credential, which carries the passphrase.
+//
+// If any step fails, the user account is deleted along with whatever was created for it.
+func (d *DefaultService) Create(ctx context.ServiceContext, client *clients.GQLClient, options *CreateOptions) (*CreateResponse, error) {
+
+	if !namePattern.MatchString(options.Name) {
+		return nil, ErrInvalidName
+	}
+
+	expiry, err := validateExpiry(options.Expiry)
+	if err != nil {
+		return nil, err
+	}
+
+	suffix, err := utils.GenerateRandomBytes(4)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := users.Create(ctx, client, &users.CreateOptions{
+		Email: options.Name + "." + hex.EncodeToString(suffix) + "@" + EMAIL_DOMAIN,
+		Name:  options.Name,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := func() (*CreateResponse, error) {
+
+		passphrase, pair, err := generateKeys(user.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := keys.CreateWithUserID(ctx, client, pair); err != nil {
+			return nil, err
+		}
+
+		key, err := sealOrgKey(options.OrgKey, pair.PublicKey)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := memberships.CreateWithUserID(ctx, client, &memberships.CreateOptions{
+			UserID: user.ID,
+			OrgID:  options.OrgID,
+			RoleID: options.RoleID,
+			Key:    key,
+		}); err != nil {
+			return nil, err
+		}
+
+		account, err := insert(ctx, client, options, user.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		credential, err := issueCredential(ctx, client, account, passphrase, expiry)
+		if err != nil {
+			return nil, err
+		}
+
+		account.User = user
+		return &CreateResponse{
+			ServiceAccount: account,
+			Credential:     credential,
+		}, nil
+	}()
+	if err != nil {
+
+		//	Best effort, since the original error is the one worth returning.
+		deleteUser(ctx, client, user.ID)
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (*DefaultService) Get(ctx context.ServiceContext, client *clients.GQLClient, id string) (*ServiceAccount, error) {
+
+	req := graphql.NewRequest(`
+	query MyQuery($id: uuid!) {
+		service_accounts_by_pk(id: $id) {` + fields + `}
+	  }
+	`)
+
+	req.Var("id", id)
+
+	var response struct {
+		ServiceAccount *ServiceAccount `json:"service_accounts_by_pk"`
+	}
+
+	if err := client.Do(ctx, req, &response); err != nil {
+		return nil, err
+	}
+
+	if response.ServiceAccount == nil {
+		return nil, ErrNotFound
+	}
+
+	return response.ServiceAccount, nil
+}
+
+func (*DefaultService) GetByUserID(ctx context.ServiceContext, client *clients.GQLClient, userID string) (*ServiceAccount, error) {
+
+	req := graphql.NewRequest(`
+	query MyQuery($user_id: uuid!) {
+		service_accounts(where: {user_id: {_eq: $user_id}}) {` + fields + `}
+	  }
+	`)
+
+	req.Var("user_id", userID)
+
+	var response struct {
+		ServiceAccounts []ServiceAccount `json:"service_accounts"`
+	}
+
+	if err := client.Do(ctx, req, &response); err != nil {
+		return nil, err
+	}
+
+	if len(response.ServiceAccounts) == 0 {
+		return nil, ErrNotFound
+	}
+
+	return &response.ServiceAccounts[0], nil
+}
+
+func (*DefaultService) List(ctx context.ServiceContext, client *clients.GQLClient, orgID string) ([]ServiceAccount, error) {
+
+	req := graphql.NewRequest(`
+	query MyQuery($org_id: uuid!) {
+		service_accounts(where: {org_id: {_eq: $org_id}}, order_by: {name: asc}) {` + fields + `}
+	  }
+	`)
+
+	req.Var("org_id", orgID)
+
+	var response struct {
+		ServiceAccounts []ServiceAccount `json:"service_accounts"`
+	}
+
+	if err := client.Do(ctx, req, &response); err != nil {
+		return nil, err
+	}
+
+	return response.ServiceAccounts, nil
+}
+
+// --- Flow ---
+//
+//  1. Generate a new key pair, protected by a new passphrase.
+//  2. Seal the organisation's key with the new public key.
+//  3. Generate a new credential.
+//  4. In a single mutation, save the new key pair, copy of the organisation's key and credential,
+//     and only then revoke every other session and credential of the service account,
+//     so that a failure leaves the old ones in place rather than a service account nobody can use.
+//
+// The environment tokens it created are kept, since they carry their own copy of the organisation's key.
+func (d *DefaultService) Rotate(ctx context.ServiceContext, client *clients.GQLClient, options *RotateOptions) (*RotateResponse, error) {
+
+	expiry, err := validateExpiry(options.Expiry)
+	if err != nil {
+		return nil, err
+	}
+
+	account, err := d.Get(ctx, client, options.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	passphrase, pair, err := generateKeys(account.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := sealOrgKey(options.OrgKey, pair.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	token, pat, err := auth.NewPAT(patOptions(account, expiry))
+	if err != nil {
+		return nil, err
+	}
+
+	//	Hasura runs the fields in order, within a single transaction.
+	req := graphql.NewRequest(`
+	mutation MyMutation($user_id: uuid!, $org_id: uuid!, $keys: keys_set_input!, $key: String!, $pat: authRefreshTokens_insert_input!, $pat_hash: bytea!) {
+		update_keys(where: {user_id: {_eq: $user_id}}, _set: $keys) {
+			affected_rows
+		}
+		update_org_has_user(where: {org_id: {_eq: $org_id}, user_id: {_eq: $user_id}}, _set: {key: $key}) {
+			affected_rows
+		}
+		insertAuthRefreshToken(object: $pat) {
+			id
+		}
+		deleteAuthRefreshTokens(where: {userId: {_eq: $user_id}, refreshTokenHash: {_neq: $pat_hash}}) {
+			affected_rows
+		}
+	  }
+	`)
+
+	req.Var("user_id", account.UserID)
+	req.Var("org_id", account.OrgID)
+	req.Var("keys", map[string]interface{}{
+		"public_key":    pair.PublicKey,
+		"private_key":   pair.PrivateKey,
+		"protected_key": pair.ProtectedKey,
+		"salt":          pair.Salt,
+		"sync_key":      pair.SyncKey,
+	})
+	req.Var("key", key)
+	req.Var("pat", pat)
+	req.Var("pat_hash", pat["refreshTokenHash"])
+
+	var response struct {
+		Tokens struct {
+			AffectedRows int `json:"affected_rows"`
+		} `json:"deleteAuthRefreshTokens"`
+	}
+
+	if err := client.Do(ctx, req, &response); err != nil {
+		return nil, err
+	}
+
+	credential := Credential{
+		PAT:        token,
+		Passphrase: passphrase,
+	}
+
+	return &RotateResponse{
+		Credential:      credential.String(),
+		RevokedSessions: response.Tokens.AffectedRows,
+	}, nil
+}
+
+// --- Flow ---
+//
+//  1. Remove the service account from its organisation, revoking the environment tokens it created.
+//  2. Revoke every session and credential of the service account.
+//  3. Disable its user account, which is kept so that audit logs still refer to it.
+//  4. Delete the service account.
+func (d *DefaultService) Delete(ctx context.ServiceContext, client *clients.GQLClient, id string) (*DeleteResponse, error) {
+
+	account, err := d.Get(ctx, client, id)
+	if err != nil {
+		return nil, err
+	}
+
+	removal, err := organisations.GetService().RemoveMember(ctx, client, &organisations.RemoveMemberOptions{
+		ID:     account.OrgID,
+		UserID: account.UserID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := auth.RevokeRefreshTokens(ctx, client, account.UserID); err != nil {
+		return nil, err
+	}
+
+	if err := users.Disable(ctx, client, account.UserID); err != nil {
+		return nil, err
+	}
+
+	req := graphql.NewRequest(`
+	mutation MyMutation($id: uuid!) {
+		delete_service_accounts_by_pk(id: $id) {
+			id
+		}
+	  }
+	`)
+
+	req.Var("id", id)
+
+	if err := client.Do(ctx, req, nil); err != nil {
+		return nil, err
+	}
+
+	return &DeleteResponse{
+		RevokedTokens: removal.RevokedTokens,
+	}, nil
+}
+
+// Records when the service account last signed in.
+func (*DefaultService) MarkUsed(ctx context.ServiceContext, client *clients.GQLClient, id string) error {
+
+	req := graphql.NewRequest(`
+	mutation MyMutation($id: uuid!, $last_used_at: timestamptz!) {
+		update_service_accounts_by_pk(pk_columns: {id: $id}, _set: {last_used_at: $last_used_at}) {
+			id
+		}
+	  }
+	`)
+
+	req.Var("id", id)
+	req.Var("last_used_at", time.Now().UTC())
+
+	return client.Do(ctx, req, nil)
+}
+
+//	---	Helpers ---
+
+func validateExpiry(expiry time.Duration) (time.Duration, error) {
+
+	if expiry == 0 {
+		return DEFAULT_EXPIRY, nil
+	}
+
+	if expiry < time.Hour || expiry > MAX_EXPIRY {
+		return 0, ErrInvalidExpiry
+	}
+
+	return expiry, nil
+}
+
+// Generates a key pair for the user, protected by a random passphrase,
+// without saving it. Returns the passphrase and the base64 encoded keys.
+func generateKeys(userID string) (string, *keyCommons.CreateWithUserIDOptions, error) {
+
+	passphraseBytes, err := utils.GenerateRandomBytes(PASSPHRASE_BYTES)
+	if err != nil {
+		return "", nil, err
+	}
+
+	passphrase := hex.EncodeToString(passphraseBytes)
+
+	pair, err := keys.GenerateKeyPair(passphrase)
+	if err != nil {
+		return "", nil, err
+	}
+
+	//	Encrypt the sync key using server's symmetric key
+	syncKey, err := keys.SealSymmetricallyByServer(pair.SyncKey[:])
+	if err != nil {
+		return "", nil, err
+	}
+
+	return passphrase, &keyCommons.CreateWithUserIDOptions{
+		UserID:       userID,
+		PublicKey:    base64.StdEncoding.EncodeToString(pair.PublicKey),
+		PrivateKey:   base64.StdEncoding.EncodeToString(pair.PrivateKey),
+		ProtectedKey: base64.StdEncoding.EncodeToString(pair.ProtectedKey),
+		Salt:         base64.StdEncoding.EncodeToString(pair.Salt),
+		SyncKey:      base64.StdEncoding.EncodeToString(syncKey),
+	}, nil
+}
+
+// Seals the organisation's key with the base64 encoded public key of the service account,
+// and base64 encodes it to be saved in its membership.
+func sealOrgKey(orgKey []byte, encodedPublicKey string) (string, error) {
+
+	publicKeyBytes, err := base64.StdEncoding.DecodeString(encodedPublicKey)
+	if err != nil {
+		return "", err
+	}
+
+	var publicKey [32]byte
+	copy(publicKey[:], publicKeyBytes)
+	result, err := keys.SealAsymmetricallyAnonymous(orgKey, publicKey)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(result), nil
+}
+
+func issueCredential(ctx context.ServiceContext, client *clients.GQLClient, account *ServiceAccount, passphrase string, expiry time.Duration) (string, error) {
+
+	pat, err := auth.CreatePAT(ctx, client, patOptions(account, expiry))
+	if err != nil {
+		return "", err
+	}
+
+	credential := Credential{
+		PAT:        pat.Token,
+		Passphrase: passphrase,
+	}
+
+	return credential.String(), nil
+}
+
+func patOptions(account *ServiceAccount, expiry time.Duration) *auth.CreatePATOptions {
+	return &auth.CreatePATOptions{
+		UserID: account.UserID,
+		Expiry: expiry,
+		Metadata: map[string]interface{}{
+			"source":             "service_account",
+			"service_account_id": account.ID,
+		},
+	}
+}
+
+func insert(ctx context.ServiceContext, client *clients.GQLClient, options *CreateOptions, userID string) (*ServiceAccount, error) {
+
+	req := graphql.NewRequest(`
+	mutation MyMutation($object: service_accounts_insert_input!) {
+		insert_service_accounts_one(object: $object) {
+			id
+			created_at
+			updated_at
+			org_id
+			user_id
+			name
+			description
+			created_by
+		}
+	  }
+	`)
+
+	object := map[string]interface{}{
+		"org_id":  options.OrgID,
+		"user_id": userID,
+		"name":    options.Name,
+	}
+
+	if options.Description != "" {
+		object["description"] = options.Description
+	}
+
+	if options.CreatedBy != "" {
+		object["created_by"] = options.CreatedBy
+	}
+
+	req.Var("object", object)
+
+	var response struct {
+		ServiceAccount ServiceAccount `json:"insert_service_accounts_one"`
+	}
+
+	if err := client.Do(ctx, req, &response); err != nil {
+		return nil, err
+	}
+
+	return &response.ServiceAccount, nil
+}
+
+// Deletes a user account which was only partially set up,
+// along with its keys and membership.
+func deleteUser(ctx context.ServiceContext, client *clients.GQLClient, id string) error {
+
+	req := graphql.NewRequest(`
+	mutation MyMutation($id: uuid!) {
+		deleteUser(id: $id) {
+			id
+		}
+	  }
+	`)
+
+	req.Var("id", id)
+
+	return client.Do(ctx, req, nil)
+}
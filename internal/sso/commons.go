@@ -0,0 +1,49 @@
+// Package sso signs in the members of an organisation through its OIDC identity provider,
+// and provisions them, along with their groups, through SCIM.
+//
+// Unlocking keys: the private key of every user is sealed with a key derived
+// from their password, and the server never holds a copy it can open on its own.
+// Users who sign in through their identity provider don't have an envsecrets password,
+// so they choose a separate unlock passphrase on their first sign in instead.
+// Their key pair is generated with it exactly as it is with a password on signup,
+// and the CLI unlocks it locally after every sign in. The passphrase never reaches the identity provider,
+// and losing it has the same consequences as losing a password:
+// the organisation's admins have to grant the member a fresh copy of the organisation's key.
+// Device bound keys, i.e. a private key sealed by the OS keychain instead of a passphrase,
+// fit the same model, since they only change how the private key is unlocked on the device.
+//
+// Claiming emails: a connection only signs in and provisions the emails of the domains
+// its organisation has proven to own, by publishing its verification token in a TXT record.
+// An identity is only bound to an envsecrets account which existed before it
+// once the account's owner confirms the link with their password, or unlock passphrase,
+// on their first sign in through the identity provider.
+package sso
+
+import "errors"
+
+const (
+
+	//	Length of the SCIM bearer tokens, in bytes.
+	SCIM_TOKEN_BYTES = 32
+
+	//	Subdomain of the TXT record which proves the ownership of a domain,
+	//	and the prefix of its value, which is followed by the connection's verification token.
+	DOMAIN_RECORD_PREFIX = "_envsecrets"
+	DOMAIN_RECORD_VALUE  = "envsecrets-verification="
+)
+
+var (
+	ErrNotConfigured        = errors.New("single sign-on is not configured for this organisation")
+	ErrIncompleteConnection = errors.New("issuer, client ID and client secret are required")
+	ErrDisabled             = errors.New("single sign-on is disabled for this organisation")
+	ErrDomainNotAllowed     = errors.New("your email domain is not allowed to sign in to this organisation")
+	ErrUnknownDomain        = errors.New("the domain is not one of the connection's domains")
+	ErrDomainNotVerified    = errors.New("the TXT record with the connection's verification token was not found")
+	ErrDomainClaimed        = errors.New("the domain is already verified by another organisation")
+	ErrLinkRequired         = errors.New("an envsecrets account already exists for your email; confirm it with its password to link it to your identity provider")
+	ErrLinkFailed           = errors.New("the password doesn't unlock the envsecrets account of your email")
+	ErrDeprovisioned        = errors.New("your account has been deprovisioned by your identity provider")
+	ErrNoRole               = errors.New("no role could be resolved for the user")
+	ErrNotFound             = errors.New("resource not found")
+	ErrConflict             = errors.New("resource already exists")
+)
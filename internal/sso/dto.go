@@ -0,0 +1,194 @@
+package sso
+
+import (
+	"strings"
+	"time"
+
+	"github.com/envsecrets/envsecrets/internal/roles"
+	"github.com/envsecrets/envsecrets/internal/users"
+)
+
+// OIDC identity provider of an organisation.
+// The client secret and the SCIM token are never returned.
+type Connection struct {
+	ID        string    `json:"id,omitempty"`
+	CreatedAt time.Time `json:"created_at,omitempty"`
+	UpdatedAt time.Time `json:"updated_at,omitempty"`
+	OrgID     string    `json:"org_id,omitempty"`
+
+	Issuer   string `json:"issuer,omitempty"`
+	ClientID string `json:"client_id,omitempty"`
+
+	//	Email domains claimed by the connection.
+	//	Only the verified ones are allowed to sign in, or to be provisioned.
+	Domains         []string `json:"domains,omitempty"`
+	VerifiedDomains []string `json:"verified_domains,omitempty"`
+
+	//	Published in a TXT record to prove the ownership of a domain.
+	VerificationToken string `json:"verification_token,omitempty"`
+
+	//	Role of provisioned users who aren't in any group mapped to a role.
+	//	Defaults to the organisation's "viewer" role.
+	DefaultRoleID string `json:"default_role_id,omitempty"`
+
+	Enabled bool `json:"enabled"`
+}
+
+// Checks whether the email's domain is verified, and so allowed to sign in through the connection.
+func (c *Connection) Allows(email string) bool {
+
+	index := strings.LastIndex(email, "@")
+	if index < 0 {
+		return false
+	}
+
+	return contains(c.VerifiedDomains, email[index+1:])
+}
+
+// Returns the name and the value of the TXT record which proves the ownership of the domain.
+func (c *Connection) VerificationRecord(domain string) (string, string) {
+	return DOMAIN_RECORD_PREFIX + "." + strings.ToLower(domain), DOMAIN_RECORD_VALUE + c.VerificationToken
+}
+
+func contains(domains []string, domain string) bool {
+	for _, item := range domains {
+		if strings.EqualFold(item, domain) {
+			return true
+		}
+	}
+	return false
+}
+
+type SaveConnectionOptions struct {
+	OrgID         string   `json:"-"`
+	Issuer        string   `json:"issuer,omitempty"`
+	ClientID      string   `json:"client_id,omitempty"`
+	ClientSecret  string   `json:"client_secret,omitempty"`
+	Domains       []string `json:"domains,omitempty"`
+	DefaultRoleID string   `json:"default_role_id,omitempty"`
+	Enabled       *bool    `json:"enabled,omitempty"`
+}
+
+type SigninOptions struct {
+	OrgID string
+
+	//	Authorization code and PKCE verifier from the client's authorization code flow.
+	Code         string
+	CodeVerifier string
+	RedirectURI  string
+	Nonce        string
+
+	//	Password, or unlock passphrase, of the user's existing account,
+	//	to link it to the identity on the first sign in.
+	Password string
+}
+
+// User of an organisation managed by its identity provider.
+type Identity struct {
+	ID        string    `json:"id,omitempty"`
+	CreatedAt time.Time `json:"created_at,omitempty"`
+	UpdatedAt time.Time `json:"updated_at,omitempty"`
+	OrgID     string    `json:"org_id,omitempty"`
+	UserID    string    `json:"user_id,omitempty"`
+	Email     string    `json:"email,omitempty"`
+
+	//	"sub" claim of the user's ID tokens, saved on their first sign in.
+	Subject string `json:"subject,omitempty"`
+
+	//	ID of the user in the identity provider, sent over SCIM.
+	ExternalID string `json:"external_id,omitempty"`
+
+	Active bool `json:"active"`
+
+	//	Whether the identity is bound to its account: either the account was created for it,
+	//	or its owner confirmed the link with their password.
+	Linked bool `json:"linked"`
+
+	User   *users.User       `json:"user,omitempty"`
+	Groups []GroupMembership `json:"groups,omitempty"`
+}
+
+type ProvisionOptions struct {
+	OrgID      string
+	Email      string
+	Name       string
+	ExternalID string
+	Active     bool
+}
+
+type UpdateIdentityOptions struct {
+	ExternalID *string
+	Active     *bool
+}
+
+type ListIdentitiesOptions struct {
+	OrgID      string
+	Email      string
+	ExternalID string
+}
+
+// Group provisioned by an identity provider.
+// Members of a group mapped to a role are assigned that role in the organisation.
+// When a user is in several mapped groups, the group with the highest priority wins.
+type Group struct {
+	ID          string    `json:"id,omitempty"`
+	CreatedAt   time.Time `json:"created_at,omitempty"`
+	UpdatedAt   time.Time `json:"updated_at,omitempty"`
+	OrgID       string    `json:"org_id,omitempty"`
+	DisplayName string    `json:"display_name,omitempty"`
+	ExternalID  string    `json:"external_id,omitempty"`
+	RoleID      string    `json:"role_id,omitempty"`
+	Priority    int       `json:"priority"`
+
+	Role    *roles.Role       `json:"role,omitempty"`
+	Members []GroupMembership `json:"members,omitempty"`
+}
+
+// Returns the IDs of the group's members.
+func (g *Group) MemberIDs() []string {
+	var result []string
+	for _, item := range g.Members {
+		result = append(result, item.IdentityID)
+	}
+	return result
+}
+
+type GroupMembership struct {
+	GroupID    string    `json:"group_id,omitempty"`
+	IdentityID string    `json:"identity_id,omitempty"`
+	Group      *Group    `json:"group,omitempty"`
+	Identity   *Identity `json:"identity,omitempty"`
+}
+
+type CreateGroupOptions struct {
+	OrgID       string
+	DisplayName string
+	ExternalID  string
+
+	//	IDs of the identities in the group.
+	Members []string
+}
+
+type UpdateGroupOptions struct {
+	DisplayName *string
+	ExternalID  *string
+
+	//	Replaces all the members of the group, if set.
+	Members *[]string
+
+	Add    []string
+	Remove []string
+}
+
+type MapGroupOptions struct {
+
+	//	Leave empty to stop assigning a role through the group.
+	RoleID   string `json:"role_id,omitempty"`
+	Priority int    `json:"priority,omitempty"`
+}
+
+type ListGroupsOptions struct {
+	OrgID       string
+	DisplayName string
+	ExternalID  string
+}
@@ -0,0 +1,5 @@
+package sso
+
+func init() {
+	SetService(&DefaultService{})
+}
@@ -0,0 +1,1445 @@
+package sso
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"net"
+	"strings"
+
+	"github.com/envsecrets/envsecrets/internal/clients"
+	"github.com/envsecrets/envsecrets/internal/context"
+	"github.com/envsecrets/envsecrets/internal/keys"
+	"github.com/envsecrets/envsecrets/internal/oidc"
+	"github.com/envsecrets/envsecrets/internal/organisations"
+	"github.com/envsecrets/envsecrets/internal/users"
+	"github.com/envsecrets/envsecrets/utils"
+	"github.com/machinebox/graphql"
+)
+
+// All the methods expect a client with admin privileges,
+// since identities and groups are only ever written by the server.
+type Service interface {
+	GetConnection(context.ServiceContext, *clients.GQLClient, string) (*Connection, error)
+	FindConnection(context.ServiceContext, *clients.GQLClient, string) (*Connection, error)
+	GetConnectionBySCIMToken(context.ServiceContext, *clients.GQLClient, string) (*Connection, error)
+	SaveConnection(context.ServiceContext, *clients.GQLClient, *SaveConnectionOptions) (*Connection, error)
+	RotateSCIMToken(context.ServiceContext, *clients.GQLClient, string) (string, error)
+	VerifyDomain(context.ServiceContext, *clients.GQLClient, string, string) (*Connection, error)
+
+	Signin(context.ServiceContext, *clients.GQLClient, *SigninOptions) (*Identity, error)
+
+	GetIdentity(context.ServiceContext, *clients.GQLClient, string, string) (*Identity, error)
+	ListIdentities(context.ServiceContext, *clients.GQLClient, *ListIdentitiesOptions) ([]Identity, error)
+	Provision(context.ServiceContext, *clients.GQLClient, *ProvisionOptions) (*Identity, error)
+	UpdateIdentity(context.ServiceContext, *clients.GQLClient, string, string, *UpdateIdentityOptions) (*Identity, error)
+	Deprovision(context.ServiceContext, *clients.GQLClient, string, string) (*organisations.RemoveMemberResponse, error)
+	DeleteIdentity(context.ServiceContext, *clients.GQLClient, string, string) (*organisations.RemoveMemberResponse, error)
+
+	GetGroup(context.ServiceContext, *clients.GQLClient, string, string) (*Group, error)
+	ListGroups(context.ServiceContext, *clients.GQLClient, *ListGroupsOptions) ([]Group, error)
+	CreateGroup(context.ServiceContext, *clients.GQLClient, *CreateGroupOptions) (*Group, error)
+	UpdateGroup(context.ServiceContext, *clients.GQLClient, string, string, *UpdateGroupOptions) (*Group, error)
+	DeleteGroup(context.ServiceContext, *clients.GQLClient, string, string) error
+	MapGroup(context.ServiceContext, *clients.GQLClient, string, string, *MapGroupOptions) (*Group, error)
+}
+
+type DefaultService struct{}
+
+const connectionFields = `
+	id
+	created_at
+	updated_at
+	org_id
+	issuer
+	client_id
+	domains
+	verified_domains
+	verification_token
+	default_role_id
+	enabled
+`
+
+const identityFields = `
+	id
+	created_at
+	updated_at
+	org_id
+	user_id
+	email
+	subject
+	external_id
+	active
+	linked
+	user {
+	  id
+	  email
+	  displayName
+	}
+	groups {
+	  group_id
+	  group {
+		id
+		display_name
+		external_id
+		role_id
+		priority
+	  }
+	}
+`
+
+const groupFields = `
+	id
+	created_at
+	updated_at
+	org_id
+	display_name
+	external_id
+	role_id
+	priority
+	role {
+	  id
+	  name
+	}
+	members(order_by: {created_at: asc}) {
+	  identity_id
+	  identity {
+		id
+		email
+		external_id
+		active
+	  }
+	}
+`
+
+func (*DefaultService) GetConnection(ctx context.ServiceContext, client *clients.GQLClient, org_id string) (*Connection, error) {
+	return getConnection(ctx, client, map[string]interface{}{
+		"org_id": map[string]interface{}{
+			"_eq": org_id,
+		},
+	})
+}
+
+// Finds the enabled connection which has verified the domain of the email.
+func (*DefaultService) FindConnection(ctx context.ServiceContext, client *clients.GQLClient, email string) (*Connection, error) {
+
+	index := strings.LastIndex(email, "@")
+	if index < 0 {
+		return nil, ErrNotConfigured
+	}
+
+	return getConnection(ctx, client, map[string]interface{}{
+		"enabled": map[string]interface{}{
+			"_eq": true,
+		},
+		"verified_domains": map[string]interface{}{
+			"_contains": strings.ToLower(email[index+1:]),
+		},
+	})
+}
+
+// Fetches the connection the SCIM token was issued for.
+// Only the hash of the token is stored.
+func (*DefaultService) GetConnectionBySCIMToken(ctx context.ServiceContext, client *clients.GQLClient, token string) (*Connection, error) {
+
+	payload, err := hex.DecodeString(token)
+	if err != nil {
+		return nil, err
+	}
+
+	return getConnection(ctx, client, map[string]interface{}{
+		"scim_token_hash": map[string]interface{}{
+			"_eq": utils.SHA256Hash(payload),
+		},
+	})
+}
+
+// Creates or updates the organisation's connection.
+// The issuer is validated by fetching its discovery document,
+// and the client secret is encrypted with the server's key before it is saved.
+func (d *DefaultService) SaveConnection(ctx context.ServiceContext, client *clients.GQLClient, options *SaveConnectionOptions) (*Connection, error) {
+
+	existing, err := d.GetConnection(ctx, client, options.OrgID)
+	if err != nil && err != ErrNotConfigured {
+		return nil, err
+	}
+
+	set := make(map[string]interface{})
+
+	if options.Issuer != "" {
+		provider, err := oidc.Discover(ctx, options.Issuer)
+		if err != nil {
+			return nil, err
+		}
+		set["issuer"] = provider.Issuer
+	}
+
+	if options.ClientID != "" {
+		set["client_id"] = options.ClientID
+	}
+
+	if options.ClientSecret != "" {
+		sealed, err := keys.SealSymmetricallyByServer([]byte(options.ClientSecret))
+		if err != nil {
+			return nil, err
+		}
+		set["client_secret"] = base64.StdEncoding.EncodeToString(sealed)
+	}
+
+	if options.Domains != nil {
+		domains := []string{}
+		for _, item := range options.Domains {
+			if item = strings.ToLower(strings.TrimSpace(item)); item != "" {
+				domains = append(domains, item)
+			}
+		}
+		set["domains"] = domains
+
+		//	Removed domains have to be verified again if they are added back.
+		verified := []string{}
+		if existing != nil {
+			for _, item := range existing.VerifiedDomains {
+				if contains(domains, item) {
+					verified = append(verified, item)
+				}
+			}
+		}
+		set["verified_domains"] = verified
+	}
+
+	if options.DefaultRoleID != "" {
+		set["default_role_id"] = options.DefaultRoleID
+	}
+
+	if options.Enabled != nil {
+		set["enabled"] = *options.Enabled
+	}
+
+	var req *graphql.Request
+	var field string
+
+	if existing == nil {
+		for _, key := range []string{"issuer", "client_id", "client_secret"} {
+			if _, ok := set[key]; !ok {
+				return nil, ErrIncompleteConnection
+			}
+		}
+
+		set["org_id"] = options.OrgID
+		field = "insert_sso_connections_one"
+		req = graphql.NewRequest(`
+		mutation MyMutation($object: sso_connections_insert_input!) {
+			insert_sso_connections_one(object: $object) {` + connectionFields + `}
+		  }
+		`)
+		req.Var("object", set)
+
+	} else {
+		field = "update_sso_connections_by_pk"
+		req = graphql.NewRequest(`
+		mutation MyMutation($id: uuid!, $set: sso_connections_set_input!) {
+			update_sso_connections_by_pk(pk_columns: {id: $id}, _set: $set) {` + connectionFields + `}
+		  }
+		`)
+		req.Var("id", existing.ID)
+		req.Var("set", set)
+	}
+
+	var response map[string]*Connection
+	if err := client.Do(ctx, req, &response); err != nil {
+		return nil, err
+	}
+
+	if response[field] == nil {
+		return nil, ErrNotConfigured
+	}
+
+	return response[field], nil
+}
+
+// Issues a new SCIM token for the organisation, replacing the previous one.
+// The token is only returned once.
+func (*DefaultService) RotateSCIMToken(ctx context.ServiceContext, client *clients.GQLClient, org_id string) (string, error) {
+
+	payload, err := utils.GenerateRandomBytes(SCIM_TOKEN_BYTES)
+	if err != nil {
+		return "", err
+	}
+
+	req := graphql.NewRequest(`
+	mutation MyMutation($org_id: uuid!, $hash: String!) {
+		update_sso_connections(where: {org_id: {_eq: $org_id}}, _set: {scim_token_hash: $hash}) {
+		  affected_rows
+		}
+	  }
+	`)
+
+	req.Var("org_id", org_id)
+	req.Var("hash", utils.SHA256Hash(payload))
+
+	var response struct {
+		Result struct {
+			AffectedRows int `json:"affected_rows"`
+		} `json:"update_sso_connections"`
+	}
+
+	if err := client.Do(ctx, req, &response); err != nil {
+		return "", err
+	}
+
+	if response.Result.AffectedRows == 0 {
+		return "", ErrNotConfigured
+	}
+
+	return hex.EncodeToString(payload), nil
+}
+
+// --- Flow ---
+//
+//  1. Validate that the domain is one of the connection's, and not yet verified by another organisation.
+//  2. Look up the TXT record of the domain for the connection's verification token.
+//  3. Mark the domain verified.
+func (d *DefaultService) VerifyDomain(ctx context.ServiceContext, client *clients.GQLClient, org_id, domain string) (*Connection, error) {
+
+	connection, err := d.GetConnection(ctx, client, org_id)
+	if err != nil {
+		return nil, err
+	}
+
+	domain = strings.ToLower(strings.TrimSpace(domain))
+	if !contains(connection.Domains, domain) {
+		return nil, ErrUnknownDomain
+	}
+
+	if contains(connection.VerifiedDomains, domain) {
+		return connection, nil
+	}
+
+	//	Sign ins are routed by domain, so a domain can only belong to one organisation.
+	claimed, err := getConnection(ctx, client, map[string]interface{}{
+		"org_id": map[string]interface{}{
+			"_neq": org_id,
+		},
+		"verified_domains": map[string]interface{}{
+			"_contains": domain,
+		},
+	})
+	if err != nil && err != ErrNotConfigured {
+		return nil, err
+	}
+
+	if claimed != nil {
+		return nil, ErrDomainClaimed
+	}
+
+	name, value := connection.VerificationRecord(domain)
+	records, err := net.LookupTXT(name)
+	if err != nil {
+		return nil, ErrDomainNotVerified
+	}
+
+	var found bool
+	for _, item := range records {
+		if strings.TrimSpace(item) == value {
+			found = true
+			break
+		}
+	}
+
+	if !found {
+		return nil, ErrDomainNotVerified
+	}
+
+	req := graphql.NewRequest(`
+	mutation MyMutation($id: uuid!, $verified_domains: jsonb!) {
+		update_sso_connections_by_pk(pk_columns: {id: $id}, _set: {verified_domains: $verified_domains}) {` + connectionFields + `}
+	  }
+	`)
+
+	req.Var("id", connection.ID)
+	req.Var("verified_domains", append(connection.VerifiedDomains, domain))
+
+	var response struct {
+		Connection *Connection `json:"update_sso_connections_by_pk"`
+	}
+
+	if err := client.Do(ctx, req, &response); err != nil {
+		return nil, err
+	}
+
+	if response.Connection == nil {
+		return nil, ErrNotConfigured
+	}
+
+	return response.Connection, nil
+}
+
+// --- Flow ---
+//
+//  1. Exchange the authorization code with the organisation's identity provider.
+//  2. Validate the ID token, and the user's email against the connection's verified domains.
+//  3. Find the user's identity by their subject, or by the email they were provisioned with.
+//     Users who were never provisioned over SCIM are provisioned just in time.
+//  4. If the identity was provisioned for an account which already existed,
+//     link them only once the user confirms the account's password.
+//  5. Save the subject on the first sign in, so that later email changes
+//     in the identity provider don't lock the user out.
+func (d *DefaultService) Signin(ctx context.ServiceContext, client *clients.GQLClient, options *SigninOptions) (*Identity, error) {
+
+	connection, err := d.GetConnection(ctx, client, options.OrgID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !connection.Enabled {
+		return nil, ErrDisabled
+	}
+
+	secret, err := clientSecret(ctx, client, connection.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	provider, err := oidc.Discover(ctx, connection.Issuer)
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := provider.Exchange(ctx, &oidc.ExchangeOptions{
+		ClientID:     connection.ClientID,
+		ClientSecret: secret,
+		Code:         options.Code,
+		CodeVerifier: options.CodeVerifier,
+		RedirectURI:  options.RedirectURI,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	claims, err := provider.Verify(ctx, raw, &oidc.VerifyOptions{
+		Audience: connection.ClientID,
+		Nonce:    options.Nonce,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	email := claims.Email()
+	if email == "" || !claims.Bool("email_verified") {
+		return nil, oidc.ErrEmailNotVerified
+	}
+
+	if !connection.Allows(email) {
+		return nil, ErrDomainNotAllowed
+	}
+
+	identity, err := findIdentity(ctx, client, connection.OrgID, claims.Subject(), email)
+	if err == ErrNotFound {
+		name := claims.String("name")
+		if name == "" {
+			name = email
+		}
+
+		identity, err = d.Provision(ctx, client, &ProvisionOptions{
+			OrgID:  connection.OrgID,
+			Email:  email,
+			Name:   name,
+			Active: true,
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if !identity.Active {
+		return nil, ErrDeprovisioned
+	}
+
+	set := make(map[string]interface{})
+
+	//	Never sign in to an account the organisation didn't create,
+	//	unless its owner proves they are the user of the identity provider.
+	if !identity.Linked {
+		if options.Password == "" {
+			return nil, ErrLinkRequired
+		}
+
+		if err := verifyPassword(ctx, client, identity.UserID, options.Password); err != nil {
+			return nil, ErrLinkFailed
+		}
+
+		set["linked"] = true
+	}
+
+	if identity.Subject == "" {
+		set["subject"] = claims.Subject()
+		identity.Subject = claims.Subject()
+	}
+
+	if len(set) > 0 {
+		if err := updateIdentity(ctx, client, identity.ID, set); err != nil {
+			return nil, err
+		}
+	}
+
+	//	Memberships of linked accounts are only queued once they are linked.
+	if !identity.Linked {
+		identity.Linked = true
+		if err := queueMembership(ctx, client, identity); err != nil {
+			return nil, err
+		}
+	}
+
+	return identity, nil
+}
+
+func (*DefaultService) GetIdentity(ctx context.ServiceContext, client *clients.GQLClient, org_id, id string) (*Identity, error) {
+
+	req := graphql.NewRequest(`
+	query MyQuery($id: uuid!, $org_id: uuid!) {
+		sso_identities(where: {id: {_eq: $id}, org_id: {_eq: $org_id}}) {` + identityFields + `}
+	  }
+	`)
+
+	req.Var("id", id)
+	req.Var("org_id", org_id)
+
+	var response struct {
+		Identities []Identity `json:"sso_identities"`
+	}
+
+	if err := client.Do(ctx, req, &response); err != nil {
+		return nil, err
+	}
+
+	if len(response.Identities) == 0 {
+		return nil, ErrNotFound
+	}
+
+	return &response.Identities[0], nil
+}
+
+func (*DefaultService) ListIdentities(ctx context.ServiceContext, client *clients.GQLClient, options *ListIdentitiesOptions) ([]Identity, error) {
+
+	where := map[string]interface{}{
+		"org_id": map[string]interface{}{
+			"_eq": options.OrgID,
+		},
+	}
+
+	if options.Email != "" {
+		where["email"] = map[string]interface{}{
+			"_eq": strings.ToLower(options.Email),
+		}
+	}
+
+	if options.ExternalID != "" {
+		where["external_id"] = map[string]interface{}{
+			"_eq": options.ExternalID,
+		}
+	}
+
+	req := graphql.NewRequest(`
+	query MyQuery($where: sso_identities_bool_exp!) {
+		sso_identities(where: $where, order_by: {created_at: asc}) {` + identityFields + `}
+	  }
+	`)
+
+	req.Var("where", where)
+
+	var response struct {
+		Identities []Identity `json:"sso_identities"`
+	}
+
+	if err := client.Do(ctx, req, &response); err != nil {
+		return nil, err
+	}
+
+	return response.Identities, nil
+}
+
+// --- Flow ---
+//
+//  1. Validate that the email's domain is verified by the organisation's connection.
+//  2. Create an account for the user, unless they already have one.
+//  3. Save their identity in the organisation. Identities of existing accounts
+//     stay unlinked until the user confirms the link on their first sign in.
+//  4. If the user is active and linked, queue their membership in the organisation.
+func (d *DefaultService) Provision(ctx context.ServiceContext, client *clients.GQLClient, options *ProvisionOptions) (*Identity, error) {
+
+	email := strings.ToLower(options.Email)
+
+	connection, err := d.GetConnection(ctx, client, options.OrgID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !connection.Allows(email) {
+		return nil, ErrDomainNotAllowed
+	}
+
+	existing, err := d.ListIdentities(ctx, client, &ListIdentitiesOptions{
+		OrgID: options.OrgID,
+		Email: email,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(existing) > 0 {
+		return nil, ErrConflict
+	}
+
+	linked := false
+	user, err := users.GetByEmail(ctx, client, email)
+	if err != nil {
+		linked = true
+		name := options.Name
+		if name == "" {
+			name = email
+		}
+
+		user, err = users.Create(ctx, client, &users.CreateOptions{
+			Email: email,
+			Name:  name,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	object := map[string]interface{}{
+		"org_id":  options.OrgID,
+		"user_id": user.ID,
+		"email":   email,
+		"active":  options.Active,
+		"linked":  linked,
+	}
+
+	if options.ExternalID != "" {
+		object["external_id"] = options.ExternalID
+	}
+
+	req := graphql.NewRequest(`
+	mutation MyMutation($object: sso_identities_insert_input!) {
+		insert_sso_identities_one(object: $object) {
+		  id
+		}
+	  }
+	`)
+
+	req.Var("object", object)
+
+	var response struct {
+		Identity struct {
+			ID string `json:"id"`
+		} `json:"insert_sso_identities_one"`
+	}
+
+	if err := client.Do(ctx, req, &response); err != nil {
+		return nil, err
+	}
+
+	identity, err := d.GetIdentity(ctx, client, options.OrgID, response.Identity.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if identity.Active && identity.Linked {
+		if err := queueMembership(ctx, client, identity); err != nil {
+			return nil, err
+		}
+	}
+
+	return identity, nil
+}
+
+// Updates the identity, and deprovisions or reactivates it if its status changes.
+func (d *DefaultService) UpdateIdentity(ctx context.ServiceContext, client *clients.GQLClient, org_id, id string, options *UpdateIdentityOptions) (*Identity, error) {
+
+	identity, err := d.GetIdentity(ctx, client, org_id, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if options.ExternalID != nil && *options.ExternalID != identity.ExternalID {
+		if err := updateIdentity(ctx, client, id, map[string]interface{}{
+			"external_id": *options.ExternalID,
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	if options.Active != nil && *options.Active != identity.Active {
+		if *options.Active {
+			if err := updateIdentity(ctx, client, id, map[string]interface{}{
+				"active": true,
+			}); err != nil {
+				return nil, err
+			}
+
+			identity.Active = true
+			if identity.Linked {
+				if err := queueMembership(ctx, client, identity); err != nil {
+					return nil, err
+				}
+			}
+		} else if _, err := d.Deprovision(ctx, client, org_id, id); err != nil {
+			return nil, err
+		}
+	}
+
+	return d.GetIdentity(ctx, client, org_id, id)
+}
+
+// --- Flow ---
+//
+//  1. Mark the identity inactive, so that the user can no longer sign in to the organisation.
+//  2. Delete the invites still waiting for the organisation's key.
+//  3. Remove the user's membership, which also revokes the environment tokens they created.
+func (d *DefaultService) Deprovision(ctx context.ServiceContext, client *clients.GQLClient, org_id, id string) (*organisations.RemoveMemberResponse, error) {
+
+	identity, err := d.GetIdentity(ctx, client, org_id, id)
+	if err != nil {
+		return nil, err
+	}
+
+	req := graphql.NewRequest(`
+	mutation MyMutation($id: uuid!, $org_id: uuid!, $email: String!, $user_id: uuid!) {
+		update_sso_identities_by_pk(pk_columns: {id: $id}, _set: {active: false}) {
+		  id
+		}
+		delete_invites(where: {org_id: {_eq: $org_id}, email: {_eq: $email}, _or: [{key: {_is_null: true}}, {accepted: {_eq: false}}]}) {
+		  affected_rows
+		}
+		org_has_user_aggregate(where: {org_id: {_eq: $org_id}, user_id: {_eq: $user_id}}) {
+		  aggregate {
+			count
+		  }
+		}
+	  }
+	`)
+
+	req.Var("id", identity.ID)
+	req.Var("org_id", org_id)
+	req.Var("email", identity.Email)
+	req.Var("user_id", identity.UserID)
+
+	var response struct {
+		Memberships struct {
+			Aggregate struct {
+				Count int `json:"count"`
+			} `json:"aggregate"`
+		} `json:"org_has_user_aggregate"`
+	}
+
+	if err := client.Do(ctx, req, &response); err != nil {
+		return nil, err
+	}
+
+	if response.Memberships.Aggregate.Count == 0 {
+		return &organisations.RemoveMemberResponse{}, nil
+	}
+
+	return organisations.GetService().RemoveMember(ctx, client, &organisations.RemoveMemberOptions{
+		ID:     org_id,
+		UserID: identity.UserID,
+	})
+}
+
+// Deprovisions the identity and deletes it, along with its group memberships.
+// The user's account is kept, since it may belong to other organisations.
+func (d *DefaultService) DeleteIdentity(ctx context.ServiceContext, client *clients.GQLClient, org_id, id string) (*organisations.RemoveMemberResponse, error) {
+
+	result, err := d.Deprovision(ctx, client, org_id, id)
+	if err != nil {
+		return nil, err
+	}
+
+	req := graphql.NewRequest(`
+	mutation MyMutation($id: uuid!) {
+		delete_sso_identities_by_pk(id: $id) {
+		  id
+		}
+	  }
+	`)
+
+	req.Var("id", id)
+
+	if err := client.Do(ctx, req, nil); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (*DefaultService) GetGroup(ctx context.ServiceContext, client *clients.GQLClient, org_id, id string) (*Group, error) {
+
+	req := graphql.NewRequest(`
+	query MyQuery($id: uuid!, $org_id: uuid!) {
+		sso_groups(where: {id: {_eq: $id}, org_id: {_eq: $org_id}}) {` + groupFields + `}
+	  }
+	`)
+
+	req.Var("id", id)
+	req.Var("org_id", org_id)
+
+	var response struct {
+		Groups []Group `json:"sso_groups"`
+	}
+
+	if err := client.Do(ctx, req, &response); err != nil {
+		return nil, err
+	}
+
+	if len(response.Groups) == 0 {
+		return nil, ErrNotFound
+	}
+
+	return &response.Groups[0], nil
+}
+
+func (*DefaultService) ListGroups(ctx context.ServiceContext, client *clients.GQLClient, options *ListGroupsOptions) ([]Group, error) {
+
+	where := map[string]interface{}{
+		"org_id": map[string]interface{}{
+			"_eq": options.OrgID,
+		},
+	}
+
+	if options.DisplayName != "" {
+		where["display_name"] = map[string]interface{}{
+			"_eq": options.DisplayName,
+		}
+	}
+
+	if options.ExternalID != "" {
+		where["external_id"] = map[string]interface{}{
+			"_eq": options.ExternalID,
+		}
+	}
+
+	req := graphql.NewRequest(`
+	query MyQuery($where: sso_groups_bool_exp!) {
+		sso_groups(where: $where, order_by: {display_name: asc}) {` + groupFields + `}
+	  }
+	`)
+
+	req.Var("where", where)
+
+	var response struct {
+		Groups []Group `json:"sso_groups"`
+	}
+
+	if err := client.Do(ctx, req, &response); err != nil {
+		return nil, err
+	}
+
+	return response.Groups, nil
+}
+
+// Creates the group with its members.
+// New groups aren't mapped to any role yet, so the roles of their members don't change.
+func (d *DefaultService) CreateGroup(ctx context.ServiceContext, client *clients.GQLClient, options *CreateGroupOptions) (*Group, error) {
+
+	existing, err := d.ListGroups(ctx, client, &ListGroupsOptions{
+		OrgID:       options.OrgID,
+		DisplayName: options.DisplayName,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(existing) > 0 {
+		return nil, ErrConflict
+	}
+
+	if err := validateIdentities(ctx, client, options.OrgID, options.Members); err != nil {
+		return nil, err
+	}
+
+	members := []map[string]interface{}{}
+	for _, item := range options.Members {
+		members = append(members, map[string]interface{}{
+			"identity_id": item,
+		})
+	}
+
+	object := map[string]interface{}{
+		"org_id":       options.OrgID,
+		"display_name": options.DisplayName,
+		"members": map[string]interface{}{
+			"data": members,
+		},
+	}
+
+	if options.ExternalID != "" {
+		object["external_id"] = options.ExternalID
+	}
+
+	req := graphql.NewRequest(`
+	mutation MyMutation($object: sso_groups_insert_input!) {
+		insert_sso_groups_one(object: $object) {
+		  id
+		}
+	  }
+	`)
+
+	req.Var("object", object)
+
+	var response struct {
+		Group struct {
+			ID string `json:"id"`
+		} `json:"insert_sso_groups_one"`
+	}
+
+	if err := client.Do(ctx, req, &response); err != nil {
+		return nil, err
+	}
+
+	return d.GetGroup(ctx, client, options.OrgID, response.Group.ID)
+}
+
+// Updates the group and its members,
+// and reassigns the roles of every member who joined or left a mapped group.
+func (d *DefaultService) UpdateGroup(ctx context.ServiceContext, client *clients.GQLClient, org_id, id string, options *UpdateGroupOptions) (*Group, error) {
+
+	group, err := d.GetGroup(ctx, client, org_id, id)
+	if err != nil {
+		return nil, err
+	}
+
+	set := make(map[string]interface{})
+	if options.DisplayName != nil {
+		set["display_name"] = *options.DisplayName
+	}
+	if options.ExternalID != nil {
+		set["external_id"] = *options.ExternalID
+	}
+
+	add := options.Add
+	remove := options.Remove
+
+	//	Replacing the members is a diff against the current ones.
+	if options.Members != nil {
+		current := make(map[string]bool)
+		for _, item := range group.MemberIDs() {
+			current[item] = true
+		}
+
+		wanted := make(map[string]bool)
+		for _, item := range *options.Members {
+			wanted[item] = true
+			if !current[item] {
+				add = append(add, item)
+			}
+		}
+
+		for item := range current {
+			if !wanted[item] {
+				remove = append(remove, item)
+			}
+		}
+	}
+
+	if err := validateIdentities(ctx, client, org_id, add); err != nil {
+		return nil, err
+	}
+
+	members := []map[string]interface{}{}
+	for _, item := range add {
+		members = append(members, map[string]interface{}{
+			"group_id":    id,
+			"identity_id": item,
+		})
+	}
+
+	req := graphql.NewRequest(`
+	mutation MyMutation($id: uuid!, $set: sso_groups_set_input!, $add: [sso_group_members_insert_input!]!, $remove: [uuid!]!) {
+		update_sso_groups_by_pk(pk_columns: {id: $id}, _set: $set) {
+		  id
+		}
+		insert_sso_group_members(objects: $add, on_conflict: {constraint: sso_group_members_pkey, update_columns: []}) {
+		  affected_rows
+		}
+		delete_sso_group_members(where: {group_id: {_eq: $id}, identity_id: {_in: $remove}}) {
+		  affected_rows
+		}
+	  }
+	`)
+
+	if remove == nil {
+		remove = []string{}
+	}
+
+	req.Var("id", id)
+	req.Var("set", set)
+	req.Var("add", members)
+	req.Var("remove", remove)
+
+	if err := client.Do(ctx, req, nil); err != nil {
+		return nil, err
+	}
+
+	if group.RoleID != "" {
+		if err := syncRoles(ctx, client, org_id, append(add, remove...)); err != nil {
+			return nil, err
+		}
+	}
+
+	return d.GetGroup(ctx, client, org_id, id)
+}
+
+// Deletes the group, and reassigns the roles of its members if it was mapped to a role.
+func (d *DefaultService) DeleteGroup(ctx context.ServiceContext, client *clients.GQLClient, org_id, id string) error {
+
+	group, err := d.GetGroup(ctx, client, org_id, id)
+	if err != nil {
+		return err
+	}
+
+	req := graphql.NewRequest(`
+	mutation MyMutation($id: uuid!) {
+		delete_sso_groups_by_pk(id: $id) {
+		  id
+		}
+	  }
+	`)
+
+	req.Var("id", id)
+
+	if err := client.Do(ctx, req, nil); err != nil {
+		return err
+	}
+
+	if group.RoleID == "" {
+		return nil
+	}
+
+	return syncRoles(ctx, client, org_id, group.MemberIDs())
+}
+
+// Maps the group to a role, and reassigns the roles of its members.
+func (d *DefaultService) MapGroup(ctx context.ServiceContext, client *clients.GQLClient, org_id, id string, options *MapGroupOptions) (*Group, error) {
+
+	group, err := d.GetGroup(ctx, client, org_id, id)
+	if err != nil {
+		return nil, err
+	}
+
+	var roleID interface{}
+	if options.RoleID != "" {
+		roleID = options.RoleID
+	}
+
+	req := graphql.NewRequest(`
+	mutation MyMutation($id: uuid!, $org_id: uuid!, $role_id: uuid, $priority: Int!) {
+		update_sso_groups(where: {id: {_eq: $id}, org_id: {_eq: $org_id}}, _set: {role_id: $role_id, priority: $priority}) {
+		  affected_rows
+		}
+	  }
+	`)
+
+	req.Var("id", id)
+	req.Var("org_id", org_id)
+	req.Var("role_id", roleID)
+	req.Var("priority", options.Priority)
+
+	if err := client.Do(ctx, req, nil); err != nil {
+		return nil, err
+	}
+
+	if err := syncRoles(ctx, client, org_id, group.MemberIDs()); err != nil {
+		return nil, err
+	}
+
+	return d.GetGroup(ctx, client, org_id, id)
+}
+
+func getConnection(ctx context.ServiceContext, client *clients.GQLClient, where map[string]interface{}) (*Connection, error) {
+
+	req := graphql.NewRequest(`
+	query MyQuery($where: sso_connections_bool_exp!) {
+		sso_connections(where: $where, limit: 1) {` + connectionFields + `}
+	  }
+	`)
+
+	req.Var("where", where)
+
+	var response struct {
+		Connections []Connection `json:"sso_connections"`
+	}
+
+	if err := client.Do(ctx, req, &response); err != nil {
+		return nil, err
+	}
+
+	if len(response.Connections) == 0 {
+		return nil, ErrNotConfigured
+	}
+
+	return &response.Connections[0], nil
+}
+
+// Validates the password, or unlock passphrase, of the user by decrypting their key pair with it.
+// Both protect the key pair the same way, and the server never stores either.
+func verifyPassword(ctx context.ServiceContext, client *clients.GQLClient, user_id, password string) error {
+
+	key, err := keys.GetByUserID(ctx, client, user_id)
+	if err != nil {
+		return err
+	}
+
+	payload, err := key.Decode()
+	if err != nil {
+		return err
+	}
+
+	return keys.DecryptPayload(payload, password)
+}
+
+// Fetches and decrypts the client secret of the connection.
+func clientSecret(ctx context.ServiceContext, client *clients.GQLClient, id string) (string, error) {
+
+	req := graphql.NewRequest(`
+	query MyQuery($id: uuid!) {
+		sso_connections_by_pk(id: $id) {
+		  client_secret
+		}
+	  }
+	`)
+
+	req.Var("id", id)
+
+	var response struct {
+		Connection *struct {
+			ClientSecret string `json:"client_secret"`
+		} `json:"sso_connections_by_pk"`
+	}
+
+	if err := client.Do(ctx, req, &response); err != nil {
+		return "", err
+	}
+
+	if response.Connection == nil {
+		return "", ErrNotConfigured
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(response.Connection.ClientSecret)
+	if err != nil {
+		return "", err
+	}
+
+	secret, err := keys.OpenSymmetricallyByServer(sealed)
+	if err != nil {
+		return "", err
+	}
+
+	return string(secret), nil
+}
+
+// Finds the identity by its subject, falling back to the email
+// for identities provisioned over SCIM which haven't signed in yet.
+func findIdentity(ctx context.ServiceContext, client *clients.GQLClient, org_id, subject, email string) (*Identity, error) {
+
+	req := graphql.NewRequest(`
+	query MyQuery($org_id: uuid!, $subject: String!, $email: String!) {
+		sso_identities(where: {org_id: {_eq: $org_id}, _or: [{subject: {_eq: $subject}}, {subject: {_is_null: true}, email: {_eq: $email}}]}, order_by: {subject: asc_nulls_last}, limit: 1) {` + identityFields + `}
+	  }
+	`)
+
+	req.Var("org_id", org_id)
+	req.Var("subject", subject)
+	req.Var("email", email)
+
+	var response struct {
+		Identities []Identity `json:"sso_identities"`
+	}
+
+	if err := client.Do(ctx, req, &response); err != nil {
+		return nil, err
+	}
+
+	if len(response.Identities) == 0 {
+		return nil, ErrNotFound
+	}
+
+	return &response.Identities[0], nil
+}
+
+func updateIdentity(ctx context.ServiceContext, client *clients.GQLClient, id string, set map[string]interface{}) error {
+
+	req := graphql.NewRequest(`
+	mutation MyMutation($id: uuid!, $set: sso_identities_set_input!) {
+		update_sso_identities_by_pk(pk_columns: {id: $id}, _set: $set) {
+		  id
+		}
+	  }
+	`)
+
+	req.Var("id", id)
+	req.Var("set", set)
+
+	var response struct {
+		Identity *struct {
+			ID string `json:"id"`
+		} `json:"update_sso_identities_by_pk"`
+	}
+
+	if err := client.Do(ctx, req, &response); err != nil {
+		return err
+	}
+
+	if response.Identity == nil {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// Validates that all the identities belong to the organisation.
+func validateIdentities(ctx context.ServiceContext, client *clients.GQLClient, org_id string, ids []string) error {
+
+	if len(ids) == 0 {
+		return nil
+	}
+
+	req := graphql.NewRequest(`
+	query MyQuery($org_id: uuid!, $ids: [uuid!]!) {
+		sso_identities_aggregate(where: {org_id: {_eq: $org_id}, id: {_in: $ids}}) {
+		  aggregate {
+			count
+		  }
+		}
+	  }
+	`)
+
+	req.Var("org_id", org_id)
+	req.Var("ids", ids)
+
+	var response struct {
+		Result struct {
+			Aggregate struct {
+				Count int `json:"count"`
+			} `json:"aggregate"`
+		} `json:"sso_identities_aggregate"`
+	}
+
+	if err := client.Do(ctx, req, &response); err != nil {
+		return err
+	}
+
+	unique := make(map[string]bool)
+	for _, item := range ids {
+		unique[item] = true
+	}
+
+	if response.Result.Aggregate.Count != len(unique) {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// --- Flow ---
+//
+//  1. Skip users who are already members, or already waiting for the organisation's key.
+//  2. Save an accepted invite without a key copy, on behalf of the organisation's owner.
+//
+// The server can't seal the organisation's key for the user,
+// so they join the same queue as invitees without a key pair.
+// Once they have set their unlock passphrase on their first sign in,
+// an admin grants them the key and their membership is created.
+func queueMembership(ctx context.ServiceContext, client *clients.GQLClient, identity *Identity) error {
+
+	organisation, err := organisations.GetService().Get(ctx, client, identity.OrgID)
+	if err != nil {
+		return err
+	}
+
+	if organisation.UserID == identity.UserID {
+		return nil
+	}
+
+	req := graphql.NewRequest(`
+	query MyQuery($org_id: uuid!, $user_id: uuid!, $email: String!) {
+		org_has_user_aggregate(where: {org_id: {_eq: $org_id}, user_id: {_eq: $user_id}}) {
+		  aggregate {
+			count
+		  }
+		}
+		invites_aggregate(where: {org_id: {_eq: $org_id}, email: {_eq: $email}, key: {_is_null: true}}) {
+		  aggregate {
+			count
+		  }
+		}
+	  }
+	`)
+
+	req.Var("org_id", identity.OrgID)
+	req.Var("user_id", identity.UserID)
+	req.Var("email", identity.Email)
+
+	type aggregate struct {
+		Aggregate struct {
+			Count int `json:"count"`
+		} `json:"aggregate"`
+	}
+
+	var response struct {
+		Memberships aggregate `json:"org_has_user_aggregate"`
+		Invites     aggregate `json:"invites_aggregate"`
+	}
+
+	if err := client.Do(ctx, req, &response); err != nil {
+		return err
+	}
+
+	if response.Memberships.Aggregate.Count > 0 || response.Invites.Aggregate.Count > 0 {
+		return nil
+	}
+
+	roleID, err := resolveRole(ctx, client, identity.OrgID, identity.ID)
+	if err != nil {
+		return err
+	}
+
+	req = graphql.NewRequest(`
+	mutation MyMutation($object: invites_insert_input!) {
+		insert_invites_one(object: $object) {
+		  id
+		}
+	  }
+	`)
+
+	req.Var("object", map[string]interface{}{
+		"org_id":   identity.OrgID,
+		"user_id":  organisation.UserID,
+		"email":    identity.Email,
+		"role_id":  roleID,
+		"accepted": true,
+	})
+
+	return client.Do(ctx, req, nil)
+}
+
+// Resolves the role of the identity from the highest priority group mapped to a role,
+// falling back to the connection's default role, and then the organisation's "viewer" role.
+func resolveRole(ctx context.ServiceContext, client *clients.GQLClient, org_id, identity_id string) (string, error) {
+
+	req := graphql.NewRequest(`
+	query MyQuery($org_id: uuid!, $identity_id: uuid!) {
+		sso_groups(where: {org_id: {_eq: $org_id}, role_id: {_is_null: false}, members: {identity_id: {_eq: $identity_id}}}, order_by: [{priority: desc}, {created_at: asc}], limit: 1) {
+		  role_id
+		}
+		sso_connections(where: {org_id: {_eq: $org_id}}) {
+		  default_role_id
+		}
+		roles(where: {org_id: {_eq: $org_id}, name: {_eq: "viewer"}}) {
+		  id
+		}
+	  }
+	`)
+
+	req.Var("org_id", org_id)
+	req.Var("identity_id", identity_id)
+
+	var response struct {
+		Groups []struct {
+			RoleID string `json:"role_id"`
+		} `json:"sso_groups"`
+		Connections []struct {
+			DefaultRoleID string `json:"default_role_id"`
+		} `json:"sso_connections"`
+		Roles []struct {
+			ID string `json:"id"`
+		} `json:"roles"`
+	}
+
+	if err := client.Do(ctx, req, &response); err != nil {
+		return "", err
+	}
+
+	if len(response.Groups) > 0 {
+		return response.Groups[0].RoleID, nil
+	}
+
+	if len(response.Connections) > 0 && response.Connections[0].DefaultRoleID != "" {
+		return response.Connections[0].DefaultRoleID, nil
+	}
+
+	if len(response.Roles) > 0 {
+		return response.Roles[0].ID, nil
+	}
+
+	return "", ErrNoRole
+}
+
+// Reassigns the roles of the active identities, both on their memberships
+// and on their invites still waiting for the organisation's key.
+// The organisation's owner keeps their role.
+func syncRoles(ctx context.ServiceContext, client *clients.GQLClient, org_id string, ids []string) error {
+
+	if len(ids) == 0 {
+		return nil
+	}
+
+	organisation, err := organisations.GetService().Get(ctx, client, org_id)
+	if err != nil {
+		return err
+	}
+
+	req := graphql.NewRequest(`
+	query MyQuery($org_id: uuid!, $ids: [uuid!]!) {
+		sso_identities(where: {org_id: {_eq: $org_id}, id: {_in: $ids}, active: {_eq: true}}) {
+		  id
+		  user_id
+		  email
+		}
+	  }
+	`)
+
+	req.Var("org_id", org_id)
+	req.Var("ids", ids)
+
+	var response struct {
+		Identities []Identity `json:"sso_identities"`
+	}
+
+	if err := client.Do(ctx, req, &response); err != nil {
+		return err
+	}
+
+	for _, identity := range response.Identities {
+
+		if identity.UserID == organisation.UserID {
+			continue
+		}
+
+		roleID, err := resolveRole(ctx, client, org_id, identity.ID)
+		if err != nil {
+			return err
+		}
+
+		req := graphql.NewRequest(`
+		mutation MyMutation($org_id: uuid!, $user_id: uuid!, $email: String!, $role_id: uuid!) {
+			update_org_has_user(where: {org_id: {_eq: $org_id}, user_id: {_eq: $user_id}}, _set: {role_id: $role_id}) {
+			  affected_rows
+			}
+			update_invites(where: {org_id: {_eq: $org_id}, email: {_eq: $email}, key: {_is_null: true}}, _set: {role_id: $role_id}) {
+			  affected_rows
+			}
+		  }
+		`)
+
+		req.Var("org_id", org_id)
+		req.Var("user_id", identity.UserID)
+		req.Var("email", identity.Email)
+		req.Var("role_id", roleID)
+
+		if err := client.Do(ctx, req, nil); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
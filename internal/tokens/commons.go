@@ -1,5 +1,42 @@
 package tokens
 
+import (
+	"errors"
+	"fmt"
+)
+
 const (
 	KEY_BYTES = 32
+
+	//	Audience of the ID tokens a CI bound token expects, unless it says otherwise.
+	DEFAULT_CI_AUDIENCE = "envsecrets"
 )
+
+var (
+	ErrInvalidCIDR       = errors.New("allowed networks must be IP addresses or CIDR ranges, like 10.0.0.0/8")
+	ErrInvalidCIIdentity = errors.New("a CI identity needs both the issuer and the subject of its ID tokens")
+	ErrInvalidMaxReads   = errors.New("maximum number of reads must be positive")
+)
+
+// Restriction of an environment token, or of its environment, which a request violated.
+type Violation string
+
+const (
+	IPNotAllowedViolation       Violation = "ip_not_allowed"
+	UserAgentMismatchViolation  Violation = "user_agent_mismatch"
+	CIIdentityMismatchViolation Violation = "ci_identity_mismatch"
+	UsageLimitViolation         Violation = "usage_limit_exceeded"
+)
+
+// Returned when a valid, unexpired token is used in a way its restrictions don't allow.
+// Unlike an invalid token, it usually means the token has leaked, so it's worth alerting on.
+type RestrictionError struct {
+	Violation Violation
+	TokenID   string
+	EnvID     string
+	Reason    string
+}
+
+func (e *RestrictionError) Error() string {
+	return fmt.Sprintf("token restriction violated (%s): %s", e.Violation, e.Reason)
+}
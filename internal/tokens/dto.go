@@ -2,6 +2,8 @@ package tokens
 
 import (
 	"encoding/json"
+	"net"
+	"strings"
 	"time"
 )
 
@@ -15,6 +17,13 @@ type Token struct {
 	Key       string    `json:"key,omitempty"`
 	Hash      string    `json:"hash,omitempty"`
 	Name      string    `json:"name,omitempty"`
+
+	Restrictions
+
+	//	Number of times the token has read secrets.
+	Reads      int        `json:"reads,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	LastUsedIP string     `json:"last_used_ip,omitempty"`
 }
 
 // IsExpired checks whether the token is expired or not.
@@ -22,19 +31,103 @@ func (t *Token) IsExpired() bool {
 	return t.Expiry.Before(time.Now())
 }
 
+// Optional restrictions on where, how and how many times an environment token can be used.
+type Restrictions struct {
+
+	//	Networks the token can be used from, as IP addresses or CIDR ranges. Empty allows any network.
+	AllowedCIDRs []string `json:"allowed_cidrs,omitempty"`
+
+	//	User agent the token must be sent with. A trailing "*" matches any suffix, like "deploy-bot/*".
+	UserAgent string `json:"user_agent,omitempty"`
+
+	//	CI identity the token is bound to. Every request must carry an ID token
+	//	of the issuer, for the audience, with this subject.
+	CIIssuer   string `json:"ci_issuer,omitempty"`
+	CIAudience string `json:"ci_audience,omitempty"`
+	CISubject  string `json:"ci_subject,omitempty"`
+
+	//	Maximum number of times the token can read secrets.
+	MaxReads *int `json:"max_reads,omitempty"`
+}
+
+// Validates the restrictions, and normalizes bare IP addresses to single address ranges.
+func (r *Restrictions) Validate() error {
+
+	for i, item := range r.AllowedCIDRs {
+		network, err := ParseCIDR(item)
+		if err != nil {
+			return err
+		}
+		r.AllowedCIDRs[i] = network.String()
+	}
+
+	if (r.CIIssuer == "") != (r.CISubject == "") {
+		return ErrInvalidCIIdentity
+	}
+
+	if r.CIIssuer != "" {
+		r.CIIssuer = strings.TrimSuffix(r.CIIssuer, "/")
+		if r.CIAudience == "" {
+			r.CIAudience = DEFAULT_CI_AUDIENCE
+		}
+	}
+
+	if r.MaxReads != nil && *r.MaxReads < 1 {
+		return ErrInvalidMaxReads
+	}
+
+	return nil
+}
+
+// Parses a CIDR range, or a bare IP address as the range of that single address.
+func ParseCIDR(value string) (*net.IPNet, error) {
+
+	value = strings.TrimSpace(value)
+
+	if ip := net.ParseIP(value); ip != nil {
+		bits := 128
+		if ip.To4() != nil {
+			ip = ip.To4()
+			bits = 32
+		}
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+	}
+
+	_, network, err := net.ParseCIDR(value)
+	if err != nil {
+		return nil, ErrInvalidCIDR
+	}
+
+	return network, nil
+}
+
 type CreateOptions struct {
-	OrgKey []byte
-	EnvID  string
-	Expiry time.Duration
-	Name   string `json:"name,omitempty"`
+	OrgKey       []byte
+	EnvID        string
+	Expiry       time.Duration
+	Name         string `json:"name,omitempty"`
+	Restrictions *Restrictions
 }
 
 type CreateGraphQLOptions struct {
-	EnvID  string
-	Expiry time.Time
-	Name   string
-	Key    []byte
-	Hash   string
+	EnvID        string
+	Expiry       time.Time
+	Name         string
+	Key          []byte
+	Hash         string
+	Restrictions *Restrictions
+}
+
+// Details of the request a token is used in, to validate its restrictions.
+type UseOptions struct {
+	IP        string
+	UserAgent string
+
+	//	Raw ID token of the CI job sending the request, if any.
+	IDToken string
+
+	//	Networks the token's environment can be read from. Empty allows any network.
+	EnvAllowedCIDRs []string
 }
 
 type DecryptResponse struct {
@@ -3,11 +3,14 @@ package tokens
 import (
 	"encoding/base64"
 	"fmt"
+	"net"
+	"strings"
 	"time"
 
 	"github.com/envsecrets/envsecrets/internal/clients"
 	"github.com/envsecrets/envsecrets/internal/context"
 	"github.com/envsecrets/envsecrets/internal/keys"
+	"github.com/envsecrets/envsecrets/internal/oidc"
 	"github.com/envsecrets/envsecrets/utils"
 	"github.com/machinebox/graphql"
 )
@@ -18,12 +21,19 @@ type Service interface {
 	GetByHash(context.ServiceContext, *clients.GQLClient, string) (*Token, error)
 	List(context.ServiceContext, *clients.GQLClient, *ListOptions) ([]*Token, error)
 	Decrypt(context.ServiceContext, *clients.GQLClient, []byte, []byte) ([]byte, error)
+	Use(context.ServiceContext, *clients.GQLClient, *Token, *UseOptions) error
 }
 
 type DefaultService struct{}
 
 func (*DefaultService) Create(ctx context.ServiceContext, client *clients.GQLClient, options *CreateOptions) ([]byte, error) {
 
+	if options.Restrictions != nil {
+		if err := options.Restrictions.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
 	now := time.Now()
 	exp := now.Add(options.Expiry)
 
@@ -45,11 +55,12 @@ func (*DefaultService) Create(ctx context.ServiceContext, client *clients.GQLCli
 	hash := utils.SHA256Hash(token)
 
 	if _, err := create(ctx, client, &CreateGraphQLOptions{
-		EnvID:  options.EnvID,
-		Name:   options.Name,
-		Expiry: exp,
-		Key:    keyBytes,
-		Hash:   hash,
+		EnvID:        options.EnvID,
+		Name:         options.Name,
+		Expiry:       exp,
+		Key:          keyBytes,
+		Hash:         hash,
+		Restrictions: options.Restrictions,
 	}); err != nil {
 		return nil, err
 	}
@@ -85,9 +96,18 @@ func (*DefaultService) GetByHash(ctx context.ServiceContext, client *clients.GQL
 	req := graphql.NewRequest(`
 	query MyQuery($hash: String!) {
 		tokens(where: {hash: {_eq: $hash}}) {
+		  id
+		  name
 		  env_id
 		  expiry
 		  key
+		  allowed_cidrs
+		  user_agent
+		  ci_issuer
+		  ci_audience
+		  ci_subject
+		  max_reads
+		  reads
 		}
 	  }			
 	`)
@@ -144,6 +164,136 @@ func (*DefaultService) Decrypt(ctx context.ServiceContext, client *clients.GQLCl
 	return decrypted, nil
 }
 
+// --- Flow ---
+//
+//  1. Validate the IP address of the request against the allow-lists of the token and of its environment.
+//  2. Validate the user agent, and the ID token of the CI identity the token is bound to.
+//  3. Count the read, unless the token has run out of reads, in which case it's rejected.
+//
+// Violations are returned as a *RestrictionError.
+func (*DefaultService) Use(ctx context.ServiceContext, client *clients.GQLClient, token *Token, options *UseOptions) error {
+
+	violation := func(kind Violation, format string, args ...interface{}) error {
+		return &RestrictionError{
+			Violation: kind,
+			TokenID:   token.ID,
+			EnvID:     token.EnvID,
+			Reason:    fmt.Sprintf(format, args...),
+		}
+	}
+
+	if !allowed(options.IP, token.AllowedCIDRs) {
+		return violation(IPNotAllowedViolation, "%s is not in the token's allowed networks", options.IP)
+	}
+
+	if !allowed(options.IP, options.EnvAllowedCIDRs) {
+		return violation(IPNotAllowedViolation, "%s is not in the environment's allowed networks", options.IP)
+	}
+
+	if token.UserAgent != "" && !matchUserAgent(token.UserAgent, options.UserAgent) {
+		return violation(UserAgentMismatchViolation, "the token is bound to another user agent")
+	}
+
+	if token.CIIssuer != "" {
+
+		if options.IDToken == "" {
+			return violation(CIIdentityMismatchViolation, "the token is bound to a CI identity, but the request has no ID token")
+		}
+
+		provider, err := oidc.Discover(ctx, token.CIIssuer)
+		if err != nil {
+			return err
+		}
+
+		claims, err := provider.Verify(ctx, options.IDToken, &oidc.VerifyOptions{
+			Audience: token.CIAudience,
+		})
+		if err != nil {
+			return violation(CIIdentityMismatchViolation, "invalid ID token: %s", err)
+		}
+
+		if claims.Subject() != token.CISubject {
+			return violation(CIIdentityMismatchViolation, "the token is bound to another CI identity")
+		}
+	}
+
+	//	The read is only counted while the token has reads left, in the same statement which checks it,
+	//	so that rejected requests don't use up reads, and concurrent ones can never read more than the limit between them.
+	req := graphql.NewRequest(`
+	mutation MyMutation($where: tokens_bool_exp!, $ip: String!, $now: timestamptz!) {
+		update_tokens(where: $where, _inc: {reads: 1}, _set: {last_used_at: $now, last_used_ip: $ip}) {
+		  returning {
+			reads
+		  }
+		}
+	  }
+	`)
+
+	req.Var("where", map[string]interface{}{
+		"id": map[string]interface{}{
+			"_eq": token.ID,
+		},
+		"_or": []map[string]interface{}{
+			{"max_reads": map[string]interface{}{"_is_null": true}},
+			{"reads": map[string]interface{}{"_clt": "max_reads"}},
+		},
+	})
+	req.Var("ip", options.IP)
+	req.Var("now", time.Now().UTC())
+
+	var response struct {
+		Result struct {
+			Returning []Token `json:"returning"`
+		} `json:"update_tokens"`
+	}
+
+	if err := client.Do(ctx, req, &response); err != nil {
+		return err
+	}
+
+	if len(response.Result.Returning) == 0 {
+		if limit := token.MaxReads; limit != nil {
+			return violation(UsageLimitViolation, "the token has used all of its %d reads", *limit)
+		}
+		return fmt.Errorf("no tokens found")
+	}
+
+	token.Reads = response.Result.Returning[0].Reads
+
+	return nil
+}
+
+//	---	Helpers ---
+
+// Reports whether the IP address is in any of the networks. An empty list allows any address.
+func allowed(ip string, networks []string) bool {
+
+	if len(networks) == 0 {
+		return true
+	}
+
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+
+	for _, item := range networks {
+		network, err := ParseCIDR(item)
+		if err == nil && network.Contains(parsed) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func matchUserAgent(pattern, userAgent string) bool {
+	if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+		return strings.HasPrefix(userAgent, prefix)
+	}
+	return userAgent == pattern
+}
+
 //
 //	--- GraphQL ---
 //
@@ -151,21 +301,47 @@ func (*DefaultService) Decrypt(ctx context.ServiceContext, client *clients.GQLCl
 func create(ctx context.ServiceContext, client *clients.GQLClient, options *CreateGraphQLOptions) (*Token, error) {
 
 	req := graphql.NewRequest(`
-	mutation MyMutation($name: String!, $key: String!, $hash: String!, $env_id: uuid!, $expiry: timestamptz) {
-		insert_tokens_one(object: {name: $name, key: $key, hash: $hash, env_id: $env_id, expiry: $expiry}) {
+	mutation MyMutation($object: tokens_insert_input!) {
+		insert_tokens_one(object: $object) {
 		  id
 		}
 	  }
 	`)
 
-	req.Var("env_id", options.EnvID)
-	req.Var("name", options.Name)
-	req.Var("key", base64.StdEncoding.EncodeToString(options.Key))
-	req.Var("hash", options.Hash)
+	object := map[string]interface{}{
+		"env_id": options.EnvID,
+		"name":   options.Name,
+		"key":    base64.StdEncoding.EncodeToString(options.Key),
+		"hash":   options.Hash,
+	}
+
 	if !options.Expiry.IsZero() {
-		req.Var("expiry", options.Expiry)
+		object["expiry"] = options.Expiry
 	}
 
+	if restrictions := options.Restrictions; restrictions != nil {
+
+		if len(restrictions.AllowedCIDRs) > 0 {
+			object["allowed_cidrs"] = restrictions.AllowedCIDRs
+		}
+
+		if restrictions.UserAgent != "" {
+			object["user_agent"] = restrictions.UserAgent
+		}
+
+		if restrictions.CIIssuer != "" {
+			object["ci_issuer"] = restrictions.CIIssuer
+			object["ci_audience"] = restrictions.CIAudience
+			object["ci_subject"] = restrictions.CISubject
+		}
+
+		if restrictions.MaxReads != nil {
+			object["max_reads"] = *restrictions.MaxReads
+		}
+	}
+
+	req.Var("object", object)
+
 	var response struct {
 		Token Token `json:"insert_tokens_one"`
 	}
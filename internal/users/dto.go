@@ -10,3 +10,8 @@ type User struct {
 	Name        string    `json:"display_name,omitempty"`
 	Email       string    `json:"email,omitempty"`
 }
+
+type CreateOptions struct {
+	Email string
+	Name  string
+}
@@ -78,3 +78,62 @@ func GetByEmail(ctx context.ServiceContext, client *clients.GQLClient, email str
 
 	return &resp[0], nil
 }
+
+// Creates a user account without a password,
+// for users who sign in through their organisation's identity provider.
+// The client must have admin privileges.
+func Create(ctx context.ServiceContext, client *clients.GQLClient, options *CreateOptions) (*User, error) {
+
+	req := graphql.NewRequest(`
+	mutation MyMutation($email: citext!, $displayName: String!) {
+		insertUser(object: {email: $email, displayName: $displayName, locale: "en", defaultRole: "user", emailVerified: true, roles: {data: [{role: "user"}]}}) {
+			id
+			email
+			displayName
+		}
+	  }
+	`)
+
+	req.Var("email", options.Email)
+	req.Var("displayName", options.Name)
+
+	var response struct {
+		User User `json:"insertUser"`
+	}
+
+	if err := client.Do(ctx, req, &response); err != nil {
+		return nil, err
+	}
+
+	return &response.User, nil
+}
+
+// Disables the user's account, so that they can no longer sign in.
+// The account is kept, since audit logs refer to it.
+// The client must have admin privileges.
+func Disable(ctx context.ServiceContext, client *clients.GQLClient, id string) error {
+
+	req := graphql.NewRequest(`
+	mutation MyMutation($id: uuid!) {
+		updateUser(pk_columns: {id: $id}, _set: {disabled: true}) {
+			id
+		}
+	  }
+	`)
+
+	req.Var("id", id)
+
+	var response struct {
+		User *User `json:"updateUser"`
+	}
+
+	if err := client.Do(ctx, req, &response); err != nil {
+		return err
+	}
+
+	if response.User == nil {
+		return errors.New("user not found")
+	}
+
+	return nil
+}
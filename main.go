@@ -2,6 +2,7 @@ package main
 
 import (
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"strings"
@@ -23,6 +24,11 @@ func main() {
 	// Echo instance
 	e := echo.New()
 
+	//	Clients could spoof the IP the rate-limit and the allow-lists of environment tokens see
+	//	by sending their own X-Forwarded-For header, so it's only read when the server runs behind proxies
+	//	listed in $TRUSTED_PROXIES, as comma separated CIDRs. Otherwise the IP of the connection is used.
+	e.IPExtractor = ipExtractor(os.Getenv("TRUSTED_PROXIES"))
+
 	//
 	// Middlewares
 	//
@@ -51,6 +57,11 @@ func main() {
 		"/auth/logout",
 		"/auth/signup",
 		"/auth/validate-password",
+		"/auth/sso/connection",
+		"/auth/sso/signin",
+		"/auth/service-accounts/signin",
+		"/tokens/oidc",
+		"/scim/",
 	}
 
 	skipper := func(c echo.Context) bool {
@@ -70,6 +81,30 @@ func main() {
 	e.Logger.Fatal(e.Start(":" + os.Getenv("PORT")))
 }
 
+// Reads the client's IP from the X-Forwarded-For header, trusting only the given proxies.
+func ipExtractor(proxies string) echo.IPExtractor {
+
+	if strings.TrimSpace(proxies) == "" {
+		return echo.ExtractIPDirect()
+	}
+
+	options := []echo.TrustOption{
+		echo.TrustLoopback(false),
+		echo.TrustLinkLocal(false),
+		echo.TrustPrivateNet(false),
+	}
+
+	for _, item := range strings.Split(proxies, ",") {
+		_, network, err := net.ParseCIDR(strings.TrimSpace(item))
+		if err != nil {
+			log.Fatalf("Invalid trusted proxy range %q: %s", item, err)
+		}
+		options = append(options, echo.TrustIPRange(network))
+	}
+
+	return echo.ExtractIPFromXFFHeader(options...)
+}
+
 // Healthcheck endpoint
 func healthz(c echo.Context) error {
 	return c.String(http.StatusOK, "API is healthy")
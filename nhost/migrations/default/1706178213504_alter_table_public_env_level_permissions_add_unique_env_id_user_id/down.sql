@@ -0,0 +1 @@
+alter table "public"."env_level_permissions" drop constraint "env_level_permissions_env_id_user_id_key";
@@ -0,0 +1 @@
+alter table "public"."project_level_permissions" drop constraint "project_level_permissions_project_id_user_id_key";
@@ -0,0 +1 @@
+alter table "public"."project_level_permissions" add constraint "project_level_permissions_project_id_user_id_key" unique ("project_id", "user_id");
@@ -0,0 +1,3 @@
+alter table "public"."invites" alter column "key" set default md5(random()::text);
+update "public"."invites" set "key" = md5(random()::text) where "key" is null;
+alter table "public"."invites" alter column "key" set not null;
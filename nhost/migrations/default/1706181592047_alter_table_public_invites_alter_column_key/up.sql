@@ -0,0 +1,2 @@
+alter table "public"."invites" alter column "key" drop not null;
+alter table "public"."invites" alter column "key" drop default;
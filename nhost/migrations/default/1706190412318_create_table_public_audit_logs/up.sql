@@ -0,0 +1,3 @@
+CREATE TABLE "public"."audit_logs" ("id" uuid NOT NULL DEFAULT gen_random_uuid(), "created_at" timestamptz NOT NULL DEFAULT now(), "org_id" uuid NOT NULL, "user_id" uuid, "action" text NOT NULL, "entity_type" text, "entity_id" text, "metadata" jsonb NOT NULL DEFAULT '{}'::jsonb, PRIMARY KEY ("id") , FOREIGN KEY ("org_id") REFERENCES "public"."organisations"("id") ON UPDATE restrict ON DELETE cascade, FOREIGN KEY ("user_id") REFERENCES "auth"."users"("id") ON UPDATE restrict ON DELETE set null);
+CREATE INDEX "audit_logs_org_id_created_at_idx" on "public"."audit_logs" using btree ("org_id", "created_at");
+CREATE EXTENSION IF NOT EXISTS pgcrypto;
@@ -0,0 +1 @@
+alter table "public"."organisations" drop column "rotate_key_on_member_removal";
@@ -0,0 +1,2 @@
+alter table "public"."organisations" add column "rotate_key_on_member_removal" boolean
+ not null default 'false';
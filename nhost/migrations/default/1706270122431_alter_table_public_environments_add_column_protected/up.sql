@@ -0,0 +1,2 @@
+alter table "public"."environments" add column "protected" boolean
+ not null default 'false';
@@ -0,0 +1 @@
+alter table "public"."environments" drop column "required_approvals";
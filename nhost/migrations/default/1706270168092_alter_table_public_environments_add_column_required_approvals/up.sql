@@ -0,0 +1,2 @@
+alter table "public"."environments" add column "required_approvals" integer
+ not null default '1';
@@ -0,0 +1,19 @@
+CREATE TABLE "public"."change_requests" ("id" uuid NOT NULL DEFAULT gen_random_uuid(), "created_at" timestamptz NOT NULL DEFAULT now(), "updated_at" timestamptz NOT NULL DEFAULT now(), "env_id" uuid NOT NULL, "user_id" uuid, "base_version" integer, "data" jsonb NOT NULL DEFAULT '{}'::jsonb, "removed" jsonb NOT NULL DEFAULT '[]'::jsonb, "diff" jsonb NOT NULL DEFAULT '{}'::jsonb, "message" text, "sync" boolean NOT NULL DEFAULT false, "status" text NOT NULL DEFAULT 'pending', "version" integer, PRIMARY KEY ("id") , FOREIGN KEY ("env_id") REFERENCES "public"."environments"("id") ON UPDATE restrict ON DELETE cascade, FOREIGN KEY ("user_id") REFERENCES "auth"."users"("id") ON UPDATE restrict ON DELETE set null);COMMENT ON TABLE "public"."change_requests" IS E'proposed secret changes of protected environments';
+CREATE INDEX "change_requests_env_id_status_idx" on "public"."change_requests" using btree ("env_id", "status");
+CREATE OR REPLACE FUNCTION "public"."set_current_timestamp_updated_at"()
+RETURNS TRIGGER AS $$
+DECLARE
+  _new record;
+BEGIN
+  _new := NEW;
+  _new."updated_at" = NOW();
+  RETURN _new;
+END;
+$$ LANGUAGE plpgsql;
+CREATE TRIGGER "set_public_change_requests_updated_at"
+BEFORE UPDATE ON "public"."change_requests"
+FOR EACH ROW
+EXECUTE PROCEDURE "public"."set_current_timestamp_updated_at"();
+COMMENT ON TRIGGER "set_public_change_requests_updated_at" ON "public"."change_requests" 
+IS 'trigger to set value of column "updated_at" to current timestamp on row update';
+CREATE EXTENSION IF NOT EXISTS pgcrypto;
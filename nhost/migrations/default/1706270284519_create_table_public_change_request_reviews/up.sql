@@ -0,0 +1,2 @@
+CREATE TABLE "public"."change_request_reviews" ("id" uuid NOT NULL DEFAULT gen_random_uuid(), "created_at" timestamptz NOT NULL DEFAULT now(), "change_request_id" uuid NOT NULL, "user_id" uuid NOT NULL, "decision" text NOT NULL, "comment" text, PRIMARY KEY ("id") , FOREIGN KEY ("change_request_id") REFERENCES "public"."change_requests"("id") ON UPDATE restrict ON DELETE cascade, FOREIGN KEY ("user_id") REFERENCES "auth"."users"("id") ON UPDATE restrict ON DELETE cascade, UNIQUE ("change_request_id", "user_id"));
+CREATE EXTENSION IF NOT EXISTS pgcrypto;
@@ -0,0 +1,19 @@
+CREATE TABLE "public"."access_grants" ("id" uuid NOT NULL DEFAULT gen_random_uuid(), "created_at" timestamptz NOT NULL DEFAULT now(), "updated_at" timestamptz NOT NULL DEFAULT now(), "org_id" uuid NOT NULL, "env_id" uuid NOT NULL, "user_id" uuid NOT NULL, "reason" text NOT NULL, "duration" integer NOT NULL, "status" text NOT NULL DEFAULT 'pending', "reviewer_id" uuid, "approved_at" timestamptz, "expires_at" timestamptz, PRIMARY KEY ("id") , FOREIGN KEY ("org_id") REFERENCES "public"."organisations"("id") ON UPDATE restrict ON DELETE cascade, FOREIGN KEY ("env_id") REFERENCES "public"."environments"("id") ON UPDATE restrict ON DELETE cascade, FOREIGN KEY ("user_id") REFERENCES "auth"."users"("id") ON UPDATE restrict ON DELETE cascade, FOREIGN KEY ("reviewer_id") REFERENCES "auth"."users"("id") ON UPDATE restrict ON DELETE set null);COMMENT ON TABLE "public"."access_grants" IS E'time-boxed access of members to the secrets of environments';
+CREATE INDEX "access_grants_env_id_user_id_status_idx" on "public"."access_grants" using btree ("env_id", "user_id", "status");
+CREATE OR REPLACE FUNCTION "public"."set_current_timestamp_updated_at"()
+RETURNS TRIGGER AS $$
+DECLARE
+  _new record;
+BEGIN
+  _new := NEW;
+  _new."updated_at" = NOW();
+  RETURN _new;
+END;
+$$ LANGUAGE plpgsql;
+CREATE TRIGGER "set_public_access_grants_updated_at"
+BEFORE UPDATE ON "public"."access_grants"
+FOR EACH ROW
+EXECUTE PROCEDURE "public"."set_current_timestamp_updated_at"();
+COMMENT ON TRIGGER "set_public_access_grants_updated_at" ON "public"."access_grants" 
+IS 'trigger to set value of column "updated_at" to current timestamp on row update';
+CREATE EXTENSION IF NOT EXISTS pgcrypto;
@@ -0,0 +1 @@
+DROP TABLE "public"."sso_connections";
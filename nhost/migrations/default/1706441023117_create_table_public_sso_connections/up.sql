@@ -0,0 +1,18 @@
+CREATE TABLE "public"."sso_connections" ("id" uuid NOT NULL DEFAULT gen_random_uuid(), "created_at" timestamptz NOT NULL DEFAULT now(), "updated_at" timestamptz NOT NULL DEFAULT now(), "org_id" uuid NOT NULL, "issuer" text NOT NULL, "client_id" text NOT NULL, "client_secret" text NOT NULL, "domains" jsonb NOT NULL DEFAULT jsonb_build_array(), "default_role_id" uuid, "scim_token_hash" text, "enabled" boolean NOT NULL DEFAULT true, PRIMARY KEY ("id") , FOREIGN KEY ("org_id") REFERENCES "public"."organisations"("id") ON UPDATE restrict ON DELETE cascade, FOREIGN KEY ("default_role_id") REFERENCES "public"."roles"("id") ON UPDATE restrict ON DELETE set null, UNIQUE ("org_id"), UNIQUE ("scim_token_hash"));COMMENT ON TABLE "public"."sso_connections" IS E'OIDC identity provider and SCIM provisioning configuration of organisations';
+CREATE OR REPLACE FUNCTION "public"."set_current_timestamp_updated_at"()
+RETURNS TRIGGER AS $$
+DECLARE
+  _new record;
+BEGIN
+  _new := NEW;
+  _new."updated_at" = NOW();
+  RETURN _new;
+END;
+$$ LANGUAGE plpgsql;
+CREATE TRIGGER "set_public_sso_connections_updated_at"
+BEFORE UPDATE ON "public"."sso_connections"
+FOR EACH ROW
+EXECUTE PROCEDURE "public"."set_current_timestamp_updated_at"();
+COMMENT ON TRIGGER "set_public_sso_connections_updated_at" ON "public"."sso_connections" 
+IS 'trigger to set value of column "updated_at" to current timestamp on row update';
+CREATE EXTENSION IF NOT EXISTS pgcrypto;
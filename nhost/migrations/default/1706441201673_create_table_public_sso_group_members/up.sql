@@ -0,0 +1 @@
+CREATE TABLE "public"."sso_group_members" ("group_id" uuid NOT NULL, "identity_id" uuid NOT NULL, "created_at" timestamptz NOT NULL DEFAULT now(), PRIMARY KEY ("group_id","identity_id") , FOREIGN KEY ("group_id") REFERENCES "public"."sso_groups"("id") ON UPDATE restrict ON DELETE cascade, FOREIGN KEY ("identity_id") REFERENCES "public"."sso_identities"("id") ON UPDATE restrict ON DELETE cascade);
@@ -0,0 +1,18 @@
+CREATE TABLE "public"."service_accounts" ("id" uuid NOT NULL DEFAULT gen_random_uuid(), "created_at" timestamptz NOT NULL DEFAULT now(), "updated_at" timestamptz NOT NULL DEFAULT now(), "org_id" uuid NOT NULL, "user_id" uuid NOT NULL, "name" text NOT NULL, "description" text, "created_by" uuid, "last_used_at" timestamptz, PRIMARY KEY ("id") , FOREIGN KEY ("org_id") REFERENCES "public"."organisations"("id") ON UPDATE restrict ON DELETE cascade, FOREIGN KEY ("user_id") REFERENCES "auth"."users"("id") ON UPDATE restrict ON DELETE cascade, FOREIGN KEY ("created_by") REFERENCES "auth"."users"("id") ON UPDATE restrict ON DELETE set null, UNIQUE ("user_id"), UNIQUE ("org_id", "name"));COMMENT ON TABLE "public"."service_accounts" IS E'non-human members of organisations, for automation';
+CREATE OR REPLACE FUNCTION "public"."set_current_timestamp_updated_at"()
+RETURNS TRIGGER AS $$
+DECLARE
+  _new record;
+BEGIN
+  _new := NEW;
+  _new."updated_at" = NOW();
+  RETURN _new;
+END;
+$$ LANGUAGE plpgsql;
+CREATE TRIGGER "set_public_service_accounts_updated_at"
+BEFORE UPDATE ON "public"."service_accounts"
+FOR EACH ROW
+EXECUTE PROCEDURE "public"."set_current_timestamp_updated_at"();
+COMMENT ON TRIGGER "set_public_service_accounts_updated_at" ON "public"."service_accounts" 
+IS 'trigger to set value of column "updated_at" to current timestamp on row update';
+CREATE EXTENSION IF NOT EXISTS pgcrypto;
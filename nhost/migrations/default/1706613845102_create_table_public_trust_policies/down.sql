@@ -0,0 +1 @@
+DROP TABLE "public"."trust_policies";
@@ -0,0 +1,19 @@
+CREATE TABLE "public"."trust_policies" ("id" uuid NOT NULL DEFAULT gen_random_uuid(), "created_at" timestamptz NOT NULL DEFAULT now(), "updated_at" timestamptz NOT NULL DEFAULT now(), "org_id" uuid NOT NULL, "env_id" uuid NOT NULL, "name" text NOT NULL, "provider" text NOT NULL DEFAULT 'generic', "issuer" text NOT NULL, "audience" text NOT NULL DEFAULT 'envsecrets', "repository" text, "branch" text, "environment" text, "claims" jsonb NOT NULL DEFAULT jsonb_build_object(), "token_expiry" integer NOT NULL DEFAULT 900, "key" text NOT NULL, "created_by" uuid, PRIMARY KEY ("id") , FOREIGN KEY ("org_id") REFERENCES "public"."organisations"("id") ON UPDATE restrict ON DELETE cascade, FOREIGN KEY ("env_id") REFERENCES "public"."environments"("id") ON UPDATE restrict ON DELETE cascade, FOREIGN KEY ("created_by") REFERENCES "auth"."users"("id") ON UPDATE restrict ON DELETE set null, UNIQUE ("org_id", "name"));COMMENT ON TABLE "public"."trust_policies" IS E'CI identities allowed to exchange their OIDC ID tokens for short-lived environment tokens';
+CREATE INDEX "trust_policies_issuer_idx" ON "public"."trust_policies" ("issuer");
+CREATE OR REPLACE FUNCTION "public"."set_current_timestamp_updated_at"()
+RETURNS TRIGGER AS $$
+DECLARE
+  _new record;
+BEGIN
+  _new := NEW;
+  _new."updated_at" = NOW();
+  RETURN _new;
+END;
+$$ LANGUAGE plpgsql;
+CREATE TRIGGER "set_public_trust_policies_updated_at"
+BEFORE UPDATE ON "public"."trust_policies"
+FOR EACH ROW
+EXECUTE PROCEDURE "public"."set_current_timestamp_updated_at"();
+COMMENT ON TRIGGER "set_public_trust_policies_updated_at" ON "public"."trust_policies" 
+IS 'trigger to set value of column "updated_at" to current timestamp on row update';
+CREATE EXTENSION IF NOT EXISTS pgcrypto;
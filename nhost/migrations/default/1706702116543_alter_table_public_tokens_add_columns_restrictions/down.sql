@@ -0,0 +1,6 @@
+alter table "public"."tokens" drop column "max_reads";
+alter table "public"."tokens" drop column "ci_subject";
+alter table "public"."tokens" drop column "ci_audience";
+alter table "public"."tokens" drop column "ci_issuer";
+alter table "public"."tokens" drop column "user_agent";
+alter table "public"."tokens" drop column "allowed_cidrs";
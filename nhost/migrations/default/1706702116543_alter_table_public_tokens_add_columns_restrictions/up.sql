@@ -0,0 +1,12 @@
+alter table "public"."tokens" add column "allowed_cidrs" jsonb
+ not null default jsonb_build_array();
+alter table "public"."tokens" add column "user_agent" text
+ null;
+alter table "public"."tokens" add column "ci_issuer" text
+ null;
+alter table "public"."tokens" add column "ci_audience" text
+ null;
+alter table "public"."tokens" add column "ci_subject" text
+ null;
+alter table "public"."tokens" add column "max_reads" integer
+ null;
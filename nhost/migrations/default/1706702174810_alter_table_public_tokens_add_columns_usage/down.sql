@@ -0,0 +1,3 @@
+alter table "public"."tokens" drop column "last_used_ip";
+alter table "public"."tokens" drop column "last_used_at";
+alter table "public"."tokens" drop column "reads";
@@ -0,0 +1,6 @@
+alter table "public"."tokens" add column "reads" integer
+ not null default '0';
+alter table "public"."tokens" add column "last_used_at" timestamptz
+ null;
+alter table "public"."tokens" add column "last_used_ip" text
+ null;
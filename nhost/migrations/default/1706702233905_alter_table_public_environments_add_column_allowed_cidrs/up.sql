@@ -0,0 +1,2 @@
+alter table "public"."environments" add column "allowed_cidrs" jsonb
+ not null default jsonb_build_array();
@@ -0,0 +1,2 @@
+alter table "public"."trust_policies" drop column "repository_id";
+alter table "public"."trust_policies" drop column "repository_owner_id";
@@ -0,0 +1,4 @@
+alter table "public"."trust_policies" add column "repository_owner_id" text
+ null;
+alter table "public"."trust_policies" add column "repository_id" text
+ null;
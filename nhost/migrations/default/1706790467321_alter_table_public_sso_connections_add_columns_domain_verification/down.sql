@@ -0,0 +1,2 @@
+alter table "public"."sso_connections" drop column "verification_token";
+alter table "public"."sso_connections" drop column "verified_domains";
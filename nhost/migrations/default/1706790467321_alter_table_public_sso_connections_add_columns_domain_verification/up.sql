@@ -0,0 +1,4 @@
+alter table "public"."sso_connections" add column "verified_domains" jsonb
+ not null default jsonb_build_array();
+alter table "public"."sso_connections" add column "verification_token" text
+ not null default encode(gen_random_bytes(16), 'hex');
@@ -0,0 +1 @@
+alter table "public"."sso_identities" drop column "linked";
@@ -0,0 +1,5 @@
+alter table "public"."sso_identities" add column "linked" boolean
+ not null default false;
+-- Accounts without a key pair never signed up with a password, so they were created for their identity.
+update "public"."sso_identities" set "linked" = true
+ where not exists (select 1 from "public"."keys" where "keys"."user_id" = "sso_identities"."user_id");
@@ -0,0 +1,225 @@
+// Package webhook lets receivers of envsecrets webhooks
+// verify the signature of the payloads and decrypt their secrets.
+//
+//	func handler(w http.ResponseWriter, r *http.Request) {
+//		body, _ := io.ReadAll(r.Body)
+//		payload, err := webhook.Verify(r.Header, body, secret, 5*time.Minute)
+//		if err != nil {
+//			w.WriteHeader(http.StatusUnauthorized)
+//			return
+//		}
+//
+//		data, err := payload.Decrypt(publicKey, privateKey)
+//		...
+//	}
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/nacl/box"
+)
+
+const (
+
+	//	HMAC-SHA256 signature of the timestamp and body, in the form "sha256=<hex>".
+	SignatureHeader = "X-Envsecrets-Signature"
+
+	//	Unix time at which the payload was signed.
+	TimestampHeader = "X-Envsecrets-Timestamp"
+
+	//	Type of the event which triggered the webhook.
+	EventHeader = "X-Envsecrets-Event"
+
+	SyncEvent = "secrets.sync"
+
+	KeyLength = 32
+)
+
+var (
+	ErrMissingSignature = errors.New("missing webhook signature")
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	ErrExpiredTimestamp = errors.New("webhook timestamp is outside the tolerance")
+	ErrInvalidURL       = errors.New("webhook url must be an absolute https URL")
+	ErrLoopbackURL      = errors.New("webhook url must not point to the loopback interface")
+)
+
+type Metadata struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type Payload struct {
+	Event       string    `json:"event"`
+	Timestamp   int64     `json:"timestamp"`
+	Version     *int      `json:"version,omitempty"`
+	Environment *Metadata `json:"environment,omitempty"`
+	Project     *Metadata `json:"project,omitempty"`
+
+	//	True if the secrets are sealed to the recipient's public key.
+	Encrypted bool `json:"encrypted"`
+
+	//	Plaintext key-value pairs, if the payload is not encrypted.
+	Data map[string]string `json:"data,omitempty"`
+
+	//	Base64 encoded sealed box of the JSON encoded key-value pairs,
+	//	if the payload is encrypted. Compatible with libsodium's crypto_box_seal.
+	Ciphertext string `json:"ciphertext,omitempty"`
+}
+
+// Seals the key-value pairs to the recipient's public key.
+func (p *Payload) Encrypt(data map[string]string, publicKey [KeyLength]byte) error {
+
+	message, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	sealed, err := box.SealAnonymous(nil, message, &publicKey, rand.Reader)
+	if err != nil {
+		return err
+	}
+
+	p.Encrypted = true
+	p.Data = nil
+	p.Ciphertext = base64.StdEncoding.EncodeToString(sealed)
+	return nil
+}
+
+// Opens the sealed key-value pairs with the recipient's key pair.
+// Returns the plaintext pairs as they are, if the payload is not encrypted.
+func (p *Payload) Decrypt(publicKey, privateKey [KeyLength]byte) (map[string]string, error) {
+
+	if !p.Encrypted {
+		return p.Data, nil
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(p.Ciphertext)
+	if err != nil {
+		return nil, err
+	}
+
+	message, ok := box.OpenAnonymous(nil, sealed, &publicKey, &privateKey)
+	if !ok {
+		return nil, errors.New("failed to decrypt the webhook payload")
+	}
+
+	var result map[string]string
+	if err := json.Unmarshal(message, &result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// Returns the signature of the body, for the given timestamp.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.", timestamp)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verifies the signature of the body against the shared secret,
+// and rejects payloads signed longer ago than the tolerance, to prevent replays.
+// A tolerance of zero disables the timestamp check.
+func Verify(header http.Header, body []byte, secret string, tolerance time.Duration) (*Payload, error) {
+
+	signature := header.Get(SignatureHeader)
+	if signature == "" || !strings.HasPrefix(signature, "sha256=") {
+		return nil, ErrMissingSignature
+	}
+
+	timestamp, err := strconv.ParseInt(header.Get(TimestampHeader), 10, 64)
+	if err != nil {
+		return nil, ErrMissingSignature
+	}
+
+	if !hmac.Equal([]byte(signature), []byte(Sign(secret, timestamp, body))) {
+		return nil, ErrInvalidSignature
+	}
+
+	if tolerance > 0 {
+		age := time.Since(time.Unix(timestamp, 0))
+		if age > tolerance || age < -tolerance {
+			return nil, ErrExpiredTimestamp
+		}
+	}
+
+	var payload Payload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, err
+	}
+
+	return &payload, nil
+}
+
+// Decodes a base64 encoded key, as shared during the setup of the integration.
+func DecodeKey(value string) (result [KeyLength]byte, err error) {
+	key, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return
+	}
+
+	if len(key) != KeyLength {
+		err = fmt.Errorf("key must be %d bytes long", KeyLength)
+		return
+	}
+
+	copy(result[:], key)
+	return
+}
+
+// Validates the URL secrets are delivered to.
+// Only https is accepted, since the payloads may carry plaintext secrets,
+// and hosts on the loopback interface are refused, so the server delivering
+// them can't be pointed at services listening on itself.
+// allowLoopback lifts the latter, along with the https requirement for
+// loopback hosts, and is only meant for local development.
+func ValidateURL(value string, allowLoopback bool) error {
+
+	parsed, err := url.Parse(value)
+	if err != nil || parsed.Host == "" {
+		return ErrInvalidURL
+	}
+
+	loopback := isLoopback(parsed.Hostname())
+	if loopback && !allowLoopback {
+		return ErrLoopbackURL
+	}
+
+	switch parsed.Scheme {
+	case "https":
+		return nil
+	case "http":
+		if loopback {
+			return nil
+		}
+	}
+
+	return ErrInvalidURL
+}
+
+func isLoopback(host string) bool {
+
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return true
+	}
+
+	ip := net.ParseIP(host)
+	return ip != nil && (ip.IsLoopback() || ip.IsUnspecified())
+}
@@ -0,0 +1,185 @@
+package webhook
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/nacl/box"
+)
+
+const secret = "whsec_test"
+
+func signedHeader(timestamp int64, body []byte) http.Header {
+	header := http.Header{}
+	header.Set(SignatureHeader, Sign(secret, timestamp, body))
+	header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+	return header
+}
+
+func TestVerify(t *testing.T) {
+
+	body, err := json.Marshal(&Payload{
+		Event:     SyncEvent,
+		Timestamp: time.Now().Unix(),
+		Data:      map[string]string{"API_KEY": "value"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now().Unix()
+
+	tests := []struct {
+		name   string
+		header http.Header
+		body   []byte
+		secret string
+		want   error
+	}{
+		{
+			name:   "valid",
+			header: signedHeader(now, body),
+			body:   body,
+			secret: secret,
+		},
+		{
+			name:   "missing signature",
+			header: http.Header{},
+			body:   body,
+			secret: secret,
+			want:   ErrMissingSignature,
+		},
+		{
+			name:   "wrong secret",
+			header: signedHeader(now, body),
+			body:   body,
+			secret: "another",
+			want:   ErrInvalidSignature,
+		},
+		{
+			name:   "tampered body",
+			header: signedHeader(now, body),
+			body:   append([]byte(" "), body...),
+			secret: secret,
+			want:   ErrInvalidSignature,
+		},
+		{
+			name:   "expired timestamp",
+			header: signedHeader(now-600, body),
+			body:   body,
+			secret: secret,
+			want:   ErrExpiredTimestamp,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			payload, err := Verify(test.header, test.body, test.secret, 5*time.Minute)
+			if !errors.Is(err, test.want) {
+				t.Fatalf("Verify() error = %v, want %v", err, test.want)
+			}
+			if test.want == nil && payload.Data["API_KEY"] != "value" {
+				t.Fatalf("Verify() data = %v", payload.Data)
+			}
+		})
+	}
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+
+	publicKey, privateKey, err := box.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := map[string]string{"API_KEY": "value"}
+
+	var payload Payload
+	if err := payload.Encrypt(data, *publicKey); err != nil {
+		t.Fatal(err)
+	}
+
+	if !payload.Encrypted || payload.Data != nil || payload.Ciphertext == "" {
+		t.Fatalf("Encrypt() left the payload in plaintext: %+v", payload)
+	}
+
+	result, err := payload.Decrypt(*publicKey, *privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result["API_KEY"] != "value" {
+		t.Fatalf("Decrypt() = %v, want %v", result, data)
+	}
+
+	otherPublicKey, otherPrivateKey, err := box.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := payload.Decrypt(*otherPublicKey, *otherPrivateKey); err == nil {
+		t.Fatal("Decrypt() with another key pair succeeded")
+	}
+}
+
+func TestDecodeKey(t *testing.T) {
+
+	key := make([]byte, KeyLength)
+	if _, err := rand.Read(key); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := DecodeKey(base64.StdEncoding.EncodeToString(key))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(result[:]) != string(key) {
+		t.Fatal("DecodeKey() returned another key")
+	}
+
+	if _, err := DecodeKey(base64.StdEncoding.EncodeToString(key[:16])); err == nil {
+		t.Fatal("DecodeKey() accepted a short key")
+	}
+}
+
+func TestValidateURL(t *testing.T) {
+
+	tests := []struct {
+		url           string
+		allowLoopback bool
+		want          error
+	}{
+		{"https://example.com/hooks/envsecrets", false, nil},
+		{"http://example.com/hook", false, ErrInvalidURL},
+		{"http://localhost.example.com/hook", false, ErrInvalidURL},
+		{"ftp://example.com/hook", false, ErrInvalidURL},
+		{"/hooks/envsecrets", false, ErrInvalidURL},
+		{"", false, ErrInvalidURL},
+		{"http://localhost:8080/hook", false, ErrLoopbackURL},
+		{"https://localhost:8443/hook", false, ErrLoopbackURL},
+		{"http://api.localhost/hook", false, ErrLoopbackURL},
+		{"http://127.0.0.1/hook", false, ErrLoopbackURL},
+		{"https://127.1.2.3/hook", false, ErrLoopbackURL},
+		{"http://[::1]:3000/hook", false, ErrLoopbackURL},
+		{"http://[::ffff:127.0.0.1]/hook", false, ErrLoopbackURL},
+		{"http://0.0.0.0/hook", false, ErrLoopbackURL},
+		{"http://localhost:8080/hook", true, nil},
+		{"http://127.0.0.1/hook", true, nil},
+		{"http://[::1]:3000/hook", true, nil},
+		{"http://example.com/hook", true, ErrInvalidURL},
+	}
+
+	for _, test := range tests {
+		err := ValidateURL(test.url, test.allowLoopback)
+		if !errors.Is(err, test.want) {
+			t.Errorf("ValidateURL(%q, %t) error = %v, want %v", test.url, test.allowLoopback, err, test.want)
+		}
+	}
+}